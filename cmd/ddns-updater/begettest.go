@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/provider/providers/beget/begettest"
+)
+
+var (
+	errBegetTestUsage = errors.New("usage: begettest [-listen address] [-login login] " +
+		"[-password password] [-token token] [-domains example.com,...] [-subdomains home.example.com,...] " +
+		"[-fail method:code[:times]]...")
+	errBegetTestFailure = errors.New("failure is not valid")
+)
+
+// failuresFlag is the repeatable -fail flag of the begettest command.
+type failuresFlag []begettest.Failure
+
+func (f *failuresFlag) String() string { return "" }
+
+// Set parses a failure such as dns/changeRecords:LIMIT_ERROR:2, where the
+// method can be * or empty for all the methods, the code can be a Beget
+// error code or an HTTP status code, and the number of times is optional.
+func (f *failuresFlag) Set(value string) (err error) {
+	parts := strings.Split(value, ":")
+	const minParts, maxParts = 2, 3
+	if len(parts) < minParts || len(parts) > maxParts {
+		return fmt.Errorf("%w: %q must be method:code[:times]", errBegetTestFailure, value)
+	}
+
+	var failure begettest.Failure
+	if parts[0] != "*" {
+		failure.Method = parts[0]
+	}
+	failure.StatusCode, err = strconv.Atoi(parts[1])
+	if err != nil {
+		failure.StatusCode = 0
+		failure.ErrorCode = parts[1]
+	}
+	if len(parts) == maxParts {
+		times, err := strconv.ParseUint(parts[2], 10, 0)
+		if err != nil {
+			return fmt.Errorf("%w: parsing times: %w", errBegetTestFailure, err)
+		}
+		failure.Times = uint(times)
+	}
+	*f = append(*f, failure)
+	return nil
+}
+
+// runBegetTest serves a fake Beget API until the context is canceled,
+// for the Beget provider "api_url" to point to it.
+func runBegetTest(ctx context.Context, args []string, logger begettest.Infoer) (err error) {
+	flagSet := flag.NewFlagSet("begettest", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	listen := flagSet.String("listen", "localhost:8081", "listening address")
+	settings := begettest.Settings{Logger: logger}
+	flagSet.StringVar(&settings.Login, "login", "", "login accepted")
+	flagSet.StringVar(&settings.Password, "password", "", "password accepted")
+	flagSet.StringVar(&settings.Token, "token", "", "bearer token accepted instead of the password")
+	domains := flagSet.String("domains", "", "comma separated domains")
+	subdomains := flagSet.String("subdomains", "", "comma separated subdomains")
+	var failures failuresFlag
+	flagSet.Var(&failures, "fail", "failure injected")
+	err = flagSet.Parse(args)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errBegetTestUsage, err)
+	} else if flagSet.NArg() > 0 {
+		return errBegetTestUsage
+	}
+	if *domains != "" {
+		settings.Domains = strings.Split(*domains, ",")
+	}
+	if *subdomains != "" {
+		settings.Subdomains = strings.Split(*subdomains, ",")
+	}
+
+	server := begettest.New(settings)
+	// The failures given first are injected first.
+	for i := len(failures) - 1; i >= 0; i-- {
+		server.Fail(failures[i])
+	}
+
+	listener, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return fmt.Errorf("listening: %w", err)
+	}
+	httpServer := &http.Server{
+		Handler:           server,
+		ReadHeaderTimeout: time.Second,
+	}
+	logger.Info("serving a fake Beget API on http://" + listener.Addr().String())
+
+	errCh := make(chan error)
+	go func() {
+		errCh <- httpServer.Serve(listener)
+	}()
+
+	select {
+	case err = <-errCh:
+		return fmt.Errorf("serving: %w", err)
+	case <-ctx.Done():
+		const shutdownTimeout = time.Second
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		return httpServer.Shutdown(shutdownCtx) //nolint:contextcheck
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/qdm12/ddns-updater/internal/config"
+	"github.com/qdm12/ddns-updater/internal/configcrypt"
+	"github.com/qdm12/gosettings/reader"
+)
+
+var (
+	errConfigUsage     = errors.New("usage: config keygen | config encrypt [file] | config decrypt [file]")
+	errConfigKeyNotSet = errors.New("CONFIG_KEY is not set")
+)
+
+// runConfigCommand generates a config key, encrypts the config file in
+// place, or writes the decrypted config file to w, the file defaulting
+// to CONFIG_FILEPATH and the key being read from CONFIG_KEY.
+func runConfigCommand(args []string, reader *reader.Reader, w io.Writer) (err error) {
+	if len(args) == 0 || len(args) > 2 {
+		return errConfigUsage
+	}
+
+	action := args[0]
+	if action == "keygen" {
+		if len(args) > 1 {
+			return errConfigUsage
+		}
+		key, err := configcrypt.GenerateKey()
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, key)
+		return err
+	} else if action != "encrypt" && action != "decrypt" {
+		return errConfigUsage
+	}
+
+	var paths config.Paths
+	err = paths.Read(reader)
+	if err != nil {
+		return fmt.Errorf("reading paths settings: %w", err)
+	}
+	paths.SetDefaults()
+	err = paths.Validate()
+	if err != nil {
+		return fmt.Errorf("validating paths settings: %w", err)
+	} else if *paths.ConfigKey == "" {
+		return errConfigKeyNotSet
+	}
+	filePath := *paths.Config
+	if len(args) == 2 { //nolint:mnd
+		filePath = args[1]
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	if action == "decrypt" {
+		data, err = configcrypt.Decrypt(data, *paths.ConfigKey)
+		if err != nil {
+			return fmt.Errorf("decrypting config file: %w", err)
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
+	data, err = configcrypt.Encrypt(data, *paths.ConfigKey)
+	if err != nil {
+		return fmt.Errorf("encrypting config file: %w", err)
+	}
+	const mode = fs.FileMode(0600)
+	err = os.WriteFile(filePath, data, mode)
+	if err != nil {
+		return fmt.Errorf("writing config file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/qdm12/ddns-updater/internal/records"
+)
+
+// historyCommand is the history command given on the command line,
+// run once the records are read from the database instead of
+// starting the updater.
+type historyCommand struct {
+	format string
+	// file is the file to write the history to,
+	// or empty to write it to the standard output.
+	file string
+}
+
+var (
+	errHistoryUsage  = errors.New("usage: history [-format json|csv] [file]")
+	errHistoryFormat = errors.New("history format is not valid")
+)
+
+func parseHistoryCommand(args []string) (command *historyCommand, err error) {
+	command = &historyCommand{}
+	flagSet := flag.NewFlagSet("history", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	flagSet.StringVar(&command.format, "format", "json", "json or csv")
+	err = flagSet.Parse(args)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errHistoryUsage, err)
+	}
+
+	switch flagSet.NArg() {
+	case 0:
+	case 1:
+		command.file = flagSet.Arg(0)
+	default:
+		return nil, errHistoryUsage
+	}
+
+	if command.format != "json" && command.format != "csv" {
+		return nil, fmt.Errorf("%w: %q must be json or csv", errHistoryFormat, command.format)
+	}
+	return command, nil
+}
+
+// runHistoryCommand writes the IP addresses history of the records
+// to the file of the command, or to stdout if it has no file.
+func runHistoryCommand(command *historyCommand, allRecords []records.Record,
+	stdout io.Writer) (err error) {
+	entries := records.HistoryEntries(allRecords)
+	if command.file == "" {
+		return writeHistory(stdout, command.format, entries)
+	}
+
+	const perms = 0o600
+	file, err := os.OpenFile(command.file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perms)
+	if err != nil {
+		return fmt.Errorf("creating history file: %w", err)
+	}
+	err = writeHistory(file, command.format, entries)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	err = file.Close()
+	if err != nil {
+		return fmt.Errorf("closing history file: %w", err)
+	}
+	return nil
+}
+
+func writeHistory(w io.Writer, format string, entries []records.HistoryEntry) (err error) {
+	if format == "csv" {
+		err = records.WriteHistoryCSV(w, entries)
+	} else {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(entries)
+	}
+	if err != nil {
+		return fmt.Errorf("writing history: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/qdm12/ddns-updater/internal/importer"
+)
+
+var (
+	errImportUsage = errors.New("usage: import [-format ddclient|inadyn] [-provider name] file")
+)
+
+// runImport converts the ddclient or inadyn config file given to the
+// JSON config of the program, written to stdout, and writes the warnings
+// about the entries skipped or to review to stderr.
+func runImport(args []string, stdout, stderr io.Writer) (err error) {
+	flagSet := flag.NewFlagSet("import", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	format := flagSet.String("format", "", "ddclient or inadyn, detected from the file if empty")
+	fallback := flagSet.String("provider", "",
+		"provider to import the hosts of unsupported protocols with, such as beget")
+	err = flagSet.Parse(args)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errImportUsage, err)
+	} else if flagSet.NArg() != 1 {
+		return errImportUsage
+	}
+
+	data, err := os.ReadFile(flagSet.Arg(0))
+	if err != nil {
+		return fmt.Errorf("reading file: %w", err)
+	}
+
+	importFormat := importer.Format(*format)
+	if importFormat == "" {
+		importFormat = importer.DetectFormat(data)
+	}
+
+	config, warnings, err := importer.Import(data, importFormat, *fallback)
+	if err != nil {
+		return fmt.Errorf("importing %s file: %w", importFormat, err)
+	}
+
+	for _, warning := range warnings {
+		_, err = fmt.Fprintln(stderr, "warning: "+warning)
+		if err != nil {
+			return fmt.Errorf("writing warning: %w", err)
+		}
+	}
+
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(config)
+	if err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	return nil
+}
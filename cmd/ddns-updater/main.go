@@ -2,11 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"sync/atomic"
 	"syscall"
 	"time"
 	_ "time/tzdata"
@@ -15,21 +17,33 @@ import (
 	"github.com/qdm12/ddns-updater/internal/backup"
 	"github.com/qdm12/ddns-updater/internal/config"
 	"github.com/qdm12/ddns-updater/internal/data"
+	"github.com/qdm12/ddns-updater/internal/email"
 	"github.com/qdm12/ddns-updater/internal/health"
 	"github.com/qdm12/ddns-updater/internal/healthchecksio"
+	"github.com/qdm12/ddns-updater/internal/logging"
+	"github.com/qdm12/ddns-updater/internal/metrics"
 	"github.com/qdm12/ddns-updater/internal/models"
 	"github.com/qdm12/ddns-updater/internal/noop"
 	jsonparams "github.com/qdm12/ddns-updater/internal/params"
 	persistence "github.com/qdm12/ddns-updater/internal/persistence/json"
+	"github.com/qdm12/ddns-updater/internal/persistence/sqlite"
 	"github.com/qdm12/ddns-updater/internal/provider"
+	"github.com/qdm12/ddns-updater/internal/provider/providers/beget"
 	recordslib "github.com/qdm12/ddns-updater/internal/records"
 	"github.com/qdm12/ddns-updater/internal/resolver"
+	"github.com/qdm12/ddns-updater/internal/secrets"
 	"github.com/qdm12/ddns-updater/internal/server"
 	"github.com/qdm12/ddns-updater/internal/shoutrrr"
+	"github.com/qdm12/ddns-updater/internal/systemd"
+	"github.com/qdm12/ddns-updater/internal/tracing"
 	"github.com/qdm12/ddns-updater/internal/update"
+	"github.com/qdm12/ddns-updater/internal/webhook"
+	"github.com/qdm12/ddns-updater/internal/winservice"
 	"github.com/qdm12/ddns-updater/pkg/publicip"
 	"github.com/qdm12/goservices"
 	"github.com/qdm12/gosettings/reader"
+	"github.com/qdm12/gosettings/reader/sources/env"
+	"github.com/qdm12/gosettings/reader/sources/flag"
 	"github.com/qdm12/gosplash"
 	"github.com/qdm12/log"
 )
@@ -49,7 +63,14 @@ func main() {
 	}
 	logger := log.New()
 
+	environ := os.Environ()
+	secretsSource := secrets.NewSource(environ, secrets.DefaultDir)
 	reader := reader.New(reader.Settings{
+		Sources: []reader.Source{
+			flag.New(os.Args),
+			env.New(env.Settings{Environ: environ}),
+			secretsSource,
+		},
 		HandleDeprecatedKey: func(source, oldKey, newKey string) {
 			logger.Warnf("%q key %s is deprecated, please use %q instead",
 				source, oldKey, newKey)
@@ -58,29 +79,56 @@ func main() {
 
 	ctx := context.Background()
 	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
+
+	// updatesShutdownTimeout is set by _main to the configured time to wait
+	// for the record updates in progress to finish, once the config is read.
+	updatesShutdownTimeout := new(atomic.Int64)
+	const shutdownGracePeriod = 5 * time.Second
+	shutdownTimeout := func() time.Duration {
+		return shutdownGracePeriod + time.Duration(updatesShutdownTimeout.Load())
+	}
+
+	ctx, service, err := winservice.Run(ctx, shutdownTimeout)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	} else if output := service.Output(); output != nil {
+		logging.SetOutput(output)
+		logger.Patch(logging.Options()...)
+	}
+	exit := func(code int) {
+		service.Stopped(code)
+		os.Exit(code)
+	}
 	ctx, cancel := context.WithCancel(ctx)
 
 	errorCh := make(chan error)
 	go func() {
-		errorCh <- _main(ctx, reader, os.Args, logger, buildInfo, time.Now)
+		errorCh <- _main(ctx, reader, secretsSource, os.Args, logger, buildInfo, time.Now, updatesShutdownTimeout)
 	}()
 
 	select {
 	case <-ctx.Done():
 		stop()
-		logger.Warn("Caught OS signal, shutting down")
+		if errors.Is(context.Cause(ctx), winservice.ErrStopRequested) {
+			logger.Warn("Windows service stop requested, shutting down")
+		} else {
+			logger.Warn("Caught OS signal, shutting down")
+		}
 	case err := <-errorCh:
 		stop()
 		close(errorCh)
 		if err == nil { // expected exit such as healthcheck
-			os.Exit(0)
+			exit(0)
+		} else if errors.Is(err, errConfigNotValid) {
+			// the validation report is already written
+			exit(1)
 		}
 		logger.Error(err.Error())
 		cancel()
 	}
 
-	const shutdownGracePeriod = 5 * time.Second
-	timer := time.NewTimer(shutdownGracePeriod)
+	timer := time.NewTimer(shutdownTimeout())
 	select {
 	case err := <-errorCh:
 		if !timer.Stop() {
@@ -94,13 +142,76 @@ func main() {
 		logger.Warn("Shutdown timed out")
 	}
 
-	os.Exit(1)
+	exit(1)
 }
 
-func _main(ctx context.Context, reader *reader.Reader, args []string, logger log.LoggerInterface,
-	buildInfo models.BuildInformation, timeNow func() time.Time) (err error) {
+func _main(ctx context.Context, reader *reader.Reader, secretsSource *secrets.Source,
+	args []string, logger log.LoggerInterface,
+	buildInfo models.BuildInformation, timeNow func() time.Time,
+	updatesShutdownTimeout *atomic.Int64) (err error) {
+	checkOnly := false
+	once := false
+	var zoneCmd *zoneCommand
+	var historyCmd *historyCommand
 	if len(args) > 1 {
 		switch args[1] {
+		case "check", "-check", "--check":
+			// Check the settings of each record against the provider
+			// API, without updating anything, and exit.
+			checkOnly = true
+		case "once", "-once", "--once":
+			// Check and update all the records once, print
+			// a summary and exit, for example from cron.
+			once = true
+		case "zone":
+			// Run a zone command, such as exporting the record
+			// set of an FQDN to a file, and exit.
+			zoneCmd, err = parseZoneCommand(args[2:])
+			if err != nil {
+				return err
+			}
+		case "history":
+			// Export the IP addresses history of the
+			// records, in JSON or CSV, and exit.
+			historyCmd, err = parseHistoryCommand(args[2:])
+			if err != nil {
+				return err
+			}
+		case "begettest":
+			// Serve a fake Beget API with an in-memory zone until
+			// stopped, for the Beget provider to be used offline.
+			return runBegetTest(ctx, args[2:], logger)
+		case "plugin":
+			// Update a record with a built-in provider as a plugin command
+			// of the external provider, keeping the standard output for
+			// the plugin response.
+			logging.SetOutput(os.Stderr)
+			logger.Patch(logging.Options()...)
+			var clientSettings config.Client
+			err = clientSettings.Read(reader)
+			if err != nil {
+				return fmt.Errorf("HTTP client settings: %w", err)
+			}
+			clientSettings.SetDefaults()
+			client := &http.Client{Timeout: clientSettings.Timeout}
+			defer client.CloseIdleConnections()
+			return runPlugin(ctx, args[2:], client, os.Stdin, os.Stdout)
+		case "config":
+			// Generate a config key, or encrypt or decrypt
+			// the config file with it, and exit.
+			return runConfigCommand(args[2:], reader, os.Stdout)
+		case "import":
+			// Convert a ddclient or inadyn config file to
+			// the JSON config, written to stdout, and exit.
+			return runImport(args[2:], os.Stdout, os.Stderr)
+		case "service":
+			// Install, uninstall, start or stop the
+			// Windows service of the program, and exit.
+			return runServiceCommand(args[2:])
+		case "validate", "-validate", "--validate":
+			// Validate the config entries without calling any
+			// provider API, print a report and exit.
+			return runValidate(args[2:], reader, logger, os.Stdout)
 		case "version", "-version", "--version":
 			fmt.Println(buildInfo.VersionString())
 			return nil
@@ -122,16 +233,25 @@ func _main(ctx context.Context, reader *reader.Reader, args []string, logger log
 		}
 	}
 
-	printSplash(buildInfo)
+	if historyCmd != nil && historyCmd.file == "" {
+		// keep the standard output for the history
+		logging.SetOutput(os.Stderr)
+		logger.Patch(logging.Options()...)
+	} else {
+		printSplash(buildInfo)
+	}
 
-	config, err := readConfig(reader, logger)
+	config, err := readConfig(reader, secretsSource, logger)
 	if err != nil {
 		return err
 	}
+	updatesShutdownTimeout.Store(int64(*config.Update.ShutdownTimeout))
 
 	shoutrrrSettings := shoutrrr.Settings{
 		Addresses:    config.Shoutrrr.Addresses,
 		DefaultTitle: config.Shoutrrr.DefaultTitle,
+		Events:       config.Shoutrrr.Events,
+		Message:      config.Shoutrrr.Message,
 		Logger:       logger.New(log.SetComponent("shoutrrr")),
 	}
 	shoutrrrClient, err := shoutrrr.New(shoutrrrSettings)
@@ -139,14 +259,17 @@ func _main(ctx context.Context, reader *reader.Reader, args []string, logger log
 		return fmt.Errorf("setting up Shoutrrr: %w", err)
 	}
 
-	persistentDB, err := persistence.NewDatabase(*config.Paths.DataDir)
+	persistentDB, err := openPersistentDB(*config.Paths.DataDir, config.Database.Backend, logger)
 	if err != nil {
 		shoutrrrClient.Notify(err.Error())
 		return err
 	}
+	defer func() {
+		_ = persistentDB.Close()
+	}()
 
-	jsonReader := jsonparams.NewReader(logger)
-	providers, warnings, err := jsonReader.JSONProviders(*config.Paths.Config)
+	jsonReader := jsonparams.NewReader(logger, *config.Paths.ConfigKey)
+	settings, warnings, err := jsonReader.JSONSettings(*config.Paths.Config)
 	for _, w := range warnings {
 		logger.Warn(w)
 		shoutrrrClient.Notify(w)
@@ -155,6 +278,7 @@ func _main(ctx context.Context, reader *reader.Reader, args []string, logger log
 		shoutrrrClient.Notify(err.Error())
 		return err
 	}
+	providers := recordslib.Providers(settings)
 
 	logProvidersCount(len(providers), logger)
 
@@ -166,11 +290,28 @@ func _main(ctx context.Context, reader *reader.Reader, args []string, logger log
 		logger.Warn(err.Error())
 	}
 
-	records, err := readRecords(providers, persistentDB, logger, shoutrrrClient)
+	if checkOnly {
+		return checkProviders(ctx, providers, client, logger)
+	}
+
+	if zoneCmd != nil {
+		return runZoneCommand(ctx, zoneCmd, providers, client)
+	}
+
+	tracer, err := createTracer(config.Tracing, config.Client.Timeout, logger, buildInfo.Version)
+	if err != nil {
+		return fmt.Errorf("creating tracer: %w", err)
+	}
+
+	records, err := readRecords(settings, persistentDB, logger, shoutrrrClient)
 	if err != nil {
 		return fmt.Errorf("reading records: %w", err)
 	}
 
+	if historyCmd != nil {
+		return runHistoryCommand(historyCmd, records, os.Stdout)
+	}
+
 	db := data.NewDatabase(records, persistentDB)
 
 	httpSettings := publicip.HTTPSettings{
@@ -183,7 +324,30 @@ func _main(ctx context.Context, reader *reader.Reader, args []string, logger log
 		Options: config.PubIP.ToDNSPOptions(),
 	}
 
-	ipGetter, err := publicip.NewFetcher(dnsSettings, httpSettings)
+	stunSettings := publicip.STUNSettings{
+		Enabled: *config.PubIP.STUNEnabled,
+		Options: config.PubIP.ToSTUNOptions(),
+	}
+
+	interfaceSettings := publicip.InterfaceSettings{
+		Enabled: *config.PubIP.InterfaceEnabled,
+		Name:    config.PubIP.InterfaceName,
+		Options: config.PubIP.ToInterfaceOptions(),
+	}
+
+	externalSettings := publicip.ExternalSettings{
+		Enabled: *config.PubIP.ExternalEnabled,
+		Options: config.PubIP.ToExternalOptions(),
+	}
+
+	routerSettings := publicip.RouterSettings{
+		Enabled: *config.PubIP.RouterEnabled,
+		Options: config.PubIP.ToRouterOptions(),
+	}
+
+	ipGetter, err := publicip.NewFetcher(dnsSettings, httpSettings,
+		stunSettings, interfaceSettings, externalSettings, routerSettings,
+		config.PubIP.Quorum)
 	if err != nil {
 		return err
 	}
@@ -200,16 +364,62 @@ func _main(ctx context.Context, reader *reader.Reader, args []string, logger log
 	hioClient := healthchecksio.New(client, config.Health.HealthchecksioBaseURL,
 		*config.Health.HealthchecksioUUID)
 
-	updater := update.NewUpdater(db, client, shoutrrrClient, logger, timeNow)
+	webhookClient, err := webhook.New(webhook.Settings{
+		URL:      config.Webhook.URL,
+		Events:   config.Webhook.Events,
+		Headers:  config.Webhook.Headers,
+		Body:     config.Webhook.Body,
+		Failures: *config.Webhook.Failures,
+		Client:   client,
+		Logger:   logger.New(log.SetComponent("webhook")),
+	})
+	if err != nil {
+		return fmt.Errorf("setting up webhook: %w", err)
+	}
+
+	emailClient, err := email.New(email.Settings{
+		Host:     config.Email.Host,
+		Port:     *config.Email.Port,
+		Security: config.Email.Security,
+		Username: config.Email.Username,
+		Password: config.Email.Password,
+		From:     config.Email.From,
+		To:       config.Email.To,
+		Events:   config.Email.Events,
+		Subject:  config.Email.Subject,
+		Body:     config.Email.Body,
+		Logger:   logger.New(log.SetComponent("email")),
+	})
+	if err != nil {
+		return fmt.Errorf("setting up email: %w", err)
+	}
+
+	eventNotifiers := []update.EventNotifier{shoutrrrClient, webhookClient, emailClient}
+	updater := update.NewUpdater(db, client, eventNotifiers, logger, timeNow, metrics.Default,
+		config.Update.Period, *config.Update.BackoffMax)
 	updaterService := update.NewService(db, updater, ipGetter, config.Update.Period,
-		config.Update.Cooldown, logger, resolver, timeNow, hioClient)
+		config.Update.Cooldown, config.Update.Jitter, *config.Update.StableDetections,
+		config.Update.StableDuration, *config.Update.Workers, *config.Update.WorkersPerHost,
+		logger, resolver, timeNow, hioClient, *config.Update.ShutdownTimeout, systemd.New())
+
+	if once {
+		_, err = tracer.Start(ctx)
+		if err != nil {
+			return fmt.Errorf("starting tracer: %w", err)
+		}
+		err = runOnce(ctx, updaterService, db, os.Stdout)
+		if stopErr := tracer.Stop(); stopErr != nil {
+			logger.Warn("stopping tracer: " + stopErr.Error())
+		}
+		return err
+	}
 
 	healthServer, err := createHealthServer(db, resolver, logger, *config.Health.ServerAddress)
 	if err != nil {
 		return fmt.Errorf("creating health server: %w", err)
 	}
 
-	server, err := createServer(ctx, config.Server, logger, db, updaterService)
+	server, err := createServer(ctx, config.Server, client, logger, db, updaterService)
 	if err != nil {
 		return fmt.Errorf("creating server: %w", err)
 	}
@@ -224,8 +434,8 @@ func _main(ctx context.Context, reader *reader.Reader, args []string, logger log
 	}
 
 	servicesSequence, err := goservices.NewSequence(goservices.SequenceSettings{
-		ServicesStart: []goservices.Service{db, updaterService, healthServer, server, backupService},
-		ServicesStop:  []goservices.Service{server, healthServer, updaterService, backupService, db},
+		ServicesStart: []goservices.Service{tracer, db, updaterService, healthServer, server, backupService},
+		ServicesStop:  []goservices.Service{server, healthServer, updaterService, backupService, db, tracer},
 	})
 	if err != nil {
 		return fmt.Errorf("creating services sequence: %w", err)
@@ -240,6 +450,9 @@ func _main(ctx context.Context, reader *reader.Reader, args []string, logger log
 	// no need to collect the resulting errors.
 	go updaterService.ForceUpdate(ctx)
 
+	go reloadConfig(ctx, *config.Paths.Config, *config.Paths.ConfigWatchPeriod,
+		jsonReader, updaterService, logger)
+
 	shoutrrrClient.Notify("Launched with " + strconv.Itoa(len(records)) + " records to watch")
 
 	select {
@@ -284,19 +497,24 @@ func printSplash(buildInfo models.BuildInformation) {
 	}
 }
 
-func readConfig(reader *reader.Reader, logger log.LoggerInterface) (
-	config config.Config, err error) {
+func readConfig(reader *reader.Reader, secretsSource *secrets.Source,
+	logger log.LoggerInterface) (config config.Config, err error) {
 	err = config.Read(reader, logger)
 	if err != nil {
 		return config, fmt.Errorf("reading settings: %w", err)
 	}
+	err = secretsSource.Err()
+	if err != nil {
+		return config, fmt.Errorf("reading settings: %w", err)
+	}
 	config.SetDefaults()
 	err = config.Validate()
 	if err != nil {
 		return config, fmt.Errorf("settings validation: %w", err)
 	}
 
-	logger.Patch(config.Logger.ToOptions()...)
+	logging.SetJSON(config.Logger.Format == "json")
+	logger.Patch(append(config.Logger.ToOptions(), logging.Options()...)...)
 	logger.Info(config.String())
 
 	return config, nil
@@ -313,11 +531,87 @@ func logProvidersCount(providersCount int, logger log.LeveledLogger) {
 	}
 }
 
-func readRecords(providers []provider.Provider, persistentDB *persistence.Database,
+var errCheckFailed = errors.New("check failed")
+
+// checkProviders runs the check of each provider implementing provider.Checker,
+// logs the outcome for each of them and returns an error if any check failed.
+func checkProviders(ctx context.Context, providers []provider.Provider,
+	client *http.Client, logger log.LeveledLogger) (err error) {
+	failed := 0
+	for _, p := range providers {
+		checker, ok := p.(provider.Checker)
+		if !ok {
+			logger.Info(p.String() + ": no check available")
+			continue
+		}
+		err = checker.Check(ctx, client)
+		if err != nil {
+			failed++
+			logger.Error(p.String() + ": " + err.Error())
+			continue
+		}
+		logger.Info(p.String() + ": check passed")
+	}
+	if failed > 0 {
+		return fmt.Errorf("%w: for %d of %d settings", errCheckFailed, failed, len(providers))
+	}
+	return nil
+}
+
+// openPersistentDB opens the database of the backend given. The SQLite
+// database stores the Beget audit entries, and is filled with the records
+// of the JSON file database when it is first created.
+func openPersistentDB(dataDir, backend string, logger log.LoggerInterface) ( //nolint:ireturn
+	db data.PersistentDatabase, err error) {
+	if backend != "sqlite" {
+		jsonDB, err := persistence.NewDatabase(dataDir)
+		if err != nil {
+			return nil, err
+		}
+		return jsonDB, nil
+	}
+
+	sqliteDB, err := sqlite.NewDatabase(dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("opening SQLite database: %w", err)
+	}
+	beget.SetAuditStore(sqliteDB)
+
+	empty, err := sqliteDB.IsEmpty()
+	if err != nil {
+		_ = sqliteDB.Close()
+		return nil, err
+	} else if !empty {
+		return sqliteDB, nil
+	}
+
+	jsonDB, err := persistence.NewDatabase(dataDir)
+	if err != nil {
+		_ = sqliteDB.Close()
+		return nil, fmt.Errorf("opening JSON database to migrate: %w", err)
+	}
+	records := jsonDB.Records()
+	_ = jsonDB.Close()
+	if len(records) == 0 {
+		return sqliteDB, nil
+	}
+
+	err = sqliteDB.Import(records)
+	if err != nil {
+		_ = sqliteDB.Close()
+		return nil, fmt.Errorf("migrating JSON database: %w", err)
+	}
+	logger.Info(fmt.Sprintf("migrated %d records from updates.json to %s",
+		len(records), sqlite.FileName))
+	return sqliteDB, nil
+}
+
+func readRecords(settings []recordslib.Settings, persistentDB data.PersistentDatabase,
 	logger log.LoggerInterface, shoutrrrClient *shoutrrr.Client) (
 	records []recordslib.Record, err error) {
-	records = make([]recordslib.Record, len(providers))
-	for i, provider := range providers {
+	records = make([]recordslib.Record, len(settings))
+	for i, recordSettings := range settings {
+		provider := recordSettings.Provider
 		logger.Info("Reading history from database: domain " +
 			provider.Domain() + " owner " + provider.Owner() +
 			" " + provider.IPVersion().String())
@@ -328,6 +622,15 @@ func readRecords(providers []provider.Provider, persistentDB *persistence.Databa
 			return nil, err
 		}
 		records[i] = recordslib.New(provider, events)
+		records[i].Period = recordSettings.Period
+		records[i].Jitter = recordSettings.Jitter
+		records[i].MirrorGroup = recordSettings.MirrorGroup
+		records[i].MaxAge = recordSettings.MaxAge
+		records[i].Resolver = recordSettings.Resolver
+		records[i].Paused, err = persistentDB.GetPaused(provider.Domain(), provider.Owner())
+		if err != nil {
+			return nil, fmt.Errorf("reading paused state: %w", err)
+		}
 	}
 	return records, nil
 }
@@ -343,6 +646,30 @@ func exitHealthchecksio(hioClient *healthchecksio.Client,
 	}
 }
 
+// createTracer creates the tracer exporting the spans of the update cycles,
+// and sets it as the default tracer, if an endpoint is set.
+//
+//nolint:ireturn
+func createTracer(config config.Tracing, timeout time.Duration,
+	logger log.LoggerInterface, version string) (tracer goservices.Service, err error) {
+	if config.Endpoint == "" {
+		return noop.New("tracing"), nil
+	}
+	tracingTracer, err := tracing.New(tracing.Settings{
+		Endpoint:       config.Endpoint,
+		Headers:        config.ToHeaders(),
+		ServiceName:    config.ServiceName,
+		ServiceVersion: version,
+		Timeout:        timeout,
+		Logger:         logger.New(log.SetComponent("tracing")),
+	})
+	if err != nil {
+		return nil, err
+	}
+	tracing.SetDefault(tracingTracer)
+	return tracingTracer, nil
+}
+
 //nolint:ireturn
 func createHealthServer(db health.AllSelecter, resolver health.LookupIPer,
 	logger log.LoggerInterface, serverAddress string) (
@@ -350,13 +677,13 @@ func createHealthServer(db health.AllSelecter, resolver health.LookupIPer,
 	if !health.IsDocker() {
 		return noop.New("healthcheck server"), nil
 	}
-	isHealthy := health.MakeIsHealthy(db, resolver)
+	isHealthy := health.MakeIsHealthy(db, resolver, time.Now)
 	healthLogger := logger.New(log.SetComponent("healthcheck server"))
 	return health.NewServer(serverAddress, healthLogger, isHealthy)
 }
 
 //nolint:ireturn
-func createServer(ctx context.Context, config config.Server,
+func createServer(ctx context.Context, config config.Server, client *http.Client,
 	logger log.LoggerInterface, db server.Database,
 	updaterService server.UpdateForcer) (
 	service goservices.Service, err error) {
@@ -364,6 +691,14 @@ func createServer(ctx context.Context, config config.Server,
 		return noop.New("server"), nil
 	}
 	serverLogger := logger.New(log.SetComponent("http server"))
-	return server.New(ctx, config.ListeningAddress, config.RootURL,
-		db, serverLogger, updaterService)
+	settings := server.Settings{
+		Address:      config.ListeningAddress,
+		RootURL:      config.RootURL,
+		Client:       client,
+		ACMEUsername: config.ACMEUsername,
+		ACMEPassword: config.ACMEPassword,
+		APIToken:     config.APIToken,
+		Metrics:      metrics.Default,
+	}
+	return server.New(ctx, settings, db, serverLogger, updaterService)
 }
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/qdm12/ddns-updater/internal/constants"
+	"github.com/qdm12/ddns-updater/internal/data"
+	"github.com/qdm12/ddns-updater/internal/update"
+)
+
+var errOnceFailed = errors.New("update pass failed")
+
+// runOnce checks and updates all the records once, writes a summary
+// of the records to w and returns an error if any of them failed.
+func runOnce(ctx context.Context, updaterService *update.Service,
+	db *data.Database, w io.Writer) (err error) {
+	_, err = updaterService.Start(ctx)
+	if err != nil {
+		return fmt.Errorf("starting updater: %w", err)
+	}
+	errs := updaterService.ForceUpdate(ctx)
+	err = updaterService.Stop()
+	if err != nil {
+		return fmt.Errorf("stopping updater: %w", err)
+	}
+
+	records := db.SelectAll()
+	var updated, upToDate, paused, failed int
+	for _, record := range records {
+		status := string(record.Status)
+		switch {
+		case record.Paused:
+			paused++
+			status = "paused"
+		case record.Status == constants.SUCCESS:
+			updated++
+		case record.Status == constants.UPTODATE:
+			upToDate++
+		default:
+			failed++
+		}
+		line := record.Provider.String() + ": " + status
+		if record.Message != "" && !record.Paused {
+			line += ": " + record.Message
+		}
+		_, err = fmt.Fprintln(w, line)
+		if err != nil {
+			return fmt.Errorf("writing summary: %w", err)
+		}
+	}
+	_, err = fmt.Fprintf(w, "%d records: %d updated, %d up to date, %d paused, %d failed\n",
+		len(records), updated, upToDate, paused, failed)
+	if err != nil {
+		return fmt.Errorf("writing summary: %w", err)
+	}
+
+	if failed > 0 || len(errs) > 0 {
+		return fmt.Errorf("%w: for %d of %d records, with %d errors",
+			errOnceFailed, failed, len(records), len(errs))
+	}
+	return nil
+}
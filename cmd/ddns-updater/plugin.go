@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+
+	"github.com/qdm12/ddns-updater/internal/models"
+	"github.com/qdm12/ddns-updater/internal/provider"
+	"github.com/qdm12/ddns-updater/internal/provider/providers/external"
+	"github.com/qdm12/ddns-updater/pkg/publicip/ipversion"
+)
+
+var (
+	errPluginUsage  = errors.New("usage: plugin <provider>")
+	errPluginFailed = errors.New("plugin update failed")
+)
+
+// runPlugin runs the built-in provider given in args as a plugin command of
+// the external provider: it reads an update request from stdin, updates the
+// record with the provider and writes the response to stdout. It serves as
+// the reference implementation of the plugin protocol.
+func runPlugin(ctx context.Context, args []string, client *http.Client,
+	stdin io.Reader, stdout io.Writer) (err error) {
+	if len(args) != 1 {
+		return errPluginUsage
+	}
+
+	var request external.Request
+	err = json.NewDecoder(stdin).Decode(&request)
+	if err != nil {
+		return fmt.Errorf("decoding request: %w", err)
+	}
+
+	response := handlePluginRequest(ctx, models.Provider(args[0]), client, request)
+	err = json.NewEncoder(stdout).Encode(response)
+	if err != nil {
+		return fmt.Errorf("writing response: %w", err)
+	}
+
+	if response.Error != "" {
+		return fmt.Errorf("%w: %s", errPluginFailed, response.Error)
+	}
+	return nil
+}
+
+var (
+	errPluginProtocolVersion = errors.New("plugin protocol version is not supported")
+	errPluginAction          = errors.New("plugin action is not supported")
+)
+
+func handlePluginRequest(ctx context.Context, providerName models.Provider,
+	client *http.Client, request external.Request) (response external.Response) {
+	ip, err := updateFromPluginRequest(ctx, providerName, client, request)
+	if err != nil {
+		return external.Response{
+			Error:     err.Error(),
+			ErrorKind: external.ErrorKind(err),
+		}
+	}
+	return external.Response{IP: ip.String()}
+}
+
+func updateFromPluginRequest(ctx context.Context, providerName models.Provider,
+	client *http.Client, request external.Request) (newIP netip.Addr, err error) {
+	switch {
+	case request.ProtocolVersion != external.ProtocolVersion:
+		return netip.Addr{}, fmt.Errorf("%w: %d", errPluginProtocolVersion, request.ProtocolVersion)
+	case request.Action != external.ActionUpdate:
+		return netip.Addr{}, fmt.Errorf("%w: %q", errPluginAction, request.Action)
+	}
+
+	ipVersion, err := ipversion.Parse(request.IPVersion)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	ip, err := netip.ParseAddr(request.IP)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("parsing IP address: %w", err)
+	}
+
+	settings := request.Settings
+	if len(settings) == 0 {
+		settings = json.RawMessage(`{}`)
+	}
+	// The IPv6 suffix is already applied to the IP address of the request.
+	p, err := provider.New(providerName, settings, request.Domain, request.Owner,
+		ipVersion, netip.Prefix{})
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("creating provider: %w", err)
+	}
+	return p.Update(ctx, client, ip)
+}
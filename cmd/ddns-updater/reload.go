@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	jsonparams "github.com/qdm12/ddns-updater/internal/params"
+	"github.com/qdm12/ddns-updater/internal/records"
+	"github.com/qdm12/log"
+)
+
+type providersReloader interface {
+	Reload(ctx context.Context, settings []records.Settings) (err error)
+}
+
+// reloadConfig reloads the providers of the config file on SIGHUP and,
+// if watchPeriod is not zero, when the config file changes, until the
+// context is canceled. A config which cannot be read is logged and the
+// current records are kept.
+func reloadConfig(ctx context.Context, configPath string, watchPeriod time.Duration,
+	jsonReader *jsonparams.Reader, reloader providersReloader, logger log.LoggerInterface) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	var ticks <-chan time.Time
+	lastModified := configModified(configPath)
+	if watchPeriod > 0 {
+		ticker := time.NewTicker(watchPeriod)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			logger.Info("Caught SIGHUP, reloading config")
+		case <-ticks:
+			modified := configModified(configPath)
+			if modified == lastModified {
+				continue
+			}
+			logger.Info("Config file changed, reloading config")
+		}
+		lastModified = configModified(configPath)
+
+		err := reloadProviders(ctx, configPath, jsonReader, reloader, logger)
+		if err != nil {
+			logger.Error("reloading config: " + err.Error())
+		}
+	}
+}
+
+// configModified returns the modification time and size of the config
+// file as a string, or an empty string if it cannot be stat-ed.
+func configModified(configPath string) string {
+	stat, err := os.Stat(configPath)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%s %d", stat.ModTime(), stat.Size())
+}
+
+func reloadProviders(ctx context.Context, configPath string, jsonReader *jsonparams.Reader,
+	reloader providersReloader, logger log.LoggerInterface) (err error) {
+	settings, warnings, err := jsonReader.JSONSettings(configPath)
+	for _, w := range warnings {
+		logger.Warn(w)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = reloader.Reload(ctx, settings)
+	if err != nil {
+		records.Release(settings)
+		return err
+	}
+	logProvidersCount(len(settings), logger)
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/winservice"
+)
+
+var errServiceUsage = errors.New("usage: service install [flags...] | service uninstall | " +
+	"service start | service stop")
+
+// runServiceCommand installs, uninstalls, starts or stops the Windows
+// service of the program. The arguments following install, such as
+// --datadir=C:\ddns-updater\data, are given to the service when it starts.
+func runServiceCommand(args []string) (err error) {
+	if len(args) == 0 {
+		return errServiceUsage
+	}
+	if args[0] == "install" {
+		return winservice.Install(args[1:])
+	} else if len(args) > 1 {
+		return errServiceUsage
+	}
+	switch args[0] {
+	case "uninstall":
+		return winservice.Uninstall()
+	case "start":
+		return winservice.Start()
+	case "stop":
+		const stopTimeout = time.Minute
+		return winservice.Stop(stopTimeout)
+	default:
+		return errServiceUsage
+	}
+}
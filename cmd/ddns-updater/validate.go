@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/qdm12/ddns-updater/internal/config"
+	jsonparams "github.com/qdm12/ddns-updater/internal/params"
+	"github.com/qdm12/gosettings/reader"
+)
+
+var (
+	errValidateUsage  = errors.New("usage: validate [-format json|text]")
+	errConfigNotValid = errors.New("config is not valid")
+	errValidateFormat = errors.New("validate format is not valid")
+)
+
+// runValidate validates the JSON config without calling any provider
+// API, writes the report to w and returns errConfigNotValid if the
+// config or any of its entries is not valid.
+func runValidate(args []string, reader *reader.Reader,
+	logger jsonparams.Logger, w io.Writer) (err error) {
+	flagSet := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	format := flagSet.String("format", "json", "json or text")
+	err = flagSet.Parse(args)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errValidateUsage, err)
+	} else if flagSet.NArg() != 0 {
+		return errValidateUsage
+	}
+
+	var paths config.Paths
+	err = paths.Read(reader)
+	if err != nil {
+		return fmt.Errorf("reading paths settings: %w", err)
+	}
+	paths.SetDefaults()
+
+	jsonReader := jsonparams.NewReader(logger, *paths.ConfigKey)
+	report := jsonReader.Validate(*paths.Config)
+
+	switch *format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	case "text":
+		_, err = io.WriteString(w, reportText(report))
+	default:
+		return fmt.Errorf("%w: %q must be json or text", errValidateFormat, *format)
+	}
+	if err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+
+	if !report.Valid {
+		return errConfigNotValid
+	}
+	return nil
+}
+
+func reportText(report jsonparams.Report) string {
+	if report.Error != "" {
+		return "config: error: " + report.Error + "\n"
+	}
+
+	var lines []string
+	for _, entry := range report.Entries {
+		name := fmt.Sprintf("entry %d (%s %s)", entry.Index, entry.Provider, entry.Domain)
+		for _, warning := range entry.Warnings {
+			lines = append(lines, name+": warning: "+warning)
+		}
+		for _, err := range entry.Errors {
+			lines = append(lines, name+": error: "+err)
+		}
+		if len(entry.Errors) == 0 {
+			lines = append(lines, name+": ok")
+		}
+	}
+	if report.Valid {
+		lines = append(lines, fmt.Sprintf("config: valid, with %d entries", len(report.Entries)))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/qdm12/ddns-updater/internal/provider"
+)
+
+// zoneCommand is a zone subcommand given on the command line,
+// run once the providers are read from the config instead of
+// starting the updater.
+type zoneCommand struct {
+	action string
+	fqdn   string
+	file   string
+	format string
+	yes    bool
+}
+
+var (
+	errZoneUsage = errors.New("usage: zone export [-format json|bind] <fqdn> <file> " +
+		"or zone restore [-yes] <file>")
+	errZoneActionUnknown  = errors.New("zone action is unknown")
+	errZoneProviderAbsent = errors.New("no provider setting can manage the zone")
+	errZoneFQDNNotSet     = errors.New("FQDN is not set in the zone file")
+)
+
+func parseZoneCommand(args []string) (command *zoneCommand, err error) {
+	if len(args) == 0 {
+		return nil, errZoneUsage
+	}
+	command = &zoneCommand{action: args[0]}
+
+	flagSet := flag.NewFlagSet("zone "+command.action, flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	var argsCount int
+	switch command.action {
+	case "export":
+		flagSet.StringVar(&command.format, "format", "json", "json or bind")
+		argsCount = 2
+	case "restore":
+		flagSet.BoolVar(&command.yes, "yes", false, "restore without confirmation")
+		argsCount = 1
+	default:
+		return nil, fmt.Errorf("%w: %s", errZoneActionUnknown, command.action)
+	}
+
+	err = flagSet.Parse(args[1:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errZoneUsage, err)
+	}
+	if flagSet.NArg() != argsCount {
+		return nil, errZoneUsage
+	}
+
+	if command.action == "export" {
+		command.fqdn = flagSet.Arg(0)
+		command.file = flagSet.Arg(1)
+	} else {
+		command.file = flagSet.Arg(0)
+	}
+	return command, nil
+}
+
+func runZoneCommand(ctx context.Context, command *zoneCommand,
+	providers []provider.Provider, client *http.Client) (err error) {
+	if command.action == "restore" {
+		return restoreZone(ctx, command, providers, client)
+	}
+
+	exporter, ok := findZoneProvider(providers, command.fqdn).(provider.ZoneExporter)
+	if !ok {
+		return fmt.Errorf("%w: for %s", errZoneProviderAbsent, command.fqdn)
+	}
+
+	data, err := exporter.ExportZone(ctx, client, command.fqdn, command.format)
+	if err != nil {
+		return fmt.Errorf("exporting zone of %s: %w", command.fqdn, err)
+	}
+
+	const perms = 0600
+	err = os.WriteFile(command.file, data, perms)
+	if err != nil {
+		return fmt.Errorf("writing zone file: %w", err)
+	}
+	return nil
+}
+
+func restoreZone(ctx context.Context, command *zoneCommand,
+	providers []provider.Provider, client *http.Client) (err error) {
+	data, err := os.ReadFile(command.file)
+	if err != nil {
+		return fmt.Errorf("reading zone file: %w", err)
+	}
+	var zoneFile struct {
+		FQDN string `json:"fqdn"`
+	}
+	err = json.Unmarshal(data, &zoneFile)
+	if err != nil {
+		return fmt.Errorf("decoding zone file: %w", err)
+	}
+	if zoneFile.FQDN == "" {
+		return fmt.Errorf("%w: %s", errZoneFQDNNotSet, command.file)
+	}
+
+	restorer, ok := findZoneProvider(providers, zoneFile.FQDN).(provider.ZoneRestorer)
+	if !ok {
+		return fmt.Errorf("%w: for %s", errZoneProviderAbsent, zoneFile.FQDN)
+	}
+
+	confirmed := false
+	confirm := func(fqdn, diff string) bool {
+		fmt.Println("Changes to the records of " + fqdn + ":\n" + diff)
+		confirmed = command.yes || askConfirmation(os.Stdin, "Restore these records?")
+		return confirmed
+	}
+	err = restorer.RestoreZone(ctx, client, data, confirm)
+	switch {
+	case err != nil:
+		return fmt.Errorf("restoring zone of %s: %w", zoneFile.FQDN, err)
+	case confirmed:
+		fmt.Println("Records of " + zoneFile.FQDN + " restored")
+	default:
+		fmt.Println("Records of " + zoneFile.FQDN + " already match the zone file")
+	}
+	return nil
+}
+
+// askConfirmation prints question and returns true
+// if the answer read from reader is yes.
+func askConfirmation(reader io.Reader, question string) (yes bool) {
+	fmt.Print(question + " [y/N] ")
+	answer, _ := bufio.NewReader(reader).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// findZoneProvider returns the first provider able to export or restore
+// the zone whose domain contains fqdn, or nil if there is none.
+func findZoneProvider(providers []provider.Provider, fqdn string) provider.Provider { //nolint:ireturn
+	fqdn = strings.TrimSuffix(fqdn, ".")
+	for _, p := range providers {
+		_, exporter := p.(provider.ZoneExporter)
+		_, restorer := p.(provider.ZoneRestorer)
+		if !exporter && !restorer {
+			continue
+		}
+		domain := p.Domain()
+		if fqdn == domain || strings.HasSuffix(fqdn, "."+domain) {
+			return p
+		}
+	}
+	return nil
+}
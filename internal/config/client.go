@@ -12,7 +12,7 @@ type Client struct {
 	Timeout time.Duration
 }
 
-func (c *Client) setDefaults() {
+func (c *Client) SetDefaults() {
 	const defaultTimeout = 20 * time.Second
 	c.Timeout = gosettings.DefaultComparable(c.Timeout, defaultTimeout)
 }
@@ -31,7 +31,7 @@ func (c Client) toLinesNode() *gotree.Node {
 	return node
 }
 
-func (c *Client) read(reader *reader.Reader) (err error) {
+func (c *Client) Read(reader *reader.Reader) (err error) {
 	c.Timeout, err = reader.Duration("HTTP_TIMEOUT")
 	if err != nil {
 		return err
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/qdm12/gosettings"
+	"github.com/qdm12/gosettings/reader"
+	"github.com/qdm12/gosettings/validate"
+	"github.com/qdm12/gotree"
+)
+
+type Database struct {
+	// Backend is "json" to store the data in the updates.json
+	// file, or "sqlite" to store it in the updates.db file.
+	Backend string
+}
+
+func (d *Database) setDefaults() {
+	d.Backend = gosettings.DefaultComparable(d.Backend, "json")
+}
+
+func (d Database) Validate() (err error) {
+	err = validate.IsOneOf(d.Backend, "json", "sqlite")
+	if err != nil {
+		return fmt.Errorf("backend: %w", err)
+	}
+	return nil
+}
+
+func (d Database) String() string {
+	return d.toLinesNode().String()
+}
+
+func (d Database) toLinesNode() *gotree.Node {
+	switch d.Backend {
+	case "sqlite":
+		return gotree.New("Database: SQLite file updates.db")
+	default:
+		return gotree.New("Database: JSON file updates.json")
+	}
+}
+
+func (d *Database) read(r *reader.Reader) {
+	d.Backend = r.String("DATABASE_BACKEND")
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/qdm12/gosettings"
+	"github.com/qdm12/gosettings/reader"
+	"github.com/qdm12/gosettings/validate"
+	"github.com/qdm12/gotree"
+)
+
+type Email struct {
+	// Host is the SMTP server host, and the emails
+	// are disabled if it is empty.
+	Host string
+	// Port is the SMTP server port, and defaults to 465 for
+	// the tls security, 587 for starttls and 25 for none.
+	Port     *uint16
+	Security string
+	Username string
+	Password string
+	// From defaults to Username.
+	From   string
+	To     []string
+	Events []string
+	// Subject and Body are the Go templates of the emails,
+	// and the defaults are used if they are empty.
+	Subject string
+	Body    string
+}
+
+func (e *Email) setDefaults() {
+	e.Security = gosettings.DefaultComparable(e.Security, "starttls")
+	defaultPort := uint16(587) //nolint:gomnd
+	switch e.Security {
+	case "tls":
+		defaultPort = 465
+	case "none":
+		defaultPort = 25
+	}
+	e.Port = gosettings.DefaultPointer(e.Port, defaultPort)
+	e.From = gosettings.DefaultComparable(e.From, e.Username)
+	e.To = gosettings.DefaultSlice(e.To, []string{})
+	e.Events = gosettings.DefaultSlice(e.Events, defaultEventTypes())
+}
+
+var (
+	ErrEmailAddressNotValid = errors.New("email address is not valid")
+	ErrEmailToNotSet        = errors.New("email recipients are not set")
+)
+
+func (e Email) Validate() (err error) {
+	if e.Host == "" {
+		return nil
+	}
+
+	err = validate.IsOneOf(e.Security, "tls", "starttls", "none")
+	if err != nil {
+		return fmt.Errorf("security: %w", err)
+	}
+
+	if len(e.To) == 0 {
+		return fmt.Errorf("%w", ErrEmailToNotSet)
+	}
+	for _, address := range append([]string{e.From}, e.To...) {
+		_, err = mail.ParseAddress(address)
+		if err != nil {
+			return fmt.Errorf("%w: %q: %w", ErrEmailAddressNotValid, address, err)
+		}
+	}
+
+	err = validateEvents(e.Events)
+	if err != nil {
+		return err
+	}
+
+	err = validateTemplate("subject", e.Subject)
+	if err != nil {
+		return err
+	}
+	return validateTemplate("body", e.Body)
+}
+
+func (e Email) String() string {
+	return e.toLinesNode().String()
+}
+
+func (e Email) toLinesNode() *gotree.Node {
+	if e.Host == "" {
+		return nil // no host means the emails are disabled
+	}
+
+	node := gotree.New("Email")
+	node.Appendf("SMTP server: %s:%d (%s)", e.Host, *e.Port, e.Security)
+	if e.Username != "" {
+		node.Appendf("Username: %s", e.Username)
+		node.Appendf("Password: [redacted]")
+	}
+	node.Appendf("From: %s", e.From)
+	node.Appendf("To: %s", strings.Join(e.To, ", "))
+	node.Appendf("Events: %s", strings.Join(e.Events, ", "))
+	if e.Subject != "" {
+		node.Appendf("Subject: custom template")
+	}
+	if e.Body != "" {
+		node.Appendf("Body: custom template")
+	}
+	return node
+}
+
+func (e *Email) read(r *reader.Reader) (err error) {
+	e.Host = r.String("EMAIL_SMTP_HOST")
+	e.Port, err = r.Uint16Ptr("EMAIL_SMTP_PORT")
+	if err != nil {
+		return err
+	}
+	e.Security = r.String("EMAIL_SMTP_SECURITY")
+	e.Username = r.String("EMAIL_SMTP_USERNAME", reader.ForceLowercase(false))
+	e.Password = r.String("EMAIL_SMTP_PASSWORD", reader.ForceLowercase(false))
+	e.From = r.String("EMAIL_FROM", reader.ForceLowercase(false))
+	e.To = r.CSV("EMAIL_TO", reader.ForceLowercase(false))
+	e.Events = r.CSV("EMAIL_EVENTS")
+	e.Subject = r.String("EMAIL_SUBJECT", reader.ForceLowercase(false))
+	e.Body = r.String("EMAIL_BODY", reader.ForceLowercase(false))
+	return nil
+}
@@ -14,11 +14,14 @@ import (
 type Logger struct {
 	Level  string
 	Caller string
+	// Format is text, or json to log one JSON object per line.
+	Format string
 }
 
 func (l *Logger) setDefaults() {
 	l.Level = gosettings.DefaultComparable(l.Level, log.LevelInfo.String())
 	l.Caller = gosettings.DefaultComparable(l.Caller, "hidden")
+	l.Format = gosettings.DefaultComparable(l.Format, "text")
 }
 
 func (l Logger) Validate() (err error) {
@@ -32,6 +35,11 @@ func (l Logger) Validate() (err error) {
 		return fmt.Errorf("log caller: %w", err)
 	}
 
+	err = validate.IsOneOf(l.Format, "text", "json")
+	if err != nil {
+		return fmt.Errorf("log format: %w", err)
+	}
+
 	return nil
 }
 
@@ -52,6 +60,7 @@ func (l Logger) toLinesNode() *gotree.Node {
 	node := gotree.New("Logger")
 	node.Appendf("Level: %s", l.Level)
 	node.Appendf("Caller: %s", l.Caller)
+	node.Appendf("Format: %s", l.Format)
 	return node
 }
 
@@ -62,4 +71,5 @@ func (l *Logger) read(reader *reader.Reader) {
 		l.Level = "warn"
 	}
 	l.Caller = reader.String("LOG_CALLER")
+	l.Format = reader.String("LOG_FORMAT")
 }
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"text/template"
+
+	"github.com/qdm12/gosettings/validate"
+)
+
+// eventTypes returns the update event types which can be notified.
+func eventTypes() []string {
+	return []string{"updated", "ip_changed", "update_failed",
+		"credentials_invalid", "suspended", "record_restored"}
+}
+
+// defaultEventTypes returns the update event types notified by
+// default, which are all of them but the updates without IP change.
+func defaultEventTypes() []string {
+	return []string{"ip_changed", "update_failed",
+		"credentials_invalid", "suspended", "record_restored"}
+}
+
+func validateEvents(events []string) (err error) {
+	for _, event := range events {
+		err = validate.IsOneOf(event, eventTypes()...)
+		if err != nil {
+			return fmt.Errorf("event: %w", err)
+		}
+	}
+	return nil
+}
+
+func validateTemplate(name, text string) (err error) {
+	_, err = template.New(name).Parse(text)
+	if err != nil {
+		return fmt.Errorf("parsing %s template: %w", name, err)
+	}
+	return nil
+}
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"path/filepath"
+	"time"
 
+	"github.com/qdm12/ddns-updater/internal/configcrypt"
 	"github.com/qdm12/gosettings"
 	"github.com/qdm12/gosettings/reader"
 	"github.com/qdm12/gotree"
@@ -11,15 +14,29 @@ import (
 type Paths struct {
 	DataDir *string
 	Config  *string
+	// ConfigWatchPeriod is the period to check the config file for
+	// changes to reload it, and 0 disables checking the file.
+	ConfigWatchPeriod *time.Duration
+	// ConfigKey is the key to decrypt the config file if it is
+	// encrypted, and to encrypt it when written from CONFIG.
+	ConfigKey *string
 }
 
-func (p *Paths) setDefaults() {
+func (p *Paths) SetDefaults() {
 	p.DataDir = gosettings.DefaultPointer(p.DataDir, "./data")
 	defaultConfig := filepath.Join(*p.DataDir, "config.json")
 	p.Config = gosettings.DefaultPointer(p.Config, defaultConfig)
+	p.ConfigWatchPeriod = gosettings.DefaultPointer(p.ConfigWatchPeriod, 0)
+	p.ConfigKey = gosettings.DefaultPointer(p.ConfigKey, "")
 }
 
 func (p Paths) Validate() (err error) {
+	if *p.ConfigKey != "" {
+		err = configcrypt.ValidateKey(*p.ConfigKey)
+		if err != nil {
+			return fmt.Errorf("config key: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -31,10 +48,19 @@ func (p Paths) toLinesNode() *gotree.Node {
 	node := gotree.New("Paths")
 	node.Appendf("Data directory: %s", *p.DataDir)
 	node.Appendf("Config file: %s", *p.Config)
+	if *p.ConfigWatchPeriod > 0 {
+		node.Appendf("Config file watch period: %s", *p.ConfigWatchPeriod)
+	}
+	if *p.ConfigKey != "" {
+		node.Appendf("Config file key: [redacted]")
+	}
 	return node
 }
 
-func (p *Paths) read(reader *reader.Reader) {
+func (p *Paths) Read(reader *reader.Reader) (err error) {
 	p.DataDir = reader.Get("DATADIR")
 	p.Config = reader.Get("CONFIG_FILEPATH")
+	p.ConfigWatchPeriod, err = reader.DurationPtr("CONFIG_WATCH_PERIOD")
+	p.ConfigKey = reader.Get("CONFIG_KEY")
+	return err
 }
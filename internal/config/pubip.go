@@ -3,13 +3,18 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net/netip"
 	"net/url"
 	"strings"
 	"time"
 
 	"github.com/qdm12/ddns-updater/pkg/publicip/dns"
+	"github.com/qdm12/ddns-updater/pkg/publicip/external"
 	"github.com/qdm12/ddns-updater/pkg/publicip/http"
+	"github.com/qdm12/ddns-updater/pkg/publicip/iface"
 	"github.com/qdm12/ddns-updater/pkg/publicip/ipversion"
+	"github.com/qdm12/ddns-updater/pkg/publicip/router"
+	"github.com/qdm12/ddns-updater/pkg/publicip/stun"
 	"github.com/qdm12/gosettings"
 	"github.com/qdm12/gosettings/reader"
 	"github.com/qdm12/gosettings/validate"
@@ -24,6 +29,30 @@ type PubIP struct {
 	DNSEnabled        *bool
 	DNSProviders      []string
 	DNSTimeout        time.Duration
+	STUNEnabled       *bool
+	STUNServers       []string
+	STUNTimeout       time.Duration
+	InterfaceEnabled  *bool
+	InterfaceName     string
+	// InterfacePrefixes are the prefixes the interface address must be
+	// within, or empty to use its first public address.
+	InterfacePrefixes []netip.Prefix
+	ExternalEnabled   *bool
+	// ExternalCommand is the command to run to get the public
+	// IP address from its output, and is exclusive with ExternalFile.
+	ExternalCommand string
+	// ExternalFile is the file to read the public IP address from.
+	ExternalFile    string
+	ExternalTimeout time.Duration
+	RouterEnabled   *bool
+	RouterProtocols []string
+	// RouterGateway is the gateway to query with NAT-PMP, or the
+	// zero value to use the default gateway of the host.
+	RouterGateway netip.Addr
+	RouterTimeout time.Duration
+	// Quorum is the number of enabled fetchers which must agree on the
+	// IP address, or 0 or 1 to use the enabled fetchers in turn.
+	Quorum uint
 }
 
 func (p *PubIP) setDefaults() {
@@ -35,6 +64,22 @@ func (p *PubIP) setDefaults() {
 	p.DNSProviders = gosettings.DefaultSlice(p.DNSProviders, []string{all})
 	const defaultDNSTimeout = 3 * time.Second
 	p.DNSTimeout = gosettings.DefaultComparable(p.DNSTimeout, defaultDNSTimeout)
+	p.STUNEnabled = gosettings.DefaultPointer(p.STUNEnabled, false)
+	p.STUNServers = gosettings.DefaultSlice(p.STUNServers, stun.ListDefaultServers())
+	const defaultSTUNTimeout = 3 * time.Second
+	p.STUNTimeout = gosettings.DefaultComparable(p.STUNTimeout, defaultSTUNTimeout)
+	p.InterfaceEnabled = gosettings.DefaultPointer(p.InterfaceEnabled, false)
+	p.ExternalEnabled = gosettings.DefaultPointer(p.ExternalEnabled, false)
+	const defaultExternalTimeout = 10 * time.Second
+	p.ExternalTimeout = gosettings.DefaultComparable(p.ExternalTimeout, defaultExternalTimeout)
+	p.RouterEnabled = gosettings.DefaultPointer(p.RouterEnabled, false)
+	defaultRouterProtocols := make([]string, 0, len(router.ListProtocols()))
+	for _, protocol := range router.ListProtocols() {
+		defaultRouterProtocols = append(defaultRouterProtocols, string(protocol))
+	}
+	p.RouterProtocols = gosettings.DefaultSlice(p.RouterProtocols, defaultRouterProtocols)
+	const defaultRouterTimeout = 3 * time.Second
+	p.RouterTimeout = gosettings.DefaultComparable(p.RouterTimeout, defaultRouterTimeout)
 }
 
 func (p PubIP) Validate() (err error) {
@@ -58,9 +103,47 @@ func (p PubIP) Validate() (err error) {
 		return fmt.Errorf("DNS providers: %w", err)
 	}
 
+	for _, server := range p.STUNServers {
+		err = stun.ValidateServer(server)
+		if err != nil {
+			return fmt.Errorf("STUN servers: %w", err)
+		}
+	}
+
+	if *p.InterfaceEnabled && p.InterfaceName == "" {
+		return fmt.Errorf("%w", ErrInterfaceNameNotSet)
+	}
+
+	err = p.validateExternal()
+	if err != nil {
+		return fmt.Errorf("external fetcher: %w", err)
+	}
+
+	for _, protocol := range p.RouterProtocols {
+		err = router.ValidateProtocol(router.Protocol(protocol))
+		if err != nil {
+			return fmt.Errorf("router protocols: %w", err)
+		}
+	}
+
+	if fetchers := p.enabledFetchers(); p.Quorum > fetchers {
+		return fmt.Errorf("%w: %d for %d fetchers enabled",
+			ErrQuorumTooHigh, p.Quorum, fetchers)
+	}
+
 	return nil
 }
 
+func (p PubIP) enabledFetchers() (count uint) {
+	for _, enabled := range []*bool{p.HTTPEnabled, p.DNSEnabled,
+		p.STUNEnabled, p.InterfaceEnabled, p.ExternalEnabled, p.RouterEnabled} {
+		if *enabled {
+			count++
+		}
+	}
+	return count
+}
+
 func (p *PubIP) String() string {
 	return p.toLinesNode().String()
 }
@@ -95,6 +178,49 @@ func (p *PubIP) toLinesNode() (node *gotree.Node) {
 		}
 	}
 
+	node.Appendf("STUN enabled: %s", gosettings.BoolToYesNo(p.STUNEnabled))
+	if *p.STUNEnabled {
+		node.Appendf("STUN timeout: %s", p.STUNTimeout)
+		childNode := node.Appendf("STUN servers")
+		for _, server := range p.STUNServers {
+			childNode.Appendf(server)
+		}
+	}
+
+	node.Appendf("Interface enabled: %s", gosettings.BoolToYesNo(p.InterfaceEnabled))
+	if *p.InterfaceEnabled {
+		node.Appendf("Interface name: %s", p.InterfaceName)
+		if len(p.InterfacePrefixes) > 0 {
+			childNode := node.Appendf("Interface address prefixes")
+			for _, prefix := range p.InterfacePrefixes {
+				childNode.Appendf(prefix.String())
+			}
+		}
+	}
+
+	node.Appendf("External enabled: %s", gosettings.BoolToYesNo(p.ExternalEnabled))
+	if *p.ExternalEnabled {
+		if p.ExternalCommand != "" {
+			node.Appendf("External command: %s", p.ExternalCommand)
+			node.Appendf("External command timeout: %s", p.ExternalTimeout)
+		} else {
+			node.Appendf("External file: %s", p.ExternalFile)
+		}
+	}
+
+	node.Appendf("Router enabled: %s", gosettings.BoolToYesNo(p.RouterEnabled))
+	if *p.RouterEnabled {
+		node.Appendf("Router protocols: %s", strings.Join(p.RouterProtocols, ", "))
+		if p.RouterGateway.IsValid() {
+			node.Appendf("Router gateway: %s", p.RouterGateway)
+		}
+		node.Appendf("Router timeout: %s", p.RouterTimeout)
+	}
+
+	if p.Quorum > 1 {
+		node.Appendf("Quorum: %d fetchers must agree", p.Quorum)
+	}
+
 	return node
 }
 
@@ -158,7 +284,68 @@ func (p *PubIP) ToDNSPOptions() (options []dns.Option) {
 	}
 }
 
+// ToSTUNOptions assumes the settings have been validated.
+func (p *PubIP) ToSTUNOptions() (options []stun.Option) {
+	return []stun.Option{
+		stun.SetTimeout(p.STUNTimeout),
+		stun.SetServers(p.STUNServers[0], p.STUNServers[1:]...),
+	}
+}
+
+func (p *PubIP) ToInterfaceOptions() (options []iface.Option) {
+	return []iface.Option{
+		iface.SetPrefixes(p.InterfacePrefixes...),
+	}
+}
+
+// ToExternalOptions assumes the settings have been validated.
+func (p *PubIP) ToExternalOptions() (options []external.Option) {
+	options = []external.Option{external.SetTimeout(p.ExternalTimeout)}
+	if p.ExternalCommand != "" {
+		return append(options, external.SetCommand(p.ExternalCommand))
+	}
+	return append(options, external.SetFile(p.ExternalFile))
+}
+
+// ToRouterOptions assumes the settings have been validated.
+func (p *PubIP) ToRouterOptions() (options []router.Option) {
+	protocols := make([]router.Protocol, len(p.RouterProtocols))
+	for i, protocol := range p.RouterProtocols {
+		protocols[i] = router.Protocol(protocol)
+	}
+	return []router.Option{
+		router.SetProtocols(protocols[0], protocols[1:]...),
+		router.SetGateway(p.RouterGateway),
+		router.SetTimeout(p.RouterTimeout),
+	}
+}
+
+var ErrQuorumTooHigh = errors.New("quorum is higher than the number of fetchers enabled")
+
+var (
+	ErrExternalSourceNotSet    = errors.New("command or file must be set")
+	ErrExternalSourceDuplicate = errors.New("command and file cannot be both set")
+)
+
+func (p PubIP) validateExternal() (err error) {
+	if !*p.ExternalEnabled {
+		return nil
+	}
+
+	switch {
+	case p.ExternalCommand == "" && p.ExternalFile == "":
+		return fmt.Errorf("%w", ErrExternalSourceNotSet)
+	case p.ExternalCommand != "" && p.ExternalFile != "":
+		return fmt.Errorf("%w", ErrExternalSourceDuplicate)
+	case p.ExternalCommand != "":
+		_, err = external.SplitCommand(p.ExternalCommand)
+		return err
+	}
+	return nil
+}
+
 var (
+	ErrInterfaceNameNotSet   = errors.New("interface name is not set")
 	ErrNoPublicIPDNSProvider = errors.New("no public IP DNS provider specified")
 )
 
@@ -234,7 +421,7 @@ func validateHTTPIPProviders(providerStrings []string,
 }
 
 func (p *PubIP) read(r *reader.Reader, warner Warner) (err error) {
-	p.HTTPEnabled, p.DNSEnabled, err = getFetchers(r)
+	err = p.readFetchers(r)
 	if err != nil {
 		return err
 	}
@@ -287,37 +474,91 @@ func (p *PubIP) read(r *reader.Reader, warner Warner) (err error) {
 		return err
 	}
 
+	p.STUNServers = r.CSV("PUBLICIP_STUN_SERVERS")
+
+	p.STUNTimeout, err = r.Duration("PUBLICIP_STUN_TIMEOUT")
+	if err != nil {
+		return err
+	}
+
+	p.InterfaceName = r.String("PUBLICIP_INTERFACE")
+	prefixes := r.CSV("PUBLICIP_INTERFACE_PREFIXES")
+	p.InterfacePrefixes = make([]netip.Prefix, len(prefixes))
+	for i, prefix := range prefixes {
+		p.InterfacePrefixes[i], err = netip.ParsePrefix(prefix)
+		if err != nil {
+			return fmt.Errorf("environment variable PUBLICIP_INTERFACE_PREFIXES: %w", err)
+		}
+	}
+
+	p.ExternalCommand = r.String("PUBLICIP_EXTERNAL_COMMAND")
+	p.ExternalFile = r.String("PUBLICIP_EXTERNAL_FILE")
+	p.ExternalTimeout, err = r.Duration("PUBLICIP_EXTERNAL_TIMEOUT")
+	if err != nil {
+		return err
+	}
+
+	p.RouterProtocols = r.CSV("PUBLICIP_ROUTER_PROTOCOLS")
+	if gateway := r.String("PUBLICIP_ROUTER_GATEWAY"); gateway != "" {
+		p.RouterGateway, err = netip.ParseAddr(gateway)
+		if err != nil {
+			return fmt.Errorf("environment variable PUBLICIP_ROUTER_GATEWAY: %w", err)
+		}
+	}
+	p.RouterTimeout, err = r.Duration("PUBLICIP_ROUTER_TIMEOUT")
+	if err != nil {
+		return err
+	}
+
+	p.Quorum, err = r.Uint("PUBLICIP_QUORUM")
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
 var ErrFetcherNotValid = errors.New("fetcher is not valid")
 
-func getFetchers(reader *reader.Reader) (http, dns *bool, err error) {
+// readFetchers sets which fetchers are enabled. The interface, external
+// and router fetchers are not enabled by "all" since they depend on the
+// host and its network.
+func (p *PubIP) readFetchers(reader *reader.Reader) (err error) {
 	// TODO change to use reader.BoolPtr with retro-compatibility
 	s := reader.String("PUBLICIP_FETCHERS")
 	if s == "" {
-		return nil, nil, nil
+		return nil
 	}
 
-	http, dns = new(bool), new(bool)
+	p.HTTPEnabled, p.DNSEnabled, p.STUNEnabled = new(bool), new(bool), new(bool)
+	p.InterfaceEnabled, p.ExternalEnabled, p.RouterEnabled = new(bool), new(bool), new(bool)
 	fields := strings.Split(s, ",")
 	for i, field := range fields {
 		switch strings.ToLower(field) {
 		case "all":
-			*http = true
-			*dns = true
+			*p.HTTPEnabled = true
+			*p.DNSEnabled = true
+			*p.STUNEnabled = true
 		case "http":
-			*http = true
+			*p.HTTPEnabled = true
 		case "dns":
-			*dns = true
+			*p.DNSEnabled = true
+		case "stun":
+			*p.STUNEnabled = true
+		case "interface":
+			*p.InterfaceEnabled = true
+		case "external":
+			*p.ExternalEnabled = true
+		case "router":
+			*p.RouterEnabled = true
 		default:
-			return nil, nil, fmt.Errorf(
+			return fmt.Errorf(
 				"%w: %q at position %d of %d",
 				ErrFetcherNotValid, field, i+1, len(fields))
 		}
 	}
 
-	return http, dns, nil
+	return nil
 }
 
 func handleRetroProvider(provider string) (updatedProvider string) {
@@ -14,6 +14,9 @@ type Server struct {
 	Enabled          *bool
 	ListeningAddress string
 	RootURL          string
+	ACMEUsername     string
+	ACMEPassword     string
+	APIToken         string
 }
 
 func (s *Server) setDefaults() {
@@ -44,6 +47,16 @@ func (s Server) toLinesNode() *gotree.Node {
 	node := gotree.New("Server")
 	node.Appendf("Listening address: %s", s.ListeningAddress)
 	node.Appendf("Root URL: %s", s.RootURL)
+	if s.ACMEPassword == "" {
+		node.Appendf("ACME challenge endpoints: disabled")
+	} else {
+		node.Appendf("ACME challenge endpoints username: %s", s.ACMEUsername)
+	}
+	if s.APIToken == "" {
+		node.Appendf("Management API: disabled")
+	} else {
+		node.Appendf("Management API: enabled")
+	}
 	return node
 }
 
@@ -66,5 +79,9 @@ func (s *Server) read(reader *reader.Reader, warner Warner) (err error) {
 
 	s.ListeningAddress = reader.String("LISTENING_ADDRESS")
 
+	s.ACMEUsername = reader.String("SERVER_ACME_USERNAME")
+	s.ACMEPassword = reader.String("SERVER_ACME_PASSWORD")
+	s.APIToken = reader.String("SERVER_API_TOKEN")
+
 	return err
 }
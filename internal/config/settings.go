@@ -15,22 +15,30 @@ type Config struct {
 	Server   Server
 	Health   Health
 	Paths    Paths
+	Database Database
 	Backup   Backup
 	Logger   Logger
 	Shoutrrr Shoutrrr
+	Webhook  Webhook
+	Email    Email
+	Tracing  Tracing
 }
 
 func (c *Config) SetDefaults() {
-	c.Client.setDefaults()
+	c.Client.SetDefaults()
 	c.Update.setDefaults()
 	c.PubIP.setDefaults()
 	c.Resolver.setDefaults()
 	c.Server.setDefaults()
 	c.Health.SetDefaults()
-	c.Paths.setDefaults()
+	c.Paths.SetDefaults()
+	c.Database.setDefaults()
 	c.Backup.setDefaults()
 	c.Logger.setDefaults()
 	c.Shoutrrr.setDefaults()
+	c.Webhook.setDefaults()
+	c.Email.setDefaults()
+	c.Tracing.setDefaults()
 }
 
 func (c Config) Validate() (err error) {
@@ -45,9 +53,13 @@ func (c Config) Validate() (err error) {
 		"server":    &c.Server,
 		"health":    &c.Health,
 		"paths":     &c.Paths,
+		"database":  &c.Database,
 		"backup":    &c.Backup,
 		"logger":    &c.Logger,
 		"shoutrrr":  &c.Shoutrrr,
+		"webhook":   &c.Webhook,
+		"email":     &c.Email,
+		"tracing":   &c.Tracing,
 	}
 
 	for name, v := range toValidate {
@@ -73,15 +85,19 @@ func (c Config) toLinesNode() *gotree.Node {
 	node.AppendNode(c.Server.toLinesNode())
 	node.AppendNode(c.Health.toLinesNode())
 	node.AppendNode(c.Paths.toLinesNode())
+	node.AppendNode(c.Database.toLinesNode())
 	node.AppendNode(c.Backup.toLinesNode())
 	node.AppendNode(c.Logger.toLinesNode())
 	node.AppendNode(c.Shoutrrr.ToLinesNode())
+	node.AppendNode(c.Webhook.toLinesNode())
+	node.AppendNode(c.Email.toLinesNode())
+	node.AppendNode(c.Tracing.toLinesNode())
 	return node
 }
 
 func (c *Config) Read(reader *reader.Reader,
 	warner Warner) (err error) {
-	err = c.Client.read(reader)
+	err = c.Client.Read(reader)
 	if err != nil {
 		return fmt.Errorf("reading client settings: %w", err)
 	}
@@ -107,7 +123,13 @@ func (c *Config) Read(reader *reader.Reader,
 	}
 
 	c.Health.Read(reader)
-	c.Paths.read(reader)
+
+	err = c.Paths.Read(reader)
+	if err != nil {
+		return fmt.Errorf("reading paths settings: %w", err)
+	}
+
+	c.Database.read(reader)
 
 	err = c.Backup.read(reader)
 	if err != nil {
@@ -121,5 +143,17 @@ func (c *Config) Read(reader *reader.Reader,
 		return fmt.Errorf("reading shoutrrr settings: %w", err)
 	}
 
+	err = c.Webhook.read(reader)
+	if err != nil {
+		return fmt.Errorf("reading webhook settings: %w", err)
+	}
+
+	err = c.Email.read(reader)
+	if err != nil {
+		return fmt.Errorf("reading email settings: %w", err)
+	}
+
+	c.Tracing.read(reader)
+
 	return nil
 }
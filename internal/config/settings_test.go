@@ -19,7 +19,10 @@ func Test_Settings_String(t *testing.T) {
 |   └── Timeout: 20s
 ├── Update
 |   ├── Period: 10m0s
-|   └── Cooldown: 5m0s
+|   ├── Cooldown: 5m0s
+|   ├── Jitter: disabled
+|   ├── Failure backoff maximum: 1h0m0s
+|   └── Shutdown timeout: 30s
 ├── Public IP fetching
 |   ├── HTTP enabled: yes
 |   ├── HTTP IP providers
@@ -30,20 +33,28 @@ func Test_Settings_String(t *testing.T) {
 |   |   └── all
 |   ├── DNS enabled: yes
 |   ├── DNS timeout: 3s
-|   └── DNS over TLS providers
-|       └── all
+|   ├── DNS over TLS providers
+|   |   └── all
+|   ├── STUN enabled: no
+|   ├── Interface enabled: no
+|   ├── External enabled: no
+|   └── Router enabled: no
 ├── Resolver: use Go default resolver
 ├── Server
 |   ├── Listening address: :8000
-|   └── Root URL: /
+|   ├── Root URL: /
+|   ├── ACME challenge endpoints: disabled
+|   └── Management API: disabled
 ├── Health
 |   └── Server listening address: 127.0.0.1:9999
 ├── Paths
 |   ├── Data directory: ./data
 |   └── Config file: data/config.json
+├── Database: JSON file updates.json
 ├── Backup: disabled
 └── Logger
     ├── Level: INFO
-    └── Caller: hidden`
+    ├── Caller: hidden
+    └── Format: text`
 	assert.Equal(t, expected, s)
 }
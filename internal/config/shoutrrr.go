@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/url"
 	"path"
+	"strings"
 
 	"github.com/containrrr/shoutrrr"
 	"github.com/qdm12/gosettings"
@@ -14,11 +15,18 @@ import (
 type Shoutrrr struct {
 	Addresses    []string
 	DefaultTitle string
+	// Events are the update event types notified, which
+	// are all of them by default.
+	Events []string
+	// Message is the Go template of the update event messages,
+	// and the default message is used if it is empty.
+	Message string
 }
 
 func (s *Shoutrrr) setDefaults() {
 	s.Addresses = gosettings.DefaultSlice(s.Addresses, []string{})
 	s.DefaultTitle = gosettings.DefaultComparable(s.DefaultTitle, "DDNS Updater")
+	s.Events = gosettings.DefaultSlice(s.Events, eventTypes())
 }
 
 func (s Shoutrrr) Validate() (err error) {
@@ -26,7 +34,12 @@ func (s Shoutrrr) Validate() (err error) {
 	if err != nil {
 		return fmt.Errorf("shoutrrr addresses: %w", err)
 	}
-	return nil
+
+	err = validateEvents(s.Events)
+	if err != nil {
+		return err
+	}
+	return validateTemplate("message", s.Message)
 }
 
 func (s Shoutrrr) String() string {
@@ -40,10 +53,16 @@ func (s Shoutrrr) ToLinesNode() *gotree.Node {
 
 	node := gotree.New("Shoutrrr")
 	node.Appendf("Default title: %s", s.DefaultTitle)
+	node.Appendf("Events: %s", strings.Join(s.Events, ", "))
+	if s.Message != "" {
+		node.Appendf("Message: custom template")
+	}
 
 	childNode := node.Appendf("Addresses")
 	for _, address := range s.Addresses {
-		childNode.Appendf(address)
+		// The addresses hold the service credentials, such as tokens.
+		scheme, _, _ := strings.Cut(address, "://")
+		childNode.Appendf("%s://[redacted]", scheme)
 	}
 
 	return node
@@ -74,6 +93,8 @@ func (s *Shoutrrr) read(r *reader.Reader, warner Warner) (err error) {
 	}
 
 	s.DefaultTitle = r.String("SHOUTRRR_DEFAULT_TITLE", reader.ForceLowercase(false))
+	s.Events = r.CSV("SHOUTRRR_EVENTS")
+	s.Message = r.String("SHOUTRRR_MESSAGE", reader.ForceLowercase(false))
 	return nil
 }
 
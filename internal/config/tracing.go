@@ -0,0 +1,113 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/qdm12/gosettings"
+	"github.com/qdm12/gosettings/reader"
+	"github.com/qdm12/gotree"
+)
+
+// Tracing holds the settings of the export of the update cycles spans,
+// read from the OpenTelemetry standard environment variables.
+type Tracing struct {
+	// Endpoint is the OTLP/HTTP traces endpoint URL. An empty
+	// endpoint means the tracing is disabled.
+	Endpoint string
+	// Headers are sent with each export request, each in the
+	// form "key=value" with the value URL encoded.
+	Headers     []string
+	ServiceName string
+}
+
+func (t *Tracing) setDefaults() {
+	t.Headers = gosettings.DefaultSlice(t.Headers, []string{})
+	t.ServiceName = gosettings.DefaultComparable(t.ServiceName, "ddns-updater")
+}
+
+var (
+	ErrTracingEndpointNotValid = errors.New("tracing endpoint is not valid")
+	ErrTracingHeaderNotValid   = errors.New("tracing header is not valid")
+)
+
+func (t Tracing) Validate() (err error) {
+	if t.Endpoint == "" {
+		return nil
+	}
+
+	u, err := url.Parse(t.Endpoint)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrTracingEndpointNotValid, err)
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: scheme %q must be http or https", ErrTracingEndpointNotValid, u.Scheme)
+	}
+
+	for _, header := range t.Headers {
+		key, value, ok := strings.Cut(header, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return fmt.Errorf("%w: %q must be in the form \"key=value\"",
+				ErrTracingHeaderNotValid, header)
+		}
+		_, err = url.QueryUnescape(value)
+		if err != nil {
+			return fmt.Errorf("%w: value of %q: %w", ErrTracingHeaderNotValid, key, err)
+		}
+	}
+	return nil
+}
+
+// ToHeaders returns the headers to send with each export request.
+func (t Tracing) ToHeaders() (headers map[string]string) {
+	headers = make(map[string]string, len(t.Headers))
+	for _, header := range t.Headers {
+		key, value, _ := strings.Cut(header, "=")
+		value, _ = url.QueryUnescape(value)
+		headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return headers
+}
+
+func (t Tracing) String() string {
+	return t.toLinesNode().String()
+}
+
+func (t Tracing) toLinesNode() *gotree.Node {
+	if t.Endpoint == "" {
+		return nil // no endpoint means the tracing is disabled
+	}
+
+	node := gotree.New("Tracing")
+	u, err := url.Parse(t.Endpoint)
+	if err == nil {
+		node.Appendf("Endpoint: %s", u.Redacted())
+	}
+	if len(t.Headers) > 0 {
+		headersNode := node.Appendf("Headers")
+		for _, header := range t.Headers {
+			key, _, _ := strings.Cut(header, "=")
+			headersNode.Appendf("%s: [redacted]", strings.TrimSpace(key))
+		}
+	}
+	node.Appendf("Service name: %s", t.ServiceName)
+	return node
+}
+
+func (t *Tracing) read(r *reader.Reader) {
+	t.Endpoint = r.String("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", reader.ForceLowercase(false))
+	if t.Endpoint == "" {
+		// The traces endpoint is derived from the base
+		// endpoint as specified by OpenTelemetry.
+		base := r.String("OTEL_EXPORTER_OTLP_ENDPOINT", reader.ForceLowercase(false))
+		if base != "" {
+			t.Endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+		}
+	}
+	t.Headers = r.CSV("OTEL_EXPORTER_OTLP_TRACES_HEADERS", reader.ForceLowercase(false))
+	if len(t.Headers) == 0 {
+		t.Headers = r.CSV("OTEL_EXPORTER_OTLP_HEADERS", reader.ForceLowercase(false))
+	}
+	t.ServiceName = r.String("OTEL_SERVICE_NAME", reader.ForceLowercase(false))
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
 	"time"
 
@@ -12,6 +14,36 @@ import (
 type Update struct {
 	Period   time.Duration
 	Cooldown time.Duration
+	// Jitter is the duration across which the checks of the records
+	// are spread, so they are not all done at the same time. A zero
+	// value disables it.
+	Jitter time.Duration
+	// BackoffMax is the maximum time to wait before updating again a
+	// record failing to update, doubling from its period after each
+	// consecutive failure. It cannot be nil in the internal state, and
+	// a zero value disables the backoff.
+	BackoffMax *time.Duration
+	// StableDetections is the number of consecutive detections of a new
+	// public IP address required before updating the records with it.
+	// It cannot be nil in the internal state, and 1 disables it.
+	StableDetections *uint
+	// StableDuration is the minimum time a new public IP address must be
+	// detected for before updating the records with it. A zero value
+	// disables it.
+	StableDuration time.Duration
+	// Workers is the maximum number of records updated at the same time.
+	// It cannot be nil in the internal state, and 1 updates the records
+	// one after the other.
+	Workers *uint
+	// WorkersPerHost is the maximum number of records updated at the same
+	// time through the same provider API host, the records of the same
+	// account being always updated one after the other. It cannot be nil
+	// in the internal state.
+	WorkersPerHost *uint
+	// ShutdownTimeout is the time to wait, when shutting down, for the
+	// record updates in progress to finish before canceling them.
+	// It cannot be nil in the internal state.
+	ShutdownTimeout *time.Duration
 }
 
 func (u *Update) setDefaults() {
@@ -19,9 +51,39 @@ func (u *Update) setDefaults() {
 	u.Period = gosettings.DefaultComparable(u.Period, defaultPeriod)
 	const defaultCooldown = 5 * time.Minute
 	u.Cooldown = gosettings.DefaultComparable(u.Cooldown, defaultCooldown)
+	const defaultBackoffMax = time.Hour
+	u.BackoffMax = gosettings.DefaultPointer(u.BackoffMax, defaultBackoffMax)
+	const defaultStableDetections = 1
+	u.StableDetections = gosettings.DefaultPointer(u.StableDetections, defaultStableDetections)
+	const defaultWorkers = 1
+	u.Workers = gosettings.DefaultPointer(u.Workers, defaultWorkers)
+	const defaultWorkersPerHost = 1
+	u.WorkersPerHost = gosettings.DefaultPointer(u.WorkersPerHost, defaultWorkersPerHost)
+	const defaultShutdownTimeout = 30 * time.Second
+	u.ShutdownTimeout = gosettings.DefaultPointer(u.ShutdownTimeout, defaultShutdownTimeout)
 }
 
+var (
+	ErrJitterNegative         = errors.New("jitter cannot be negative")
+	ErrStableDetectionsZero   = errors.New("stable detections cannot be zero")
+	ErrStableDurationNegative = errors.New("stable duration cannot be negative")
+	ErrWorkersZero            = errors.New("workers cannot be zero")
+	ErrWorkersPerHostZero     = errors.New("workers per host cannot be zero")
+)
+
 func (u Update) Validate() (err error) {
+	switch {
+	case u.Jitter < 0:
+		return fmt.Errorf("%w: %s", ErrJitterNegative, u.Jitter)
+	case *u.StableDetections == 0:
+		return ErrStableDetectionsZero
+	case u.StableDuration < 0:
+		return fmt.Errorf("%w: %s", ErrStableDurationNegative, u.StableDuration)
+	case *u.Workers == 0:
+		return ErrWorkersZero
+	case *u.WorkersPerHost == 0:
+		return ErrWorkersPerHostZero
+	}
 	return nil
 }
 
@@ -33,6 +95,28 @@ func (u Update) toLinesNode() *gotree.Node {
 	node := gotree.New("Update")
 	node.Appendf("Period: %s", u.Period)
 	node.Appendf("Cooldown: %s", u.Cooldown)
+	if u.Jitter > 0 {
+		node.Appendf("Jitter: %s", u.Jitter)
+	} else {
+		node.Appendf("Jitter: disabled")
+	}
+	if *u.BackoffMax > 0 {
+		node.Appendf("Failure backoff maximum: %s", *u.BackoffMax)
+	} else {
+		node.Appendf("Failure backoff: disabled")
+	}
+	switch {
+	case *u.StableDetections > 1 && u.StableDuration > 0:
+		node.Appendf("New IP stable for: %d detections and %s", *u.StableDetections, u.StableDuration)
+	case *u.StableDetections > 1:
+		node.Appendf("New IP stable for: %d detections", *u.StableDetections)
+	case u.StableDuration > 0:
+		node.Appendf("New IP stable for: %s", u.StableDuration)
+	}
+	if *u.Workers > 1 {
+		node.Appendf("Workers: %d, %d per host", *u.Workers, *u.WorkersPerHost)
+	}
+	node.Appendf("Shutdown timeout: %s", *u.ShutdownTimeout)
 	return node
 }
 
@@ -43,6 +127,41 @@ func (u *Update) read(reader *reader.Reader, warner Warner) (err error) {
 	}
 
 	u.Cooldown, err = reader.Duration("UPDATE_COOLDOWN_PERIOD")
+	if err != nil {
+		return err
+	}
+
+	u.Jitter, err = reader.Duration("UPDATE_JITTER")
+	if err != nil {
+		return err
+	}
+
+	u.BackoffMax, err = reader.DurationPtr("UPDATE_BACKOFF_MAX")
+	if err != nil {
+		return err
+	}
+
+	u.StableDetections, err = reader.UintPtr("UPDATE_STABLE_DETECTIONS")
+	if err != nil {
+		return err
+	}
+
+	u.StableDuration, err = reader.Duration("UPDATE_STABLE_DURATION")
+	if err != nil {
+		return err
+	}
+
+	u.Workers, err = reader.UintPtr("UPDATE_WORKERS")
+	if err != nil {
+		return err
+	}
+
+	u.WorkersPerHost, err = reader.UintPtr("UPDATE_WORKERS_PER_HOST")
+	if err != nil {
+		return err
+	}
+
+	u.ShutdownTimeout, err = reader.DurationPtr("UPDATE_SHUTDOWN_TIMEOUT")
 	return err
 }
 
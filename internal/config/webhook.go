@@ -0,0 +1,114 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/qdm12/gosettings"
+	"github.com/qdm12/gosettings/reader"
+	"github.com/qdm12/gotree"
+)
+
+type Webhook struct {
+	URL    string
+	Events []string
+	// Headers are the headers sent with each request, such as
+	// "Authorization: Bearer token", in addition to the content type.
+	Headers []string
+	// Body is the Go template of the request body. An empty body
+	// means the event is sent encoded in JSON.
+	Body string
+	// Failures is the number of consecutive failed updates
+	// of a record needed to fire an update failure event.
+	Failures *uint
+}
+
+func (w *Webhook) setDefaults() {
+	w.Events = gosettings.DefaultSlice(w.Events, defaultEventTypes())
+	w.Headers = gosettings.DefaultSlice(w.Headers, []string{})
+	w.Failures = gosettings.DefaultPointer(w.Failures, 1)
+}
+
+var (
+	ErrWebhookURLNotValid      = errors.New("webhook URL is not valid")
+	ErrWebhookHeaderNotValid   = errors.New("webhook header is not valid")
+	ErrWebhookFailuresNotValid = errors.New("webhook failures count is not valid")
+)
+
+func (w Webhook) Validate() (err error) {
+	if w.URL == "" {
+		return nil
+	}
+
+	u, err := url.Parse(w.URL)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrWebhookURLNotValid, err)
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: scheme %q must be http or https", ErrWebhookURLNotValid, u.Scheme)
+	}
+
+	for _, header := range w.Headers {
+		name, _, ok := strings.Cut(header, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return fmt.Errorf("%w: %q must be in the form \"Name: value\"",
+				ErrWebhookHeaderNotValid, header)
+		}
+	}
+
+	err = validateEvents(w.Events)
+	if err != nil {
+		return err
+	}
+
+	err = validateTemplate("body", w.Body)
+	if err != nil {
+		return err
+	}
+
+	if *w.Failures == 0 {
+		return fmt.Errorf("%w: must be at least 1", ErrWebhookFailuresNotValid)
+	}
+	return nil
+}
+
+func (w Webhook) String() string {
+	return w.toLinesNode().String()
+}
+
+func (w Webhook) toLinesNode() *gotree.Node {
+	if w.URL == "" {
+		return nil // no URL means the webhook is disabled
+	}
+
+	node := gotree.New("Webhook")
+	u, err := url.Parse(w.URL)
+	if err == nil {
+		node.Appendf("URL: %s", u.Redacted())
+	}
+	node.Appendf("Events: %s", strings.Join(w.Events, ", "))
+	if len(w.Headers) > 0 {
+		headersNode := node.Appendf("Headers")
+		for _, header := range w.Headers {
+			name, _, _ := strings.Cut(header, ":")
+			headersNode.Appendf("%s: [redacted]", strings.TrimSpace(name))
+		}
+	}
+	if w.Body == "" {
+		node.Appendf("Body: JSON event")
+	} else {
+		node.Appendf("Body: custom template")
+	}
+	node.Appendf("Consecutive failures to notify: %d", *w.Failures)
+	return node
+}
+
+func (w *Webhook) read(r *reader.Reader) (err error) {
+	w.URL = r.String("WEBHOOK_URL", reader.ForceLowercase(false))
+	w.Events = r.CSV("WEBHOOK_EVENTS")
+	w.Headers = r.CSV("WEBHOOK_HEADERS", reader.ForceLowercase(false))
+	w.Body = r.String("WEBHOOK_BODY", reader.ForceLowercase(false))
+	w.Failures, err = r.UintPtr("WEBHOOK_FAILURES")
+	return err
+}
@@ -0,0 +1,137 @@
+// Package configcrypt encrypts and decrypts the JSON config file with
+// age (https://age-encryption.org), in its ASCII armored format so the
+// encrypted file can be kept as text, for example in a git repository,
+// and created, decrypted or re-encrypted with the age tools as well.
+package configcrypt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// binaryIntro is the first line of an age file which is not armored.
+const binaryIntro = "age-encryption.org/v1\n"
+
+var (
+	ErrKeyNotValid      = errors.New("key is not valid")
+	ErrNotEncrypted     = errors.New("data is not encrypted")
+	ErrAlreadyEncrypted = errors.New("data is already encrypted")
+	ErrDecryptionFailed = errors.New("decryption failed")
+)
+
+// GenerateKey returns a new age identity, in the format of age-keygen,
+// that is its public key as a comment followed by its secret key.
+func GenerateKey() (key string, err error) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		return "", fmt.Errorf("generating key: %w", err)
+	}
+	return "# public key: " + identity.Recipient().String() + "\n" + identity.String(), nil
+}
+
+// ValidateKey returns an error if the key is not made of one or more age
+// secret keys, one per line, empty lines and lines starting with # being
+// ignored, as in an age identity file.
+func ValidateKey(key string) (err error) {
+	_, err = parseKey(key)
+	return err
+}
+
+// IsEncrypted returns true if the data is an age encrypted file,
+// armored or not.
+func IsEncrypted(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	return bytes.HasPrefix(trimmed, []byte(armor.Header)) ||
+		bytes.HasPrefix(data, []byte(binaryIntro))
+}
+
+// Encrypt encrypts the data to the public keys of the age secret keys of
+// key, so that it can be decrypted with any of them, and armors it.
+func Encrypt(data []byte, key string) (encrypted []byte, err error) {
+	if IsEncrypted(data) {
+		return nil, ErrAlreadyEncrypted
+	}
+	identities, err := parseKey(key)
+	if err != nil {
+		return nil, err
+	}
+	recipients := make([]age.Recipient, len(identities))
+	for i, identity := range identities {
+		recipients[i] = identity.Recipient()
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	armorWriter := armor.NewWriter(buffer)
+	writer, err := age.Encrypt(armorWriter, recipients...)
+	if err != nil {
+		return nil, fmt.Errorf("encrypting: %w", err)
+	}
+	_, err = writer.Write(data)
+	if err != nil {
+		return nil, fmt.Errorf("encrypting: %w", err)
+	}
+	err = writer.Close()
+	if err != nil {
+		return nil, fmt.Errorf("encrypting: %w", err)
+	}
+	err = armorWriter.Close()
+	if err != nil {
+		return nil, fmt.Errorf("armoring: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// Decrypt decrypts the age encrypted data, armored or not,
+// with any of the age secret keys of key.
+func Decrypt(encrypted []byte, key string) (data []byte, err error) {
+	if !IsEncrypted(encrypted) {
+		return nil, ErrNotEncrypted
+	}
+	identities, err := parseKey(key)
+	if err != nil {
+		return nil, err
+	}
+	ageIdentities := make([]age.Identity, len(identities))
+	for i, identity := range identities {
+		ageIdentities[i] = identity
+	}
+
+	var source io.Reader = bytes.NewReader(encrypted)
+	if !bytes.HasPrefix(encrypted, []byte(binaryIntro)) {
+		source = armor.NewReader(bytes.NewReader(bytes.TrimSpace(encrypted)))
+	}
+	reader, err := age.Decrypt(source, ageIdentities...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecryptionFailed, err)
+	}
+	data, err = io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecryptionFailed, err)
+	}
+	return data, nil
+}
+
+// parseKey parses the age secret keys of key.
+func parseKey(key string) (identities []*age.X25519Identity, err error) {
+	for i, line := range strings.Split(key, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		identity, err := age.ParseX25519Identity(line)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrKeyNotValid, i+1, err)
+		}
+		identities = append(identities, identity)
+	}
+	if len(identities) == 0 {
+		return nil, fmt.Errorf("%w: no age secret key found", ErrKeyNotValid)
+	}
+	return identities, nil
+}
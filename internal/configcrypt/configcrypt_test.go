@@ -0,0 +1,106 @@
+package configcrypt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Encrypt_Decrypt(t *testing.T) {
+	t.Parallel()
+
+	key, err := GenerateKey()
+	require.NoError(t, err)
+	require.NoError(t, ValidateKey(key))
+	otherKey, err := GenerateKey()
+	require.NoError(t, err)
+
+	data := []byte(`{"settings":[{"provider":"beget","password":"secret"}]}`)
+	encrypted, err := Encrypt(data, key)
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(encrypted))
+	assert.False(t, IsEncrypted(data))
+	assert.True(t, strings.HasPrefix(string(encrypted), "-----BEGIN AGE ENCRYPTED FILE-----\n"))
+	assert.NotContains(t, string(encrypted), "secret")
+
+	decrypted, err := Decrypt(encrypted, key)
+	require.NoError(t, err)
+	assert.Equal(t, data, decrypted)
+
+	_, err = Encrypt(encrypted, key)
+	assert.ErrorIs(t, err, ErrAlreadyEncrypted)
+
+	_, err = Decrypt(encrypted, otherKey)
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+
+	// A key with several secret keys encrypts to all of them,
+	// and decrypts with any of them.
+	encrypted, err = Encrypt(data, key+"\n"+otherKey)
+	require.NoError(t, err)
+	decrypted, err = Decrypt(encrypted, otherKey)
+	require.NoError(t, err)
+	assert.Equal(t, data, decrypted)
+
+	tampered := []byte(strings.Replace(string(encrypted), "\n", "\nAAAA", 1))
+	_, err = Decrypt(tampered, key)
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+
+	_, err = Decrypt(data, key)
+	assert.ErrorIs(t, err, ErrNotEncrypted)
+}
+
+func Test_Decrypt_binary(t *testing.T) {
+	t.Parallel()
+
+	// Files encrypted with age without its -a flag are binary.
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	buffer := bytes.NewBuffer(nil)
+	writer, err := age.Encrypt(buffer, identity.Recipient())
+	require.NoError(t, err)
+	_, err = writer.Write([]byte(`{"settings":[]}`))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	assert.True(t, IsEncrypted(buffer.Bytes()))
+	decrypted, err := Decrypt(buffer.Bytes(), identity.String())
+	require.NoError(t, err)
+	assert.Equal(t, `{"settings":[]}`, string(decrypted))
+}
+
+func Test_ValidateKey(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		key        string
+		errMessage string
+	}{
+		"not_age_key": {
+			key:        "# comment\nnot a key",
+			errMessage: "key is not valid: line 2: malformed secret key: separator '1' at invalid position: pos=-1, len=9",
+		},
+		"public_key": {
+			key:        "age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p",
+			errMessage: `key is not valid: line 1: malformed secret key: unknown type "age"`,
+		},
+		"empty": {
+			key:        "# public key: age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p\n",
+			errMessage: "key is not valid: no age secret key found",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateKey(testCase.key)
+
+			assert.ErrorIs(t, err, ErrKeyNotValid)
+			assert.EqualError(t, err, testCase.errMessage)
+		})
+	}
+}
@@ -14,6 +14,9 @@ func (db *Database) Update(id uint, record records.Record) (err error) {
 	}
 	currentCount := len(db.data[id].History)
 	newCount := len(record.History)
+	// The paused state is only changed with SetPaused, and is kept
+	// if it changed while the record given was being modified.
+	record.Paused = db.data[id].Paused
 	db.data[id] = record
 	// new IP address added
 	if newCount > currentCount {
@@ -28,3 +31,26 @@ func (db *Database) Update(id uint, record records.Record) (err error) {
 	}
 	return nil
 }
+
+// SetPaused pauses or resumes the updates of a record, and stores its
+// paused state in the persistent database. Since the state is stored
+// by domain and owner, the records of the same domain and owner, such
+// as their IPv4 and IPv6 records, are paused and resumed together.
+func (db *Database) SetPaused(id uint, paused bool) (err error) {
+	db.Lock()
+	defer db.Unlock()
+	if int(id) > len(db.data)-1 {
+		return fmt.Errorf("%w: for id %d", ErrRecordNotFound, id)
+	}
+	domain, owner := db.data[id].Provider.Domain(), db.data[id].Provider.Owner()
+	err = db.persistentDB.SetPaused(domain, owner, paused)
+	if err != nil {
+		return err
+	}
+	for i, record := range db.data {
+		if record.Provider.Domain() == domain && record.Provider.Owner() == owner {
+			db.data[i].Paused = paused
+		}
+	}
+	return nil
+}
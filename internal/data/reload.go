@@ -0,0 +1,67 @@
+package data
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/records"
+)
+
+// Reload replaces the records with the records of the settings given,
+// for example read from the reloaded config file. The record of a provider
+// with the same key as a current record, see records.Key, keeps the state
+// of the current record, such as its history and status, with the new
+// settings, except it stops backing off after its failures. The others
+// are read from the persistent database.
+func (db *Database) Reload(settings []records.Settings) (err error) {
+	db.Lock()
+	defer db.Unlock()
+
+	current := make(map[string]records.Record, len(db.data))
+	for _, record := range db.data {
+		current[records.Key(record.Provider)] = record
+	}
+
+	data := make([]records.Record, len(settings))
+	for i, setting := range settings {
+		provider := setting.Provider
+		record, ok := current[records.Key(provider)]
+		if ok {
+			record.Provider = provider
+			record.Period = setting.Period
+			record.Jitter = setting.Jitter
+			record.MirrorGroup = setting.MirrorGroup
+			record.MaxAge = setting.MaxAge
+			record.Resolver = setting.Resolver
+			// the new settings may fix the failures, such as wrong credentials
+			record.RetryTime = time.Time{}
+			data[i] = record
+			continue
+		}
+
+		events, err := db.persistentDB.GetEvents(provider.Domain(),
+			provider.Owner(), provider.IPVersion())
+		if err != nil {
+			return fmt.Errorf("reading history of %s: %w", provider, err)
+		}
+		data[i] = records.New(provider, events)
+		data[i].Period = setting.Period
+		data[i].Jitter = setting.Jitter
+		data[i].MirrorGroup = setting.MirrorGroup
+		data[i].MaxAge = setting.MaxAge
+		data[i].Resolver = setting.Resolver
+		data[i].Paused, err = db.persistentDB.GetPaused(provider.Domain(), provider.Owner())
+		if err != nil {
+			return fmt.Errorf("reading paused state of %s: %w", provider, err)
+		}
+	}
+
+	previous := db.data
+	db.data = data
+	// release the resources the replaced providers share with the
+	// new providers, such as the circuit breakers of their account
+	for _, record := range previous {
+		records.ReleaseProvider(record.Provider)
+	}
+	return nil
+}
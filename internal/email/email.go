@@ -0,0 +1,155 @@
+// Package email sends the update events
+// of the records by email over SMTP.
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/models"
+	"github.com/qdm12/ddns-updater/internal/notify"
+)
+
+type Client struct {
+	settings Settings
+	filter   notify.Filter
+	subject  *template.Template
+	body     *template.Template
+	timeNow  func() time.Time
+}
+
+func New(settings Settings) (client *Client, err error) {
+	settings.setDefaults()
+	subject, err := notify.ParseTemplate("subject", settings.Subject)
+	if err != nil {
+		return nil, err
+	}
+	body, err := notify.ParseTemplate("body", settings.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		settings: settings,
+		filter:   notify.Filter{Events: settings.Events},
+		subject:  subject,
+		body:     body,
+		timeNow:  time.Now,
+	}, nil
+}
+
+// Updated sends an email for the update event of a record, if the emails
+// are enabled for its event type. For a series of failed updates of the
+// same record, only the first failure is sent.
+func (c *Client) Updated(ctx context.Context, event models.UpdateEvent) {
+	if c.settings.Host == "" || !c.filter.Match(event) {
+		return
+	}
+
+	err := c.sendEvent(ctx, event)
+	if err != nil {
+		c.settings.Logger.Error("email: " + err.Error())
+	}
+}
+
+func (c *Client) sendEvent(ctx context.Context, event models.UpdateEvent) (err error) {
+	subject, err := notify.Execute(c.subject, event)
+	if err != nil {
+		return err
+	}
+	// Remove line breaks, which would end the subject header.
+	subject = strings.Join(strings.Fields(subject), " ")
+	body, err := notify.Execute(c.body, event)
+	if err != nil {
+		return err
+	}
+
+	const timeout = 30 * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return c.send(ctx, subject, body)
+}
+
+// message returns the email message with its headers.
+func (c *Client) message(subject, body string) []byte {
+	headers := []string{
+		"From: " + c.settings.From,
+		"To: " + strings.Join(c.settings.To, ", "),
+		"Subject: " + subject,
+		"Date: " + c.timeNow().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+	}
+	return []byte(strings.Join(headers, "\r\n") + "\r\n\r\n" + body)
+}
+
+func (c *Client) send(ctx context.Context, subject, body string) (err error) {
+	address := net.JoinHostPort(c.settings.Host, strconv.Itoa(int(c.settings.Port)))
+	tlsConfig := &tls.Config{ServerName: c.settings.Host, MinVersion: tls.VersionTLS12}
+
+	var dialer net.Dialer
+	connection, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return fmt.Errorf("connecting to SMTP server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = connection.SetDeadline(deadline)
+	}
+	if c.settings.Security == SecurityTLS {
+		connection = tls.Client(connection, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(connection, c.settings.Host)
+	if err != nil {
+		_ = connection.Close()
+		return fmt.Errorf("creating SMTP client: %w", err)
+	}
+	defer client.Close()
+
+	if c.settings.Security == SecurityStartTLS {
+		err = client.StartTLS(tlsConfig)
+		if err != nil {
+			return fmt.Errorf("starting TLS: %w", err)
+		}
+	}
+
+	if c.settings.Username != "" {
+		auth := smtp.PlainAuth("", c.settings.Username, c.settings.Password, c.settings.Host)
+		err = client.Auth(auth)
+		if err != nil {
+			return fmt.Errorf("authenticating: %w", err)
+		}
+	}
+
+	err = client.Mail(c.settings.From)
+	if err != nil {
+		return fmt.Errorf("setting sender: %w", err)
+	}
+	for _, to := range c.settings.To {
+		err = client.Rcpt(to)
+		if err != nil {
+			return fmt.Errorf("setting recipient %s: %w", to, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("starting message: %w", err)
+	}
+	_, err = writer.Write(c.message(subject, body))
+	if err != nil {
+		_ = writer.Close()
+		return fmt.Errorf("writing message: %w", err)
+	}
+	err = writer.Close()
+	if err != nil {
+		return fmt.Errorf("sending message: %w", err)
+	}
+	return client.Quit()
+}
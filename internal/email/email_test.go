@@ -0,0 +1,138 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveSMTP runs a minimal SMTP server accepting any message
+// on the listener, and sends the messages received on the channel.
+func serveSMTP(t *testing.T, listener net.Listener, messages chan<- string) {
+	t.Helper()
+	for {
+		connection, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer connection.Close()
+			reader := bufio.NewReader(connection)
+			reply := func(line string) {
+				_, _ = connection.Write([]byte(line + "\r\n"))
+			}
+			reply("220 ready")
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				command := strings.ToUpper(strings.Fields(line + " ")[0])
+				switch command {
+				case "DATA":
+					reply("354 go ahead")
+					var message strings.Builder
+					for {
+						line, err = reader.ReadString('\n')
+						if err != nil || line == ".\r\n" {
+							break
+						}
+						message.WriteString(line)
+					}
+					messages <- message.String()
+					reply("250 ok")
+				case "QUIT":
+					reply("221 bye")
+					return
+				default:
+					reply("250 ok")
+				}
+			}
+		}()
+	}
+}
+
+func Test_Client_Updated(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	messages := make(chan string, 10)
+	go serveSMTP(t, listener, messages)
+
+	address := listener.Addr().(*net.TCPAddr) //nolint:forcetypeassert
+	client, err := New(Settings{
+		Host:     "127.0.0.1",
+		Port:     uint16(address.Port),
+		Security: SecurityNone,
+		From:     "ddns@example.com",
+		To:       []string{"me@example.com", "you@example.com"},
+		Events:   []string{models.EventIPChanged, models.EventUpdateFailed},
+	})
+	require.NoError(t, err)
+	client.timeNow = func() time.Time { return time.Unix(0, 0).UTC() }
+
+	ctx := context.Background()
+	event := models.UpdateEvent{
+		FQDN:       "example.com",
+		IPVersion:  "ipv4",
+		PreviousIP: "1.1.1.1",
+		Time:       time.Unix(0, 0).UTC(),
+	}
+
+	unchanged := event
+	unchanged.Type = models.EventUpdated
+	unchanged.IP = "1.1.1.1"
+	client.Updated(ctx, unchanged)
+
+	changed := event
+	changed.Type = models.EventIPChanged
+	changed.IP = "2.2.2.2"
+	changed.Message = "changed to 2.2.2.2"
+	client.Updated(ctx, changed)
+
+	failed := event
+	failed.Type = models.EventUpdateFailed
+	failed.Error = "bad auth"
+	for failures := uint(1); failures <= 2; failures++ {
+		failed.Failures = failures
+		client.Updated(ctx, failed)
+	}
+
+	const headers = "From: ddns@example.com\r\n" +
+		"To: me@example.com, you@example.com\r\n"
+	const trailers = "Date: Thu, 01 Jan 1970 00:00:00 +0000\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" +
+		"Record: example.com (ipv4)\r\n" +
+		"Time: Thu, 01 Jan 1970 00:00:00 +0000\r\n" +
+		"Previous IP address: 1.1.1.1\r\n"
+	expected := []string{
+		headers + "Subject: example.com changed to 2.2.2.2\r\n" + trailers +
+			"New IP address: 2.2.2.2\r\n",
+		headers + "Subject: example.com update failed\r\n" + trailers +
+			"Error: bad auth\r\n",
+	}
+	for _, expectedMessage := range expected {
+		assert.Equal(t, expectedMessage, <-messages)
+	}
+	assert.Empty(t, messages)
+}
+
+func Test_Client_Updated_disabled(t *testing.T) {
+	t.Parallel()
+
+	client, err := New(Settings{})
+	require.NoError(t, err)
+
+	client.Updated(context.Background(), models.UpdateEvent{Type: models.EventIPChanged})
+}
@@ -0,0 +1,68 @@
+package email
+
+import (
+	"github.com/qdm12/ddns-updater/internal/models"
+	"github.com/qdm12/gosettings"
+)
+
+// Security modes of the SMTP connection.
+const (
+	SecurityTLS      = "tls"
+	SecurityStartTLS = "starttls"
+	SecurityNone     = "none"
+)
+
+type Settings struct {
+	// Host is the SMTP server host. An empty host disables the emails.
+	Host     string
+	Port     uint16
+	Security string
+	Username string
+	Password string
+	From     string
+	To       []string
+	// Events are the event types sent by email, see models.UpdateEvent.
+	Events []string
+	// Subject and Body are the Go templates of the email
+	// subject and body, executed with the models.UpdateEvent.
+	Subject string
+	Body    string
+	Logger  Erroer
+}
+
+const (
+	defaultSubject = "{{.FQDN}} {{if .Error}}update failed{{else}}{{.Message}}{{end}}"
+	defaultBody    = `Record: {{.FQDN}} ({{.IPVersion}})
+Time: {{.Time.Format "Mon, 02 Jan 2006 15:04:05 -0700"}}
+{{if .PreviousIP}}Previous IP address: {{.PreviousIP}}
+{{end}}{{if .Error}}Error: {{.Error}}{{else}}New IP address: {{.IP}}{{end}}
+`
+)
+
+func (s *Settings) setDefaults() {
+	s.Security = gosettings.DefaultComparable(s.Security, SecurityStartTLS)
+	defaultPort := uint16(587) //nolint:gomnd
+	switch s.Security {
+	case SecurityTLS:
+		defaultPort = 465
+	case SecurityNone:
+		defaultPort = 25
+	}
+	s.Port = gosettings.DefaultComparable(s.Port, defaultPort)
+	s.From = gosettings.DefaultComparable(s.From, s.Username)
+	s.Events = gosettings.DefaultSlice(s.Events, []string{
+		models.EventIPChanged, models.EventUpdateFailed,
+		models.EventCredentialsInvalid, models.EventSuspended, models.EventRecordRestored,
+	})
+	s.Subject = gosettings.DefaultComparable(s.Subject, defaultSubject)
+	s.Body = gosettings.DefaultComparable(s.Body, defaultBody)
+	s.Logger = gosettings.DefaultComparable[Erroer](s.Logger, &noopLogger{})
+}
+
+type Erroer interface {
+	Error(s string)
+}
+
+type noopLogger struct{}
+
+func (l noopLogger) Error(_ string) {}
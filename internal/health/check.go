@@ -6,29 +6,54 @@ import (
 	"fmt"
 	"net/netip"
 	"strings"
+	"time"
 
 	"github.com/qdm12/ddns-updater/internal/constants"
+	"github.com/qdm12/ddns-updater/internal/records"
 )
 
-func MakeIsHealthy(db AllSelecter, resolver LookupIPer) func(ctx context.Context) error {
+func MakeIsHealthy(db AllSelecter, resolver LookupIPer,
+	timeNow func() time.Time) func(ctx context.Context) error {
 	return func(ctx context.Context) (err error) {
-		return isHealthy(ctx, db, resolver)
+		return isHealthy(ctx, db, resolver, timeNow())
 	}
 }
 
 var (
 	ErrRecordUpdateFailed = errors.New("record update failed")
 	ErrRecordIPNotSet     = errors.New("record IP not set")
+	ErrRecordStale        = errors.New("record is stale")
 	ErrLookupMismatch     = errors.New("lookup IP addresses do not match")
+	ErrProviderAPIFailed  = errors.New("provider API call failed")
 )
 
-// isHealthy checks all the records were updated successfully and returns an error if not.
-func isHealthy(ctx context.Context, db AllSelecter, resolver LookupIPer) (err error) {
+// isHealthy checks all the records were updated successfully, and did not
+// exceed their maximum age since their last success at now, and returns an
+// error if not.
+func isHealthy(ctx context.Context, db AllSelecter, resolver LookupIPer,
+	now time.Time) (err error) {
 	records := db.SelectAll()
 	for _, record := range records {
 		if record.Status == constants.FAIL {
 			return fmt.Errorf("%w: %s", ErrRecordUpdateFailed, record.String())
-		} else if record.Provider.Proxied() {
+		}
+
+		err = checkMaxAge(record, now)
+		if err != nil {
+			return err
+		}
+
+		if reporter, ok := record.Provider.(APIStatusReporter); ok {
+			status := reporter.LastAPIStatus()
+			if status.Error != "" {
+				return fmt.Errorf("%w: for %s: %s", ErrProviderAPIFailed,
+					record.Provider.BuildDomainName(), status)
+			}
+		}
+
+		if record.Provider.Proxied() {
+			continue
+		} else if skipper, ok := record.Provider.(LookupSkipper); ok && skipper.SkipLookup() {
 			continue
 		}
 
@@ -39,7 +64,11 @@ func isHealthy(ctx context.Context, db AllSelecter, resolver LookupIPer) (err er
 			return fmt.Errorf("%w: for hostname %s", ErrRecordIPNotSet, hostname)
 		}
 
-		lookedUpNetIPs, err := resolver.LookupIP(ctx, "ip", hostname)
+		recordResolver := resolver
+		if record.Resolver != nil {
+			recordResolver = record.Resolver
+		}
+		lookedUpNetIPs, err := recordResolver.LookupIP(ctx, "ip", hostname)
 		if err != nil {
 			return err
 		}
@@ -68,3 +97,26 @@ func isHealthy(ctx context.Context, db AllSelecter, resolver LookupIPer) (err er
 	}
 	return nil
 }
+
+// checkMaxAge returns an error if the record has a maximum age and
+// its last success, or its last update in its history if it did not
+// succeed since the program started, is older than it at now.
+func checkMaxAge(record records.Record, now time.Time) (err error) {
+	if record.MaxAge == 0 || record.Paused {
+		return nil
+	}
+	lastSuccess := record.LastSuccess
+	if lastSuccess.IsZero() {
+		lastSuccess = record.History.GetSuccessTime()
+	}
+	hostname := record.Provider.BuildDomainName()
+	switch {
+	case lastSuccess.IsZero():
+		return fmt.Errorf("%w: %s never succeeded, with a maximum age of %s",
+			ErrRecordStale, hostname, record.MaxAge)
+	case now.Sub(lastSuccess) > record.MaxAge:
+		return fmt.Errorf("%w: %s last succeeded %s ago, exceeding its maximum age of %s",
+			ErrRecordStale, hostname, now.Sub(lastSuccess).Round(time.Second), record.MaxAge)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net"
 
+	"github.com/qdm12/ddns-updater/internal/models"
 	"github.com/qdm12/ddns-updater/internal/records"
 )
 
@@ -11,6 +12,18 @@ type AllSelecter interface {
 	SelectAll() (records []records.Record)
 }
 
+// APIStatusReporter is implemented by providers reporting
+// the outcome of their last API call.
+type APIStatusReporter interface {
+	LastAPIStatus() models.APIStatus
+}
+
+// LookupSkipper is implemented by providers whose domain
+// name cannot be resolved to check their IP address.
+type LookupSkipper interface {
+	SkipLookup() bool
+}
+
 type LookupIPer interface {
 	LookupIP(ctx context.Context, network, host string) (ips []net.IP, err error)
 }
@@ -0,0 +1,185 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ddclientProtocols maps the ddclient protocols
+// to the providers of the program.
+var ddclientProtocols = map[string]string{ //nolint:gochecknoglobals
+	"changeip":   "changeip",
+	"cloudflare": "cloudflare",
+	"duckdns":    "duckdns",
+	"dyndns1":    "dyn",
+	"freedns":    "freedns",
+	"godaddy":    "godaddy",
+	"namecheap":  "namecheap",
+	"noip":       "noip",
+	"ovh":        "ovh",
+	"porkbun":    "porkbun",
+	"zoneedit1":  "zoneedit",
+}
+
+// ddclientDyndns2Servers maps the servers of the ddclient dyndns2
+// protocol to the providers of the program.
+var ddclientDyndns2Servers = map[string]string{ //nolint:gochecknoglobals
+	"":                      "dyn",
+	"members.dyndns.org":    "dyn",
+	"dynupdate.no-ip.com":   "noip",
+	"dyn.dns.he.net":        "he",
+	"updates.dnsomatic.com": "dnsomatic",
+	"api.dynu.com":          "dynu",
+	"nic.changeip.com":      "changeip",
+	"update.spdyn.de":       "spdyn",
+	"carol.selfhost.de":     "selfhostde",
+	"www.ddnss.de":          "ddnss",
+	"now-dns.com":           "nowdns",
+	"www.ovh.com":           "ovh",
+	"dyndns.strato.com":     "strato",
+}
+
+// parseDDClient parses the ddclient.conf content given. Each line, with
+// its continuation lines ending with a backslash, is made of options
+// written as name=value followed by the hosts they apply to, separated by
+// commas or spaces. The options of a line without hosts apply to the next
+// lines.
+func parseDDClient(content string) (sources []source, err error) {
+	globals := make(map[string]string)
+	lineNumber := 0
+	logicalLine, logicalLineNumber := "", 0
+	for _, line := range strings.Split(content, "\n") {
+		lineNumber++
+		line = stripComment(line)
+		trimmed := strings.TrimRight(line, " \t\r")
+		continued := strings.HasSuffix(trimmed, "\\")
+		if continued {
+			trimmed = strings.TrimSuffix(trimmed, "\\")
+		}
+		if logicalLine == "" {
+			logicalLineNumber = lineNumber
+		}
+		logicalLine += " " + trimmed
+		if continued {
+			continue
+		}
+
+		options, hosts, err := tokenizeDDClient(logicalLine, logicalLineNumber)
+		if err != nil {
+			return nil, err
+		}
+		logicalLine = ""
+		if len(hosts) == 0 {
+			for name, value := range options {
+				globals[name] = value
+			}
+			continue
+		}
+
+		merged := make(map[string]string, len(globals)+len(options))
+		for name, value := range globals {
+			merged[name] = value
+		}
+		for name, value := range options {
+			merged[name] = value
+		}
+		sources = append(sources, makeDDClientSource(logicalLineNumber, merged, hosts))
+	}
+	return sources, nil
+}
+
+func makeDDClientSource(line int, options map[string]string, hosts []string) source {
+	protocol := options["protocol"]
+	if protocol == "" {
+		protocol = "dyndns2"
+	}
+	provider := ddclientProtocols[protocol]
+	if protocol == "dyndns2" {
+		server := strings.TrimPrefix(strings.TrimPrefix(options["server"], "https://"), "http://")
+		server = strings.TrimSuffix(server, "/")
+		provider = ddclientDyndns2Servers[server]
+		if options["server"] != "" {
+			protocol += " with server " + options["server"]
+		}
+	}
+	return source{
+		line:     line,
+		protocol: protocol,
+		provider: provider,
+		login:    options["login"],
+		password: options["password"],
+		zone:     options["zone"],
+		options:  options,
+		hosts:    hosts,
+	}
+}
+
+// stripComment removes the comment starting with # from the line,
+// unless it is escaped with a backslash.
+func stripComment(line string) string {
+	for i := 0; i < len(line); i++ {
+		if line[i] == '#' && (i == 0 || line[i-1] != '\\') {
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// tokenizeDDClient splits the logical line given, starting at the line
+// number given, into its options and its hosts. Option values can be
+// quoted with single or double quotes.
+func tokenizeDDClient(line string, lineNumber int) (
+	options map[string]string, hosts []string, err error) {
+	options = make(map[string]string)
+	i := 0
+	for i < len(line) {
+		switch line[i] {
+		case ' ', '\t', ',':
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(line) && line[i] != '=' && line[i] != ' ' && line[i] != '\t' && line[i] != ',' {
+			i++
+		}
+		word := line[start:i]
+		i = skipSpaces(line, i)
+		if i == len(line) || line[i] != '=' {
+			if word != "" {
+				hosts = append(hosts, word)
+			}
+			continue
+		}
+		i = skipSpaces(line, i+1) // skip = and spaces
+
+		var value string
+		if i < len(line) && (line[i] == '\'' || line[i] == '"') {
+			quote := line[i]
+			end := strings.IndexByte(line[i+1:], quote)
+			if end == -1 {
+				return nil, nil, fmt.Errorf("%w: line %d: unterminated quote for option %s",
+					ErrSyntax, lineNumber, word)
+			}
+			value = line[i+1 : i+1+end]
+			i += end + 2 //nolint:mnd
+		} else {
+			start = i
+			for i < len(line) && line[i] != ' ' && line[i] != '\t' && line[i] != ',' {
+				i++
+			}
+			value = line[start:i]
+		}
+		options[strings.ToLower(word)] = strings.ReplaceAll(value, `\#`, "#")
+	}
+	return options, hosts, nil
+}
+
+// skipSpaces returns the index of the first character of line
+// from i which is not a space or a tab.
+func skipSpaces(line string, i int) int {
+	for i < len(line) && (line[i] == ' ' || line[i] == '\t') {
+		i++
+	}
+	return i
+}
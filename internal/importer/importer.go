@@ -0,0 +1,203 @@
+// Package importer converts the configuration files of ddclient
+// and inadyn to the JSON configuration of the program.
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type Format string
+
+const (
+	FormatDDClient Format = "ddclient"
+	FormatInadyn   Format = "inadyn"
+)
+
+var (
+	ErrFormatUnknown = errors.New("format is unknown")
+	ErrSyntax        = errors.New("syntax error")
+)
+
+// Config is the JSON configuration of the program,
+// as written to its config.json file.
+type Config struct {
+	Settings []Entry `json:"settings"`
+}
+
+// Entry is a config entry, mapping its JSON field names to their value.
+type Entry map[string]any
+
+var inadynBlockRegex = regexp.MustCompile(`(?m)^\s*(provider|custom)\s+\S+\s*\{`)
+
+// DetectFormat returns FormatInadyn if the data given contains an inadyn
+// provider or custom section, and FormatDDClient otherwise.
+func DetectFormat(data []byte) Format {
+	if inadynBlockRegex.Match(data) {
+		return FormatInadyn
+	}
+	return FormatDDClient
+}
+
+// Import converts the ddclient or inadyn configuration data given to the
+// config of the program, with a config entry for each host of a supported
+// protocol. The hosts of an unsupported protocol are skipped with a
+// warning, unless fallback is set to a provider name, such as beget, in
+// which case an entry of this provider is created for them, with its
+// credentials left to fill in. The warnings also list the fields to fill
+// in or to review in the entries created.
+func Import(data []byte, format Format, fallback string) (
+	config Config, warnings []string, err error) {
+	var sources []source
+	switch format {
+	case FormatDDClient:
+		sources, err = parseDDClient(string(data))
+	case FormatInadyn:
+		sources, err = parseInadyn(string(data))
+	default:
+		return config, nil, fmt.Errorf("%w: %s", ErrFormatUnknown, format)
+	}
+	if err != nil {
+		return config, nil, err
+	}
+
+	config.Settings = []Entry{}
+	for _, source := range sources {
+		entries, sourceWarnings := source.entries(fallback)
+		config.Settings = append(config.Settings, entries...)
+		warnings = append(warnings, sourceWarnings...)
+	}
+	return config, warnings, nil
+}
+
+// source is a set of hosts updated with the same protocol
+// and credentials, read from the ddclient or inadyn file.
+type source struct {
+	// line is the line number of the source in the file, for warnings.
+	line int
+	// protocol is the name of the protocol or provider in the file.
+	protocol string
+	// provider is the name of the provider of the program
+	// matching the protocol, or empty if it is not supported.
+	provider string
+	login    string
+	password string
+	// zone is the zone of the hosts, for the protocols requiring it.
+	zone string
+	// options are the other options of the source by name.
+	options map[string]string
+	hosts   []string
+}
+
+// entries returns the config entries of the hosts of the
+// source, and the warnings about them.
+func (s source) entries(fallback string) (entries []Entry, warnings []string) {
+	if len(s.hosts) == 0 {
+		return nil, nil
+	}
+	hosts := strings.Join(s.hosts, ", ")
+
+	provider := s.provider
+	if provider == "" {
+		if fallback == "" {
+			return nil, []string{fmt.Sprintf("line %d: protocol %s is not supported, skipping %s",
+				s.line, s.protocol, hosts)}
+		}
+		provider = fallback
+		warnings = append(warnings, fmt.Sprintf("line %d: protocol %s is not supported, "+
+			"%s imported as %s entries: fill in their credentials", s.line, s.protocol, hosts, fallback))
+	}
+
+	fields, fieldWarnings := s.fields(provider)
+	if s.provider == "" {
+		// the credentials of the fallback provider are all to fill in
+		fieldWarnings = nil
+	}
+	for _, warning := range fieldWarnings {
+		warnings = append(warnings, fmt.Sprintf("line %d: %s for %s", s.line, warning, hosts))
+	}
+
+	entries = make([]Entry, len(s.hosts))
+	for i, host := range s.hosts {
+		entry := Entry{
+			"provider": provider,
+			"domain":   s.domain(provider, host),
+		}
+		for name, value := range fields {
+			entry[name] = value
+		}
+		entries[i] = entry
+	}
+	return entries, warnings
+}
+
+// domain returns the FQDN of the host given, which is relative
+// to the login domain for the namecheap protocol.
+func (s source) domain(provider, host string) string {
+	if provider != "namecheap" || s.login == "" ||
+		host == s.login || strings.HasSuffix(host, "."+s.login) {
+		return host
+	} else if host == "@" {
+		return s.login
+	}
+	return host + "." + s.login
+}
+
+// fields returns the provider specific fields of the entries of the
+// source for the provider given, and the warnings about them.
+func (s source) fields(provider string) (fields map[string]any, warnings []string) {
+	switch provider {
+	case "beget":
+		fields = map[string]any{"login": "", "password": ""}
+	case "cloudflare":
+		fields = map[string]any{"zone_identifier": "", "ttl": 1}
+		if s.login == "" || s.login == "token" || s.protocol != "cloudflare" {
+			// inadyn uses the zone name as username and a token as password
+			fields["token"] = s.password
+		} else {
+			fields["email"] = s.login
+			fields["key"] = s.password
+		}
+		zone := s.zone
+		if zone == "" {
+			zone = s.login
+		}
+		warnings = append(warnings, "set zone_identifier to the Cloudflare zone ID of "+zone)
+	case "dyn":
+		fields = map[string]any{"username": s.login, "client_key": s.password}
+	case "duckdns", "dynv6", "freedns":
+		fields = map[string]any{"token": s.password}
+		if provider == "freedns" {
+			warnings = append(warnings, "check token is the FreeDNS update token")
+		}
+	case "godaddy":
+		fields = map[string]any{"key": s.login, "secret": s.password}
+	case "he", "namecheap", "strato":
+		fields = map[string]any{"password": s.password}
+	case "porkbun":
+		fields = map[string]any{
+			"api_key":        s.options["apikey"],
+			"secret_api_key": s.options["secretapikey"],
+		}
+	case "spdyn":
+		fields = map[string]any{"user": s.login, "password": s.password}
+	case "zoneedit":
+		fields = map[string]any{"username": s.login, "token": s.password}
+	case "changeip", "ddnss", "dnsomatic", "dynu", "noip", "nowdns", "ovh", "selfhostde":
+		fields = map[string]any{"username": s.login, "password": s.password}
+	default: // fallback provider
+		return nil, nil
+	}
+
+	missing := make([]string, 0, len(fields))
+	for name, value := range fields {
+		if value == "" && name != "zone_identifier" {
+			missing = append(missing, "set "+name)
+		}
+	}
+	sort.Strings(missing)
+	return fields, append(warnings, missing...)
+}
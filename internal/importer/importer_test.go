@@ -0,0 +1,173 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DetectFormat(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, FormatInadyn, DetectFormat([]byte("period = 300\nprovider default@dyndns.org {\n}\n")))
+	assert.Equal(t, FormatInadyn, DetectFormat([]byte("custom beget:1 {\n}\n")))
+	assert.Equal(t, FormatDDClient, DetectFormat([]byte("protocol=noip\nlogin=user password=pass host.example.com\n")))
+}
+
+func Test_Import(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		data       string
+		format     Format
+		fallback   string
+		config     Config
+		warnings   []string
+		errWrapped error
+		errMessage string
+	}{
+		"ddclient": {
+			data: `# ddclient.conf
+daemon=300
+use=web, web=checkip.dyndns.com
+
+protocol=noip, login=user, password='pa ss' home.example.com,nas.example.com
+
+protocol=dyndns2 \
+server=dyn.dns.he.net \
+password=hepass # comment
+vpn.example.org
+`,
+			format: FormatDDClient,
+			config: Config{Settings: []Entry{
+				{"provider": "noip", "domain": "home.example.com", "username": "user", "password": "pa ss"},
+				{"provider": "noip", "domain": "nas.example.com", "username": "user", "password": "pa ss"},
+				{"provider": "he", "domain": "vpn.example.org", "password": "hepass"},
+			}},
+		},
+		"ddclient_cloudflare_and_namecheap": {
+			data: `protocol=cloudflare, zone=example.com, login=token, password=cftoken
+www.example.com
+protocol=namecheap, login=example.net, password=ncpass @, www
+`,
+			format: FormatDDClient,
+			config: Config{Settings: []Entry{
+				{"provider": "cloudflare", "domain": "www.example.com", "token": "cftoken",
+					"zone_identifier": "", "ttl": 1},
+				{"provider": "namecheap", "domain": "example.net", "password": "ncpass"},
+				{"provider": "namecheap", "domain": "www.example.net", "password": "ncpass"},
+			}},
+			warnings: []string{
+				"line 2: set zone_identifier to the Cloudflare zone ID of example.com for www.example.com",
+			},
+		},
+		"ddclient_unsupported": {
+			data:   "protocol=nsupdate, password=key home.example.ru\n",
+			format: FormatDDClient,
+			config: Config{Settings: []Entry{}},
+			warnings: []string{
+				"line 1: protocol nsupdate is not supported, skipping home.example.ru",
+			},
+		},
+		"ddclient_unsupported_fallback": {
+			data:     "protocol=dyndns2, server=dyndns.example.ru, login=user home.example.ru\n",
+			format:   FormatDDClient,
+			fallback: "beget",
+			config: Config{Settings: []Entry{
+				{"provider": "beget", "domain": "home.example.ru", "login": "", "password": ""},
+			}},
+			warnings: []string{
+				"line 1: protocol dyndns2 with server dyndns.example.ru is not supported, " +
+					"home.example.ru imported as beget entries: fill in their credentials",
+			},
+		},
+		"ddclient_unterminated_quote": {
+			data:       "login=user\npassword='pass host.example.com\n",
+			format:     FormatDDClient,
+			errWrapped: ErrSyntax,
+			errMessage: "syntax error: line 2: unterminated quote for option password",
+		},
+		"inadyn": {
+			data: `# inadyn.conf
+period = 300
+allow-ipv6 = true
+
+provider default@dyndns.org {
+    username = user
+    password = "pa ss"
+    hostname = { "home.example.com", "nas.example.com" }
+}
+
+provider cloudflare.com:2 {
+    username = example.org
+    password = cftoken
+    hostname = vpn.example.org
+    ttl = 1
+}
+
+provider default@duckdns.org {
+    hostname = myhome.duckdns.org
+}
+`,
+			format: FormatInadyn,
+			config: Config{Settings: []Entry{
+				{"provider": "dyn", "domain": "home.example.com", "username": "user", "client_key": "pa ss"},
+				{"provider": "dyn", "domain": "nas.example.com", "username": "user", "client_key": "pa ss"},
+				{"provider": "cloudflare", "domain": "vpn.example.org", "token": "cftoken",
+					"zone_identifier": "", "ttl": 1},
+				{"provider": "duckdns", "domain": "myhome.duckdns.org", "token": ""},
+			}},
+			warnings: []string{
+				"line 11: set zone_identifier to the Cloudflare zone ID of example.org for vpn.example.org",
+				"line 18: set token for myhome.duckdns.org",
+			},
+		},
+		"inadyn_custom": {
+			data: `custom beget:1 {
+    ddns-server = api.example.ru
+    ddns-path = "/update?host=%h"
+    hostname = home.example.ru
+}
+`,
+			format: FormatInadyn,
+			config: Config{Settings: []Entry{}},
+			warnings: []string{
+				"line 1: protocol custom beget:1 is not supported, skipping home.example.ru",
+			},
+		},
+		"inadyn_section_not_closed": {
+			data:       "provider default@no-ip.com {\n  username = user\n",
+			format:     FormatInadyn,
+			errWrapped: ErrSyntax,
+			errMessage: "syntax error: line 1: provider section default@no-ip.com is not closed",
+		},
+		"inadyn_bad_setting": {
+			data:       "period 300\n",
+			format:     FormatInadyn,
+			errWrapped: ErrSyntax,
+			errMessage: `syntax error: line 1: expected setting name = value, got "period"`,
+		},
+		"unknown_format": {
+			format:     "ez-ipupdate",
+			errWrapped: ErrFormatUnknown,
+			errMessage: "format is unknown: ez-ipupdate",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			config, warnings, err := Import([]byte(testCase.data), testCase.format, testCase.fallback)
+
+			require.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.Equal(t, testCase.config, config)
+			assert.Equal(t, testCase.warnings, warnings)
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// inadynProviders maps the inadyn providers, without their
+// "default@" prefix, to the providers of the program.
+var inadynProviders = map[string]string{ //nolint:gochecknoglobals
+	"changeip.com":       "changeip",
+	"cloudflare.com":     "cloudflare",
+	"ddnss.de":           "ddnss",
+	"dnsomatic.com":      "dnsomatic",
+	"duckdns.org":        "duckdns",
+	"dyndns.org":         "dyn",
+	"dynu.com":           "dynu",
+	"dynv6.com":          "dynv6",
+	"freedns.afraid.org": "freedns",
+	"he.net":             "he",
+	"namecheap.com":      "namecheap",
+	"no-ip.com":          "noip",
+	"ovh.com":            "ovh",
+	"selfhost.de":        "selfhostde",
+	"spdyn.de":           "spdyn",
+	"strato.com":         "strato",
+	"zoneedit.com":       "zoneedit",
+}
+
+// inadynProvider returns the provider of the program matching the
+// inadyn provider name given, such as default@dyndns.org or
+// cloudflare.com:2, or an empty string if it is not supported.
+func inadynProvider(name string) (provider string) {
+	name, _, _ = strings.Cut(name, ":")
+	_, domain, found := strings.Cut(name, "@")
+	if !found {
+		domain = name
+	}
+	return inadynProviders[strings.ToLower(domain)]
+}
+
+// inadynToken is a token of an inadyn configuration file, which is a
+// word, a quoted string or one of the characters {, }, = and ,.
+type inadynToken struct {
+	value  string
+	quoted bool
+	line   int
+}
+
+func (t inadynToken) is(symbol string) bool {
+	return !t.quoted && t.value == symbol
+}
+
+// parseInadyn parses the inadyn.conf content given, made of global
+// settings written as name = value, and of provider and custom sections
+// written as provider NAME { name = value ... }. A value is a word, a
+// quoted string or a list of them, such as { "a.example.com", "b" }.
+func parseInadyn(content string) (sources []source, err error) {
+	tokens, err := tokenizeInadyn(content)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < len(tokens); {
+		token := tokens[i]
+		if token.is("provider") || token.is("custom") {
+			var s source
+			s, i, err = parseInadynSection(tokens, i)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, s)
+			continue
+		}
+		// global setting, such as period = 300
+		_, i, err = parseInadynSetting(tokens, i)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sources, nil
+}
+
+// parseInadynSection parses the provider or custom section
+// starting at the token index i, and returns the index of the
+// token following it.
+func parseInadynSection(tokens []inadynToken, i int) (s source, next int, err error) {
+	kind := tokens[i]
+	if i+2 >= len(tokens) || !tokens[i+2].is("{") {
+		return s, 0, fmt.Errorf("%w: line %d: expected %s name followed by {",
+			ErrSyntax, kind.line, kind.value)
+	}
+	name := tokens[i+1].value
+	s = source{
+		line:     kind.line,
+		protocol: kind.value + " " + name,
+		options:  make(map[string]string),
+	}
+	if kind.value == "provider" {
+		s.provider = inadynProvider(name)
+	}
+
+	i += 3
+	for {
+		if i >= len(tokens) {
+			return s, 0, fmt.Errorf("%w: line %d: %s section %s is not closed",
+				ErrSyntax, kind.line, kind.value, name)
+		} else if tokens[i].is("}") {
+			break
+		}
+		var setting inadynSetting
+		setting, i, err = parseInadynSetting(tokens, i)
+		if err != nil {
+			return s, 0, err
+		}
+		switch setting.name {
+		case "username":
+			s.login = setting.first()
+		case "password":
+			s.password = setting.first()
+		case "hostname":
+			s.hosts = append(s.hosts, setting.values...)
+		default:
+			s.options[setting.name] = setting.first()
+		}
+	}
+	if s.provider == "cloudflare" {
+		s.zone = s.login
+	}
+	return s, i + 1, nil
+}
+
+type inadynSetting struct {
+	name   string
+	values []string
+}
+
+func (s inadynSetting) first() string {
+	if len(s.values) == 0 {
+		return ""
+	}
+	return s.values[0]
+}
+
+// parseInadynSetting parses the name = value setting starting at the
+// token index i, and returns the index of the token following it.
+func parseInadynSetting(tokens []inadynToken, i int) (
+	setting inadynSetting, next int, err error) {
+	name := tokens[i]
+	if name.quoted || name.is("{") || name.is("}") || name.is("=") || name.is(",") ||
+		i+2 >= len(tokens) || !tokens[i+1].is("=") {
+		return setting, 0, fmt.Errorf("%w: line %d: expected setting name = value, got %q",
+			ErrSyntax, name.line, name.value)
+	}
+	setting.name = strings.ToLower(name.value)
+
+	i += 2
+	if !tokens[i].is("{") {
+		setting.values = []string{tokens[i].value}
+		return setting, i + 1, nil
+	}
+
+	for i++; ; i++ {
+		switch {
+		case i >= len(tokens):
+			return setting, 0, fmt.Errorf("%w: line %d: list of setting %s is not closed",
+				ErrSyntax, name.line, setting.name)
+		case tokens[i].is("}"):
+			return setting, i + 1, nil
+		case tokens[i].is(","):
+		default:
+			setting.values = append(setting.values, tokens[i].value)
+		}
+	}
+}
+
+// tokenizeInadyn splits the content given into its tokens,
+// ignoring the comments starting with #.
+func tokenizeInadyn(content string) (tokens []inadynToken, err error) {
+	line := 1
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ', c == '\t', c == '\r':
+			i++
+		case c == '#':
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+		case c == '{', c == '}', c == '=', c == ',':
+			tokens = append(tokens, inadynToken{value: string(c), line: line})
+			i++
+		case c == '"', c == '\'':
+			end := strings.IndexByte(content[i+1:], c)
+			if end == -1 {
+				return nil, fmt.Errorf("%w: line %d: unterminated quote", ErrSyntax, line)
+			}
+			value := content[i+1 : i+1+end]
+			tokens = append(tokens, inadynToken{value: value, quoted: true, line: line})
+			line += strings.Count(value, "\n")
+			i += end + 2 //nolint:mnd
+		default:
+			start := i
+			for i < len(content) && !strings.ContainsRune(" \t\r\n#{}=,\"'", rune(content[i])) {
+				i++
+			}
+			tokens = append(tokens, inadynToken{value: content[start:i], line: line})
+		}
+	}
+	return tokens, nil
+}
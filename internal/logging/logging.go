@@ -0,0 +1,118 @@
+// Package logging adds a JSON format to the loggers, logging one JSON
+// object per line with the structured fields of the message, if any.
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/qdm12/log"
+)
+
+// jsonFormat is set with SetJSON.
+var jsonFormat atomic.Bool //nolint:gochecknoglobals
+
+// SetJSON sets whether the loggers created with the options of Options
+// log in JSON, and whether Message adds its fields to the message.
+func SetJSON(enabled bool) {
+	jsonFormat.Store(enabled)
+}
+
+var (
+	outputMutex sync.Mutex
+	// output is the writer set with SetOutput, and jsonWriter
+	// the JSON writer of it shared by the loggers using it, so
+	// their lines are not interleaved.
+	output     io.Writer = os.Stdout                //nolint:gochecknoglobals
+	jsonWriter           = NewJSONWriter(os.Stdout) //nolint:gochecknoglobals
+)
+
+// SetOutput sets the writer of the loggers created with the options
+// of Options, which defaults to the standard output.
+func SetOutput(w io.Writer) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	output = w
+	jsonWriter = NewJSONWriter(w)
+}
+
+// Options returns the options to give to a logger, when created from
+// scratch such as with log.New or when patched, to log in the format
+// set with SetJSON to the writer set with SetOutput.
+func Options() (options []log.Option) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	if !jsonFormat.Load() {
+		return []log.Option{log.SetWriters(output)}
+	}
+	return []log.Option{
+		log.SetWriters(jsonWriter),
+		log.SetTimeFormat(time.RFC3339Nano),
+	}
+}
+
+// Field is a key value pair logged with a message in the JSON format.
+type Field struct {
+	Key   string
+	Value any
+}
+
+func With(key string, value any) Field {
+	return Field{Key: key, Value: value}
+}
+
+// fieldsSeparator separates the message from its fields encoded in JSON,
+// and is a control character never found in messages.
+const fieldsSeparator = "\x1f"
+
+// Message returns the message with the fields given, which are only
+// logged in the JSON format, so the text format is unchanged.
+func Message(message string, fields ...Field) string {
+	if !jsonFormat.Load() || len(fields) == 0 {
+		return message
+	}
+	var builder strings.Builder
+	builder.WriteString(message)
+	builder.WriteString(fieldsSeparator)
+	writeFields(&builder, fields)
+	return builder.String()
+}
+
+// ErrorFields returns the error field of err, and its error code field
+// if err wraps an error with an error code, such as a Beget API error.
+func ErrorFields(err error) (fields []Field) {
+	fields = []Field{With("error", err.Error())}
+	var coder interface {
+		ErrorCode() string
+	}
+	if errors.As(err, &coder) {
+		fields = append(fields, With("error_code", coder.ErrorCode()))
+	}
+	return fields
+}
+
+// writeFields writes the fields as a JSON object,
+// keeping their order unlike a map would.
+func writeFields(builder *strings.Builder, fields []Field) {
+	builder.WriteByte('{')
+	for i, field := range fields {
+		if i > 0 {
+			builder.WriteByte(',')
+		}
+		key, _ := json.Marshal(field.Key)
+		builder.Write(key)
+		builder.WriteByte(':')
+		value, err := json.Marshal(field.Value)
+		if err != nil {
+			value, _ = json.Marshal(err.Error())
+		}
+		builder.Write(value)
+	}
+	builder.WriteByte('}')
+}
@@ -0,0 +1,108 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/qdm12/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_JSONWriter(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		line string
+		json string
+	}{
+		"minimal": {
+			line: "2024-06-01T10:00:00Z INFO hello\n",
+			json: `{"time":"2024-06-01T10:00:00Z","level":"info","message":"hello"}` + "\n",
+		},
+		"component_and_caller": {
+			line: "2024-06-01T10:00:00Z \x1b[33mWARN\x1b[0m [beget] a\tb\tmain.go:12\n",
+			json: `{"time":"2024-06-01T10:00:00Z","level":"warn","component":"beget",` +
+				`"message":"a\tb","caller":"main.go:12"}` + "\n",
+		},
+		"multiline_message": {
+			line: "2024-06-01T10:00:00Z INFO Settings summary:\n├── Update\n",
+			json: `{"time":"2024-06-01T10:00:00Z","level":"info",` +
+				`"message":"Settings summary:\n├── Update"}` + "\n",
+		},
+		"fields": {
+			line: "2024-06-01T10:00:00Z ERROR failed\x1f{\"record\":\"example.com\",\"duration_ms\":12}\n",
+			json: `{"time":"2024-06-01T10:00:00Z","level":"error","message":"failed",` +
+				`"record":"example.com","duration_ms":12}` + "\n",
+		},
+		"malformed_fields": {
+			line: "2024-06-01T10:00:00Z ERROR failed\x1f{\n",
+			json: `{"time":"2024-06-01T10:00:00Z","level":"error","message":"failed"}` + "\n",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			buffer := bytes.NewBuffer(nil)
+			writer := NewJSONWriter(buffer)
+
+			n, err := writer.Write([]byte(testCase.line))
+
+			require.NoError(t, err)
+			assert.Equal(t, len(testCase.line), n)
+			assert.Equal(t, testCase.json, buffer.String())
+		})
+	}
+}
+
+type codeError struct {
+	code string
+}
+
+func (e *codeError) Error() string     { return e.code + ": failed" }
+func (e *codeError) ErrorCode() string { return e.code }
+
+func Test_Message(t *testing.T) { //nolint:paralleltest
+	SetJSON(false)
+	assert.Equal(t, "failed", Message("failed", With("record", "example.com")))
+
+	SetJSON(true)
+	t.Cleanup(func() {
+		SetJSON(false)
+	})
+
+	err := fmt.Errorf("updating: %w", &codeError{code: "AUTH_ERROR"})
+	fields := append([]Field{With("record", "example.com"), With("duration_ms", 12)},
+		ErrorFields(err)...)
+	message := Message("failed", fields...)
+	assert.Equal(t, "failed\x1f"+`{"record":"example.com","duration_ms":12,`+
+		`"error":"updating: AUTH_ERROR: failed","error_code":"AUTH_ERROR"}`, message)
+	assert.Equal(t, []Field{With("error", "plain")}, ErrorFields(errors.New("plain")))
+
+	buffer := bytes.NewBuffer(nil)
+	logger := log.New(log.SetWriters(NewJSONWriter(buffer)), log.SetTimeFormat(time.RFC3339Nano),
+		log.SetComponent("updater"))
+	logger.Error(message)
+	var object map[string]any
+	err = json.Unmarshal(buffer.Bytes(), &object)
+	require.NoError(t, err)
+	assert.NotEmpty(t, object["time"])
+	delete(object, "time")
+	expected := map[string]any{
+		"level":       "error",
+		"component":   "updater",
+		"message":     "failed",
+		"record":      "example.com",
+		"duration_ms": float64(12),
+		"error":       "updating: AUTH_ERROR: failed",
+		"error_code":  "AUTH_ERROR",
+	}
+	assert.Equal(t, expected, object)
+}
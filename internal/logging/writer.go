@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// JSONWriter converts each log line written to it into a JSON object
+// with the time, level, component, message, caller and message fields.
+type JSONWriter struct {
+	writer io.Writer
+	mutex  sync.Mutex
+}
+
+func NewJSONWriter(writer io.Writer) *JSONWriter {
+	return &JSONWriter{
+		writer: writer,
+	}
+}
+
+var (
+	colorRegex  = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	callerRegex = regexp.MustCompile(`^\S+:\d+$`)
+)
+
+// Write writes the log entry p, formatted as
+// "<time> <level> [<component>] <message>\t<caller>\n"
+// with the component and caller being optional. Since a logger
+// writes each entry at once, p is a single entry whose message
+// can span several lines.
+func (w *JSONWriter) Write(p []byte) (n int, err error) {
+	line := toJSON(strings.TrimSuffix(string(p), "\n")) + "\n"
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	_, err = io.WriteString(w.writer, line)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func toJSON(line string) string {
+	line = colorRegex.ReplaceAllString(line, "")
+	fields := make([]Field, 0, 5) //nolint:mnd
+
+	timeString, rest, _ := strings.Cut(line, " ")
+	fields = append(fields, With("time", timeString))
+	level, rest, _ := strings.Cut(rest, " ")
+	fields = append(fields, With("level", strings.ToLower(level)))
+
+	if strings.HasPrefix(rest, "[") {
+		component, message, ok := strings.Cut(rest[1:], "] ")
+		if ok {
+			fields = append(fields, With("component", component))
+			rest = message
+		}
+	}
+
+	var caller string
+	if i := strings.LastIndex(rest, "\t"); i >= 0 && callerRegex.MatchString(rest[i+1:]) {
+		rest, caller = rest[:i], rest[i+1:]
+	}
+
+	message, messageFields, hasFields := strings.Cut(rest, fieldsSeparator)
+	fields = append(fields, With("message", message))
+	if caller != "" {
+		fields = append(fields, With("caller", caller))
+	}
+
+	var builder strings.Builder
+	writeFields(&builder, fields)
+	object := builder.String()
+	if hasFields && json.Valid([]byte(messageFields)) &&
+		strings.HasPrefix(messageFields, "{") && len(messageFields) > len("{}") {
+		// merge the message fields into the object
+		object = strings.TrimSuffix(object, "}") + "," + messageFields[1:]
+	}
+	return object
+}
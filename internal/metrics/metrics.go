@@ -0,0 +1,283 @@
+// Package metrics implements a minimal registry of counters, gauges
+// and histograms served in the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry holds the metrics to serve.
+type Registry struct {
+	mutex   sync.Mutex
+	metrics []metric
+}
+
+type metric interface {
+	write(w io.Writer) (err error)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Default is the registry used by the providers, which cannot
+// have a registry injected, and served by the HTTP server.
+var Default = NewRegistry() //nolint:gochecknoglobals
+
+// NewCounterVec creates and registers a counter with the given label names.
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	counter := &CounterVec{
+		family: newFamily(name, help, "counter", labelNames),
+		values: make(map[string]float64),
+	}
+	r.register(counter)
+	return counter
+}
+
+// NewGaugeVec creates and registers a gauge with the given label names.
+func (r *Registry) NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	gauge := &GaugeVec{
+		CounterVec: CounterVec{
+			family: newFamily(name, help, "gauge", labelNames),
+			values: make(map[string]float64),
+		},
+	}
+	r.register(gauge)
+	return gauge
+}
+
+// NewHistogramVec creates and registers a histogram with the given
+// upper bounds of its buckets and label names.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64,
+	labelNames ...string) *HistogramVec {
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	histogram := &HistogramVec{
+		family:  newFamily(name, help, "histogram", labelNames),
+		buckets: buckets,
+		values:  make(map[string]*histogramValue),
+	}
+	r.register(histogram)
+	return histogram
+}
+
+func (r *Registry) register(m metric) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.metrics = append(r.metrics, m)
+}
+
+// Write writes all the metrics in the Prometheus text format.
+func (r *Registry) Write(w io.Writer) (err error) {
+	r.mutex.Lock()
+	metrics := slices.Clone(r.metrics)
+	r.mutex.Unlock()
+
+	for _, m := range metrics {
+		err = m.write(w)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = r.Write(w)
+}
+
+// family holds the fields common to all metric types.
+type family struct {
+	mutex      sync.Mutex
+	name       string
+	help       string
+	metricType string
+	labelNames []string
+}
+
+func newFamily(name, help, metricType string, labelNames []string) family {
+	return family{
+		name:       name,
+		help:       help,
+		metricType: metricType,
+		labelNames: labelNames,
+	}
+}
+
+// key returns the label pairs string for the label values given, which
+// is used both as map key and in the text format, such as
+// `endpoint="dns/getData",status="200"`.
+func (f *family) key(labelValues []string) string {
+	if len(labelValues) != len(f.labelNames) {
+		panic(fmt.Sprintf("metric %s has %d labels but %d values are given",
+			f.name, len(f.labelNames), len(labelValues)))
+	}
+	pairs := make([]string, len(labelValues))
+	for i, value := range labelValues {
+		pairs[i] = f.labelNames[i] + `="` + labelValueReplacer.Replace(value) + `"`
+	}
+	return strings.Join(pairs, ",")
+}
+
+// labelValueReplacer escapes the backslashes, double quotes and line feeds
+// of label values, which are the only escapes of the text format, and keeps
+// the other characters as they are, in UTF-8.
+var labelValueReplacer = strings.NewReplacer( //nolint:gochecknoglobals
+	`\`, `\\`,
+	`"`, `\"`,
+	"\n", `\n`,
+)
+
+func (f *family) writeHeader(w io.Writer) (err error) {
+	_, err = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n",
+		f.name, f.help, f.name, f.metricType)
+	return err
+}
+
+// CounterVec is a counter partitioned by label values.
+type CounterVec struct {
+	family
+	values map[string]float64
+}
+
+// Inc increments the counter for the given label values.
+func (c *CounterVec) Inc(labelValues ...string) {
+	key := c.key(labelValues)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.values[key]++
+}
+
+// Value returns the counter value for the given label values.
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	key := c.key(labelValues)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.values[key]
+}
+
+func (c *CounterVec) write(w io.Writer) (err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	err = c.writeHeader(w)
+	if err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(c.values) {
+		_, err = fmt.Fprintf(w, "%s%s %s\n", c.name, braced(key), formatFloat(c.values[key]))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GaugeVec is a gauge partitioned by label values.
+// It is written like a counter, with its own metric type.
+type GaugeVec struct {
+	CounterVec
+}
+
+// Set sets the gauge to value for the given label values.
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	key := g.key(labelValues)
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.values[key] = value
+}
+
+// HistogramVec is a histogram partitioned by label values.
+type HistogramVec struct {
+	family
+	buckets []float64
+	values  map[string]*histogramValue
+}
+
+type histogramValue struct {
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// Observe adds the value to the histogram for the given label values.
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	key := h.key(labelValues)
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	histogram, ok := h.values[key]
+	if !ok {
+		histogram = &histogramValue{counts: make([]uint64, len(h.buckets))}
+		h.values[key] = histogram
+	}
+	histogram.count++
+	histogram.sum += value
+	for i, upperBound := range h.buckets {
+		if value <= upperBound {
+			histogram.counts[i]++
+			break
+		}
+	}
+}
+
+func (h *HistogramVec) write(w io.Writer) (err error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	err = h.writeHeader(w)
+	if err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(h.values) {
+		histogram := h.values[key]
+		labelsPrefix := key
+		if labelsPrefix != "" {
+			labelsPrefix += ","
+		}
+		var cumulative uint64
+		for i, upperBound := range h.buckets {
+			cumulative += histogram.counts[i]
+			_, err = fmt.Fprintf(w, "%s_bucket{%sle=%q} %d\n",
+				h.name, labelsPrefix, formatFloat(upperBound), cumulative)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = fmt.Fprintf(w, "%s_bucket{%sle=\"+Inf\"} %d\n%s_sum%s %s\n%s_count%s %d\n",
+			h.name, labelsPrefix, histogram.count,
+			h.name, braced(key), formatFloat(histogram.sum),
+			h.name, braced(key), histogram.count)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) (keys []string) {
+	keys = make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func braced(labelPairs string) string {
+	if labelPairs == "" {
+		return ""
+	}
+	return "{" + labelPairs + "}"
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
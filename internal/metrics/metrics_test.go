@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Registry_Write(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	counter := registry.NewCounterVec("calls_total", "Calls made.", "endpoint", "status")
+	histogram := registry.NewHistogramVec("call_duration_seconds", "Call durations.",
+		[]float64{1, 0.5}, "endpoint")
+	gauge := registry.NewGaugeVec("last_success_seconds", "Last success time.", "domain")
+
+	counter.Inc("dns/getData", "200")
+	counter.Inc("dns/getData", "200")
+	counter.Inc("dns/changeRecords", "500")
+	histogram.Observe(0.25, "dns/getData")
+	histogram.Observe(0.75, "dns/getData")
+	histogram.Observe(2, "dns/getData")
+	gauge.Set(10, "example.com")
+	gauge.Set(5, "example.com")
+
+	var builder strings.Builder
+	err := registry.Write(&builder)
+	require.NoError(t, err)
+
+	const expected = `# HELP calls_total Calls made.
+# TYPE calls_total counter
+calls_total{endpoint="dns/changeRecords",status="500"} 1
+calls_total{endpoint="dns/getData",status="200"} 2
+# HELP call_duration_seconds Call durations.
+# TYPE call_duration_seconds histogram
+call_duration_seconds_bucket{endpoint="dns/getData",le="0.5"} 1
+call_duration_seconds_bucket{endpoint="dns/getData",le="1"} 2
+call_duration_seconds_bucket{endpoint="dns/getData",le="+Inf"} 3
+call_duration_seconds_sum{endpoint="dns/getData"} 3
+call_duration_seconds_count{endpoint="dns/getData"} 3
+# HELP last_success_seconds Last success time.
+# TYPE last_success_seconds gauge
+last_success_seconds{domain="example.com"} 5
+`
+	assert.Equal(t, expected, builder.String())
+	assert.Equal(t, float64(2), counter.Value("dns/getData", "200"))
+}
+
+func Test_family_key(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		value string
+		key   string
+	}{
+		"plain": {
+			value: "example.com",
+			key:   `domain="example.com"`,
+		},
+		"unicode": {
+			value: "пример.рф",
+			key:   `domain="пример.рф"`,
+		},
+		"escaped": {
+			value: "a\\b\"c\nd\te",
+			key:   `domain="a\\b\"c\nd` + "\t" + `e"`,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			family := newFamily("last_success_seconds", "Last success time.", "gauge",
+				[]string{"domain"})
+
+			key := family.key([]string{testCase.value})
+
+			assert.Equal(t, testCase.key, key)
+		})
+	}
+}
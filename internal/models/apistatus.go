@@ -0,0 +1,27 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// APIStatus is the outcome of the last call made by a provider to its API.
+type APIStatus struct {
+	Time     time.Time
+	Endpoint string
+	// ErrorCode is the error code returned by the API, if any.
+	ErrorCode string
+	// Error is the error message of the call, or empty if it succeeded.
+	Error string
+}
+
+func (s APIStatus) String() string {
+	if s.Error == "" {
+		return fmt.Sprintf("%s succeeded at %s", s.Endpoint, s.Time.Format(time.RFC3339))
+	}
+	message := fmt.Sprintf("%s failed at %s", s.Endpoint, s.Time.Format(time.RFC3339))
+	if s.ErrorCode != "" {
+		message += " with error code " + s.ErrorCode
+	}
+	return message + ": " + s.Error
+}
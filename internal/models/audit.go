@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// AuditEntry is a change of the records of an FQDN made by a provider.
+type AuditEntry struct {
+	Time time.Time
+	FQDN string
+	// Before and After are the record sets before and after the change,
+	// in the provider API format.
+	Before string
+	After  string
+	// Error is the error message of the change, or empty if it succeeded.
+	Error string
+}
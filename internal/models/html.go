@@ -9,6 +9,8 @@ type HTMLData struct {
 // HTMLRow contains HTML fields to be rendered
 // It is exported so that the HTML template engine can render it.
 type HTMLRow struct {
+	// ID is the identifier of the record, used to update it on demand.
+	ID          uint
 	Domain      string
 	Owner       string
 	Provider    string
@@ -16,4 +18,9 @@ type HTMLRow struct {
 	Status      string
 	CurrentIP   string
 	PreviousIPs string
+	// Paused is true if the record updates are paused.
+	Paused bool
+	// Details is provider specific information, such as
+	// the settings used and the last API error, if any.
+	Details string
 }
@@ -0,0 +1,10 @@
+package models
+
+// PersistedRecord is the persisted state of a record, used
+// to migrate the records from a database to another one.
+type PersistedRecord struct {
+	Domain string
+	Owner  string
+	Events []HistoryEvent
+	Paused bool
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Update event types.
+const (
+	// EventUpdated is a successful update without IP address change.
+	EventUpdated = "updated"
+	// EventIPChanged is a successful update changing the IP address.
+	EventIPChanged = "ip_changed"
+	// EventUpdateFailed is a failed update.
+	EventUpdateFailed = "update_failed"
+	// EventCredentialsInvalid is a failed update because the
+	// provider rejected the credentials.
+	EventCredentialsInvalid = "credentials_invalid"
+	// EventSuspended is a failed update because the provider calls are
+	// suspended after repeated authentication or rate limit errors. It is
+	// only sent for the first update failing this way in a row.
+	EventSuspended = "suspended"
+	// EventRecordRestored is a successful update after failed updates.
+	EventRecordRestored = "record_restored"
+)
+
+// UpdateEvent is the outcome of an update of a record,
+// sent to the shoutrrr, webhook and email notifiers.
+type UpdateEvent struct {
+	Type       string    `json:"type"`
+	Time       time.Time `json:"time"`
+	Domain     string    `json:"domain"`
+	Owner      string    `json:"owner"`
+	FQDN       string    `json:"fqdn"`
+	IPVersion  string    `json:"ip_version"`
+	IP         string    `json:"ip,omitempty"`
+	PreviousIP string    `json:"previous_ip,omitempty"`
+	// Message is the message of the record on the web UI,
+	// such as "changed to 1.2.3.4" or the update error.
+	Message string `json:"message"`
+	Error   string `json:"error,omitempty"`
+	// Failures is the number of consecutive failed updates of the
+	// record, including this one if it failed. For a record restored
+	// event, it is the number of failed updates before this one.
+	Failures uint `json:"failures,omitempty"`
+}
@@ -0,0 +1,62 @@
+// Package notify holds the event filter and message templates
+// shared by the notifiers of the record update events.
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"text/template"
+
+	"github.com/qdm12/ddns-updater/internal/models"
+)
+
+// Filter selects the update events sent to a notifier.
+type Filter struct {
+	// Events are the event types to send.
+	Events []string
+	// Failures is the number of consecutive failed updates of a
+	// record needed to send a failure event, which is sent once per
+	// series of failures. A record restored event is only sent if
+	// the failure event of its series was sent. It defaults to 1.
+	Failures uint
+}
+
+// Match returns true if the event should be sent.
+func (f Filter) Match(event models.UpdateEvent) bool {
+	if !slices.Contains(f.Events, event.Type) {
+		return false
+	}
+	failures := max(f.Failures, 1)
+	switch event.Type {
+	case models.EventUpdateFailed, models.EventCredentialsInvalid:
+		return event.Failures == failures
+	case models.EventRecordRestored:
+		return event.Failures >= failures
+	default:
+		return true
+	}
+}
+
+// ParseTemplate parses the Go template text of a message, executed with a
+// models.UpdateEvent, or returns a nil template if the text is empty.
+func ParseTemplate(name, text string) (tmpl *template.Template, err error) {
+	if text == "" {
+		return nil, nil //nolint:nilnil
+	}
+	tmpl, err = template.New(name).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s template: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// Execute returns the message of the template for the event.
+func Execute(tmpl *template.Template, event models.UpdateEvent) (message string, err error) {
+	var buffer bytes.Buffer
+	err = tmpl.Execute(&buffer, event)
+	if err != nil {
+		return "", fmt.Errorf("executing %s template: %w", tmpl.Name(), err)
+	}
+	return buffer.String(), nil
+}
@@ -0,0 +1,95 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Filter_Match(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		filter Filter
+		event  models.UpdateEvent
+		match  bool
+	}{
+		"event_type_not_enabled": {
+			filter: Filter{Events: []string{models.EventIPChanged}},
+			event:  models.UpdateEvent{Type: models.EventUpdated},
+		},
+		"event_type_enabled": {
+			filter: Filter{Events: []string{models.EventIPChanged}},
+			event:  models.UpdateEvent{Type: models.EventIPChanged},
+			match:  true,
+		},
+		"first_failure_with_default_failures": {
+			filter: Filter{Events: []string{models.EventUpdateFailed}},
+			event:  models.UpdateEvent{Type: models.EventUpdateFailed, Failures: 1},
+			match:  true,
+		},
+		"second_failure_with_default_failures": {
+			filter: Filter{Events: []string{models.EventUpdateFailed}},
+			event:  models.UpdateEvent{Type: models.EventUpdateFailed, Failures: 2},
+		},
+		"credentials_failure_below_failures": {
+			filter: Filter{Events: []string{models.EventCredentialsInvalid}, Failures: 2},
+			event:  models.UpdateEvent{Type: models.EventCredentialsInvalid, Failures: 1},
+		},
+		"credentials_failure_at_failures": {
+			filter: Filter{Events: []string{models.EventCredentialsInvalid}, Failures: 2},
+			event:  models.UpdateEvent{Type: models.EventCredentialsInvalid, Failures: 2},
+			match:  true,
+		},
+		"restored_without_failure_sent": {
+			filter: Filter{Events: []string{models.EventRecordRestored}, Failures: 3},
+			event:  models.UpdateEvent{Type: models.EventRecordRestored, Failures: 2},
+		},
+		"restored_with_failure_sent": {
+			filter: Filter{Events: []string{models.EventRecordRestored}, Failures: 3},
+			event:  models.UpdateEvent{Type: models.EventRecordRestored, Failures: 4},
+			match:  true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			match := testCase.filter.Match(testCase.event)
+
+			assert.Equal(t, testCase.match, match)
+		})
+	}
+}
+
+func Test_ParseTemplate_Execute(t *testing.T) {
+	t.Parallel()
+
+	tmpl, err := ParseTemplate("message", "")
+	require.NoError(t, err)
+	assert.Nil(t, tmpl)
+
+	_, err = ParseTemplate("message", "{{.FQDN")
+	assert.ErrorContains(t, err, "parsing message template")
+
+	tmpl, err = ParseTemplate("message",
+		`{{.Type}} {{.FQDN}} {{.Time.Format "2006-01-02"}}`)
+	require.NoError(t, err)
+	message, err := Execute(tmpl, models.UpdateEvent{
+		Type: models.EventIPChanged,
+		FQDN: "example.com",
+		Time: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ip_changed example.com 2024-05-01", message)
+
+	tmpl, err = ParseTemplate("message", "{{.Unknown}}")
+	require.NoError(t, err)
+	_, err = Execute(tmpl, models.UpdateEvent{})
+	assert.ErrorContains(t, err, "executing message template")
+}
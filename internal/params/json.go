@@ -6,13 +6,18 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"net"
 	"net/netip"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/qdm12/ddns-updater/internal/configcrypt"
 	"github.com/qdm12/ddns-updater/internal/models"
 	"github.com/qdm12/ddns-updater/internal/provider"
 	"github.com/qdm12/ddns-updater/internal/provider/utils"
+	"github.com/qdm12/ddns-updater/internal/records"
+	"github.com/qdm12/ddns-updater/internal/resolver"
 	"github.com/qdm12/ddns-updater/pkg/publicip/ipversion"
 	"golang.org/x/net/publicsuffix"
 )
@@ -27,27 +32,40 @@ type commonSettings struct {
 	Owner      string       `json:"owner,omitempty"`
 	IPVersion  string       `json:"ip_version"`
 	IPv6Suffix netip.Prefix `json:"ipv6_suffix,omitempty"`
+	// Period overrides the global update period for the entry.
+	Period string `json:"period,omitempty"`
+	// Jitter overrides the global update jitter for the entry.
+	Jitter string `json:"jitter,omitempty"`
+	// MirrorGroup is the name of the group of entries updated
+	// together, such as the same FQDN on several providers.
+	MirrorGroup string `json:"mirror_group,omitempty"`
+	// MaxAge is the maximum duration since the last success of the entry
+	// records above which the program is reported unhealthy.
+	MaxAge string `json:"max_age,omitempty"`
+	// Resolver is the address of the DNS server used to resolve the
+	// entry records before updating them, overriding RESOLVER_ADDRESS.
+	Resolver string `json:"resolver,omitempty"`
 	// Retro values for warnings
 	ProviderIP *bool `json:"provider_ip,omitempty"`
 }
 
-// JSONProviders obtain the update settings from the JSON content,
+// JSONSettings obtain the update settings from the JSON content,
 // first trying from the environment variable CONFIG and then from
 // the file config.json.
-func (r *Reader) JSONProviders(filePath string) (
-	providers []provider.Provider, warnings []string, err error) {
-	providers, warnings, err = r.getProvidersFromEnv(filePath)
-	if providers != nil || warnings != nil || err != nil {
-		return providers, warnings, err
+func (r *Reader) JSONSettings(filePath string) (
+	settings []records.Settings, warnings []string, err error) {
+	settings, warnings, err = r.getSettingsFromEnv(filePath)
+	if settings != nil || warnings != nil || err != nil {
+		return settings, warnings, err
 	}
-	return r.getProvidersFromFile(filePath)
+	return r.getSettingsFromFile(filePath)
 }
 
 var errWriteConfigToFile = errors.New("cannot write configuration to file")
 
-// getProvidersFromFile obtain the update settings from config.json.
-func (r *Reader) getProvidersFromFile(filePath string) (
-	providers []provider.Provider, warnings []string, err error) {
+// getSettingsFromFile obtain the update settings from config.json.
+func (r *Reader) getSettingsFromFile(filePath string) (
+	settings []records.Settings, warnings []string, err error) {
 	r.logger.Info("reading JSON config from file " + filePath)
 	bytes, err := r.readFile(filePath)
 	if err != nil {
@@ -67,13 +85,36 @@ func (r *Reader) getProvidersFromFile(filePath string) (
 	}
 	r.logger.Debug("config read: " + string(bytes))
 
+	bytes, err = r.decrypt(bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	return extractAllSettings(bytes)
 }
 
-// getProvidersFromEnv obtain the update settings from the environment variable CONFIG.
-// If the settings are valid, they are written to the filePath.
-func (r *Reader) getProvidersFromEnv(filePath string) (
-	providers []provider.Provider, warnings []string, err error) {
+var ErrConfigKeyNotSet = errors.New("config key is not set")
+
+// decrypt returns the config decrypted if it is encrypted,
+// and returns it as it is otherwise.
+func (r *Reader) decrypt(data []byte) (jsonBytes []byte, err error) {
+	if !configcrypt.IsEncrypted(data) {
+		return data, nil
+	} else if r.configKey == "" {
+		return nil, fmt.Errorf("%w: set CONFIG_KEY to decrypt the config", ErrConfigKeyNotSet)
+	}
+	jsonBytes, err = configcrypt.Decrypt(data, r.configKey)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting config: %w", err)
+	}
+	return jsonBytes, nil
+}
+
+// getSettingsFromEnv obtain the update settings from the environment variable CONFIG.
+// If the settings are valid, they are written to the filePath, encrypted
+// if the config key is set.
+func (r *Reader) getSettingsFromEnv(filePath string) (
+	settings []records.Settings, warnings []string, err error) {
 	s := os.Getenv("CONFIG")
 	if s == "" {
 		return nil, nil, nil
@@ -82,24 +123,38 @@ func (r *Reader) getProvidersFromEnv(filePath string) (
 	r.logger.Debug("config read: " + s)
 
 	b := []byte(s)
-
-	providers, warnings, err = extractAllSettings(b)
+	jsonBytes, err := r.decrypt(b)
 	if err != nil {
-		return providers, warnings, fmt.Errorf("configuration given: %w", err)
+		return nil, nil, fmt.Errorf("configuration given: %w", err)
 	}
 
-	buffer := bytes.NewBuffer(nil)
-	err = json.Indent(buffer, b, "", "  ")
+	settings, warnings, err = extractAllSettings(jsonBytes)
 	if err != nil {
-		return providers, warnings, fmt.Errorf("%w: %w", errWriteConfigToFile, err)
+		return settings, warnings, fmt.Errorf("configuration given: %w", err)
+	}
+
+	data := b
+	if !configcrypt.IsEncrypted(b) {
+		buffer := bytes.NewBuffer(nil)
+		err = json.Indent(buffer, b, "", "  ")
+		if err != nil {
+			return settings, warnings, fmt.Errorf("%w: %w", errWriteConfigToFile, err)
+		}
+		data = buffer.Bytes()
+		if r.configKey != "" {
+			data, err = configcrypt.Encrypt(data, r.configKey)
+			if err != nil {
+				return settings, warnings, fmt.Errorf("%w: %w", errWriteConfigToFile, err)
+			}
+		}
 	}
 	const mode = fs.FileMode(0600)
-	err = r.writeFile(filePath, buffer.Bytes(), mode)
+	err = r.writeFile(filePath, data, mode)
 	if err != nil {
-		return providers, warnings, fmt.Errorf("%w: %w", errWriteConfigToFile, err)
+		return settings, warnings, fmt.Errorf("%w: %w", errWriteConfigToFile, err)
 	}
 
-	return providers, warnings, nil
+	return settings, warnings, nil
 }
 
 var (
@@ -108,7 +163,7 @@ var (
 )
 
 func extractAllSettings(jsonBytes []byte) (
-	allProviders []provider.Provider, warnings []string, err error) {
+	allSettings []records.Settings, warnings []string, err error) {
 	config := struct {
 		CommonSettings []commonSettings `json:"settings"`
 	}{}
@@ -130,25 +185,30 @@ func extractAllSettings(jsonBytes []byte) (
 	}
 
 	for i, common := range config.CommonSettings {
-		newProvider, newWarnings, err := makeSettingsFromObject(common, rawConfig.Settings[i],
+		newSettings, newWarnings, err := makeSettingsFromObject(common, rawConfig.Settings[i],
 			retroIPv6Suffix)
 		warnings = append(warnings, newWarnings...)
 		if err != nil {
+			records.Release(allSettings)
 			return nil, warnings, err
 		}
-		allProviders = append(allProviders, newProvider...)
+		allSettings = append(allSettings, newSettings...)
 	}
 
-	return allProviders, warnings, nil
+	return allSettings, warnings, nil
 }
 
 var (
 	ErrProviderNoLongerSupported = errors.New("provider no longer supported")
+	ErrPeriodNotValid            = errors.New("period is not valid")
+	ErrJitterNotValid            = errors.New("jitter is not valid")
+	ErrMaxAgeNotValid            = errors.New("max age is not valid")
+	ErrResolverNotValid          = errors.New("resolver is not valid")
 )
 
 func makeSettingsFromObject(common commonSettings, rawSettings json.RawMessage,
 	retroGlobalIPv6Suffix netip.Prefix) (
-	providers []provider.Provider, warnings []string, err error) {
+	settings []records.Settings, warnings []string, err error) {
 	if common.Provider == "google" {
 		return nil, nil, fmt.Errorf("%w: %s", ErrProviderNoLongerSupported, common.Provider)
 	}
@@ -202,17 +262,71 @@ func makeSettingsFromObject(common commonSettings, rawSettings json.RawMessage,
 		warnings = append(warnings, warning)
 	}
 
+	var period time.Duration
+	if common.Period != "" {
+		period, err = time.ParseDuration(common.Period)
+		if err != nil {
+			return nil, warnings, fmt.Errorf("%w: %w", ErrPeriodNotValid, err)
+		} else if period <= 0 {
+			return nil, warnings, fmt.Errorf("%w: %s must be positive", ErrPeriodNotValid, period)
+		}
+	}
+
+	var jitter time.Duration
+	if common.Jitter != "" {
+		jitter, err = time.ParseDuration(common.Jitter)
+		if err != nil {
+			return nil, warnings, fmt.Errorf("%w: %w", ErrJitterNotValid, err)
+		} else if jitter < 0 {
+			return nil, warnings, fmt.Errorf("%w: %s cannot be negative", ErrJitterNotValid, jitter)
+		}
+	}
+
+	var maxAge time.Duration
+	if common.MaxAge != "" {
+		maxAge, err = time.ParseDuration(common.MaxAge)
+		if err != nil {
+			return nil, warnings, fmt.Errorf("%w: %w", ErrMaxAgeNotValid, err)
+		} else if maxAge <= 0 {
+			return nil, warnings, fmt.Errorf("%w: %s must be positive", ErrMaxAgeNotValid, maxAge)
+		}
+	}
+
+	var recordResolver *net.Resolver
+	if common.Resolver != "" {
+		address := common.Resolver
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			const defaultDNSPort = "53"
+			address = net.JoinHostPort(address, defaultDNSPort)
+		}
+		recordResolver, err = resolver.New(resolver.Settings{Address: &address})
+		if err != nil {
+			return nil, warnings, fmt.Errorf("%w: %w", ErrResolverNotValid, err)
+		}
+	}
+
+	rawSettings, err = resolveSecrets(rawSettings, os.LookupEnv)
+	if err != nil {
+		return nil, warnings, fmt.Errorf("resolving secrets: %w", err)
+	}
+
 	providerName := models.Provider(common.Provider)
-	providers = make([]provider.Provider, len(owners))
+	settings = make([]records.Settings, len(owners))
 	for i, owner := range owners {
 		owner = strings.TrimSpace(owner)
-		providers[i], err = provider.New(providerName, rawSettings, domain,
+		settings[i].Provider, err = provider.New(providerName, rawSettings, domain,
 			owner, ipVersion, ipv6Suffix)
 		if err != nil {
+			records.Release(settings[:i])
 			return nil, warnings, err
 		}
+		settings[i].Period = period
+		settings[i].Jitter = jitter
+		settings[i].MirrorGroup = common.MirrorGroup
+		settings[i].MaxAge = maxAge
+		settings[i].Resolver = recordResolver
 	}
-	return providers, warnings, nil
+	return settings, warnings, nil
 }
 
 var (
@@ -221,6 +335,11 @@ var (
 
 func extractFromDomainField(domainField string) (domainRegistered string,
 	owners []string, err error) {
+	if domainField == "*" {
+		// account wide domain for providers discovering domains themselves
+		return "*", []string{"@"}, nil
+	}
+
 	domains := strings.Split(domainField, ",")
 	owners = make([]string, len(domains))
 	for i, domain := range domains {
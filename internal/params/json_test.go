@@ -3,7 +3,9 @@ package params
 import (
 	"testing"
 
+	"github.com/qdm12/ddns-updater/internal/configcrypt"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_extractFromDomainField(t *testing.T) {
@@ -36,6 +38,11 @@ func Test_extractFromDomainField(t *testing.T) {
 			domainRegistered: "example.com",
 			owners:           []string{"*"},
 		},
+		"account_wide": {
+			domainField:      "*",
+			domainRegistered: "*",
+			owners:           []string{"@"},
+		},
 		"multiple": {
 			domainField:      "*.example.com,example.com",
 			domainRegistered: "example.com",
@@ -74,3 +81,26 @@ func Test_extractFromDomainField(t *testing.T) {
 		})
 	}
 }
+
+func Test_Reader_decrypt(t *testing.T) {
+	t.Parallel()
+
+	key, err := configcrypt.GenerateKey()
+	require.NoError(t, err)
+	plain := []byte(`{"settings":[]}`)
+	encrypted, err := configcrypt.Encrypt(plain, key)
+	require.NoError(t, err)
+
+	reader := &Reader{configKey: key}
+	data, err := reader.decrypt(plain)
+	require.NoError(t, err)
+	assert.Equal(t, plain, data)
+	data, err = reader.decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, plain, data)
+
+	reader = &Reader{}
+	_, err = reader.decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrConfigKeyNotSet)
+	assert.EqualError(t, err, "config key is not set: set CONFIG_KEY to decrypt the config")
+}
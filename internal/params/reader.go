@@ -6,7 +6,9 @@ import (
 )
 
 type Reader struct {
-	logger    Logger
+	logger Logger
+	// configKey is the key of the config file if it is encrypted.
+	configKey string
 	readFile  func(filename string) ([]byte, error)
 	writeFile func(filename string, data []byte, perm fs.FileMode) (err error)
 }
@@ -16,9 +18,10 @@ type Logger interface {
 	Debug(s string)
 }
 
-func NewReader(logger Logger) *Reader {
+func NewReader(logger Logger, configKey string) *Reader {
 	return &Reader{
 		logger:    logger,
+		configKey: configKey,
 		readFile:  os.ReadFile,
 		writeFile: os.WriteFile,
 	}
@@ -0,0 +1,74 @@
+package params
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/qdm12/ddns-updater/internal/secrets"
+)
+
+// secretKeys are the provider settings keys holding credentials, which can
+// be set with references to environment variables such as ${API_TOKEN}, or
+// read from the file at the path given by the key suffixed with _file.
+//
+//nolint:gochecknoglobals
+var secretKeys = []string{
+	"access_key", "access_key_id", "access_secret", "api_key", "apikey",
+	"apipassword", "app_key", "app_secret", "client_key", "consumer_key",
+	"key", "login", "password", "personal_access_token", "secret",
+	"secret_api_key", "secret_key", "secretapikey", "token", "user",
+	"user_service_key", "username",
+}
+
+// resolveSecrets returns the raw settings of an entry with its secret
+// values resolved and its secret _file keys removed, leaving the raw
+// settings given untouched so the secrets are never written back.
+func resolveSecrets(rawSettings json.RawMessage,
+	lookupEnv func(key string) (value string, ok bool)) (
+	resolved json.RawMessage, err error) {
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(rawSettings, &fields)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errUnmarshalRaw, err)
+	}
+
+	changed := false
+	for _, key := range secretKeys {
+		fileKey := key + "_file"
+		rawValue, valueSet := fields[key]
+		rawFilePath, filePathSet := fields[fileKey]
+		if !filePathSet && !valueSet {
+			continue
+		}
+
+		var value, filePath string
+		if valueSet && json.Unmarshal(rawValue, &value) != nil {
+			continue // not a string value, such as a JSON object
+		}
+		if filePathSet {
+			err = json.Unmarshal(rawFilePath, &filePath)
+			if err != nil {
+				return nil, fmt.Errorf("decoding %s: %w", fileKey, err)
+			}
+		}
+
+		secret, err := secrets.Resolve(key, value, filePath, lookupEnv)
+		if err != nil {
+			return nil, err
+		}
+		if secret == value && !filePathSet {
+			continue
+		}
+		fields[key], err = json.Marshal(secret)
+		if err != nil {
+			return nil, fmt.Errorf("encoding %s: %w", key, err)
+		}
+		delete(fields, fileKey)
+		changed = true
+	}
+
+	if !changed {
+		return rawSettings, nil
+	}
+	return json.Marshal(fields)
+}
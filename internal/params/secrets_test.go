@@ -0,0 +1,81 @@
+package params
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qdm12/ddns-updater/internal/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_resolveSecrets(t *testing.T) {
+	t.Parallel()
+
+	filePath := filepath.Join(t.TempDir(), "token")
+	err := os.WriteFile(filePath, []byte("file-token\n"), 0o600)
+	require.NoError(t, err)
+
+	lookupEnv := func(key string) (value string, ok bool) {
+		if key == "API_TOKEN" {
+			return "env-token", true
+		}
+		return "", false
+	}
+
+	testCases := map[string]struct {
+		rawSettings string
+		resolved    string
+		errWrapped  error
+		errMessage  string
+	}{
+		"no_secret": {
+			rawSettings: `{"provider":"duckdns", "ttl":300}`,
+			resolved:    `{"provider":"duckdns", "ttl":300}`,
+		},
+		"plain_value": {
+			rawSettings: `{"token":"pa$$word"}`,
+			resolved:    `{"token":"pa$$word"}`,
+		},
+		"env_reference": {
+			rawSettings: `{"token":"${API_TOKEN}","audit_file":"/audit.log"}`,
+			resolved:    `{"token":"env-token","audit_file":"/audit.log"}`,
+		},
+		"file": {
+			rawSettings: `{"token_file":"` + filePath + `"}`,
+			resolved:    `{"token":"file-token"}`,
+		},
+		"object_value": {
+			rawSettings: `{"key":{"id":1}}`,
+			resolved:    `{"key":{"id":1}}`,
+		},
+		"value_and_file": {
+			rawSettings: `{"token":"x","token_file":"` + filePath + `"}`,
+			errWrapped:  secrets.ErrValueAndFileSet,
+			errMessage:  "value and file cannot be both set: token and token_file",
+		},
+		"env_not_set": {
+			rawSettings: `{"password":"${MISSING}"}`,
+			errWrapped:  secrets.ErrEnvNotSet,
+			errMessage:  "password: environment variable is not set: MISSING",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			resolved, err := resolveSecrets(json.RawMessage(testCase.rawSettings), lookupEnv)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+				return
+			}
+			assert.JSONEq(t, testCase.resolved, string(resolved))
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package params
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Report is the validation outcome of the JSON config.
+type Report struct {
+	Valid bool `json:"valid"`
+	// Error is an error of the whole config, such as a JSON syntax error.
+	Error   string        `json:"error,omitempty"`
+	Entries []EntryReport `json:"entries"`
+}
+
+// EntryReport is the validation outcome of an entry of the
+// "settings" array of the JSON config.
+type EntryReport struct {
+	// Index is the position of the entry in the array, starting from 0.
+	Index    int      `json:"index"`
+	Provider string   `json:"provider"`
+	Domain   string   `json:"domain"`
+	Errors   []string `json:"errors,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Validate validates the JSON config from the environment variable CONFIG,
+// or else from the file, without writing it. Each entry is validated with
+// the settings constructor of its provider, and the entries with the same
+// domain, owner and IP version as a previous entry are reported, unless
+// both are in the same mirror group with different providers.
+func (r *Reader) Validate(filePath string) (report Report) {
+	jsonBytes := []byte(os.Getenv("CONFIG"))
+	if len(jsonBytes) == 0 {
+		var err error
+		jsonBytes, err = r.readFile(filePath)
+		if err != nil {
+			report.Error = fmt.Sprintf("reading config file: %s", err)
+			return report
+		}
+	}
+	jsonBytes, err := r.decrypt(jsonBytes)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+
+	rawConfig := struct {
+		Settings []json.RawMessage `json:"settings"`
+	}{}
+	err = json.Unmarshal(jsonBytes, &rawConfig)
+	if err != nil {
+		report.Error = fmt.Sprintf("%s: %s", errUnmarshalRaw, err)
+		return report
+	}
+
+	retroIPv6Suffix, err := getRetroIPv6Suffix()
+	if err != nil {
+		report.Error = fmt.Sprintf("getting retro-compatible global IPV6 suffix: %s", err)
+		return report
+	}
+
+	report.Valid = true
+	report.Entries = make([]EntryReport, len(rawConfig.Settings))
+	// keyEntries maps the domain, owner and IP version of
+	// records to the entries defining them.
+	keyEntries := make(map[string][]keyEntry)
+	for i, rawSettings := range rawConfig.Settings {
+		entry := &report.Entries[i]
+		entry.Index = i
+
+		var common commonSettings
+		err = json.Unmarshal(rawSettings, &common)
+		if err != nil {
+			entry.Errors = append(entry.Errors, fmt.Sprintf("%s: %s", errUnmarshalCommon, err))
+			report.Valid = false
+			continue
+		}
+		entry.Provider = common.Provider
+		entry.Domain = common.Domain
+
+		settings, warnings, err := makeSettingsFromObject(common, rawSettings, retroIPv6Suffix)
+		entry.Warnings = warnings
+		if err != nil {
+			entry.Errors = append(entry.Errors, err.Error())
+			report.Valid = false
+			continue
+		}
+
+		for _, setting := range settings {
+			key := setting.Provider.BuildDomainName() + " " + setting.Provider.IPVersion().String()
+			current := keyEntry{index: i, provider: common.Provider, mirrorGroup: setting.MirrorGroup}
+			previous, duplicate := findDuplicate(keyEntries[key], current)
+			if duplicate {
+				entry.Errors = append(entry.Errors, fmt.Sprintf(
+					"%s is already updated by entry %d", key, previous.index))
+				report.Valid = false
+				continue
+			}
+			keyEntries[key] = append(keyEntries[key], current)
+		}
+	}
+	return report
+}
+
+type keyEntry struct {
+	index       int
+	provider    string
+	mirrorGroup string
+}
+
+// findDuplicate returns the first of the entries with the same domain,
+// owner and IP version as the current entry it conflicts with, which
+// is any entry unless both are in the same mirror group with different
+// providers.
+func findDuplicate(previous []keyEntry, current keyEntry) (duplicate keyEntry, ok bool) {
+	for _, entry := range previous {
+		mirrored := current.mirrorGroup != "" &&
+			entry.mirrorGroup == current.mirrorGroup &&
+			entry.provider != current.provider
+		if !mirrored {
+			return entry, true
+		}
+	}
+	return keyEntry{}, false
+}
@@ -0,0 +1,154 @@
+package params
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Reader_Validate(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		config  string
+		readErr error
+		report  Report
+	}{
+		"read_error": {
+			readErr: errors.New("test error"),
+			report:  Report{Error: "reading config file: test error"},
+		},
+		"json_syntax_error": {
+			config: `{"settings": [`,
+			report: Report{Error: "cannot unmarshal raw configuration: unexpected end of JSON input"},
+		},
+		"valid": {
+			config: `{"settings": [
+				{"provider": "beget", "domain": "example.com", "login": "user", "password": "pass"}
+			]}`,
+			report: Report{
+				Valid: true,
+				Entries: []EntryReport{
+					{Index: 0, Provider: "beget", Domain: "example.com"},
+				},
+			},
+		},
+		"period_not_valid": {
+			config: `{"settings": [
+				{"provider": "beget", "domain": "example.com", "login": "user", "password": "pass",
+					"period": "-1m"},
+				{"provider": "beget", "domain": "other.com", "login": "user", "password": "pass",
+					"period": "1m"}
+			]}`,
+			report: Report{
+				Entries: []EntryReport{
+					{
+						Index:    0,
+						Provider: "beget",
+						Domain:   "example.com",
+						Errors:   []string{"period is not valid: -1m0s must be positive"},
+					},
+					{Index: 1, Provider: "beget", Domain: "other.com"},
+				},
+			},
+		},
+		"resolver_not_valid": {
+			config: `{"settings": [
+				{"provider": "beget", "domain": "example.com", "login": "user", "password": "pass",
+					"resolver": ":53"},
+				{"provider": "beget", "domain": "other.com", "login": "user", "password": "pass",
+					"resolver": "ns1.beget.com"}
+			]}`,
+			report: Report{
+				Entries: []EntryReport{
+					{
+						Index:    0,
+						Provider: "beget",
+						Domain:   "example.com",
+						Errors: []string{"resolver is not valid: validating settings: " +
+							"address host is empty: in :53"},
+					},
+					{Index: 1, Provider: "beget", Domain: "other.com"},
+				},
+			},
+		},
+		"mirror_group": {
+			config: `{"settings": [
+				{"provider": "beget", "domain": "example.com", "login": "user", "password": "pass",
+					"mirror_group": "home"},
+				{"provider": "cloudflare", "domain": "example.com", "zone_identifier": "zone",
+					"token": "token", "ttl": 600, "mirror_group": "home"},
+				{"provider": "cloudflare", "domain": "example.com", "zone_identifier": "zone",
+					"token": "token", "ttl": 600}
+			]}`,
+			report: Report{
+				Entries: []EntryReport{
+					{Index: 0, Provider: "beget", Domain: "example.com"},
+					{Index: 1, Provider: "cloudflare", Domain: "example.com"},
+					{
+						Index:    2,
+						Provider: "cloudflare",
+						Domain:   "example.com",
+						Errors:   []string{"example.com ipv4 or ipv6 is already updated by entry 0"},
+					},
+				},
+			},
+		},
+		"entry_errors": {
+			config: `{"settings": [
+				{"provider": "beget", "domain": "other.com", "login": "user", "password": "pass",
+					"record_mode": "invalid"},
+				{"provider": "beget", "domain": 1},
+				{"provider": "beget", "domain": "example.com", "login": "user", "password": "pass"},
+				{"provider": "beget", "domain": "example.com", "login": "user", "password": "pass",
+					"ip_version": "ipv4"},
+				{"provider": "beget", "domain": "example.com", "login": "user", "password": "pass",
+					"ip_version": "ipv4"}
+			]}`,
+			report: Report{
+				Entries: []EntryReport{
+					{
+						Index:    0,
+						Provider: "beget",
+						Domain:   "other.com",
+						Errors: []string{"validating provider specific settings: " +
+							`record mode is not valid: "invalid" must be one of "replace" or "merge"`},
+					},
+					{
+						Index: 1,
+						Errors: []string{"cannot unmarshal common settings: " +
+							"json: cannot unmarshal number into Go struct field " +
+							"commonSettings.domain of type string"},
+					},
+					{Index: 2, Provider: "beget", Domain: "example.com"},
+					{Index: 3, Provider: "beget", Domain: "example.com"},
+					{
+						Index:    4,
+						Provider: "beget",
+						Domain:   "example.com",
+						Errors:   []string{"example.com ipv4 is already updated by entry 3"},
+					},
+				},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			reader := &Reader{
+				readFile: func(filename string) ([]byte, error) {
+					assert.Equal(t, "config.json", filename)
+					return []byte(testCase.config), testCase.readErr
+				},
+			}
+
+			report := reader.Validate("config.json")
+
+			assert.Equal(t, testCase.report, report)
+		})
+	}
+}
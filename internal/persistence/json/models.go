@@ -16,6 +16,8 @@ type record struct {
 	Host   string                `json:"host,omitempty"`
 	Owner  string                `json:"owner"`
 	Events []models.HistoryEvent `json:"ips"`
+	// Paused is true if the record must not be updated until resumed.
+	Paused bool `json:"paused,omitempty"`
 }
 
 func (r record) String() string {
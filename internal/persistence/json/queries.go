@@ -53,6 +53,58 @@ func (db *Database) GetEvents(domain, owner string,
 	return nil, nil
 }
 
+// SetPaused stores whether the record for a certain domain
+// and owner is paused, so it stays paused across restarts.
+func (db *Database) SetPaused(domain, owner string, paused bool) (err error) {
+	db.Lock()
+	defer db.Unlock()
+
+	for i, record := range db.data.Records {
+		if record.Domain == domain && record.Owner == owner {
+			db.data.Records[i].Paused = paused
+			return db.write()
+		}
+	}
+
+	if !paused {
+		return nil
+	}
+	db.data.Records = append(db.data.Records, record{
+		Domain: domain,
+		Owner:  owner,
+		Paused: true,
+	})
+	return db.write()
+}
+
+// GetPaused returns whether the record for a certain domain and owner is paused.
+func (db *Database) GetPaused(domain, owner string) (paused bool, err error) {
+	db.RLock()
+	defer db.RUnlock()
+	for _, record := range db.data.Records {
+		if record.Domain == domain && record.Owner == owner {
+			return record.Paused, nil
+		}
+	}
+	return false, nil
+}
+
+// Records returns the records stored, to migrate them to another database.
+func (db *Database) Records() (records []models.PersistedRecord) {
+	db.RLock()
+	defer db.RUnlock()
+	records = make([]models.PersistedRecord, len(db.data.Records))
+	for i, record := range db.data.Records {
+		records[i] = models.PersistedRecord{
+			Domain: record.Domain,
+			Owner:  record.Owner,
+			Events: record.Events,
+			Paused: record.Paused,
+		}
+	}
+	return records
+}
+
 func filterEvents(events []models.HistoryEvent, ipVersion ipversion.IPVersion) (filteredEvents []models.HistoryEvent) {
 	filteredEvents = make([]models.HistoryEvent, 0, len(events))
 	for _, event := range events {
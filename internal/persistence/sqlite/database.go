@@ -0,0 +1,109 @@
+// Package sqlite implements the persistent database in an SQLite
+// file, written in transactions so it is not corrupted by an unclean
+// shutdown, unlike the JSON file rewritten on each change.
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite" // registers the sqlite driver
+)
+
+type Database struct {
+	db *sql.DB
+}
+
+// FileName is the name of the SQLite file in the data directory.
+const FileName = "updates.db"
+
+const schema = `
+CREATE TABLE IF NOT EXISTS records (
+	domain TEXT NOT NULL,
+	owner TEXT NOT NULL,
+	paused INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (domain, owner)
+);
+CREATE TABLE IF NOT EXISTS events (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	domain TEXT NOT NULL,
+	owner TEXT NOT NULL,
+	ip TEXT NOT NULL,
+	time TEXT NOT NULL,
+	FOREIGN KEY (domain, owner) REFERENCES records (domain, owner)
+);
+CREATE INDEX IF NOT EXISTS events_record ON events (domain, owner);
+CREATE TABLE IF NOT EXISTS audit (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	time TEXT NOT NULL,
+	fqdn TEXT NOT NULL,
+	before TEXT NOT NULL,
+	after TEXT NOT NULL,
+	error TEXT NOT NULL
+);`
+
+// NewDatabase opens or creates the SQLite file database in the data directory.
+func NewDatabase(dataDir string) (*Database, error) {
+	filePath := filepath.Join(dataDir, FileName)
+
+	const perm fs.FileMode = 0700
+	err := os.MkdirAll(dataDir, perm)
+	if err != nil {
+		return nil, fmt.Errorf("creating data directory: %w", err)
+	}
+
+	// The write-ahead log with full synchronous commits keeps the
+	// database consistent and its commits durable on power loss.
+	query := url.Values{}
+	query.Add("_pragma", "journal_mode(WAL)")
+	query.Add("_pragma", "synchronous(FULL)")
+	query.Add("_pragma", "foreign_keys(ON)")
+	query.Add("_pragma", "busy_timeout(5000)")
+	db, err := sql.Open("sqlite", "file:"+filePath+"?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+	// A single connection serializes the writes.
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(schema)
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("creating tables: %w", err)
+	}
+
+	return &Database{db: db}, nil
+}
+
+func (db *Database) Close() error {
+	return db.db.Close()
+}
+
+// transaction runs fn in a transaction, which is
+// committed if fn succeeds and rolled back otherwise.
+func (db *Database) transaction(fn func(tx *sql.Tx) error) (err error) {
+	tx, err := db.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+
+	err = fn(tx)
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			err = errors.Join(err, fmt.Errorf("rolling back transaction: %w", rollbackErr))
+		}
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+	return nil
+}
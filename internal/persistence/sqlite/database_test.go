@@ -0,0 +1,107 @@
+package sqlite
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/models"
+	"github.com/qdm12/ddns-updater/pkg/publicip/ipversion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Database(t *testing.T) {
+	t.Parallel()
+
+	dataDir := t.TempDir()
+	db, err := NewDatabase(dataDir)
+	require.NoError(t, err)
+
+	empty, err := db.IsEmpty()
+	require.NoError(t, err)
+	assert.True(t, empty)
+
+	time1 := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	time2 := time1.Add(time.Hour)
+	err = db.StoreNewIP("example.com", "@", netip.MustParseAddr("1.1.1.1"), time1)
+	require.NoError(t, err)
+	err = db.StoreNewIP("example.com", "@", netip.MustParseAddr("::1"), time2)
+	require.NoError(t, err)
+	err = db.SetPaused("example.com", "@", true)
+	require.NoError(t, err)
+
+	entry := models.AuditEntry{
+		Time:   time1,
+		FQDN:   "example.com",
+		Before: `{"A":[]}`,
+		After:  `{"A":[{"value":"1.1.1.1"}]}`,
+	}
+	err = db.StoreAuditEntry(entry)
+	require.NoError(t, err)
+
+	err = db.Close()
+	require.NoError(t, err)
+
+	// Reopen the database to check the data is persisted.
+	db, err = NewDatabase(dataDir)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	events, err := db.GetEvents("example.com", "@", ipversion.IP4)
+	require.NoError(t, err)
+	assert.Equal(t, []models.HistoryEvent{
+		{IP: netip.MustParseAddr("1.1.1.1"), Time: time1},
+	}, events)
+
+	events, err = db.GetEvents("example.com", "@", ipversion.IP4or6)
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+
+	events, err = db.GetEvents("other.com", "@", ipversion.IP4or6)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	paused, err := db.GetPaused("example.com", "@")
+	require.NoError(t, err)
+	assert.True(t, paused)
+	paused, err = db.GetPaused("other.com", "@")
+	require.NoError(t, err)
+	assert.False(t, paused)
+
+	entries, err := db.AuditEntries()
+	require.NoError(t, err)
+	assert.Equal(t, []models.AuditEntry{entry}, entries)
+}
+
+func Test_Database_Import(t *testing.T) {
+	t.Parallel()
+
+	db, err := NewDatabase(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	eventTime := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	records := []models.PersistedRecord{
+		{
+			Domain: "example.com",
+			Owner:  "@",
+			Events: []models.HistoryEvent{{IP: netip.MustParseAddr("1.1.1.1"), Time: eventTime}},
+		},
+		{Domain: "example.com", Owner: "www", Paused: true},
+	}
+	err = db.Import(records)
+	require.NoError(t, err)
+
+	empty, err := db.IsEmpty()
+	require.NoError(t, err)
+	assert.False(t, empty)
+
+	events, err := db.GetEvents("example.com", "@", ipversion.IP4or6)
+	require.NoError(t, err)
+	assert.Equal(t, records[0].Events, events)
+
+	paused, err := db.GetPaused("example.com", "www")
+	require.NoError(t, err)
+	assert.True(t, paused)
+}
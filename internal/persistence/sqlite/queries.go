@@ -0,0 +1,195 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/models"
+	"github.com/qdm12/ddns-updater/pkg/publicip/ipversion"
+)
+
+// StoreNewIP stores a new IP address for a certain domain and owner.
+func (db *Database) StoreNewIP(domain, owner string, ip netip.Addr, t time.Time) (err error) {
+	return db.transaction(func(tx *sql.Tx) error {
+		err := insertRecord(tx, domain, owner)
+		if err != nil {
+			return err
+		}
+		return insertEvent(tx, domain, owner, models.HistoryEvent{IP: ip, Time: t})
+	})
+}
+
+func insertRecord(tx *sql.Tx, domain, owner string) (err error) {
+	_, err = tx.Exec(`INSERT INTO records (domain, owner) VALUES (?, ?)
+		ON CONFLICT (domain, owner) DO NOTHING`, domain, owner)
+	if err != nil {
+		return fmt.Errorf("inserting record: %w", err)
+	}
+	return nil
+}
+
+func insertEvent(tx *sql.Tx, domain, owner string, event models.HistoryEvent) (err error) {
+	_, err = tx.Exec(`INSERT INTO events (domain, owner, ip, time) VALUES (?, ?, ?, ?)`,
+		domain, owner, event.IP.String(), event.Time.Format(time.RFC3339Nano))
+	if err != nil {
+		return fmt.Errorf("inserting event: %w", err)
+	}
+	return nil
+}
+
+// GetEvents gets all the IP addresses history for a certain domain, owner and
+// IP version, in the order from oldest to newest.
+func (db *Database) GetEvents(domain, owner string,
+	ipVersion ipversion.IPVersion) (events []models.HistoryEvent, err error) {
+	rows, err := db.db.Query(`SELECT ip, time FROM events
+		WHERE domain = ? AND owner = ? ORDER BY id`, domain, owner)
+	if err != nil {
+		return nil, fmt.Errorf("querying events: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ipString, timeString string
+		err = rows.Scan(&ipString, &timeString)
+		if err != nil {
+			return nil, fmt.Errorf("scanning event: %w", err)
+		}
+		event, err := parseEvent(ipString, timeString)
+		if err != nil {
+			return nil, fmt.Errorf("for domain %s and owner %s: %w", domain, owner, err)
+		}
+		switch {
+		case ipVersion == ipversion.IP4 && !event.IP.Is4(),
+			ipVersion == ipversion.IP6 && !event.IP.Is6():
+			continue
+		}
+		events = append(events, event)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("iterating events: %w", err)
+	}
+	return events, nil
+}
+
+func parseEvent(ipString, timeString string) (event models.HistoryEvent, err error) {
+	event.IP, err = netip.ParseAddr(ipString)
+	if err != nil {
+		return event, fmt.Errorf("parsing event IP address: %w", err)
+	}
+	event.Time, err = time.Parse(time.RFC3339Nano, timeString)
+	if err != nil {
+		return event, fmt.Errorf("parsing event time: %w", err)
+	}
+	return event, nil
+}
+
+// SetPaused stores whether the record for a certain domain
+// and owner is paused, so it stays paused across restarts.
+func (db *Database) SetPaused(domain, owner string, paused bool) (err error) {
+	return db.transaction(func(tx *sql.Tx) error {
+		err := insertRecord(tx, domain, owner)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`UPDATE records SET paused = ? WHERE domain = ? AND owner = ?`,
+			paused, domain, owner)
+		if err != nil {
+			return fmt.Errorf("updating paused state: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetPaused returns whether the record for a certain domain and owner is paused.
+func (db *Database) GetPaused(domain, owner string) (paused bool, err error) {
+	err = db.db.QueryRow(`SELECT paused FROM records WHERE domain = ? AND owner = ?`,
+		domain, owner).Scan(&paused)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("querying paused state: %w", err)
+	}
+	return paused, nil
+}
+
+// StoreAuditEntry stores a change of the records of an FQDN.
+func (db *Database) StoreAuditEntry(entry models.AuditEntry) (err error) {
+	_, err = db.db.Exec(`INSERT INTO audit (time, fqdn, before, after, error)
+		VALUES (?, ?, ?, ?, ?)`, entry.Time.Format(time.RFC3339Nano),
+		entry.FQDN, entry.Before, entry.After, entry.Error)
+	if err != nil {
+		return fmt.Errorf("inserting audit entry: %w", err)
+	}
+	return nil
+}
+
+// AuditEntries returns the changes stored, from the oldest to the newest.
+func (db *Database) AuditEntries() (entries []models.AuditEntry, err error) {
+	rows, err := db.db.Query(`SELECT time, fqdn, before, after, error
+		FROM audit ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("querying audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.AuditEntry
+		var timeString string
+		err = rows.Scan(&timeString, &entry.FQDN, &entry.Before, &entry.After, &entry.Error)
+		if err != nil {
+			return nil, fmt.Errorf("scanning audit entry: %w", err)
+		}
+		entry.Time, err = time.Parse(time.RFC3339Nano, timeString)
+		if err != nil {
+			return nil, fmt.Errorf("parsing audit entry time: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("iterating audit entries: %w", err)
+	}
+	return entries, nil
+}
+
+// IsEmpty returns true if the database has no record.
+func (db *Database) IsEmpty() (empty bool, err error) {
+	var count int
+	err = db.db.QueryRow(`SELECT COUNT(*) FROM records`).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("counting records: %w", err)
+	}
+	return count == 0, nil
+}
+
+// Import stores the records given in a single transaction,
+// for example to migrate them from the JSON file database.
+func (db *Database) Import(records []models.PersistedRecord) (err error) {
+	return db.transaction(func(tx *sql.Tx) error {
+		for _, record := range records {
+			err := insertRecord(tx, record.Domain, record.Owner)
+			if err != nil {
+				return err
+			}
+			if record.Paused {
+				_, err = tx.Exec(`UPDATE records SET paused = 1 WHERE domain = ? AND owner = ?`,
+					record.Domain, record.Owner)
+				if err != nil {
+					return fmt.Errorf("updating paused state: %w", err)
+				}
+			}
+			for _, event := range record.Events {
+				err = insertEvent(tx, record.Domain, record.Owner, event)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
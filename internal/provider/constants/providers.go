@@ -24,6 +24,7 @@ const (
 	DynV6        models.Provider = "dynv6"
 	EasyDNS      models.Provider = "easydns"
 	Example      models.Provider = "example"
+	External     models.Provider = "external"
 	FreeDNS      models.Provider = "freedns"
 	Gandi        models.Provider = "gandi"
 	GCP          models.Provider = "gcp"
@@ -74,6 +75,7 @@ func ProviderChoices() []models.Provider {
 		DynV6,
 		EasyDNS,
 		Example,
+		External,
 		FreeDNS,
 		Gandi,
 		GCP,
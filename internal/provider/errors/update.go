@@ -10,6 +10,7 @@ var (
 	ErrBannedUserAgent           = errors.New("user agent is banned")
 	ErrConflictingRecord         = errors.New("conflicting record")
 	ErrDNSServerSide             = errors.New("server side DNS error")
+	ErrDryRun                    = errors.New("dry run")
 	ErrDomainDisabled            = errors.New("record disabled")
 	ErrDomainIDNotFound          = errors.New("ID not found in domain record")
 	ErrDomainNotFound            = errors.New("domain not found")
@@ -20,15 +21,22 @@ var (
 	ErrIPReceivedMismatch        = errors.New("mismatching IP address received")
 	ErrIPSentMalformed           = errors.New("malformed IP address sent")
 	ErrNoService                 = errors.New("no service")
+	ErrNotDelegated              = errors.New("domain is not delegated to the provider nameservers")
+	ErrNotPropagated             = errors.New("record not propagated")
 	ErrPrivateIPSent             = errors.New("private IP cannot be routed")
+	ErrRateLimit                 = errors.New("rate limit exceeded")
 	ErrReceivedNoIP              = errors.New("received no IP address in response")
 	ErrReceivedNoResult          = errors.New("received no result in response")
 	ErrRecordNotEditable         = errors.New("record is not editable")
 	ErrRecordNotFound            = errors.New("record not found")
 	ErrRecordResourceSetNotFound = errors.New("record resource set not found")
+	ErrRecordsMismatch           = errors.New("records mismatch")
+	ErrRollbackFailed            = errors.New("rollback failed")
+	ErrRestoreDeclined           = errors.New("restore declined")
 	ErrResponseTooShort          = errors.New("response is too short")
 	ErrResultsCountReceived      = errors.New("wrong number of results received")
 	ErrSessionIsEmpty            = errors.New("session received is empty")
+	ErrSuspended                 = errors.New("provider calls suspended")
 	ErrSystemParamNotValid       = errors.New("system parameter is not valid")
 	ErrUnknownResponse           = errors.New("unknown response received")
 	ErrUnsuccessful              = errors.New("unsuccessful result")
@@ -9,13 +9,19 @@ var (
 	ErrAPIKeyNotSet           = errors.New("API key is not set")
 	ErrAPISecretNotSet        = errors.New("API secret is not set")
 	ErrAppKeyNotSet           = errors.New("app key is not set")
+	ErrAuthModeNotValid       = errors.New("auth mode is not valid")
+	ErrCAFileNotValid         = errors.New("CA file is not valid")
+	ErrCommandNotSet          = errors.New("command is not set")
 	ErrConsumerKeyNotSet      = errors.New("consumer key is not set")
 	ErrCredentialsNotSet      = errors.New("credentials are not set")
 	ErrCredentialsNotValid    = errors.New("credentials are not valid")
 	ErrCustomerNumberNotSet   = errors.New("customer number is not set")
+	ErrDelayNotValid          = errors.New("delay is not valid")
 	ErrDomainNotSet           = errors.New("domain is not set")
+	ErrDualStackIPVersion     = errors.New("IP version is not valid for dual stack")
 	ErrEmailNotSet            = errors.New("email is not set")
 	ErrEmailNotValid          = errors.New("email address is not valid")
+	ErrFormatNotValid         = errors.New("format is not valid")
 	ErrGCPProjectNotSet       = errors.New("GCP project is not set")
 	ErrDomainNotValid         = errors.New("domain is not valid")
 	ErrOwnerNotSet            = errors.New("owner is not set")
@@ -27,15 +33,24 @@ var (
 	ErrNameNotSet             = errors.New("name is not set")
 	ErrPasswordNotSet         = errors.New("password is not set")
 	ErrPasswordNotValid       = errors.New("password is not valid")
+	ErrPortNotValid           = errors.New("port is not valid")
+	ErrPriorityNotValid       = errors.New("priority is not valid")
+	ErrRecordModeNotValid     = errors.New("record mode is not valid")
+	ErrRecordTypeNotValid     = errors.New("record type is not valid")
 	ErrSecretKeyNotSet        = errors.New("secret key is not set")
 	ErrSecretNotSet           = errors.New("secret is not set")
 	ErrSuccessRegexNotSet     = errors.New("success regex is not set")
+	ErrTimeoutNotValid        = errors.New("timeout is not valid")
+	ErrTLSVersionNotValid     = errors.New("TLS version is not valid")
 	ErrTokenNotSet            = errors.New("token is not set")
 	ErrTokenNotValid          = errors.New("token is not valid")
 	ErrTTLNotSet              = errors.New("TTL is not set")
 	ErrTTLTooLow              = errors.New("TTL is too low")
+	ErrTTLTooHigh             = errors.New("TTL is too high")
 	ErrURLNotHTTPS            = errors.New("url is not https")
 	ErrURLNotSet              = errors.New("url is not set")
+	ErrURLNotValid            = errors.New("url is not valid")
+	ErrUserAgentNotValid      = errors.New("user agent is not valid")
 	ErrUsernameNotSet         = errors.New("username is not set")
 	ErrUsernameNotValid       = errors.New("username is not valid")
 	ErrUserServiceKeyNotValid = errors.New("user service key is not valid")
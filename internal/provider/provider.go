@@ -30,6 +30,7 @@ import (
 	"github.com/qdm12/ddns-updater/internal/provider/providers/dynv6"
 	"github.com/qdm12/ddns-updater/internal/provider/providers/easydns"
 	"github.com/qdm12/ddns-updater/internal/provider/providers/example"
+	"github.com/qdm12/ddns-updater/internal/provider/providers/external"
 	"github.com/qdm12/ddns-updater/internal/provider/providers/freedns"
 	"github.com/qdm12/ddns-updater/internal/provider/providers/gandi"
 	"github.com/qdm12/ddns-updater/internal/provider/providers/gcp"
@@ -73,6 +74,65 @@ type Provider interface {
 	Update(ctx context.Context, client *http.Client, ip netip.Addr) (newIP netip.Addr, err error)
 }
 
+// DualStackProvider is implemented by providers able to update
+// both their A and AAAA records within a single operation.
+type DualStackProvider interface {
+	DualStack() bool
+	UpdateDualStack(ctx context.Context, client *http.Client, ipv4, ipv6 netip.Addr) (
+		newIPv4, newIPv6 netip.Addr, err error)
+}
+
+// Checker is implemented by providers able to verify their settings
+// against their API, such as credentials and domain ownership,
+// without changing any record.
+type Checker interface {
+	Check(ctx context.Context, client *http.Client) (err error)
+}
+
+// ZoneExporter is implemented by providers able to export the
+// record set of an FQDN of their zone, in the format given.
+type ZoneExporter interface {
+	ExportZone(ctx context.Context, client *http.Client,
+		fqdn, format string) (data []byte, err error)
+}
+
+// ZoneRestorer is implemented by providers able to write back a record
+// set exported by ZoneExporter in the JSON format, after confirm returns
+// true for the differences with the current record set of its FQDN.
+type ZoneRestorer interface {
+	RestoreZone(ctx context.Context, client *http.Client, data []byte,
+		confirm func(fqdn, diff string) bool) (err error)
+}
+
+// ConcurrencyScoper is implemented by providers giving the API host and
+// the account of their updates, to limit the concurrent updates per host
+// and to serialize the updates of the same account.
+type ConcurrencyScoper interface {
+	ConcurrencyScope() (host, account string)
+}
+
+// PreviousIPsSetter is implemented by providers needing the IP addresses
+// they set before the program started, given as the last IP address of
+// each IP family of the record history.
+type PreviousIPsSetter interface {
+	SetPreviousIPs(ips []netip.Addr)
+}
+
+// Releaser is implemented by providers sharing resources with other
+// providers, such as rate limiters, to release once they are replaced,
+// for example after a config reload.
+type Releaser interface {
+	Release()
+}
+
+// LookupSkipper is implemented by providers whose domain name cannot be
+// resolved to determine if an update is needed, such as a wildcard
+// standing for several domains, so that the last IP address of their
+// history is used instead of a DNS lookup.
+type LookupSkipper interface {
+	SkipLookup() bool
+}
+
 var ErrProviderUnknown = errors.New("unknown provider")
 
 //nolint:gocyclo
@@ -84,7 +144,7 @@ func New(providerName models.Provider, data json.RawMessage, domain, owner strin
 	case constants.AllInkl:
 		return allinkl.New(data, domain, owner, ipVersion, ipv6Suffix)
 	case constants.Beget:
-		return beget.New(data, domain, owner)
+		return beget.New(data, domain, owner, ipVersion, ipv6Suffix)
 	case constants.Changeip:
 		return changeip.New(data, domain, owner, ipVersion, ipv6Suffix)
 	case constants.Cloudflare:
@@ -119,6 +179,8 @@ func New(providerName models.Provider, data json.RawMessage, domain, owner strin
 		return easydns.New(data, domain, owner, ipVersion, ipv6Suffix)
 	case constants.Example:
 		return example.New(data, domain, owner, ipVersion, ipv6Suffix)
+	case constants.External:
+		return external.New(data, domain, owner, ipVersion, ipv6Suffix)
 	case constants.FreeDNS:
 		return freedns.New(data, domain, owner, ipVersion, ipv6Suffix)
 	case constants.Gandi:
@@ -0,0 +1,31 @@
+package beget
+
+import "sync"
+
+// accountLocks holds a mutex for each Beget account, shared by all the
+// providers using the same account, so that their read-modify-write
+// transactions never interleave and overwrite each other with stale data.
+type accountLocks struct {
+	mutex sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func newAccountLocks() *accountLocks {
+	return &accountLocks{
+		locks: make(map[string]*sync.Mutex),
+	}
+}
+
+var defaultAccountLocks = newAccountLocks() //nolint:gochecknoglobals
+
+// get returns the mutex of the account identified by accountKey.
+func (a *accountLocks) get(accountKey string) *sync.Mutex {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	lock, ok := a.locks[accountKey]
+	if !ok {
+		lock = new(sync.Mutex)
+		a.locks[accountKey] = lock
+	}
+	return lock
+}
@@ -0,0 +1,101 @@
+package beget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+)
+
+const txtRecordType = "TXT"
+
+// txtEntry is a single entry of a TXT record. The getData method
+// returns the text as "txtdata" whereas changeRecords expects "value".
+type txtEntry struct {
+	Priority int    `json:"priority"`
+	Value    string `json:"value,omitempty"`
+	TXTData  string `json:"txtdata,omitempty"`
+}
+
+func (e txtEntry) text() string {
+	if e.Value != "" {
+		return e.Value
+	}
+	return e.TXTData
+}
+
+// PresentTXT adds a TXT entry with the given value to the record set
+// of fqdn, typically an _acme-challenge subdomain of the provider domain.
+// Other TXT entries and other records of fqdn are kept as they are.
+func (p *Provider) PresentTXT(ctx context.Context, client *http.Client,
+	fqdn, value string) (err error) {
+	return p.modifyTXT(ctx, client, fqdn, func(entries []txtEntry) []txtEntry {
+		for _, entry := range entries {
+			if entry.text() == value {
+				return entries
+			}
+		}
+		return append(entries, txtEntry{Priority: p.priorityOf(fqdn), Value: value})
+	})
+}
+
+// CleanupTXT removes the TXT entry with the given value from
+// the record set of fqdn.
+func (p *Provider) CleanupTXT(ctx context.Context, client *http.Client,
+	fqdn, value string) (err error) {
+	return p.modifyTXT(ctx, client, fqdn, func(entries []txtEntry) []txtEntry {
+		kept := make([]txtEntry, 0, len(entries))
+		for _, entry := range entries {
+			if entry.text() != value {
+				kept = append(kept, entry)
+			}
+		}
+		return kept
+	})
+}
+
+func (p *Provider) modifyTXT(ctx context.Context, client *http.Client, fqdn string,
+	modify func(entries []txtEntry) []txtEntry) (err error) {
+	fqdn, err = p.withinDomain(fqdn)
+	if err != nil {
+		return err
+	}
+
+	client = p.http.wrapClient(client)
+	return p.applyChange(ctx, client, fqdn, func(records map[string]json.RawMessage) (
+		changed bool, err error) {
+		var entries []txtEntry
+		if raw, ok := records[txtRecordType]; ok {
+			err = json.Unmarshal(raw, &entries)
+			if err != nil {
+				return false, fmt.Errorf("Failed unmarshalling current TXT entries: %w", err)
+			}
+		}
+
+		entries = modify(entries)
+		for i, entry := range entries {
+			entries[i] = txtEntry{Priority: entry.Priority, Value: entry.text()}
+		}
+		newEntriesJSON, err := json.Marshal(entries)
+		if err != nil {
+			return false, fmt.Errorf("Couldn't marshal new TXT entries JSON: %w", err)
+		}
+		records[txtRecordType] = json.RawMessage(newEntriesJSON)
+		return true, nil
+	})
+}
+
+// withinDomain returns fqdn in ASCII without its trailing dot, or an
+// error if it is not the provider domain or one of its subdomains.
+func (p *Provider) withinDomain(fqdn string) (asciiFQDN string, err error) {
+	asciiFQDN = toASCII(strings.TrimSuffix(fqdn, "."))
+	domain := toASCII(p.domain)
+	if asciiFQDN != domain && !strings.HasSuffix(asciiFQDN, "."+domain) {
+		return "", fmt.Errorf("%w: %s is not within %s",
+			errors.ErrDomainNotValid, fqdn, p.domain)
+	}
+	return asciiFQDN, nil
+}
@@ -0,0 +1,70 @@
+package beget
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+	"github.com/qdm12/ddns-updater/internal/provider/providers/beget/begettest"
+	"github.com/qdm12/ddns-updater/pkg/publicip/ipversion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Provider_PresentTXT_CleanupTXT(t *testing.T) {
+	t.Parallel()
+
+	const fqdn = "_acme-challenge.example.com"
+	server := begettest.New(begettest.Settings{
+		Domains:    []string{"example.com"},
+		Subdomains: []string{fqdn},
+	})
+	server.SetRecords(fqdn, map[string]json.RawMessage{
+		"A":   json.RawMessage(`[{"address":"1.1.1.1","priority":10}]`),
+		"TXT": json.RawMessage(`[{"priority":10,"txtdata":"v=spf1 -all"}]`),
+	})
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	settings, err := json.Marshal(map[string]any{
+		"login":            "login",
+		"password":         "password",
+		"api_url":          httpServer.URL,
+		"retry":            map[string]any{"max_attempts": 1},
+		"rate_limit":       0,
+		"check_delegation": false,
+	})
+	require.NoError(t, err)
+	provider, err := New(settings, "example.com", "@", ipversion.IP4, netip.Prefix{})
+	require.NoError(t, err)
+	ctx := context.Background()
+	client := httpServer.Client()
+
+	err = provider.PresentTXT(ctx, client, fqdn+".", "token")
+	require.NoError(t, err)
+	records := server.Records(fqdn)
+	assert.JSONEq(t, `[{"priority":10,"value":"v=spf1 -all"},{"priority":10,"value":"token"}]`,
+		string(records["TXT"]))
+	assert.JSONEq(t, `[{"address":"1.1.1.1","priority":10}]`, string(records["A"]))
+
+	// Presenting the same value again does not add a second entry.
+	err = provider.PresentTXT(ctx, client, fqdn, "token")
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"priority":10,"value":"v=spf1 -all"},{"priority":10,"value":"token"}]`,
+		string(server.Records(fqdn)["TXT"]))
+
+	err = provider.CleanupTXT(ctx, client, fqdn, "token")
+	require.NoError(t, err)
+	records = server.Records(fqdn)
+	assert.JSONEq(t, `[{"priority":10,"value":"v=spf1 -all"}]`, string(records["TXT"]))
+	assert.JSONEq(t, `[{"address":"1.1.1.1","priority":10}]`, string(records["A"]))
+
+	changes := server.Calls("dns/changeRecords")
+	err = provider.PresentTXT(ctx, client, "_acme-challenge.example.org", "token")
+	assert.ErrorIs(t, err, errors.ErrDomainNotValid)
+	assert.EqualError(t, err, "domain is not valid: _acme-challenge.example.org is not within example.com")
+	assert.Equal(t, changes, server.Calls("dns/changeRecords"))
+}
@@ -0,0 +1,250 @@
+package beget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+	"github.com/qdm12/ddns-updater/internal/provider/headers"
+	"github.com/qdm12/ddns-updater/internal/tracing"
+)
+
+const defaultAPIURL = "https://api.beget.com"
+
+// parseAPIURL parses the API URL setting, which can point to a proxy
+// or to a mock server, and therefore may use plain HTTP.
+func parseAPIURL(apiURL string) (u *url.URL, err error) {
+	if apiURL == "" {
+		apiURL = defaultAPIURL
+	}
+	u, err = url.Parse(apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errors.ErrURLNotValid, err)
+	}
+	switch {
+	case u.Scheme != "https" && u.Scheme != "http":
+		return nil, fmt.Errorf("%w: scheme %q must be https or http",
+			errors.ErrURLNotValid, u.Scheme)
+	case u.Host == "":
+		return nil, fmt.Errorf("%w: host is not set in %q", errors.ErrURLNotValid, apiURL)
+	}
+	return u, nil
+}
+
+// begetAPI is the subset of the Beget API used by the provider.
+type begetAPI interface {
+	GetData(ctx context.Context, client *http.Client, fqdn string) (
+		records map[string]json.RawMessage, err error)
+	ChangeRecords(ctx context.Context, client *http.Client, fqdn string,
+		records map[string]json.RawMessage) (err error)
+	ListDomains(ctx context.Context, client *http.Client) (domains []domainEntry, err error)
+	ListSubdomains(ctx context.Context, client *http.Client) (subdomains []domainEntry, err error)
+	AddSubdomain(ctx context.Context, client *http.Client, subdomain string, domainID int64) (err error)
+}
+
+// apiClient is the default begetAPI implementation,
+// calling the Beget API over HTTP.
+type apiClient struct {
+	login    string
+	password string
+	// token is the API token sent as a bearer token instead of the
+	// password, and is empty in the password authentication mode.
+	token  string
+	apiURL *url.URL
+	retry  retryPolicy
+	// limiter is shared by the clients of the same account,
+	// and is nil if rate limiting is disabled.
+	limiter *rateLimiter
+	// circuit is shared by the clients of the same account,
+	// and is nil if the circuit breaker is disabled.
+	circuit *circuitBreaker
+	metrics *apiMetrics
+	status  *apiStatus
+	// logger is nil unless debug logging is enabled.
+	logger debugLogger
+}
+
+func newAPIClient(login, password, token string, apiURL *url.URL, retry retryPolicy,
+	limiter *rateLimiter, circuit *circuitBreaker) *apiClient {
+	return &apiClient{
+		login:    login,
+		password: password,
+		token:    token,
+		apiURL:   apiURL,
+		retry:    retry,
+		limiter:  limiter,
+		circuit:  circuit,
+		metrics:  defaultAPIMetrics,
+		status:   &apiStatus{timeNow: time.Now},
+	}
+}
+
+// callOption modifies the way apiCall calls an endpoint.
+type callOption func(settings *callSettings)
+
+type callSettings struct {
+	noRetry bool
+}
+
+// withoutRetry makes apiCall call the endpoint once, for
+// the endpoints which are not idempotent.
+func withoutRetry() callOption {
+	return func(settings *callSettings) {
+		settings.noRetry = true
+	}
+}
+
+// apiCall performs authenticated POST request to the given URLEndpoint of the API URL
+// (https://api.beget.com by default) with given inputJSON as input_data and returns
+// resulting json as []byte.
+// Credentials and input_data are sent as a form encoded body, so that they
+// never appear in the request URL, and therefore in proxy logs or errors.
+// In the token authentication mode, the token is sent as a bearer token in
+// the Authorization header instead of the password.
+// Transient failures are retried according to the provider retry policy,
+// which is only safe for idempotent endpoints such as getData and
+// changeRecords: the calls to the other endpoints, such as
+// addSubdomainVirtual, must be given the withoutRetry option, since a
+// call whose response is lost may have been applied.
+// Each attempt waits for the account rate limiter, if enabled.
+// No call is made while the account circuit breaker is open, and
+// the outcome of the call is recorded in the breaker, if enabled.
+// Errors returned have the credentials redacted from their message, in case
+// a transport error echoes the request.
+// The call, with all its attempts, is traced as a span, if tracing is enabled.
+func (c *apiClient) apiCall(ctx context.Context, client *http.Client, URLEndpoint string,
+	inputJSON []byte, options ...callOption) (b []byte, err error) {
+	var settings callSettings
+	for _, option := range options {
+		option(&settings)
+	}
+	endpoint := endpointLabel(URLEndpoint)
+	ctx, span := tracing.Start(ctx, "beget "+endpoint, tracing.KindClient,
+		tracing.String("beget.endpoint", endpoint))
+	attempts := 0
+	defer func() {
+		span.SetAttributes(tracing.Int("beget.attempts", attempts))
+		span.End(err)
+	}()
+
+	if c.circuit != nil {
+		err = c.circuit.allow()
+		if err != nil {
+			return []byte{}, err
+		}
+		defer func() {
+			c.circuit.record(b, err)
+		}()
+	}
+
+	for attempt := uint(1); ; attempt++ {
+		attempts++
+		if c.limiter != nil {
+			err := c.limiter.wait(ctx)
+			if err != nil {
+				return []byte{}, err
+			}
+		}
+
+		b, retryable, err := c.apiCallOnce(ctx, client, URLEndpoint, inputJSON)
+		if err == nil || !retryable || settings.noRetry || attempt >= c.retry.maxAttempts {
+			return b, c.redactError(err)
+		}
+
+		timer := time.NewTimer(c.retry.delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return b, c.redactError(fmt.Errorf("%w (after attempt %d failed: %w)", ctx.Err(), attempt, err))
+		case <-timer.C:
+		}
+	}
+}
+
+// apiCallOnce performs a single API call, and returns whether
+// the error returned, if any, is worth retrying.
+// The call is recorded in the API metrics, if set, and logged with
+// a request ID, also sent as the X-Request-Id header, if debug is enabled.
+func (c *apiClient) apiCallOnce(ctx context.Context, client *http.Client, URLEndpoint string,
+	inputJSON []byte) (b []byte, retryable bool, err error) {
+	var requestID string
+	if c.logger != nil {
+		requestID = newRequestID()
+		c.logRequest(requestID, URLEndpoint, inputJSON)
+	}
+
+	var statusCode int
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start)
+		if c.metrics != nil {
+			c.metrics.observe(URLEndpoint, statusCode, b, duration)
+		}
+		if c.logger != nil {
+			c.logResponse(requestID, URLEndpoint, statusCode, b, duration, err)
+		}
+	}()
+
+	u := *c.apiURL
+	u.Path = path.Join("/", u.Path, URLEndpoint)
+
+	v := url.Values{}
+	if c.token == "" {
+		v.Set("login", c.login)
+		v.Set("passwd", c.password)
+	} else if c.login != "" {
+		v.Set("login", c.login)
+	}
+	v.Set("input_format", "json")
+	v.Set("output_format", "json")
+	v.Set("input_data", string(inputJSON))
+	body := strings.NewReader(v.Encode())
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), body)
+	if err != nil {
+		return []byte{}, false, fmt.Errorf("%s: Failed creating HTTP request: %w", u.Path, err)
+	}
+	headers.SetUserAgent(request)
+	headers.SetContentType(request, "application/x-www-form-urlencoded")
+	headers.SetAccept(request, "application/json")
+	if c.token != "" {
+		request.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if requestID != "" {
+		request.Header.Set("X-Request-Id", requestID)
+	}
+	tracing.Inject(ctx, request.Header)
+
+	response, err := client.Do(request)
+	if err != nil {
+		retryable = ctx.Err() == nil
+		return []byte{}, retryable, fmt.Errorf("%s: Failed performing HTTP request: %w", u.Path, err)
+	}
+	defer response.Body.Close()
+	statusCode = response.StatusCode
+
+	b, err = io.ReadAll(response.Body)
+	if err != nil {
+		return []byte{}, true, fmt.Errorf("%s: Failed reading response body: %w", u.Path, err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		retryable = response.StatusCode >= http.StatusInternalServerError ||
+			response.StatusCode == http.StatusTooManyRequests
+		return b, retryable, fmt.Errorf("%s: %w: %d: %s", u.Path, errors.ErrHTTPStatusNotValid,
+			response.StatusCode, trimErrorText(string(b)))
+	}
+
+	if isLimitError(b) {
+		return b, true, fmt.Errorf("%s: %w", u.Path, errors.ErrRateLimit)
+	}
+
+	return b, false, nil
+}
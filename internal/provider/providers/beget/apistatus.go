@@ -0,0 +1,50 @@
+package beget
+
+import (
+	"sync"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/models"
+)
+
+// apiStatus holds the outcome of the last getData or changeRecords call.
+type apiStatus struct {
+	mutex   sync.Mutex
+	last    models.APIStatus
+	timeNow func() time.Time
+}
+
+// record records the outcome of a call to the endpoint, given its
+// response body, if any, and the error it failed with, if any.
+func (s *apiStatus) record(endpoint string, body []byte, err error) {
+	status := models.APIStatus{
+		Time:     s.timeNow(),
+		Endpoint: endpoint,
+	}
+	if err != nil {
+		status.Error = err.Error()
+		if codes := errorCodes(body); len(codes) > 0 {
+			status.ErrorCode = codes[0]
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.last = status
+}
+
+func (s *apiStatus) get() models.APIStatus {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.last
+}
+
+// LastAPIStatus returns the outcome of the last getData or changeRecords
+// call made by the provider, with a zero time if no call was made yet.
+func (p *Provider) LastAPIStatus() models.APIStatus {
+	client, ok := p.api.(*apiClient)
+	if !ok {
+		return models.APIStatus{}
+	}
+	return client.status.get()
+}
@@ -0,0 +1,170 @@
+package beget
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/models"
+)
+
+// auditRecord is a changeRecords call, appended as a JSON line to the audit file.
+type auditRecord struct {
+	Time   time.Time                  `json:"time"`
+	FQDN   string                     `json:"fqdn"`
+	Before map[string]json.RawMessage `json:"before"`
+	After  map[string]json.RawMessage `json:"after"`
+	Error  string                     `json:"error,omitempty"`
+}
+
+// auditFileMutex serializes the writes to the audit files, which
+// can be shared by several config entries.
+var auditFileMutex sync.Mutex //nolint:gochecknoglobals
+
+// AuditStore stores the changeRecords calls of all the
+// config entries, such as the SQLite database.
+type AuditStore interface {
+	StoreAuditEntry(entry models.AuditEntry) (err error)
+	AuditEntries() (entries []models.AuditEntry, err error)
+}
+
+// auditStore is the audit store set with SetAuditStore, or nil.
+var auditStore AuditStore //nolint:gochecknoglobals
+
+// SetAuditStore sets the store of the changeRecords calls of all the config
+// entries, in addition to their audit file, and from which the changes are
+// read instead of the audit files. It must be called before any update.
+func SetAuditStore(store AuditStore) {
+	auditStore = store
+}
+
+// changeRecords writes the records of fqdn with changeRecords, replacing the
+// before record set, and appends the call and its outcome to the audit store
+// and to the audit file, if set. It returns the error of the audit write
+// separately, since the records are written even if it fails.
+func (p *Provider) changeRecords(ctx context.Context, client *http.Client, fqdn string,
+	before, after map[string]json.RawMessage) (auditErr, err error) {
+	err = p.api.ChangeRecords(ctx, client, fqdn, after)
+	if auditStore == nil && p.auditFile == "" {
+		return nil, err
+	}
+
+	record := auditRecord{
+		Time:   time.Now(),
+		FQDN:   fqdn,
+		Before: before,
+		After:  after,
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if auditStore != nil {
+		storeErr := auditStore.StoreAuditEntry(record.toEntry())
+		if storeErr != nil {
+			auditErr = fmt.Errorf("storing audit entry: %w", storeErr)
+		}
+	}
+	if p.auditFile != "" {
+		fileErr := appendAuditRecord(p.auditFile, record)
+		if fileErr != nil {
+			auditErr = stderrors.Join(auditErr, fmt.Errorf("writing audit log: %w", fileErr))
+		}
+	}
+	return auditErr, err
+}
+
+func (r auditRecord) toEntry() models.AuditEntry {
+	return models.AuditEntry{
+		Time:   r.Time,
+		FQDN:   r.FQDN,
+		Before: recordsJSON(r.Before),
+		After:  recordsJSON(r.After),
+		Error:  r.Error,
+	}
+}
+
+func appendAuditRecord(path string, record auditRecord) (err error) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("encoding audit record: %w", err)
+	}
+	line = append(line, '\n')
+
+	auditFileMutex.Lock()
+	defer auditFileMutex.Unlock()
+
+	const dirPerms, filePerms = 0o700, 0o600
+	err = os.MkdirAll(filepath.Dir(path), dirPerms)
+	if err != nil {
+		return fmt.Errorf("creating audit directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, filePerms)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(line)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// AuditEntries returns the changes recorded in the audit store for the
+// domain, or else in the audit file, from the oldest to the newest, or no
+// entry if none of them is set.
+func (p *Provider) AuditEntries() (entries []models.AuditEntry, err error) {
+	if auditStore != nil {
+		storeEntries, err := auditStore.AuditEntries()
+		if err != nil {
+			return nil, fmt.Errorf("reading audit store: %w", err)
+		}
+		for _, entry := range storeEntries {
+			if _, err := p.withinDomain(entry.FQDN); err == nil {
+				entries = append(entries, entry)
+			}
+		}
+		return entries, nil
+	}
+
+	if p.auditFile == "" {
+		return nil, nil
+	}
+
+	auditFileMutex.Lock()
+	data, err := os.ReadFile(p.auditFile)
+	auditFileMutex.Unlock()
+	if stderrors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading audit log: %w", err)
+	}
+
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var record auditRecord
+		err = json.Unmarshal(line, &record)
+		if err != nil {
+			return nil, fmt.Errorf("decoding audit log line %d: %w", i+1, err)
+		}
+		entries = append(entries, record.toEntry())
+	}
+	return entries, nil
+}
+
+func recordsJSON(records map[string]json.RawMessage) string {
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
@@ -0,0 +1,63 @@
+package beget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"path/filepath"
+	"testing"
+
+	"github.com/qdm12/ddns-updater/pkg/publicip/ipversion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Provider_AuditEntries(t *testing.T) {
+	t.Parallel()
+
+	errTimeout := fmt.Errorf("dns/changeRecords: %w", context.DeadlineExceeded)
+	api := &fakeAPI{
+		records: map[string]json.RawMessage{
+			"A": json.RawMessage(`[{"address":"1.1.1.1"}]`),
+		},
+		changeErrs: []error{nil, errTimeout},
+	}
+	auditFile := filepath.Join(t.TempDir(), "audit", "beget.jsonl")
+	settings, err := json.Marshal(map[string]any{
+		"login":      "login",
+		"password":   "password",
+		"audit_file": auditFile,
+		"verify":     false,
+	})
+	require.NoError(t, err)
+	provider, err := New(settings, "example.com", "@", ipversion.IP4, netip.Prefix{}, withAPI(api))
+	require.NoError(t, err)
+
+	entries, err := provider.AuditEntries()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	_, err = provider.Update(context.Background(), nil, netip.MustParseAddr("2.2.2.2"))
+	require.NoError(t, err)
+	_, err = provider.Update(context.Background(), nil, netip.MustParseAddr("3.3.3.3"))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	entries, err = provider.AuditEntries()
+	require.NoError(t, err)
+	// The update failing without an answer from Beget is rolled
+	// back with a second changeRecords call.
+	require.Len(t, entries, 3)
+
+	assert.Equal(t, "example.com", entries[0].FQDN)
+	assert.JSONEq(t, `{"A":[{"address":"1.1.1.1"}]}`, entries[0].Before)
+	assert.JSONEq(t, `{"A":[{"priority":10,"value":"2.2.2.2"}]}`, entries[0].After)
+	assert.Empty(t, entries[0].Error)
+
+	assert.JSONEq(t, `{"A":[{"priority":10,"value":"3.3.3.3"}]}`, entries[1].After)
+	assert.Equal(t, "dns/changeRecords: context deadline exceeded", entries[1].Error)
+
+	assert.Equal(t, entries[1].After, entries[2].Before)
+	assert.Equal(t, entries[1].Before, entries[2].After)
+	assert.Empty(t, entries[2].Error)
+}
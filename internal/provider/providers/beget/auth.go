@@ -0,0 +1,66 @@
+package beget
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+)
+
+const (
+	authPassword = "password"
+	authToken    = "token"
+)
+
+// validateAuth validates the credentials resolved for the authentication
+// mode given. The password mode sends the login and password of the
+// account, whereas the token mode sends an API token of the account as a
+// bearer token, optionally along with the login, so that the account
+// password is not needed.
+func validateAuth(mode, login, password, token string) (err error) {
+	switch mode {
+	case authPassword:
+		switch {
+		case login == "":
+			return fmt.Errorf("%w: for auth %q", errors.ErrUsernameNotSet, mode)
+		case password == "":
+			return fmt.Errorf("%w: for auth %q", errors.ErrPasswordNotSet, mode)
+		case token != "":
+			return fmt.Errorf("%w: token is set but auth is %q", errors.ErrCredentialsNotValid, mode)
+		}
+	case authToken:
+		switch {
+		case token == "":
+			return fmt.Errorf("%w: for auth %q", errors.ErrTokenNotSet, mode)
+		case password != "":
+			return fmt.Errorf("%w: password is set but auth is %q", errors.ErrCredentialsNotValid, mode)
+		}
+	default:
+		return fmt.Errorf("%w: %q must be one of %q or %q",
+			errors.ErrAuthModeNotValid, mode, authPassword, authToken)
+	}
+	return nil
+}
+
+// accountIdentity returns the login identifying the Beget account,
+// or a fingerprint of the token if the login is not set, so that the
+// token never appears in the account keys.
+func accountIdentity(login, token string) string {
+	if login != "" || token == "" {
+		return login
+	}
+	return "token:" + fingerprint(token)
+}
+
+// credentialsFingerprint returns a fingerprint of the password and token,
+// which changes with them without revealing them.
+func credentialsFingerprint(password, token string) string {
+	return fingerprint(password + "\x00" + token)
+}
+
+func fingerprint(secret string) string {
+	const fingerprintLength = 8
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:fingerprintLength])
+}
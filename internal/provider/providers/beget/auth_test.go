@@ -0,0 +1,99 @@
+package beget
+
+import (
+	"testing"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_validateAuth(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		mode       string
+		login      string
+		password   string
+		token      string
+		errWrapped error
+		errMessage string
+	}{
+		"password": {
+			mode:     authPassword,
+			login:    "login",
+			password: "password",
+		},
+		"login_not_set": {
+			mode:       authPassword,
+			password:   "password",
+			errWrapped: errors.ErrUsernameNotSet,
+			errMessage: `username is not set: for auth "password"`,
+		},
+		"password_not_set": {
+			mode:       authPassword,
+			login:      "login",
+			errWrapped: errors.ErrPasswordNotSet,
+			errMessage: `password is not set: for auth "password"`,
+		},
+		"password_with_token": {
+			mode:       authPassword,
+			login:      "login",
+			password:   "password",
+			token:      "token",
+			errWrapped: errors.ErrCredentialsNotValid,
+			errMessage: `credentials are not valid: token is set but auth is "password"`,
+		},
+		"token": {
+			mode:  authToken,
+			token: "token",
+		},
+		"token_with_login": {
+			mode:  authToken,
+			login: "login",
+			token: "token",
+		},
+		"token_not_set": {
+			mode:       authToken,
+			login:      "login",
+			errWrapped: errors.ErrTokenNotSet,
+			errMessage: `token is not set: for auth "token"`,
+		},
+		"token_with_password": {
+			mode:       authToken,
+			password:   "password",
+			token:      "token",
+			errWrapped: errors.ErrCredentialsNotValid,
+			errMessage: `credentials are not valid: password is set but auth is "token"`,
+		},
+		"mode_not_valid": {
+			mode:       "oauth",
+			errWrapped: errors.ErrAuthModeNotValid,
+			errMessage: `auth mode is not valid: "oauth" must be one of "password" or "token"`,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateAuth(testCase.mode, testCase.login, testCase.password, testCase.token)
+
+			require.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+		})
+	}
+}
+
+func Test_accountIdentity(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "login", accountIdentity("login", ""))
+	assert.Equal(t, "login", accountIdentity("login", "token"))
+	identity := accountIdentity("", "token")
+	assert.Equal(t, "token:3c469e9d6c5875d3", identity)
+	assert.NotEqual(t, identity, accountIdentity("", "other"))
+}
@@ -0,0 +1,271 @@
+package beget
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+)
+
+// recordChange modifies the record set of an FQDN in place,
+// and returns true if it changed it.
+type recordChange func(records map[string]json.RawMessage) (changed bool, err error)
+
+// batcher coalesces the record changes of the same FQDN into a single
+// read-modify-write transaction, that is a single getData and changeRecords
+// pair. Changes submitted while a transaction is running for their FQDN are
+// queued, and all applied together by the next transactions, one for each
+// set of write settings of the providers submitting them.
+type batcher struct {
+	mutex   sync.Mutex
+	batches map[string]*batch
+}
+
+type batch struct {
+	pending []pendingChange
+}
+
+// pendingChange is a change submitted to the batcher, with the context,
+// HTTP client and provider of the caller submitting it, so that it is
+// written with the settings of its own provider.
+type pendingChange struct {
+	ctx      context.Context //nolint:containedctx
+	client   *http.Client
+	provider *Provider
+	change   recordChange
+	result   chan error
+}
+
+// writeSettings are the provider settings applying to a write,
+// changes with different write settings being written separately.
+// They include the HTTP client settings, such as the timeout and the
+// proxy, since a write is made with the client of its first change.
+type writeSettings struct {
+	verify      bool
+	snapshotDir string
+	auditFile   string
+	http        httpSettings
+}
+
+func (p *Provider) writeSettings() writeSettings {
+	return writeSettings{
+		verify:      p.verify,
+		snapshotDir: p.snapshotDir,
+		auditFile:   p.auditFile,
+		http:        p.http,
+	}
+}
+
+func newBatcher() *batcher {
+	return &batcher{
+		batches: make(map[string]*batch),
+	}
+}
+
+// defaultBatcher is shared by all the Beget providers, since several
+// config entries can manage records of the same FQDN.
+var defaultBatcher = newBatcher() //nolint:gochecknoglobals
+
+// do applies the change given to the record set identified by key, within a
+// transaction run by transact. If no transaction is running for key, the
+// calling goroutine runs transactions until no change is left pending for
+// key. Otherwise, it waits for the change to be applied by the goroutine
+// running transactions, or for its context to be canceled. The pending
+// changes are grouped by the write settings of their provider, and each
+// group is given to transact with a context canceled once the contexts of
+// all its changes are canceled. Changes whose context is already canceled
+// are skipped. The transact function must return an error for each change
+// given.
+func (b *batcher) do(key string, change pendingChange,
+	transact func(ctx context.Context, changes []pendingChange) (errs []error)) (err error) {
+	result := make(chan error, 1)
+	change.result = result
+
+	b.mutex.Lock()
+	currentBatch, running := b.batches[key]
+	if running {
+		currentBatch.pending = append(currentBatch.pending, change)
+		b.mutex.Unlock()
+		select {
+		case err = <-result:
+			return err
+		case <-change.ctx.Done():
+			return change.ctx.Err()
+		}
+	}
+	currentBatch = &batch{pending: []pendingChange{change}}
+	b.batches[key] = currentBatch
+
+	for len(currentBatch.pending) > 0 {
+		pending := currentBatch.pending
+		currentBatch.pending = nil
+		b.mutex.Unlock()
+
+		for _, group := range groupChanges(pending) {
+			ctx, cancel := contextOfAll(group)
+			errs := transact(ctx, group)
+			cancel()
+			for i, pendingChange := range group {
+				pendingChange.result <- errs[i]
+			}
+		}
+
+		b.mutex.Lock()
+	}
+	delete(b.batches, key)
+	b.mutex.Unlock()
+
+	return <-result
+}
+
+// groupChanges groups the changes by the write settings of their provider,
+// in the order they were submitted, and sends the context error of the
+// changes whose context is canceled instead of grouping them.
+func groupChanges(pending []pendingChange) (groups [][]pendingChange) {
+	indexes := make(map[writeSettings]int)
+	for _, change := range pending {
+		err := change.ctx.Err()
+		if err != nil {
+			change.result <- err
+			continue
+		}
+		settings := change.provider.writeSettings()
+		i, ok := indexes[settings]
+		if !ok {
+			i = len(groups)
+			indexes[settings] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], change)
+	}
+	return groups
+}
+
+// contextOfAll returns a context with the values of the first change
+// context, canceled once the contexts of all the changes are canceled,
+// so that a transaction is not aborted for the changes still waiting
+// for it.
+func contextOfAll(changes []pendingChange) (ctx context.Context, cancel context.CancelFunc) {
+	ctx, cancelCtx := context.WithCancel(context.WithoutCancel(changes[0].ctx))
+	var remaining atomic.Int64
+	remaining.Store(int64(len(changes)))
+	stops := make([]func() bool, len(changes))
+	for i, change := range changes {
+		stops[i] = context.AfterFunc(change.ctx, func() {
+			if remaining.Add(-1) == 0 {
+				cancelCtx()
+			}
+		})
+	}
+	return ctx, func() {
+		for _, stop := range stops {
+			stop()
+		}
+		cancelCtx()
+	}
+}
+
+// batchKey returns the key identifying the record set of fqdn
+// for the Beget account used by the provider.
+func (p *Provider) batchKey(fqdn string) string {
+	return p.accountKey + " " + fqdn
+}
+
+// applyChange applies change to the record set of fqdn, together with the
+// changes of the same FQDN submitted concurrently by other providers. Each
+// transaction is run by the provider and HTTP client of its first change.
+func (p *Provider) applyChange(ctx context.Context, client *http.Client,
+	fqdn string, change recordChange) (err error) {
+	submitted := pendingChange{ctx: ctx, client: client, provider: p, change: change}
+	return p.batcher.do(p.batchKey(fqdn), submitted, func(ctx context.Context,
+		changes []pendingChange) (errs []error) {
+		recordChanges := make([]recordChange, len(changes))
+		for i, change := range changes {
+			recordChanges[i] = change.change
+		}
+		first := changes[0]
+		return first.provider.readModifyWrite(ctx, first.client, fqdn, recordChanges)
+	})
+}
+
+// readModifyWrite fetches the record set of fqdn, applies the changes to it,
+// and writes it back if any of them changed it. It returns an error for each
+// change, which is the error of the change itself or the error of the write.
+func (p *Provider) readModifyWrite(ctx context.Context, client *http.Client,
+	fqdn string, changes []recordChange) (errs []error) {
+	errs = make([]error, len(changes))
+
+	p.accountLock.Lock()
+	defer p.accountLock.Unlock()
+
+	// Before we call Beget API's /api/dns/changeRecords method, we need to fetch current DNS
+	// configuration as setting A record alone will clear all other records for this domain.
+	// This behavior is undocumented.
+	records, err := p.api.GetData(ctx, client, fqdn)
+	if err != nil {
+		p.cache.invalidate(fqdn)
+		return fillErrors(errs, err)
+	}
+	p.cache.set(fqdn, records)
+
+	// Each change is applied to a copy of the record set, so that a change
+	// failing halfway does not affect the record set written, and so that
+	// the record set fetched can be restored if the update fails.
+	original := records
+	changed := false
+	for i, change := range changes {
+		changedRecords := maps.Clone(records)
+		var recordsChanged bool
+		recordsChanged, errs[i] = change(changedRecords)
+		if errs[i] == nil && recordsChanged {
+			records = changedRecords
+			changed = true
+		}
+	}
+
+	// Skip changeRecords if all records are already up to date,
+	// to save on the Beget API quota.
+	if !changed {
+		return errs
+	}
+
+	snapshotPath, err := p.saveSnapshot(fqdn, original)
+	if err != nil {
+		return fillErrors(errs, fmt.Errorf("saving record set snapshot: %w", err))
+	}
+
+	auditErr, err := p.changeRecords(ctx, client, fqdn, original, records)
+	p.cache.invalidate(fqdn)
+	if err == nil && p.verify {
+		err = p.verifyRecords(ctx, client, fqdn, records)
+		if err != nil && !stderrors.Is(err, errors.ErrRecordsMismatch) {
+			// The record set could not be fetched, so nothing
+			// indicates the update went wrong.
+			return fillErrors(errs, stderrors.Join(err, auditErr))
+		}
+	}
+	if err != nil && mayBeApplied(err) {
+		err = p.rollback(ctx, client, fqdn, records, original, snapshotPath, err)
+	}
+	return fillErrors(errs, stderrors.Join(err, auditErr))
+}
+
+// fillErrors sets err for each change without error
+// in errs, if err is not nil, and returns errs.
+func fillErrors(errs []error, err error) []error {
+	if err == nil {
+		return errs
+	}
+	for i := range errs {
+		if errs[i] == nil {
+			errs[i] = err
+		}
+	}
+	return errs
+}
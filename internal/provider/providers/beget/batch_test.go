@@ -0,0 +1,174 @@
+package beget
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_batcher_do(t *testing.T) {
+	t.Parallel()
+
+	batcher := newBatcher()
+	const key = "key"
+	errTest := errors.New("test error")
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var batchSizes []int
+	transact := func(_ context.Context, changes []pendingChange) (errs []error) {
+		batchSizes = append(batchSizes, len(changes))
+		if len(batchSizes) == 1 {
+			close(started)
+			<-release
+		}
+		errs = make([]error, len(changes))
+		for i, change := range changes {
+			_, errs[i] = change.change(nil)
+		}
+		return errs
+	}
+	succeed := func(map[string]json.RawMessage) (bool, error) { return true, nil }
+	fail := func(map[string]json.RawMessage) (bool, error) { return false, errTest }
+	provider, otherProvider := &Provider{}, &Provider{auditFile: "audit.jsonl"}
+	ctx := context.Background()
+
+	results := make([]error, 4)
+	var waitGroup sync.WaitGroup
+	waitGroup.Add(1)
+	go func() {
+		defer waitGroup.Done()
+		results[0] = batcher.do(key, pendingChange{ctx: ctx, provider: provider, change: succeed}, transact)
+	}()
+	<-started
+
+	// Queue three changes while the first transaction is running,
+	// the last one with other write settings.
+	queued := []pendingChange{
+		{ctx: ctx, provider: provider, change: succeed},
+		{ctx: ctx, provider: provider, change: fail},
+		{ctx: ctx, provider: otherProvider, change: succeed},
+	}
+	for i, change := range queued {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			results[i+1] = batcher.do(key, change, transact)
+		}()
+	}
+	assert.Eventually(t, func() bool {
+		batcher.mutex.Lock()
+		defer batcher.mutex.Unlock()
+		return len(batcher.batches[key].pending) == len(queued)
+	}, time.Second, time.Millisecond)
+
+	close(release)
+	waitGroup.Wait()
+
+	// The changes are queued in any order by their goroutines.
+	assert.ElementsMatch(t, []int{1, 2, 1}, batchSizes)
+	assert.Equal(t, []error{nil, nil, errTest, nil}, results)
+	assert.Empty(t, batcher.batches)
+}
+
+func Test_batcher_do_canceled(t *testing.T) {
+	t.Parallel()
+
+	batcher := newBatcher()
+	const key = "key"
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var batchSizes []int
+	var ctxErrs []error
+	transact := func(ctx context.Context, changes []pendingChange) (errs []error) {
+		batchSizes = append(batchSizes, len(changes))
+		if len(batchSizes) == 1 {
+			close(started)
+			<-release
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+		}
+		ctxErrs = append(ctxErrs, ctx.Err())
+		return make([]error, len(changes))
+	}
+	succeed := func(map[string]json.RawMessage) (bool, error) { return true, nil }
+	provider := &Provider{}
+
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	canceledCtx, cancelFollower := context.WithCancel(context.Background())
+
+	leaderErr := make(chan error)
+	go func() {
+		leaderErr <- batcher.do(key, pendingChange{ctx: leaderCtx, provider: provider, change: succeed}, transact)
+	}()
+	<-started
+
+	canceledErr := make(chan error)
+	go func() {
+		canceledErr <- batcher.do(key, pendingChange{ctx: canceledCtx, provider: provider, change: succeed}, transact)
+	}()
+	followerErr := make(chan error)
+	go func() {
+		followerErr <- batcher.do(key, pendingChange{
+			ctx: context.Background(), provider: provider, change: succeed}, transact)
+	}()
+	assert.Eventually(t, func() bool {
+		batcher.mutex.Lock()
+		defer batcher.mutex.Unlock()
+		return len(batcher.batches[key].pending) == 2
+	}, time.Second, time.Millisecond)
+
+	// The follower canceled returns without waiting for the transaction.
+	cancelFollower()
+	assert.ErrorIs(t, <-canceledErr, context.Canceled)
+
+	// The leader context being canceled cancels its own transaction only.
+	cancelLeader()
+	close(release)
+	assert.NoError(t, <-leaderErr)
+	assert.NoError(t, <-followerErr)
+
+	assert.Equal(t, []int{1, 1}, batchSizes)
+	assert.Equal(t, []error{context.Canceled, nil}, ctxErrs)
+	assert.Empty(t, batcher.batches)
+}
+
+func Test_groupChanges(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	provider := &Provider{}
+	sameSettings := &Provider{}
+	otherTimeout := &Provider{http: httpSettings{timeout: time.Second}}
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	canceled := pendingChange{ctx: canceledCtx, provider: provider, result: make(chan error, 1)}
+	pending := []pendingChange{
+		{ctx: ctx, provider: provider},
+		{ctx: ctx, provider: otherTimeout},
+		canceled,
+		{ctx: ctx, provider: sameSettings},
+	}
+
+	groups := groupChanges(pending)
+
+	assert.Equal(t, [][]pendingChange{{pending[0], pending[3]}, {pending[1]}}, groups)
+	assert.ErrorIs(t, <-canceled.result, context.Canceled)
+}
+
+func Test_accountLocks_get(t *testing.T) {
+	t.Parallel()
+
+	locks := newAccountLocks()
+
+	assert.Same(t, locks.get("https://api.beget.com login"), locks.get("https://api.beget.com login"))
+	assert.NotSame(t, locks.get("https://api.beget.com login"), locks.get("https://api.beget.com other"))
+}
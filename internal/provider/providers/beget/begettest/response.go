@@ -0,0 +1,86 @@
+package begettest
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type response struct {
+	Status    string  `json:"status"`
+	ErrorCode string  `json:"error_code,omitempty"`
+	ErrorText string  `json:"error_text,omitempty"`
+	Answer    *answer `json:"answer,omitempty"`
+}
+
+type answer struct {
+	Status string          `json:"status"`
+	Errors []answerError   `json:"errors,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+}
+
+type answerError struct {
+	ErrorCode string `json:"error_code"`
+	ErrorText string `json:"error_text"`
+}
+
+type getDataResult struct {
+	FQDN    string                     `json:"fqdn"`
+	Records map[string]json.RawMessage `json:"records"`
+}
+
+func writeResponse(w http.ResponseWriter, response response) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// writeError writes the Beget error response of the error code, which
+// is an error of the request itself for the authentication, limit and
+// request errors, as Beget does, and an error of the method otherwise.
+func writeError(w http.ResponseWriter, code, text string) {
+	switch code {
+	case "AUTH_ERROR", "LIMIT_ERROR", "INCORRECT_REQUEST", "NO_SUCH_METHOD":
+		writeResponse(w, response{Status: "error", ErrorCode: code, ErrorText: text})
+	default:
+		writeResponse(w, response{
+			Status: "success",
+			Answer: &answer{
+				Status: "error",
+				Errors: []answerError{{ErrorCode: code, ErrorText: text}},
+			},
+		})
+	}
+}
+
+// getDataRecords returns the records in the format of the getData method,
+// which gives the IP address of the A and AAAA entries in the "address"
+// field instead of the "value" field used by the changeRecords method.
+func getDataRecords(records map[string]json.RawMessage) (result map[string]json.RawMessage) {
+	result = make(map[string]json.RawMessage, len(records))
+	for recordType, raw := range records {
+		result[recordType] = raw
+		if recordType != "A" && recordType != "AAAA" {
+			continue
+		}
+		var entries []map[string]any
+		err := json.Unmarshal(raw, &entries)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if value, ok := entry["value"]; ok {
+				entry["address"] = value
+				delete(entry, "value")
+			}
+		}
+		converted, err := json.Marshal(entries)
+		if err == nil {
+			result[recordType] = converted
+		}
+	}
+	return result
+}
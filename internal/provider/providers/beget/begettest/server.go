@@ -0,0 +1,255 @@
+// Package begettest implements a fake Beget API server holding the
+// record sets of its domains in memory, with failure injection, to
+// exercise the Beget provider offline by setting its API URL.
+package begettest
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Settings are the settings of the fake Beget API server.
+type Settings struct {
+	// Login and Password are the credentials accepted,
+	// and any credentials are accepted if both are empty.
+	Login    string
+	Password string
+	// Token, if set, is the API token accepted as a bearer token in the
+	// Authorization header, along with the Login if it is set, and the
+	// password is then not accepted.
+	Token string
+	// Domains are the domains of the account, such as example.com.
+	Domains []string
+	// Subdomains are the subdomains of the account, such as
+	// home.example.com, each of them ending with one of the domains.
+	Subdomains []string
+	// Logger logs each API call, and can be nil.
+	Logger Infoer
+}
+
+type Infoer interface {
+	Info(message string)
+}
+
+// Failure is an error returned by the server instead of
+// running the API method called.
+type Failure struct {
+	// Method is the API method failing, such as dns/changeRecords,
+	// or empty for all the methods.
+	Method string
+	// ErrorCode is the Beget error code returned, such as
+	// LIMIT_ERROR or METHOD_FAILED, if StatusCode is zero.
+	ErrorCode string
+	// StatusCode is the HTTP status code returned, if not zero.
+	StatusCode int
+	// Times is the number of calls failing,
+	// or zero for all the calls.
+	Times uint
+}
+
+// Server is a fake Beget API server implementing the dns/getData,
+// dns/changeRecords, domain/getList, domain/getSubdomainList and
+// domain/addSubdomainVirtual methods. It is safe for concurrent use.
+type Server struct {
+	mutex      sync.Mutex
+	login      string
+	password   string
+	token      string
+	domains    []entry
+	subdomains []entry
+	nextID     int64
+	// records maps the FQDNs to their record set.
+	records  map[string]map[string]json.RawMessage
+	failures []Failure
+	calls    map[string]int
+	logger   Infoer
+}
+
+type entry struct {
+	ID   int64  `json:"id"`
+	FQDN string `json:"fqdn"`
+}
+
+// New returns a fake Beget API server with empty record sets.
+func New(settings Settings) *Server {
+	s := &Server{
+		login:    settings.Login,
+		password: settings.Password,
+		token:    settings.Token,
+		records:  make(map[string]map[string]json.RawMessage),
+		calls:    make(map[string]int),
+		logger:   settings.Logger,
+	}
+	for _, domain := range settings.Domains {
+		s.domains = append(s.domains, s.newEntry(domain))
+	}
+	for _, subdomain := range settings.Subdomains {
+		s.subdomains = append(s.subdomains, s.newEntry(subdomain))
+	}
+	return s
+}
+
+func (s *Server) newEntry(fqdn string) entry {
+	s.nextID++
+	return entry{ID: s.nextID, FQDN: strings.ToLower(fqdn)}
+}
+
+// Fail makes the calls matching the failure return its error,
+// before the failures given previously.
+func (s *Server) Fail(failure Failure) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.failures = append([]Failure{failure}, s.failures...)
+}
+
+// Records returns a copy of the record set of the FQDN.
+func (s *Server) Records(fqdn string) (records map[string]json.RawMessage) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return maps.Clone(s.records[fqdn])
+}
+
+// SetRecords sets the record set of the FQDN, with the entries of the A and
+// AAAA records having their IP address in the "value" or "address" field.
+func (s *Server) SetRecords(fqdn string, records map[string]json.RawMessage) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.records[fqdn] = maps.Clone(records)
+}
+
+// Calls returns the number of calls of the API method, such as
+// dns/changeRecords, including the failed ones.
+func (s *Server) Calls(method string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.calls[method]
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	method := strings.TrimPrefix(r.URL.Path, "/api/")
+	s.calls[method]++
+	if s.logger != nil {
+		s.logger.Info(r.Method + " " + r.URL.Path)
+	}
+
+	if failure, ok := s.nextFailure(method); ok {
+		if failure.StatusCode != 0 {
+			http.Error(w, http.StatusText(failure.StatusCode), failure.StatusCode)
+			return
+		}
+		writeError(w, failure.ErrorCode, "injected failure")
+		return
+	}
+
+	if !s.authorized(r) {
+		writeResponse(w, response{Status: "error", ErrorCode: "AUTH_ERROR", ErrorText: "No such user"})
+		return
+	}
+
+	var input struct {
+		FQDN      string                     `json:"fqdn"`
+		Records   map[string]json.RawMessage `json:"records"`
+		Subdomain string                     `json:"subdomain"`
+		DomainID  int64                      `json:"domain_id"`
+	}
+	inputData := r.FormValue("input_data")
+	if inputData != "" {
+		err := json.Unmarshal([]byte(inputData), &input)
+		if err != nil {
+			writeError(w, "INCORRECT_REQUEST", "decoding input data: "+err.Error())
+			return
+		}
+	}
+	input.FQDN = strings.ToLower(input.FQDN)
+
+	var result any
+	switch method {
+	case "dns/getData":
+		if !s.exists(input.FQDN) {
+			writeError(w, "INVALID_DATA", "no such domain: "+input.FQDN)
+			return
+		}
+		result = getDataResult{FQDN: input.FQDN, Records: getDataRecords(s.records[input.FQDN])}
+	case "dns/changeRecords":
+		if !s.exists(input.FQDN) {
+			writeError(w, "INVALID_DATA", "no such domain: "+input.FQDN)
+			return
+		}
+		s.records[input.FQDN] = input.Records
+		result = true
+	case "domain/getList":
+		result = s.domains
+	case "domain/getSubdomainList":
+		result = s.subdomains
+	case "domain/addSubdomainVirtual":
+		index := slices.IndexFunc(s.domains, func(domain entry) bool {
+			return domain.ID == input.DomainID
+		})
+		if index == -1 || input.Subdomain == "" {
+			writeError(w, "INVALID_DATA", fmt.Sprintf("no such domain id: %d", input.DomainID))
+			return
+		}
+		subdomain := s.newEntry(input.Subdomain + "." + s.domains[index].FQDN)
+		s.subdomains = append(s.subdomains, subdomain)
+		result = subdomain.ID
+	default:
+		writeError(w, "NO_SUCH_METHOD", "no such method: "+method)
+		return
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeResponse(w, response{
+		Status: "success",
+		Answer: &answer{Status: "success", Result: resultJSON},
+	})
+}
+
+// nextFailure returns the first failure matching the method,
+// and counts it if it fails a limited number of times.
+func (s *Server) nextFailure(method string) (failure Failure, ok bool) {
+	for i, failure := range s.failures {
+		if failure.Method != "" && failure.Method != method {
+			continue
+		}
+		if failure.Times == 0 {
+			return failure, true
+		}
+		s.failures[i].Times--
+		if s.failures[i].Times == 0 {
+			s.failures = slices.Delete(s.failures, i, i+1)
+		}
+		return failure, true
+	}
+	return Failure{}, false
+}
+
+// authorized returns true if the request has the credentials accepted,
+// which are the bearer token and the login if the token is set, or the
+// login and password otherwise.
+func (s *Server) authorized(r *http.Request) bool {
+	if s.token != "" {
+		return r.Header.Get("Authorization") == "Bearer "+s.token &&
+			r.FormValue("passwd") == "" &&
+			(s.login == "" || r.FormValue("login") == s.login)
+	}
+	return (s.login == "" && s.password == "") ||
+		(r.FormValue("login") == s.login && r.FormValue("passwd") == s.password)
+}
+
+func (s *Server) exists(fqdn string) bool {
+	matches := func(entry entry) bool { return entry.FQDN == fqdn }
+	return slices.ContainsFunc(s.domains, matches) ||
+		slices.ContainsFunc(s.subdomains, matches)
+}
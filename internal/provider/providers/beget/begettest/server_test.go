@@ -0,0 +1,120 @@
+package begettest_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+	"github.com/qdm12/ddns-updater/internal/provider/providers/beget"
+	"github.com/qdm12/ddns-updater/internal/provider/providers/beget/begettest"
+	"github.com/qdm12/ddns-updater/pkg/publicip/ipversion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newProvider(t *testing.T, apiURL, password string) *beget.Provider {
+	t.Helper()
+	settings, err := json.Marshal(map[string]any{
+		"login":            "login",
+		"password":         password,
+		"api_url":          apiURL,
+		"retry":            map[string]any{"max_attempts": 1},
+		"rate_limit":       0,
+		"check_delegation": false,
+	})
+	require.NoError(t, err)
+	provider, err := beget.New(settings, "example.com", "home", ipversion.IP4, netip.Prefix{})
+	require.NoError(t, err)
+	return provider
+}
+
+func Test_Server(t *testing.T) {
+	t.Parallel()
+
+	server := begettest.New(begettest.Settings{
+		Login:      "login",
+		Password:   "password",
+		Domains:    []string{"example.com"},
+		Subdomains: []string{"home.example.com"},
+	})
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+	server.SetRecords("home.example.com", map[string]json.RawMessage{
+		"A":  json.RawMessage(`[{"priority":10,"address":"1.1.1.1"}]`),
+		"MX": json.RawMessage(`[{"priority":10,"value":"mx.example.com"}]`),
+	})
+
+	ctx := context.Background()
+	client := httpServer.Client()
+	provider := newProvider(t, httpServer.URL, "password")
+	ip := netip.MustParseAddr("2.2.2.2")
+
+	newIP, err := provider.Update(ctx, client, ip)
+	require.NoError(t, err)
+	assert.Equal(t, ip, newIP)
+	assert.JSONEq(t, `[{"priority":10,"value":"2.2.2.2"}]`,
+		string(server.Records("home.example.com")["A"]))
+	assert.JSONEq(t, `[{"priority":10,"value":"mx.example.com"}]`,
+		string(server.Records("home.example.com")["MX"]))
+	assert.Equal(t, 1, server.Calls("dns/changeRecords"))
+
+	server.Fail(begettest.Failure{Method: "dns/changeRecords", ErrorCode: "LIMIT_ERROR", Times: 1})
+	_, err = provider.Update(ctx, client, netip.MustParseAddr("3.3.3.3"))
+	assert.ErrorIs(t, err, errors.ErrRateLimit)
+	_, err = provider.Update(ctx, client, netip.MustParseAddr("3.3.3.3"))
+	assert.NoError(t, err)
+
+	server.Fail(begettest.Failure{StatusCode: http.StatusBadGateway, Times: 1})
+	_, err = provider.Update(ctx, client, netip.MustParseAddr("4.4.4.4"))
+	assert.ErrorIs(t, err, errors.ErrHTTPStatusNotValid)
+
+	_, err = newProvider(t, httpServer.URL, "wrong").Update(ctx, client, ip)
+	assert.ErrorIs(t, err, errors.ErrAuth)
+}
+
+func Test_Server_token(t *testing.T) {
+	t.Parallel()
+
+	server := begettest.New(begettest.Settings{
+		Token:      "token",
+		Domains:    []string{"example.com"},
+		Subdomains: []string{"home.example.com"},
+	})
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	newTokenProvider := func(token string) *beget.Provider {
+		settings, err := json.Marshal(map[string]any{
+			"auth":             "token",
+			"token":            token,
+			"api_url":          httpServer.URL,
+			"retry":            map[string]any{"max_attempts": 1},
+			"rate_limit":       0,
+			"check_delegation": false,
+		})
+		require.NoError(t, err)
+		provider, err := beget.New(settings, "example.com", "home", ipversion.IP4, netip.Prefix{})
+		require.NoError(t, err)
+		return provider
+	}
+
+	ctx := context.Background()
+	client := httpServer.Client()
+	ip := netip.MustParseAddr("2.2.2.2")
+
+	newIP, err := newTokenProvider("token").Update(ctx, client, ip)
+	require.NoError(t, err)
+	assert.Equal(t, ip, newIP)
+	assert.JSONEq(t, `[{"priority":10,"value":"2.2.2.2"}]`,
+		string(server.Records("home.example.com")["A"]))
+
+	_, err = newTokenProvider("wrong").Update(ctx, client, ip)
+	assert.ErrorIs(t, err, errors.ErrAuth)
+
+	_, err = newProvider(t, httpServer.URL, "password").Update(ctx, client, ip)
+	assert.ErrorIs(t, err, errors.ErrAuth)
+}
@@ -0,0 +1,63 @@
+package beget
+
+import (
+	"encoding/json"
+	"maps"
+	"sync"
+	"time"
+)
+
+// recordsCache caches the record sets returned by getData for each FQDN,
+// so that an update cycle with an unchanged IP address can be skipped
+// without calling the Beget API. A zero ttl disables the cache.
+type recordsCache struct {
+	ttl     time.Duration
+	timeNow func() time.Time
+	mutex   sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	records   map[string]json.RawMessage
+	expiresAt time.Time
+}
+
+func newRecordsCache(ttl time.Duration) *recordsCache {
+	return &recordsCache{
+		ttl:     ttl,
+		timeNow: time.Now,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// get returns a copy of the record set cached for fqdn,
+// and false if there is none or if it has expired.
+func (c *recordsCache) get(fqdn string) (records map[string]json.RawMessage, ok bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, ok := c.entries[fqdn]
+	if !ok || !c.timeNow().Before(entry.expiresAt) {
+		return nil, false
+	}
+	return maps.Clone(entry.records), true
+}
+
+// set caches a copy of the record set of fqdn.
+func (c *recordsCache) set(fqdn string, records map[string]json.RawMessage) {
+	if c.ttl == 0 {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries[fqdn] = cacheEntry{
+		records:   maps.Clone(records),
+		expiresAt: c.timeNow().Add(c.ttl),
+	}
+}
+
+// invalidate removes the record set cached for fqdn, if any.
+func (c *recordsCache) invalidate(fqdn string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.entries, fqdn)
+}
@@ -0,0 +1,48 @@
+package beget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+	"github.com/qdm12/ddns-updater/internal/provider/utils"
+)
+
+// ChangeRecords replaces the whole record set of the given FQDN with records.
+// See https://beget.com/en/kb/api/dns-administration-functions#changerecords
+func (c *apiClient) ChangeRecords(ctx context.Context, client *http.Client,
+	fqdn string, records map[string]json.RawMessage) (err error) {
+	inputData := struct {
+		FQDN    string                     `json:"fqdn"`
+		Records map[string]json.RawMessage `json:"records"`
+	}{
+		FQDN:    fqdn,
+		Records: records,
+	}
+
+	inputDataRaw, err := json.Marshal(inputData)
+	if err != nil {
+		return fmt.Errorf("Couldn't marshal json: %w", err)
+	}
+
+	// Calling API & parsing response
+	changeRecordsResponseRaw, err := c.apiCall(ctx, client, "/api/dns/changeRecords", inputDataRaw)
+	defer func() { c.status.record("dns/changeRecords", changeRecordsResponseRaw, err) }()
+	if err != nil {
+		return fmt.Errorf("Calling changeRecords failed: %w", err)
+	}
+
+	var result bool
+	err = c.redactError(decodeResponse(changeRecordsResponseRaw, &result))
+	if err != nil {
+		return fmt.Errorf("changeRecords: %w", err)
+	}
+
+	if !result {
+		return fmt.Errorf("%w: changeRecords response doesn't indicate success: %s",
+			errors.ErrUnsuccessful, utils.ToSingleLine(string(changeRecordsResponseRaw)))
+	}
+	return nil
+}
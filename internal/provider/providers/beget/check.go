@@ -0,0 +1,96 @@
+package beget
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+)
+
+// Check verifies, without writing anything, that the credentials are valid,
+// that the domain is delegated to the Beget nameservers, and that each FQDN
+// to update belongs to the Beget account and has its record set readable
+// with getData. Missing subdomains are accepted if a domain of the account
+// contains them and "create_missing" is enabled, in which case they are
+// created on the first update, or "detect_entity" is enabled, in which
+// case the records of their closest parent entity are read instead.
+func (p *Provider) Check(ctx context.Context, client *http.Client) (err error) {
+	client = p.http.wrapClient(client)
+
+	domains, subdomains, err := p.listEntries(ctx, client)
+	if err != nil {
+		return fmt.Errorf("listing account domains: %w", err)
+	}
+	entries := slices.Concat(domains, subdomains)
+
+	fqdns := p.getFQDNs()
+	if p.discover {
+		fqdns = make([]string, len(entries))
+		for i, entry := range entries {
+			fqdns[i] = entry.FQDN
+		}
+	}
+
+	var errs []error
+	if !p.discover && p.delegation.lookupNS != nil {
+		delegationErr, err := p.delegation.lookup(ctx, p.domain)
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("looking up NS records of %s: %w", p.domain, err))
+		case delegationErr != nil:
+			errs = append(errs, delegationErr)
+		}
+	}
+
+	for _, fqdn := range fqdns {
+		err = p.checkFQDN(ctx, client, fqdn, domains, entries)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", fqdn, err))
+		}
+	}
+	return stderrors.Join(errs...)
+}
+
+func (p *Provider) checkFQDN(ctx context.Context, client *http.Client, fqdn string,
+	domains, entries []domainEntry) (err error) {
+	exists := slices.ContainsFunc(entries,
+		func(entry domainEntry) bool { return entry.FQDN == fqdn })
+	if !exists {
+		inDomain := slices.ContainsFunc(domains,
+			func(domain domainEntry) bool { return strings.HasSuffix(fqdn, "."+domain.FQDN) })
+		switch {
+		case !inDomain:
+			return fmt.Errorf("%w: no domain of the account contains it", errors.ErrDomainNotFound)
+		case p.createMissing:
+			return nil // created on the first update
+		case !p.detectEntity:
+			return fmt.Errorf("%w: the subdomain does not exist on the account "+
+				"and create_missing is disabled", errors.ErrDomainNotFound)
+		default:
+			return fmt.Errorf("%w: the subdomain does not exist on the account and its records "+
+				"are held by %s, whose own A and AAAA records are not overwritten",
+				errors.ErrDomainNotFound, closestEntry(fqdn, entries))
+		}
+	}
+
+	_, err = p.api.GetData(ctx, client, fqdn)
+	if err != nil {
+		return fmt.Errorf("reading records: %w", err)
+	}
+	return nil
+}
+
+// closestEntry returns the FQDN of the entry which is the closest
+// parent of fqdn, assuming at least one entry is a parent of fqdn.
+func closestEntry(fqdn string, entries []domainEntry) (closest string) {
+	for _, entry := range entries {
+		if strings.HasSuffix(fqdn, "."+entry.FQDN) && len(entry.FQDN) > len(closest) {
+			closest = entry.FQDN
+		}
+	}
+	return closest
+}
@@ -0,0 +1,77 @@
+package beget
+
+import (
+	"context"
+	"encoding/json"
+	"net/netip"
+	"testing"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+	"github.com/qdm12/ddns-updater/pkg/publicip/ipversion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Provider_Check(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		domain     string
+		settings   string
+		errWrapped error
+		errMessage string
+	}{
+		"all_found": {
+			domain:   "example.com",
+			settings: `{"login":"login","password":"password","hosts":["@","www"]}`,
+		},
+		"missing_subdomain": {
+			domain:     "example.com",
+			settings:   `{"login":"login","password":"password","hosts":["@","vpn"]}`,
+			errWrapped: errors.ErrDomainNotFound,
+			errMessage: "vpn.example.com: domain not found: the subdomain does not exist " +
+				"on the account and create_missing is disabled",
+		},
+		"missing_subdomain_detected": {
+			domain:     "example.com",
+			settings:   `{"login":"login","password":"password","hosts":["vpn.www"],"detect_entity":true}`,
+			errWrapped: errors.ErrDomainNotFound,
+			errMessage: "vpn.www.example.com: domain not found: the subdomain does not exist " +
+				"on the account and its records are held by www.example.com, " +
+				"whose own A and AAAA records are not overwritten",
+		},
+		"missing_subdomain_created": {
+			domain:   "example.com",
+			settings: `{"login":"login","password":"password","hosts":["vpn"],"create_missing":true}`,
+		},
+		"not_in_account": {
+			domain:     "example.net",
+			settings:   `{"login":"login","password":"password","hosts":["@","www"]}`,
+			errWrapped: errors.ErrDomainNotFound,
+			errMessage: "example.net: domain not found: no domain of the account contains it\n" +
+				"www.example.net: domain not found: no domain of the account contains it",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			api := &fakeAPI{
+				domains:    []domainEntry{{ID: 1, FQDN: "example.com"}},
+				subdomains: []domainEntry{{ID: 2, FQDN: "www.example.com"}},
+			}
+			provider, err := New(json.RawMessage(testCase.settings), testCase.domain, "@",
+				ipversion.IP4, netip.Prefix{}, withAPI(api))
+			require.NoError(t, err)
+
+			err = provider.Check(context.Background(), nil)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package beget
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+)
+
+// circuitBreaker stops the API calls of a Beget account for a cool-down
+// period after consecutive AUTH_ERROR or LIMIT_ERROR responses, so that
+// Beget is not called with wrong credentials or over its limits on
+// every update cycle.
+type circuitBreaker struct {
+	mutex     sync.Mutex
+	threshold uint
+	cooldown  time.Duration
+	failures  uint
+	lastCode  string
+	openUntil time.Time
+	timeNow   func() time.Time
+	// leases maps the lease IDs of the providers using the
+	// breaker to their settings, see acquire.
+	leases    map[uint]circuitLease
+	nextLease uint
+}
+
+type circuitLease struct {
+	threshold uint
+	cooldown  time.Duration
+}
+
+type circuitSettings struct {
+	Failures *uint  `json:"failures,omitempty"`
+	Cooldown string `json:"cooldown,omitempty"`
+}
+
+func (s circuitSettings) parse() (threshold uint, cooldown time.Duration, err error) {
+	const defaultThreshold = 3
+	threshold = defaultThreshold
+	if s.Failures != nil {
+		threshold = *s.Failures
+	}
+
+	const defaultCooldown = 30 * time.Minute
+	cooldown = defaultCooldown
+	if s.Cooldown != "" {
+		cooldown, err = time.ParseDuration(s.Cooldown)
+		if err != nil {
+			return 0, 0, fmt.Errorf("parsing circuit breaker cooldown: %w", err)
+		}
+		if cooldown <= 0 {
+			return 0, 0, fmt.Errorf("%w: circuit breaker cooldown %s must be positive",
+				errors.ErrTimeoutNotValid, cooldown)
+		}
+	}
+	return threshold, cooldown, nil
+}
+
+func newCircuitBreaker(threshold uint, cooldown time.Duration,
+	timeNow func() time.Time) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		timeNow:   timeNow,
+		leases:    make(map[uint]circuitLease),
+	}
+}
+
+// acquire registers a provider using the breaker with its settings, and
+// returns the ID of its lease to give to release once it is not used.
+// The strictest settings of the providers using the breaker apply, that
+// is the lowest threshold and the longest cooldown.
+func (c *circuitBreaker) acquire(threshold uint, cooldown time.Duration) (id uint) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	id = c.nextLease
+	c.nextLease++
+	c.leases[id] = circuitLease{threshold: threshold, cooldown: cooldown}
+	c.applyLeases()
+	return id
+}
+
+// release unregisters the provider lease id, so that only the settings
+// of the providers still using the breaker apply, and returns true if
+// no provider uses the breaker anymore.
+func (c *circuitBreaker) release(id uint) (unused bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.leases, id)
+	if len(c.leases) == 0 {
+		return true
+	}
+	c.applyLeases()
+	return false
+}
+
+func (c *circuitBreaker) applyLeases() {
+	first := true
+	for _, lease := range c.leases {
+		if first {
+			c.threshold, c.cooldown = lease.threshold, lease.cooldown
+			first = false
+			continue
+		}
+		c.threshold = min(c.threshold, lease.threshold)
+		c.cooldown = max(c.cooldown, lease.cooldown)
+	}
+}
+
+// allow returns an error wrapping errors.ErrSuspended if
+// the circuit is open, and nil if the API can be called.
+func (c *circuitBreaker) allow() (err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.timeNow().Before(c.openUntil) {
+		return nil
+	}
+	return fmt.Errorf("%w: after %d %s errors in a row, until %s",
+		errors.ErrSuspended, c.failures, c.lastCode, c.openUntil.Format(time.RFC3339))
+}
+
+// record records the response body b of an API call and the error it
+// failed with, if any, and opens the circuit once the threshold of
+// consecutive AUTH_ERROR or LIMIT_ERROR responses is reached. Once the
+// cooldown elapsed, a single such response opens it again.
+func (c *circuitBreaker) record(b []byte, err error) {
+	codes := errorCodes(b)
+	var code string
+	for _, tripping := range []string{"AUTH_ERROR", "LIMIT_ERROR"} {
+		if slices.Contains(codes, tripping) {
+			code = tripping
+			break
+		}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	switch {
+	case code != "":
+		c.failures++
+		c.lastCode = code
+		if c.failures >= c.threshold {
+			c.openUntil = c.timeNow().Add(c.cooldown)
+		}
+	case err == nil:
+		c.failures = 0
+		c.openUntil = time.Time{}
+	}
+}
+
+// circuitBreakers holds the circuit breaker of each Beget account and
+// credentials, shared by all the providers using them. Providers with
+// other credentials for the same account, such as after a config reload
+// fixing the password, get another breaker, which is closed.
+type circuitBreakers struct {
+	mutex    sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+func newCircuitBreakers() *circuitBreakers {
+	return &circuitBreakers{
+		breakers: make(map[string]*circuitBreaker),
+	}
+}
+
+var defaultCircuitBreakers = newCircuitBreakers() //nolint:gochecknoglobals
+
+// get returns the circuit breaker of the credentials identified by key,
+// or nil if threshold is zero, and the function to call once the provider
+// does not use it anymore, after which the breaker is forgotten if no
+// other provider uses it.
+func (b *circuitBreakers) get(key string, threshold uint,
+	cooldown time.Duration) (breaker *circuitBreaker, release func()) {
+	if threshold == 0 {
+		return nil, func() {}
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	breaker, ok := b.breakers[key]
+	if !ok {
+		breaker = newCircuitBreaker(threshold, cooldown, time.Now)
+		b.breakers[key] = breaker
+	}
+	id := breaker.acquire(threshold, cooldown)
+	return breaker, func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		if breaker.release(id) && b.breakers[key] == breaker {
+			delete(b.breakers, key)
+		}
+	}
+}
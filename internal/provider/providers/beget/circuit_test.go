@@ -0,0 +1,72 @@
+package beget
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_circuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0).UTC()
+	breaker := newCircuitBreaker(2, time.Minute, func() time.Time { return now })
+	authError := []byte(`{"status":"error","error_code":"AUTH_ERROR","error_text":"bad login"}`)
+	success := []byte(`{"status":"success","answer":{"status":"success","result":true}}`)
+
+	breaker.record(authError, nil)
+	assert.NoError(t, breaker.allow())
+	breaker.record(success, nil)
+	breaker.record(authError, nil)
+	assert.NoError(t, breaker.allow(), "failures count not reset by a success")
+
+	breaker.record(nil, errors.ErrHTTPStatusNotValid)
+	assert.NoError(t, breaker.allow())
+	breaker.record(authError, nil)
+	err := breaker.allow()
+	assert.ErrorIs(t, err, errors.ErrSuspended)
+	assert.EqualError(t, err, "provider calls suspended: after 2 AUTH_ERROR errors in a row, "+
+		"until 1970-01-01T00:01:00Z")
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, breaker.allow())
+	breaker.record(authError, nil)
+	assert.ErrorIs(t, breaker.allow(), errors.ErrSuspended)
+
+	now = now.Add(time.Minute)
+	breaker.record(success, nil)
+	assert.NoError(t, breaker.allow())
+}
+
+func Test_circuitBreakers_get(t *testing.T) {
+	t.Parallel()
+
+	breakers := newCircuitBreakers()
+
+	breaker, release := breakers.get("key", 0, time.Minute)
+	assert.Nil(t, breaker)
+	release()
+
+	breaker, releaseOld := breakers.get("key", 3, time.Minute)
+	sameBreaker, releaseNew := breakers.get("key", 2, time.Second)
+	assert.Same(t, breaker, sameBreaker)
+	assert.Equal(t, uint(2), breaker.threshold)
+	assert.Equal(t, time.Minute, breaker.cooldown)
+
+	// the settings of the released providers no longer apply
+	releaseOld()
+	assert.Equal(t, uint(2), breaker.threshold)
+	assert.Equal(t, time.Second, breaker.cooldown)
+
+	otherBreaker, releaseOther := breakers.get("other", 3, time.Minute)
+	assert.NotSame(t, breaker, otherBreaker)
+	releaseOther()
+
+	releaseNew()
+	newBreaker, release := breakers.get("key", 3, time.Minute)
+	assert.NotSame(t, breaker, newBreaker, "unused breaker not forgotten")
+	release()
+	assert.Empty(t, breakers.breakers)
+}
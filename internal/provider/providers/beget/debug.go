@@ -0,0 +1,65 @@
+package beget
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/logging"
+	"github.com/qdm12/ddns-updater/internal/provider/utils"
+)
+
+// debugLogger logs the Beget API exchanges if the debug setting is enabled.
+type debugLogger interface {
+	Debug(s string)
+}
+
+// newRequestID returns a random identifier to match
+// the debug logs of a request and its response.
+func newRequestID() string {
+	return fmt.Sprintf("%08x", rand.Uint32()) //nolint:gosec
+}
+
+// logRequest logs the API call to the endpoint with its input data,
+// which contains no credentials, but is redacted nonetheless.
+func (c *apiClient) logRequest(requestID, endpoint string, inputJSON []byte) {
+	message := fmt.Sprintf("beget request %s: %s: input_data: %s",
+		requestID, endpoint, c.redactString(string(inputJSON)))
+	c.logger.Debug(logging.Message(message,
+		logging.With("request_id", requestID),
+		logging.With("endpoint", endpointLabel(endpoint))))
+}
+
+// logResponse logs the outcome of the API call with the HTTP status code
+// of its response, or zero if no response was received, its Beget error
+// codes, its duration, its redacted body and the error it failed with.
+func (c *apiClient) logResponse(requestID, endpoint string, statusCode int, body []byte,
+	duration time.Duration, err error) {
+	fields := []logging.Field{
+		logging.With("request_id", requestID),
+		logging.With("endpoint", endpointLabel(endpoint)),
+		logging.With("status", statusCode),
+		logging.With("duration_ms", duration.Milliseconds()),
+	}
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "beget response %s: ", requestID)
+	if statusCode == 0 {
+		builder.WriteString("no response")
+	} else {
+		fmt.Fprintf(&builder, "HTTP %d", statusCode)
+	}
+	fmt.Fprintf(&builder, " in %s", duration.Round(time.Millisecond))
+	if codes := errorCodes(body); len(codes) > 0 {
+		builder.WriteString(": error codes " + strings.Join(codes, ", "))
+		fields = append(fields, logging.With("error_code", codes[0]))
+	}
+	if len(body) > 0 {
+		builder.WriteString(": body: " + c.redactString(utils.ToSingleLine(string(body))))
+	}
+	if err != nil {
+		builder.WriteString(": error: " + c.redactString(err.Error()))
+		fields = append(fields, logging.With("error", c.redactString(err.Error())))
+	}
+	c.logger.Debug(logging.Message(builder.String(), fields...))
+}
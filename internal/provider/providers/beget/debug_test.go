@@ -0,0 +1,52 @@
+package beget
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLogger struct {
+	lines []string
+}
+
+func (f *fakeLogger) Debug(s string) { f.lines = append(f.lines, s) }
+
+func Test_apiClient_apiCall_debug(t *testing.T) {
+	t.Parallel()
+
+	var requestIDHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestIDHeader = r.Header.Get("X-Request-Id")
+		_, _ = w.Write([]byte(`{"status":"error","error_code":"AUTH_ERROR",` +
+			`"error_text":"No such user secret-login"}`))
+	}))
+	t.Cleanup(server.Close)
+	apiURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	logger := &fakeLogger{}
+	client := newAPIClient("secret-login", "secret-password", "", apiURL, retryPolicy{maxAttempts: 1}, nil, nil)
+	client.metrics = nil
+	client.logger = logger
+
+	_, err = client.apiCall(context.Background(), server.Client(),
+		"/api/dns/getData", []byte(`{"fqdn":"example.com"}`))
+	require.NoError(t, err)
+
+	require.Len(t, logger.lines, 2)
+	requestID := regexp.MustCompile(`^beget request ([0-9a-f]{8}): `).FindStringSubmatch(logger.lines[0])
+	require.Len(t, requestID, 2)
+	assert.Equal(t, requestID[1], requestIDHeader)
+	assert.Equal(t, "beget request "+requestID[1]+`: /api/dns/getData: input_data: {"fqdn":"example.com"}`,
+		logger.lines[0])
+	assert.Regexp(t, `^beget response `+requestID[1]+`: HTTP 200 in \S+: error codes AUTH_ERROR: `+
+		`body: {"status":"error","error_code":"AUTH_ERROR","error_text":"No such user \[redacted\]"}$`,
+		logger.lines[1])
+}
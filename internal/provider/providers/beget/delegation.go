@@ -0,0 +1,84 @@
+package beget
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+)
+
+// delegationCheckPeriod is the period at which the delegation
+// of the domain to the Beget nameservers is checked.
+const delegationCheckPeriod = time.Hour
+
+// begetNameserverSuffixes are the suffixes of the Beget nameservers,
+// such as ns1.beget.com and ns2.beget.pro.
+var begetNameserverSuffixes = []string{".beget.com", ".beget.pro"} //nolint:gochecknoglobals
+
+// delegationChecker checks the NS records of a domain include the Beget
+// nameservers, since updating the Beget zone is otherwise pointless.
+// A nil lookupNS disables the check.
+type delegationChecker struct {
+	lookupNS func(ctx context.Context, name string) (nameservers []*net.NS, err error)
+	// mutex protects the fields below, since updates and ACME
+	// challenges can check the delegation concurrently.
+	mutex     sync.Mutex
+	checkedAt time.Time
+	err       error
+}
+
+// check returns an error wrapping errors.ErrNotDelegated if the NS records
+// of domain do not include any Beget nameserver. The outcome is kept for
+// delegationCheckPeriod. Lookup failures are ignored, keeping the previous
+// outcome, so that a DNS outage does not fail the updates, and the lookup
+// is retried on the next call.
+func (c *delegationChecker) check(ctx context.Context, domain string) (err error) {
+	if c.lookupNS == nil {
+		return nil
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if time.Since(c.checkedAt) < delegationCheckPeriod {
+		return c.err
+	}
+
+	delegationErr, err := c.lookup(ctx, domain)
+	if err != nil {
+		return c.err
+	}
+	c.err = delegationErr
+	c.checkedAt = time.Now()
+	return c.err
+}
+
+// lookup returns the delegation error, if any, and the error
+// looking up the NS records of domain.
+func (c *delegationChecker) lookup(ctx context.Context, domain string) (
+	delegationErr, err error) {
+	records, err := c.lookupNS(ctx, toASCII(domain))
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make([]string, len(records))
+	for i, record := range records {
+		hosts[i] = strings.ToLower(strings.TrimSuffix(record.Host, "."))
+	}
+
+	delegated := slices.ContainsFunc(hosts, func(host string) bool {
+		return slices.ContainsFunc(begetNameserverSuffixes, func(suffix string) bool {
+			return strings.HasSuffix(host, suffix)
+		})
+	})
+	if !delegated {
+		return fmt.Errorf("%w: %s is served by %s, updating the Beget zone has no effect",
+			errors.ErrNotDelegated, domain, strings.Join(hosts, ", ")), nil
+	}
+	return nil, nil
+}
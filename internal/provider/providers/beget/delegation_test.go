@@ -0,0 +1,47 @@
+package beget
+
+import (
+	"context"
+	stderrors "errors"
+	"net"
+	"testing"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_delegationChecker_check(t *testing.T) {
+	t.Parallel()
+
+	errLookup := stderrors.New("lookup failed")
+	lookups := 0
+	var nameservers []*net.NS
+	var lookupErr error
+	checker := &delegationChecker{
+		lookupNS: func(_ context.Context, _ string) ([]*net.NS, error) {
+			lookups++
+			return nameservers, lookupErr
+		},
+	}
+
+	lookupErr = errLookup
+	assert.NoError(t, checker.check(context.Background(), "example.com"))
+
+	lookupErr = nil
+	nameservers = []*net.NS{{Host: "ns1.example.net."}, {Host: "ns2.example.net."}}
+	err := checker.check(context.Background(), "example.com")
+	assert.ErrorIs(t, err, errors.ErrNotDelegated)
+	assert.EqualError(t, err, "domain is not delegated to the provider nameservers: "+
+		"example.com is served by ns1.example.net, ns2.example.net, updating the Beget zone has no effect")
+
+	// The outcome is kept for the check period.
+	nameservers = []*net.NS{{Host: "NS1.Beget.com."}}
+	assert.ErrorIs(t, checker.check(context.Background(), "example.com"), errors.ErrNotDelegated)
+	assert.Equal(t, 2, lookups)
+
+	checker.checkedAt = checker.checkedAt.Add(-delegationCheckPeriod)
+	assert.NoError(t, checker.check(context.Background(), "example.com"))
+	assert.Equal(t, 3, lookups)
+
+	assert.NoError(t, (&delegationChecker{}).check(context.Background(), "example.com"))
+}
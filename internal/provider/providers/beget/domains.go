@@ -0,0 +1,90 @@
+package beget
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// discoveryPeriod is the period at which the domains and subdomains
+// of the account are discovered again in account wide mode.
+const discoveryPeriod = time.Hour
+
+type domainEntry struct {
+	ID   int64  `json:"id"`
+	FQDN string `json:"fqdn"`
+}
+
+// ListDomains returns the domains of the Beget account.
+// See https://beget.com/en/kb/api/functions-for-work-with-domains#getlist
+func (c *apiClient) ListDomains(ctx context.Context, client *http.Client) (
+	domains []domainEntry, err error) {
+	return c.listDomainEntries(ctx, client, "/api/domain/getList")
+}
+
+// ListSubdomains returns the subdomains of the Beget account.
+// See https://beget.com/en/kb/api/functions-for-work-with-domains#getsubdomainlist
+func (c *apiClient) ListSubdomains(ctx context.Context, client *http.Client) (
+	subdomains []domainEntry, err error) {
+	return c.listDomainEntries(ctx, client, "/api/domain/getSubdomainList")
+}
+
+func (c *apiClient) listDomainEntries(ctx context.Context, client *http.Client,
+	endpoint string) (entries []domainEntry, err error) {
+	responseRaw, err := c.apiCall(ctx, client, endpoint, []byte("{}"))
+	if err != nil {
+		return nil, fmt.Errorf("Calling %s failed: %w", endpoint, err)
+	}
+
+	err = c.redactError(decodeResponse(responseRaw, &entries))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", endpoint, err)
+	}
+	return entries, nil
+}
+
+// listEntries returns the domains and subdomains of the Beget account.
+func (p *Provider) listEntries(ctx context.Context, client *http.Client) (
+	domains, subdomains []domainEntry, err error) {
+	domains, err = p.api.ListDomains(ctx, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	subdomains, err = p.api.ListSubdomains(ctx, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	return domains, subdomains, nil
+}
+
+// refreshFQDNs discovers the FQDNs of the account if the provider is in
+// account wide mode and the last discovery is older than discoveryPeriod.
+func (p *Provider) refreshFQDNs(ctx context.Context, client *http.Client) (err error) {
+	if !p.discover || time.Since(p.discoveredAt) < discoveryPeriod {
+		return nil
+	}
+
+	domains, subdomains, err := p.listEntries(ctx, client)
+	if err != nil {
+		return fmt.Errorf("discovering account domains: %w", err)
+	}
+	fqdns := make([]string, 0, len(domains)+len(subdomains))
+	for _, entry := range slices.Concat(domains, subdomains) {
+		fqdns = append(fqdns, entry.FQDN)
+	}
+	p.mutex.Lock()
+	p.fqdns = fqdns
+	p.mutex.Unlock()
+	p.discoveredAt = time.Now()
+	return nil
+}
+
+// getFQDNs returns a copy of the FQDNs to update, which may be
+// discovered again by an update while the web UI reads them.
+func (p *Provider) getFQDNs() (fqdns []string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return slices.Clone(p.fqdns)
+}
@@ -0,0 +1,90 @@
+package beget
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+)
+
+// entity is the Beget domain or subdomain found to hold the records
+// of an FQDN, and the time it was found at.
+type entity struct {
+	fqdn    string
+	foundAt time.Time
+}
+
+// entityFor returns fqdn if Beget knows it as a domain or subdomain. If
+// entity detection is enabled and getData rejects fqdn as an unknown domain,
+// the labels of fqdn are walked up with getData calls, down to the domain,
+// to find the closest FQDN Beget knows. Since the A and AAAA records of that
+// entity are its own and not the ones of fqdn, they are never written, and an
+// error naming the entity is returned instead. The entities found are kept
+// for the discovery period, and the records read are cached so they are not
+// fetched again before the update.
+func (p *Provider) entityFor(ctx context.Context, client *http.Client,
+	fqdn string) (entityFQDN string, err error) {
+	domain := toASCII(p.domain)
+	if !p.detectEntity || p.discover || p.createMissing ||
+		!strings.HasSuffix(fqdn, "."+domain) {
+		return fqdn, nil
+	}
+
+	p.mutex.Lock()
+	found, ok := p.entities[fqdn]
+	p.mutex.Unlock()
+	if !ok || time.Since(found.foundAt) >= discoveryPeriod {
+		found, err = p.detectEntityOf(ctx, client, fqdn, domain)
+		if err != nil {
+			return "", err
+		}
+		p.mutex.Lock()
+		p.entities[fqdn] = found
+		p.mutex.Unlock()
+	}
+
+	if found.fqdn != fqdn {
+		return "", fmt.Errorf("%w: %s is not known to Beget and its records are held by %s, "+
+			"whose own A and AAAA records are not overwritten: create the subdomain %s "+
+			"or enable create_missing", errors.ErrDomainNotFound, fqdn, found.fqdn, fqdn)
+	}
+	return fqdn, nil
+}
+
+// detectEntityOf walks up the labels of fqdn, down to domain, and returns
+// the first entity Beget knows, caching its records.
+func (p *Provider) detectEntityOf(ctx context.Context, client *http.Client,
+	fqdn, domain string) (found entity, err error) {
+	candidate := fqdn
+	var firstErr error
+	for {
+		records, err := p.api.GetData(ctx, client, candidate)
+		switch {
+		case err == nil:
+			p.cache.set(candidate, records)
+			return entity{fqdn: candidate, foundAt: time.Now()}, nil
+		case !isNoSuchDomain(err):
+			return entity{}, err
+		case firstErr == nil:
+			firstErr = err
+		}
+
+		if candidate == domain {
+			return entity{}, fmt.Errorf("%w: neither %s nor its parent domains are known to Beget: %w",
+				errors.ErrDomainNotFound, fqdn, firstErr)
+		}
+		_, candidate, _ = strings.Cut(candidate, ".")
+	}
+}
+
+// isNoSuchDomain returns true if err is the INVALID_DATA error
+// Beget returns for a domain or subdomain it does not know.
+func isNoSuchDomain(err error) bool {
+	var codeErr *apiCodeError
+	return stderrors.As(err, &codeErr) && codeErr.code == "INVALID_DATA" &&
+		strings.HasPrefix(strings.ToLower(codeErr.text), "no such domain")
+}
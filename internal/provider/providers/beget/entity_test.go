@@ -0,0 +1,105 @@
+package beget
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+	"github.com/qdm12/ddns-updater/internal/provider/providers/beget/begettest"
+	"github.com/qdm12/ddns-updater/pkg/publicip/ipversion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Provider_entityFor(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		domain       string
+		detectEntity bool
+		failure      *begettest.Failure
+		entity       string
+		errWrapped   error
+		errMessage   string
+	}{
+		"entity_itself": {
+			domain:       "example.com",
+			detectEntity: true,
+			entity:       "a.home.example.com",
+		},
+		"parent_subdomain": {
+			domain:       "example.com",
+			detectEntity: true,
+			errWrapped:   errors.ErrDomainNotFound,
+			errMessage: "domain not found: b.a.home.example.com is not known to Beget " +
+				"and its records are held by home.example.com, whose own A and AAAA " +
+				"records are not overwritten: create the subdomain b.a.home.example.com " +
+				"or enable create_missing",
+		},
+		"detection_disabled": {
+			domain: "example.com",
+			entity: "b.a.home.example.com",
+		},
+		"other_bad_request": {
+			domain:       "example.com",
+			detectEntity: true,
+			failure:      &begettest.Failure{Method: "dns/getData", ErrorCode: "INVALID_DATA", Times: 1},
+			errWrapped:   errors.ErrBadRequest,
+			errMessage:   "getData: bad request sent: INVALID_DATA: injected failure",
+		},
+		"not_known": {
+			domain:       "example.net",
+			detectEntity: true,
+			errWrapped:   errors.ErrDomainNotFound,
+			errMessage: "domain not found: neither b.a.home.example.net nor its parent " +
+				"domains are known to Beget: getData: bad request sent: " +
+				"INVALID_DATA: no such domain: b.a.home.example.net",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			subdomains := []string{"home.example.com"}
+			fqdn := "b.a.home." + testCase.domain
+			if name == "entity_itself" {
+				subdomains = append(subdomains, "a.home.example.com")
+				fqdn = "a.home.example.com"
+			}
+			server := begettest.New(begettest.Settings{
+				Domains:    []string{"example.com"},
+				Subdomains: subdomains,
+			})
+			if testCase.failure != nil {
+				server.Fail(*testCase.failure)
+			}
+			httpServer := httptest.NewServer(server)
+			t.Cleanup(httpServer.Close)
+
+			settings, err := json.Marshal(map[string]any{
+				"login":            "login",
+				"password":         "password",
+				"api_url":          httpServer.URL,
+				"retry":            map[string]any{"max_attempts": 1},
+				"rate_limit":       0,
+				"check_delegation": false,
+				"detect_entity":    testCase.detectEntity,
+			})
+			require.NoError(t, err)
+			provider, err := New(settings, testCase.domain, "@", ipversion.IP4, netip.Prefix{})
+			require.NoError(t, err)
+
+			entity, err := provider.entityFor(context.Background(), httpServer.Client(), fqdn)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.Equal(t, testCase.entity, entity)
+		})
+	}
+}
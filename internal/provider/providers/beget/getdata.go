@@ -0,0 +1,48 @@
+package beget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+)
+
+type getDataResult struct {
+	FQDN    string                     `json:"fqdn"`
+	Records map[string]json.RawMessage `json:"records"`
+}
+
+// GetData fetches the current record set of the given FQDN.
+// See https://beget.com/en/kb/api/dns-administration-functions#getdata
+func (c *apiClient) GetData(ctx context.Context, client *http.Client, fqdn string) (
+	records map[string]json.RawMessage, err error) {
+	getDataRequest, err := json.Marshal(map[string]string{"fqdn": fqdn})
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't marshal getData request: %w", err)
+	}
+
+	currentDataRaw, err := c.apiCall(ctx, client, "api/dns/getData", getDataRequest)
+	defer func() { c.status.record("dns/getData", currentDataRaw, err) }()
+	if err != nil {
+		return nil, fmt.Errorf("Calling getData failed: %w", err)
+	}
+
+	var result getDataResult
+	err = c.redactError(decodeResponse(currentDataRaw, &result))
+	if err != nil {
+		return nil, fmt.Errorf("getData: %w", err)
+	}
+
+	if result.FQDN != fqdn {
+		return nil, fmt.Errorf("%w: getData returned records for %q instead of %q",
+			errors.ErrUnknownResponse, result.FQDN, fqdn)
+	}
+
+	records = result.Records
+	if records == nil {
+		records = make(map[string]json.RawMessage)
+	}
+	return records, nil
+}
@@ -0,0 +1,34 @@
+package beget
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// hostSetting is an element of the hosts setting, which is either
+// a host string, or an object with a host and its own record priority.
+type hostSetting struct {
+	Host     string `json:"host"`
+	Priority *int   `json:"priority,omitempty"`
+}
+
+func (h *hostSetting) UnmarshalJSON(data []byte) (err error) {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		type plainHostSetting hostSetting // avoid recursion
+		var plain plainHostSetting
+		err = json.Unmarshal(data, &plain)
+		if err != nil {
+			return fmt.Errorf("decoding host object: %w", err)
+		}
+		*h = hostSetting(plain)
+		return nil
+	}
+
+	*h = hostSetting{}
+	err = json.Unmarshal(data, &h.Host)
+	if err != nil {
+		return fmt.Errorf("decoding host string: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package beget
+
+import "golang.org/x/net/idna"
+
+// idnaProfile converts internationalized domain names for lookups,
+// accepting the "*" and "_acme-challenge" labels used by Beget.
+var idnaProfile = idna.New(idna.MapForLookup(), //nolint:gochecknoglobals
+	idna.StrictDomainName(false), idna.Transitional(false))
+
+// toASCII returns fqdn in lowercase ASCII, with its internationalized
+// labels converted to punycode, such as "пример.рф" to
+// "xn--e1afmkfd.xn--p1ai", which is the form expected by the Beget API.
+// It returns fqdn as it is if it cannot be converted, so that the domain
+// validation reports it.
+func toASCII(fqdn string) string {
+	ascii, err := idnaProfile.ToASCII(fqdn)
+	if err != nil {
+		return fqdn
+	}
+	return ascii
+}
@@ -0,0 +1,53 @@
+package beget
+
+import (
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/metrics"
+)
+
+// apiMetrics instruments the Beget API calls.
+type apiMetrics struct {
+	calls    *metrics.CounterVec
+	duration *metrics.HistogramVec
+}
+
+func newAPIMetrics(registry *metrics.Registry) *apiMetrics {
+	return &apiMetrics{
+		calls: registry.NewCounterVec("beget_api_calls_total",
+			"Beget API calls by endpoint, HTTP status and Beget error code.",
+			"endpoint", "status", "error_code"),
+		duration: registry.NewHistogramVec("beget_api_call_duration_seconds",
+			"Beget API call durations by endpoint.",
+			[]float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}, //nolint:gomnd
+			"endpoint"),
+	}
+}
+
+var defaultAPIMetrics = newAPIMetrics(metrics.Default) //nolint:gochecknoglobals
+
+// observe records an API call attempt to the endpoint, with the HTTP status
+// code of its response, or zero if no response was received, and its body.
+// The endpoint is labelled without its /api/ prefix, such as dns/getData.
+func (m *apiMetrics) observe(endpoint string, statusCode int, body []byte,
+	duration time.Duration) {
+	endpoint = endpointLabel(endpoint)
+	status := "none"
+	if statusCode != 0 {
+		status = strconv.Itoa(statusCode)
+	}
+	var errorCode string
+	if codes := errorCodes(body); len(codes) > 0 {
+		errorCode = codes[0]
+	}
+	m.calls.Inc(endpoint, status, errorCode)
+	m.duration.Observe(duration.Seconds(), endpoint)
+}
+
+// endpointLabel returns the endpoint without its /api/ prefix, such as dns/getData.
+func endpointLabel(endpoint string) string {
+	return strings.TrimPrefix(path.Join("/", endpoint), "/api/")
+}
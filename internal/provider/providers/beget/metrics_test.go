@@ -0,0 +1,37 @@
+package beget
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/qdm12/ddns-updater/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_apiClient_apiCall_metrics(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(&fakeServer{password: "password"})
+	t.Cleanup(server.Close)
+	apiURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	registry := metrics.NewRegistry()
+	client := newAPIClient("login", "password", "", apiURL, retryPolicy{maxAttempts: 1}, nil, nil)
+	client.metrics = newAPIMetrics(registry)
+
+	_, err = client.apiCall(context.Background(), http.DefaultClient,
+		"/api/dns/getData", []byte(`{"fqdn":"example.com"}`))
+	require.NoError(t, err)
+	client.password = "wrong"
+	_, err = client.apiCall(context.Background(), http.DefaultClient,
+		"/api/dns/getData", []byte(`{"fqdn":"example.com"}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, float64(1), client.metrics.calls.Value("dns/getData", "200", ""))
+	assert.Equal(t, float64(1), client.metrics.calls.Value("dns/getData", "200", "AUTH_ERROR"))
+}
@@ -0,0 +1,142 @@
+package beget
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+)
+
+// propagationChecker waits for the IP addresses written to be served
+// by the Beget authoritative nameservers. A zero timeout disables it.
+type propagationChecker struct {
+	timeout     time.Duration
+	interval    time.Duration
+	nameservers []string
+	lookupNetIP func(ctx context.Context, nameserver, network, host string) (
+		ips []netip.Addr, err error)
+}
+
+type propagationSettings struct {
+	Timeout     string   `json:"timeout"`
+	Interval    string   `json:"interval"`
+	Nameservers []string `json:"nameservers"`
+}
+
+func (s propagationSettings) toChecker() (checker propagationChecker, err error) {
+	const defaultInterval = 5 * time.Second
+	checker = propagationChecker{
+		interval:    defaultInterval,
+		nameservers: []string{"ns1.beget.com", "ns2.beget.com"},
+		lookupNetIP: lookupNetIP,
+	}
+
+	if s.Timeout != "" {
+		checker.timeout, err = time.ParseDuration(s.Timeout)
+		if err != nil {
+			return checker, fmt.Errorf("parsing propagation timeout: %w", err)
+		}
+		if checker.timeout < 0 {
+			return checker, fmt.Errorf("%w: propagation timeout %s cannot be negative",
+				errors.ErrTimeoutNotValid, checker.timeout)
+		}
+	}
+
+	if s.Interval != "" {
+		checker.interval, err = time.ParseDuration(s.Interval)
+		if err != nil {
+			return checker, fmt.Errorf("parsing propagation interval: %w", err)
+		}
+		if checker.interval <= 0 {
+			return checker, fmt.Errorf("%w: propagation interval %s must be positive",
+				errors.ErrTimeoutNotValid, checker.interval)
+		}
+	}
+
+	if len(s.Nameservers) > 0 {
+		checker.nameservers = s.Nameservers
+	}
+	for i, nameserver := range checker.nameservers {
+		if _, _, err := net.SplitHostPort(nameserver); err != nil {
+			checker.nameservers[i] = net.JoinHostPort(nameserver, "53")
+		}
+	}
+
+	return checker, nil
+}
+
+// wait returns nil once all the valid IP addresses ips are served for fqdn
+// by all the nameservers, or an error wrapping errors.ErrNotPropagated
+// listing the nameservers not serving them after the timeout.
+func (c propagationChecker) wait(ctx context.Context, fqdn string, ips []netip.Addr) (err error) {
+	if c.timeout == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	for {
+		pending := c.pendingNameservers(ctx, fqdn, ips)
+		if len(pending) == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(c.interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w: after %s: %s is not served yet by %s",
+				errors.ErrNotPropagated, c.timeout, fqdn, strings.Join(pending, ", "))
+		case <-timer.C:
+		}
+	}
+}
+
+// pendingNameservers returns the nameservers not serving all the
+// valid IP addresses ips for fqdn, or failing to answer.
+func (c propagationChecker) pendingNameservers(ctx context.Context,
+	fqdn string, ips []netip.Addr) (pending []string) {
+	for _, nameserver := range c.nameservers {
+		for _, ip := range ips {
+			if !ip.IsValid() {
+				continue
+			}
+			network := "ip4"
+			if ip.Is6() {
+				network = "ip6"
+			}
+			served, err := c.lookupNetIP(ctx, nameserver, network, fqdn)
+			if err != nil || !slices.Contains(served, ip) {
+				pending = append(pending, nameserver)
+				break
+			}
+		}
+	}
+	return pending
+}
+
+// lookupNetIP resolves host directly against the nameserver address.
+func lookupNetIP(ctx context.Context, nameserver, network, host string) (
+	ips []netip.Addr, err error) {
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, nameserver)
+		},
+	}
+	ips, err = resolver.LookupNetIP(ctx, network, host)
+	if err != nil {
+		return nil, err
+	}
+	for i := range ips {
+		ips[i] = ips[i].Unmap()
+	}
+	return ips, nil
+}
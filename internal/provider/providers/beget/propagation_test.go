@@ -0,0 +1,114 @@
+package beget
+
+import (
+	"context"
+	stderrors "errors"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_propagationSettings_toChecker(t *testing.T) {
+	t.Parallel()
+
+	checker, err := propagationSettings{
+		Timeout:     "1m",
+		Nameservers: []string{"ns1.example.com", "192.0.2.1:5353"},
+	}.toChecker()
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, checker.timeout)
+	assert.Equal(t, 5*time.Second, checker.interval)
+	assert.Equal(t, []string{"ns1.example.com:53", "192.0.2.1:5353"}, checker.nameservers)
+
+	_, err = propagationSettings{Interval: "0s"}.toChecker()
+	assert.ErrorIs(t, err, errors.ErrTimeoutNotValid)
+}
+
+func Test_propagationChecker_wait(t *testing.T) {
+	t.Parallel()
+
+	ipv4 := netip.MustParseAddr("1.2.3.4")
+	ipv6 := netip.MustParseAddr("2001:db8::1")
+
+	testCases := map[string]struct {
+		// served maps nameservers and networks to the IP addresses
+		// served after the given number of lookups.
+		served     func(nameserver, network string, lookups int) []netip.Addr
+		errWrapped error
+		errMessage string
+	}{
+		"served_immediately": {
+			served: func(_, network string, _ int) []netip.Addr {
+				if network == "ip6" {
+					return []netip.Addr{ipv6}
+				}
+				return []netip.Addr{ipv4}
+			},
+		},
+		"served_eventually": {
+			served: func(_, network string, lookups int) []netip.Addr {
+				switch {
+				case lookups < 3:
+					return nil
+				case network == "ip6":
+					return []netip.Addr{ipv6}
+				default:
+					return []netip.Addr{ipv4}
+				}
+			},
+		},
+		"never_served_by_ns2": {
+			served: func(nameserver, network string, _ int) []netip.Addr {
+				switch {
+				case nameserver == "ns2:53":
+					return []netip.Addr{netip.MustParseAddr("9.9.9.9")}
+				case network == "ip6":
+					return []netip.Addr{ipv6}
+				default:
+					return []netip.Addr{ipv4}
+				}
+			},
+			errWrapped: errors.ErrNotPropagated,
+			errMessage: "record not propagated: after 50ms: example.com is not served yet by ns2:53",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var mutex sync.Mutex
+			lookups := make(map[string]int)
+			checker := propagationChecker{
+				timeout:     50 * time.Millisecond,
+				interval:    time.Millisecond,
+				nameservers: []string{"ns1:53", "ns2:53"},
+				lookupNetIP: func(_ context.Context, nameserver, network, host string) (
+					ips []netip.Addr, err error) {
+					mutex.Lock()
+					defer mutex.Unlock()
+					if host != "example.com" {
+						return nil, stderrors.New("unexpected host")
+					}
+					key := nameserver + network
+					lookups[key]++
+					return testCase.served(nameserver, network, lookups[key]), nil
+				},
+			}
+
+			err := checker.wait(context.Background(), "example.com",
+				[]netip.Addr{ipv4, ipv6, {}})
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+		})
+	}
+}
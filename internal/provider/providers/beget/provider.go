@@ -4,51 +4,160 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
+	"html"
+	"net"
 	"net/http"
 	"net/netip"
-	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/qdm12/ddns-updater/internal/logging"
 	"github.com/qdm12/ddns-updater/internal/models"
 	"github.com/qdm12/ddns-updater/internal/provider/constants"
 	"github.com/qdm12/ddns-updater/internal/provider/errors"
-	"github.com/qdm12/ddns-updater/internal/provider/headers"
 	"github.com/qdm12/ddns-updater/internal/provider/utils"
+	"github.com/qdm12/ddns-updater/internal/secrets"
 	"github.com/qdm12/ddns-updater/pkg/publicip/ipversion"
+	"github.com/qdm12/log"
 )
 
 type Provider struct {
 	domain string
-	// Note: For some reason ddns-updater strips subdomains from "domain"
-	// We introduce "target", which contains unstripped domain name
-	target   string
-	owner    string
-	login    string
-	password string
-	priority int
-}
-
-type getDataResponse struct {
-	Status string
-	Answer struct {
-		Status string
-		Result struct {
-			FQDN    string `json:"fqdn"`
-			Records map[string]json.RawMessage
-		}
+	// ddns-updater strips subdomains from "domain", so target
+	// contains the FQDN rebuilt from the domain and owner.
+	target string
+	// fqdns contains the FQDNs to update, starting with target
+	// followed by the FQDNs built from the "hosts" setting.
+	// In account wide mode, they are discovered at runtime instead,
+	// so fqdns is protected by mutex, and read with getFQDNs.
+	fqdns        []string
+	discover     bool
+	discoveredAt time.Time
+	owner        string
+	ipVersion    ipversion.IPVersion
+	ipv6Suffix   netip.Prefix
+	dualStack    bool
+	priority     int
+	// priorities maps FQDNs to their priority, if it differs from priority.
+	priorities map[string]int
+	ttl        *uint32
+	recordMode string
+	srv        []srvEntry
+	// staticRecords are enforced on every update of their FQDN.
+	staticRecords []staticRecord
+	// previousIPs maps FQDNs and record types to the last IP
+	// address written or found up to date, used in merge mode.
+	// It is guarded by previousIPsMutex together with historyIPs,
+	// since the record set of an FQDN can be changed by the goroutine
+	// of another provider, see batcher.
+	previousIPsMutex sync.Mutex
+	previousIPs      map[previousIPKey]netip.Addr
+	// historyIPs maps the record types to the last IP address of the
+	// record history, used in merge mode for the FQDNs without previous
+	// IP address since the program started.
+	historyIPs map[string]netip.Addr
+	// createMissing is true if subdomains missing on Beget are created,
+	// and existingFQDNs contains the FQDNs known to exist on Beget.
+	createMissing bool
+	existingFQDNs map[string]struct{}
+	// detectEntity is true if the parent domains of an FQDN unknown to
+	// Beget are looked for, to report the entity holding its records, and
+	// entities maps the FQDNs to the Beget domain or subdomain found for
+	// them. entities is protected by mutex.
+	detectEntity bool
+	entities     map[string]entity
+	mutex        sync.Mutex
+	verify       bool
+	snapshotDir  string
+	auditFile    string
+	dryRun       bool
+	http         httpSettings
+	cache        *recordsCache
+	// accountKey identifies the Beget account, to batch the changes
+	// of the same FQDN with batcher, and to serialize the transactions
+	// of the account with accountLock.
+	accountKey string
+	// apiHost is the host of the Beget API, shared by all the accounts.
+	apiHost     string
+	batcher     *batcher
+	accountLock *sync.Mutex
+	propagation propagationChecker
+	delegation  *delegationChecker
+	api         begetAPI
+	// release releases the circuit breaker and rate limiter shared
+	// with the other providers, and is called once by Release.
+	release     func()
+	releaseOnce sync.Once
+}
+
+// Option is an option to modify the provider created by New.
+type Option func(p *Provider)
+
+// withAPI sets the Beget API implementation used by the provider,
+// and disables the delegation check which does not apply to it.
+func withAPI(api begetAPI) Option {
+	return func(p *Provider) {
+		p.api = api
+		p.delegation = &delegationChecker{}
 	}
 }
 
-func New(data json.RawMessage, domain, owner string) (
-	p *Provider, err error) {
+type previousIPKey struct {
+	fqdn       string
+	recordType string
+}
+
+const (
+	recordModeReplace = "replace"
+	recordModeMerge   = "merge"
+)
+
+func New(data json.RawMessage, domain, owner string,
+	ipVersion ipversion.IPVersion, ipv6Suffix netip.Prefix,
+	options ...Option) (p *Provider, err error) {
 	// TODO: is "owner" argument really needed?
 
 	extraSettings := struct {
-		Login    string `json:"login"`
-		Password string `json:"password"`
-		// "domain" arg has subdomains stripped, so parse it again
-		Domain   string `json:"domain"`
-		Priority int    `json:"priority"`
+		Login        string `json:"login"`
+		LoginFile    string `json:"login_file"`
+		Password     string `json:"password"`
+		PasswordFile string `json:"password_file"`
+		// Auth is the authentication mode, "password" by default,
+		// or "token" to authenticate with Token instead of Password.
+		Auth      string `json:"auth"`
+		Token     string `json:"token"`
+		TokenFile string `json:"token_file"`
+		// Domain is the legacy FQDN setting, now optional since the FQDN
+		// is rebuilt from the domain and owner arguments. It can still
+		// be set, to "*" for the account wide mode, or to that FQDN.
+		Domain          string              `json:"domain"`
+		Priority        *int                `json:"priority"`
+		DualStack       bool                `json:"dual_stack"`
+		TTL             *uint32             `json:"ttl,omitempty"`
+		RecordMode      string              `json:"record_mode"`
+		Hosts           []hostSetting       `json:"hosts"`
+		Aliases         []string            `json:"aliases"`
+		Retry           retrySettings       `json:"retry"`
+		APIURL          string              `json:"api_url"`
+		CreateMissing   bool                `json:"create_missing"`
+		Verify          *bool               `json:"verify"`
+		SnapshotDir     string              `json:"snapshot_dir"`
+		AuditFile       string              `json:"audit_file"`
+		DryRun          bool                `json:"dry_run"`
+		CacheTTL        string              `json:"cache_ttl"`
+		Propagation     propagationSettings `json:"propagation"`
+		SRV             []srvEntry          `json:"srv"`
+		StaticRecords   []staticRecord      `json:"static_records"`
+		RateLimit       *uint               `json:"rate_limit"`
+		CircuitBreaker  circuitSettings     `json:"circuit_breaker"`
+		Debug           bool                `json:"debug"`
+		CheckDelegation *bool               `json:"check_delegation"`
+		DetectEntity    *bool               `json:"detect_entity"`
+		// transportSettings has the settings of the HTTP client.
+		transportSettings
 	}{}
 
 	err = json.Unmarshal(data, &extraSettings)
@@ -56,27 +165,314 @@ func New(data json.RawMessage, domain, owner string) (
 		return nil, err
 	}
 
-	// validating settings
-	err = utils.CheckDomain(extraSettings.Domain)
+	login, err := secrets.Resolve("login", extraSettings.Login,
+		extraSettings.LoginFile, os.LookupEnv)
+	if err != nil {
+		return nil, fmt.Errorf("validating provider specific settings: %w", err)
+	}
+	password, err := secrets.Resolve("password", extraSettings.Password,
+		extraSettings.PasswordFile, os.LookupEnv)
+	if err != nil {
+		return nil, fmt.Errorf("validating provider specific settings: %w", err)
+	}
+	token, err := secrets.Resolve("token", extraSettings.Token,
+		extraSettings.TokenFile, os.LookupEnv)
+	if err != nil {
+		return nil, fmt.Errorf("validating provider specific settings: %w", err)
+	}
+
+	if extraSettings.Auth == "" {
+		extraSettings.Auth = authPassword
+	}
+	err = validateAuth(extraSettings.Auth, login, password, token)
+	if err != nil {
+		return nil, fmt.Errorf("validating provider specific settings: %w", err)
+	}
+
+	if extraSettings.RecordMode == "" {
+		extraSettings.RecordMode = recordModeReplace
+	}
+
+	retry, err := extraSettings.Retry.toPolicy()
+	if err != nil {
+		return nil, fmt.Errorf("validating provider specific settings: %w", err)
+	}
+
+	httpSettings, err := parseHTTPSettings(extraSettings.transportSettings)
+	if err != nil {
+		return nil, fmt.Errorf("validating provider specific settings: %w", err)
+	}
+
+	var cacheTTL time.Duration
+	if extraSettings.CacheTTL != "" {
+		cacheTTL, err = time.ParseDuration(extraSettings.CacheTTL)
+		if err != nil {
+			return nil, fmt.Errorf("validating provider specific settings: parsing cache TTL: %w", err)
+		}
+	}
+
+	propagation, err := extraSettings.Propagation.toChecker()
 	if err != nil {
-		return nil, fmt.Errorf("%w: %w", errors.ErrDomainNotValid, err)
+		return nil, fmt.Errorf("validating provider specific settings: %w", err)
 	}
 
-	return &Provider{
-		domain:   domain,
-		target:   extraSettings.Domain,
-		priority: extraSettings.Priority,
-		owner:    owner,
-		login:    extraSettings.Login,
-		password: extraSettings.Password,
-	}, nil
+	apiURL, err := parseAPIURL(extraSettings.APIURL)
+	if err != nil {
+		return nil, fmt.Errorf("validating provider specific settings: %w", err)
+	}
 
+	target, err := resolveTarget(domain, owner, extraSettings.Domain)
+	if err != nil {
+		return nil, fmt.Errorf("validating provider specific settings: %w", err)
+	}
+
+	// A domain of "*" designates all the domains of the account,
+	// which are then discovered with the Beget API.
+	discover := target == "*"
+	var fqdns []string
+	var priorities map[string]int
+	if !discover {
+		fqdns, priorities = buildFQDNs(target, domain, extraSettings.Hosts, extraSettings.Aliases)
+	}
+
+	const defaultPriority = 10
+	priority := defaultPriority
+	if extraSettings.Priority != nil {
+		priority = *extraSettings.Priority
+	}
+
+	err = validateSettings(fqdns, discover, extraSettings.Hosts, extraSettings.Aliases,
+		extraSettings.TTL, extraSettings.RecordMode, priority)
+	if err != nil {
+		return nil, fmt.Errorf("validating provider specific settings: %w", err)
+	}
+
+	err = validateSRVEntries(extraSettings.SRV)
+	if err != nil {
+		return nil, fmt.Errorf("validating provider specific settings: %w", err)
+	}
+
+	err = validateStaticRecords(extraSettings.StaticRecords, target, fqdns)
+	if err != nil {
+		return nil, fmt.Errorf("validating provider specific settings: %w", err)
+	}
+
+	if extraSettings.DualStack {
+		switch ipVersion {
+		case ipversion.IP4or6, ipversion.IP4:
+			// Dual stack records are tracked as IPv4 records, and
+			// the IPv6 address is passed along to UpdateDualStack.
+			ipVersion = ipversion.IP4
+		default:
+			return nil, fmt.Errorf("%w: %s", errors.ErrDualStackIPVersion, ipVersion)
+		}
+	}
+
+	const defaultRateLimit = 60
+	rateLimit := uint(defaultRateLimit)
+	if extraSettings.RateLimit != nil {
+		rateLimit = *extraSettings.RateLimit
+	}
+	accountKey := apiURL.String() + " " + accountIdentity(login, token)
+
+	circuitThreshold, circuitCooldown, err := extraSettings.CircuitBreaker.parse()
+	if err != nil {
+		return nil, fmt.Errorf("validating provider specific settings: %w", err)
+	}
+
+	delegation := &delegationChecker{}
+	if extraSettings.CheckDelegation == nil || *extraSettings.CheckDelegation {
+		delegation.lookupNS = net.DefaultResolver.LookupNS
+	}
+
+	limiter, releaseLimiter := defaultRateLimiters.get(accountKey, rateLimit)
+	// The circuit breaker opened by wrong credentials does
+	// not apply to the providers with other credentials.
+	breaker, releaseBreaker := defaultCircuitBreakers.get(
+		accountKey+" "+credentialsFingerprint(password, token),
+		circuitThreshold, circuitCooldown)
+	client := newAPIClient(login, password, token, apiURL, retry, limiter, breaker)
+	if extraSettings.Debug {
+		client.logger = log.New(append(logging.Options(),
+			log.SetLevel(log.LevelDebug), log.SetComponent("beget"))...)
+	}
+
+	p = &Provider{
+		domain:        domain,
+		target:        target,
+		fqdns:         fqdns,
+		discover:      discover,
+		priority:      priority,
+		priorities:    priorities,
+		owner:         owner,
+		ipVersion:     ipVersion,
+		ipv6Suffix:    ipv6Suffix,
+		dualStack:     extraSettings.DualStack,
+		ttl:           extraSettings.TTL,
+		recordMode:    extraSettings.RecordMode,
+		srv:           extraSettings.SRV,
+		staticRecords: extraSettings.StaticRecords,
+		previousIPs:   make(map[previousIPKey]netip.Addr),
+		createMissing: extraSettings.CreateMissing,
+		existingFQDNs: make(map[string]struct{}),
+		detectEntity:  extraSettings.DetectEntity != nil && *extraSettings.DetectEntity,
+		entities:      make(map[string]entity),
+		verify:        extraSettings.Verify == nil || *extraSettings.Verify,
+		snapshotDir:   extraSettings.SnapshotDir,
+		auditFile:     extraSettings.AuditFile,
+		dryRun:        extraSettings.DryRun,
+		http:          httpSettings,
+		cache:         newRecordsCache(cacheTTL),
+		accountKey:    accountKey,
+		apiHost:       apiURL.Host,
+		batcher:       defaultBatcher,
+		propagation:   propagation,
+		delegation:    delegation,
+		api:           client,
+		release: func() {
+			releaseLimiter()
+			releaseBreaker()
+		},
+	}
+	p.accountLock = defaultAccountLocks.get(p.accountKey)
+	for _, option := range options {
+		option(p)
+	}
+	return p, nil
+}
+
+// resolveTarget returns the FQDN to update, built from the domain and owner
+// given to New, since ddns-updater strips the subdomains from the domain.
+// The "domain" setting is read again as legacyDomain to detect the account
+// wide mode "*". Otherwise, one of its comma separated values must match
+// the FQDN built, or the domain given if the owner is set with the
+// retro-compatible "owner" setting. Internationalized domain names are
+// converted to punycode, see toASCII.
+func resolveTarget(domain, owner, legacyDomain string) (target string, err error) {
+	target = toASCII(utils.BuildURLQueryHostname(owner, domain))
+	switch legacyDomain {
+	case "":
+		return target, nil
+	case "*":
+		return legacyDomain, nil
+	}
+
+	for _, value := range strings.Split(legacyDomain, ",") {
+		value = strings.TrimSuffix(strings.TrimSpace(value), ".")
+		value = toASCII(value)
+		if value == target || value == toASCII(domain) {
+			return target, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q set in the provider settings does not match "+
+		"%q built from the domain and owner",
+		errors.ErrDomainNotValid, legacyDomain, target)
+}
+
+// buildFQDNs returns the FQDNs to update, starting with target followed
+// by each host relative to target, where "@" designates target itself,
+// and then by each alias owner relative to the registered domain, such
+// as "www" or "@" for the apex. FQDNs given more than once are only kept
+// once, so their record set is fetched and written once per update.
+// It also returns the priorities set for specific hosts, by FQDN.
+func buildFQDNs(target, domain string, hosts []hostSetting, aliases []string) (
+	fqdns []string, priorities map[string]int) {
+	fqdns = make([]string, 0, 1+len(hosts)+len(aliases))
+	fqdns = append(fqdns, target)
+	priorities = make(map[string]int)
+	for _, host := range hosts {
+		fqdn := toASCII(utils.BuildURLQueryHostname(host.Host, target))
+		if host.Priority != nil {
+			priorities[fqdn] = *host.Priority
+		}
+		if slices.Contains(fqdns, fqdn) {
+			continue
+		}
+		fqdns = append(fqdns, fqdn)
+	}
+	for _, alias := range aliases {
+		fqdn := toASCII(utils.BuildURLQueryHostname(alias, domain))
+		if slices.Contains(fqdns, fqdn) {
+			continue
+		}
+		fqdns = append(fqdns, fqdn)
+	}
+	return fqdns, priorities
+}
+
+func validateSettings(fqdns []string, discover bool, hosts []hostSetting,
+	aliases []string, ttl *uint32, recordMode string, priority int) (err error) {
+	switch {
+	case discover && len(hosts) > 0:
+		return fmt.Errorf("%w: hosts cannot be set with the account wide domain \"*\"",
+			errors.ErrDomainNotValid)
+	case discover && len(aliases) > 0:
+		return fmt.Errorf("%w: aliases cannot be set with the account wide domain \"*\"",
+			errors.ErrDomainNotValid)
+	}
+
+	err = validatePriority(priority)
+	if err != nil {
+		return err
+	}
+	for _, host := range hosts {
+		if host.Priority == nil {
+			continue
+		}
+		err = validatePriority(*host.Priority)
+		if err != nil {
+			return fmt.Errorf("for host %s: %w", host.Host, err)
+		}
+	}
+
+	for _, fqdn := range fqdns {
+		err = utils.CheckDomain(fqdn)
+		if err != nil {
+			return fmt.Errorf("%w: %w", errors.ErrDomainNotValid, err)
+		}
+	}
+
+	// Range of TTL values accepted by the Beget DNS control panel.
+	const minTTL, maxTTL = uint32(300), uint32(86400)
+	switch {
+	case ttl != nil && *ttl < minTTL:
+		return fmt.Errorf("%w: %d must be at least %d",
+			errors.ErrTTLTooLow, *ttl, minTTL)
+	case ttl != nil && *ttl > maxTTL:
+		return fmt.Errorf("%w: %d must be at most %d",
+			errors.ErrTTLTooHigh, *ttl, maxTTL)
+	case recordMode != recordModeReplace && recordMode != recordModeMerge:
+		return fmt.Errorf("%w: %q must be one of %q or %q",
+			errors.ErrRecordModeNotValid, recordMode, recordModeReplace, recordModeMerge)
+	default:
+		return nil
+	}
+}
+
+// validatePriority checks priority fits in the 16 bits unsigned
+// integer range of DNS priorities, as accepted by Beget.
+func validatePriority(priority int) (err error) {
+	const minPriority, maxPriority = 0, 65535
+	if priority < minPriority || priority > maxPriority {
+		return fmt.Errorf("%w: %d must be between %d and %d",
+			errors.ErrPriorityNotValid, priority, minPriority, maxPriority)
+	}
+	return nil
+}
+
+// priorityOf returns the priority of the records of fqdn.
+func (p *Provider) priorityOf(fqdn string) int {
+	priority, ok := p.priorities[fqdn]
+	if !ok {
+		return p.priority
+	}
+	return priority
 }
 
 // Next few functions were blindly adapted from other provider.go files.
 
 func (p *Provider) String() string {
-	return utils.ToString(p.domain, p.owner, constants.Beget, ipversion.IP4)
+	return utils.ToString(p.domain, p.owner, constants.Beget, p.ipVersion)
 }
 
 func (p *Provider) Domain() string {
@@ -88,141 +484,134 @@ func (p *Provider) Owner() string {
 }
 
 func (p *Provider) IPVersion() ipversion.IPVersion {
-	return ipversion.IP4
+	return p.ipVersion
+}
+
+func (p *Provider) ipVersionString() string {
+	if p.dualStack {
+		return "ipv4 and ipv6"
+	}
+	return p.ipVersion.String()
 }
 
 func (p *Provider) IPv6Suffix() netip.Prefix {
-	return netip.Prefix{}
+	return p.ipv6Suffix
 }
 
 func (p *Provider) Proxied() bool {
 	return false
 }
 
+// SkipLookup returns true in account wide mode, since the "*" domain
+// cannot be resolved to determine if an update is needed. Each discovered
+// FQDN is checked against its getData record set instead.
+func (p *Provider) SkipLookup() bool {
+	return p.discover
+}
+
+// Release releases the circuit breaker and rate limiter shared with the
+// other providers of the Beget account, once the provider is replaced,
+// for example by a config reload, so that only the settings of the
+// providers still in use apply to them. It can be called more than once.
+func (p *Provider) Release() {
+	if p.release == nil { // provider not created with New
+		return
+	}
+	p.releaseOnce.Do(p.release)
+}
+
+// SetPreviousIPs sets the last IP addresses of the record history, so that
+// the entry written by a previous run is found again in merge mode. It must
+// be called before any update.
+func (p *Provider) SetPreviousIPs(ips []netip.Addr) {
+	p.previousIPsMutex.Lock()
+	defer p.previousIPsMutex.Unlock()
+	p.historyIPs = make(map[string]netip.Addr, len(ips))
+	for _, ip := range ips {
+		p.historyIPs[ipRecordType(ip)] = ip
+	}
+}
+
 func (p *Provider) BuildDomainName() string {
 	return utils.BuildDomainName(p.owner, p.domain)
 }
 
 func (p *Provider) HTML() models.HTMLRow {
+	domain := p.BuildDomainName()
+	if domain != "*" { // all the domains of the account cannot be linked to
+		domain = fmt.Sprintf("<a href=\"http://%s\">%s</a>", domain, domain)
+	}
 	return models.HTMLRow{
-		Domain:    fmt.Sprintf("<a href=\"http://%s\">%s</a>", p.BuildDomainName(), p.BuildDomainName()),
+		Domain:    domain,
 		Owner:     p.Owner(),
 		Provider:  "<a href=\"https://beget.com\">Beget</a>",
-		IPVersion: ipversion.IP4.String(),
+		IPVersion: p.ipVersionString(),
+		Details:   p.htmlDetails(),
 	}
 }
 
-// apiCall performs authenticated GET request to the given URLEndpoint of api.beget.com
-// with given inputJSON as input_data and returns resulting json as []byte.
-func (p *Provider) apiCall(ctx context.Context, client *http.Client, URLEndpoint string, inputJSON []byte) ([]byte, error) {
-	u := url.URL{
-		Scheme: "https",
-		Host:   "api.beget.com",
-		Path:   URLEndpoint,
-	}
-
-	v := url.Values{}
-	v.Set("login", p.login)
-	v.Set("passwd", p.password)
-	v.Set("input_format", "json")
-	v.Set("output_format", "json")
-	v.Set("input_data", string(inputJSON))
-	u.RawQuery = v.Encode()
-
-	request, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
-	if err != nil {
-		return []byte{}, fmt.Errorf("%s: Failed creating HTTP request: %w", u.Path, err)
+// htmlDetails returns the FQDNs updated with their priority, the TTL
+// and the last Beget API error, if any, to be shown in the web UI.
+func (p *Provider) htmlDetails() string {
+	var lines []string
+	if p.discover {
+		lines = append(lines, "FQDNs: all of the account")
+	} else {
+		fqdns := p.getFQDNs()
+		for i, fqdn := range fqdns {
+			priority, ok := p.priorities[fqdn]
+			if !ok {
+				priority = p.priority
+			}
+			fqdns[i] = fmt.Sprintf("%s (priority %d)", fqdn, priority)
+		}
+		lines = append(lines, "FQDNs: "+strings.Join(fqdns, ", "))
 	}
-	headers.SetUserAgent(request)
-	headers.SetAccept(request, "application/json")
 
-	response, err := client.Do(request)
-	if err != nil {
-		return []byte{}, fmt.Errorf("%s: Failed performing HTTP request: %w", u.Path, err)
+	ttl := "Beget default"
+	if p.ttl != nil {
+		ttl = fmt.Sprint(*p.ttl) + "s"
 	}
-	defer response.Body.Close()
+	lines = append(lines, "TTL: "+ttl)
 
-	b, err := io.ReadAll(response.Body)
-	if err != nil {
-		return []byte{}, fmt.Errorf("%s: Failed reading response body: %w", u.Path, err)
+	for i := range lines {
+		lines[i] = html.EscapeString(lines[i])
 	}
-
-	if response.StatusCode != http.StatusOK {
-		return b, fmt.Errorf("%s: HTTP status is %d", u.Path, response.StatusCode)
+	if status := p.LastAPIStatus(); status.Error != "" {
+		lines = append(lines, `<span class="error">Last API error: `+
+			html.EscapeString(status.String())+"</span>")
 	}
-
-	return b, nil
+	return strings.Join(lines, "<br>")
 }
 
 func (p *Provider) Update(ctx context.Context, client *http.Client, ip netip.Addr) (newIP netip.Addr, err error) {
-	// Beget API DNS administration docs: https://beget.com/en/kb/api/dns-administration-functions
-
-	// Before we call Beget API's /api/dns/changeRecords method, we need to fetch current DNS
-	// configuration as setting A record alone will clear all other records for this domain.
-	// This behavior is undocumented.
-
-	// Part 1: getData
-	getDataRequest, err := json.Marshal(map[string]string{"fqdn": p.target})
-	if err != nil {
-		return netip.Addr{}, fmt.Errorf("Couldn't marshal getData request: %w", err)
-	}
-
-	currentDataRaw, err := p.apiCall(ctx, client, "api/dns/getData", getDataRequest)
-	if err != nil {
-		return netip.Addr{}, fmt.Errorf("Calling getData failed: %w", err)
-	}
-
-	currentDataStruct := getDataResponse{}
-	err = json.Unmarshal(currentDataRaw, &currentDataStruct)
+	err = p.updateAll(ctx, p.http.wrapClient(client), ip)
 	if err != nil {
-		return netip.Addr{}, fmt.Errorf("Failed unmarshalling getData response: %w", err)
+		return netip.Addr{}, err
 	}
+	return ip, nil
+}
 
-	if currentDataStruct.Status != "success" || currentDataStruct.Answer.Status != "success" || currentDataStruct.Answer.Result.FQDN != p.target {
-		return netip.Addr{}, fmt.Errorf("getData response doesn't indicate success")
-	}
-
-	// Part 2: changeRecords
-	// Preparing request
-	inputData := struct {
-		FQDN    string                     `json:"fqdn"`
-		Records map[string]json.RawMessage `json:"records"`
-	}{
-		FQDN:    p.target,
-		Records: currentDataStruct.Answer.Result.Records,
-	}
-	newAEntryJSON, err := json.Marshal(
-		[]struct {
-			Priority int    `json:"priority"`
-			Value    string `json:"value"`
-		}{{Priority: p.priority, Value: ip.String()}})
-	if err != nil {
-		return netip.Addr{}, fmt.Errorf("Couldn't marshal new A entry JSON: %w", err)
-	}
-	inputData.Records["A"] = json.RawMessage(newAEntryJSON)
-
-	inputDataRaw, err := json.Marshal(inputData)
-	if err != nil {
-		return netip.Addr{}, fmt.Errorf("Couldn't marshal json: %w", err)
-	}
+// ConcurrencyScope returns the Beget API host and the account of the
+// provider, so the updates of the same account are serialized.
+func (p *Provider) ConcurrencyScope() (host, account string) {
+	return p.apiHost, p.accountKey
+}
 
-	// Calling API & parsing response
-	changeRecordsResponseRaw, err := p.apiCall(ctx, client, "/api/dns/changeRecords", inputDataRaw)
+// DualStack returns true if both the A and AAAA records
+// are to be updated together by UpdateDualStack.
+func (p *Provider) DualStack() bool {
+	return p.dualStack
+}
 
-	result := struct {
-		Status string
-		Answer struct {
-			Status string
-			Result bool
-		}
-	}{}
-	err = json.Unmarshal(changeRecordsResponseRaw, &result)
+// UpdateDualStack updates both the A and AAAA records with a single
+// getData and changeRecords pair. An invalid ipv4 or ipv6 leaves the
+// corresponding record untouched.
+func (p *Provider) UpdateDualStack(ctx context.Context, client *http.Client,
+	ipv4, ipv6 netip.Addr) (newIPv4, newIPv6 netip.Addr, err error) {
+	err = p.updateAll(ctx, p.http.wrapClient(client), ipv4, ipv6)
 	if err != nil {
-		return netip.Addr{}, fmt.Errorf("Failed unmarshalling changeRecords response: %w", err)
-	}
-
-	if result.Status != "success" || result.Answer.Status != "success" {
-		return netip.Addr{}, fmt.Errorf("changeRequest response doesn't indicate success: %s", utils.ToSingleLine(string(changeRecordsResponseRaw)))
+		return netip.Addr{}, netip.Addr{}, err
 	}
-	return ip, nil
+	return ipv4, ipv6, nil
 }
@@ -0,0 +1,826 @@
+package beget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+	"github.com/qdm12/ddns-updater/pkg/publicip/ipversion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer is a minimal Beget API server holding
+// the record sets of its FQDNs in memory.
+type fakeServer struct {
+	mutex          sync.Mutex
+	password       string
+	records        map[string]map[string]json.RawMessage
+	changeRequests int
+	// rawResponse, if set, is written as is for every request.
+	rawResponse string
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.rawResponse != "" {
+		_, _ = w.Write([]byte(s.rawResponse))
+		return
+	}
+
+	if r.Method != http.MethodPost || r.FormValue("passwd") != s.password {
+		_, _ = w.Write([]byte(`{"status":"error","error_code":"AUTH_ERROR","error_text":"No such user"}`))
+		return
+	}
+
+	var input struct {
+		FQDN    string                     `json:"fqdn"`
+		Records map[string]json.RawMessage `json:"records"`
+	}
+	err := json.Unmarshal([]byte(r.FormValue("input_data")), &input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var result any
+	switch r.URL.Path {
+	case "/api/dns/getData":
+		result = getDataResult{FQDN: input.FQDN, Records: s.records[input.FQDN]}
+	case "/api/dns/changeRecords":
+		s.changeRequests++
+		s.records[input.FQDN] = input.Records
+		result = true
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, _ = w.Write([]byte(`{"status":"success","answer":{"status":"success","result":` +
+		string(resultJSON) + `}}`))
+}
+
+func newTestProvider(t *testing.T, apiURL, password string, ipVersion ipversion.IPVersion) *Provider {
+	t.Helper()
+	settings, err := json.Marshal(map[string]any{
+		"domain":   "sub.example.com",
+		"login":    "login",
+		"password": password,
+		"priority": 10,
+		"api_url":  apiURL,
+		"retry":    map[string]any{"max_attempts": 1},
+		// The domain is served by the fake server only.
+		"check_delegation": false,
+	})
+	require.NoError(t, err)
+	provider, err := New(settings, "example.com", "sub", ipVersion, netip.Prefix{})
+	require.NoError(t, err)
+	return provider
+}
+
+func Test_Provider_Update(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		password       string
+		rawResponse    string
+		records        map[string]json.RawMessage
+		ip             netip.Addr
+		expectedIP     netip.Addr
+		changeRequests int
+		expectedTypes  map[string]string
+		errWrapped     error
+		errMessage     string
+	}{
+		"success_preserves_other_records": {
+			password: "password",
+			records: map[string]json.RawMessage{
+				"A":    json.RawMessage(`[{"address":"1.1.1.1","ttl":600}]`),
+				"AAAA": json.RawMessage(`[{"address":"::1","ttl":600}]`),
+				"MX":   json.RawMessage(`[{"exchange":"mx.example.com","preference":10}]`),
+			},
+			ip:             netip.MustParseAddr("2.2.2.2"),
+			expectedIP:     netip.MustParseAddr("2.2.2.2"),
+			changeRequests: 1,
+			expectedTypes: map[string]string{
+				"A":    `[{"priority":10,"value":"2.2.2.2"}]`,
+				"AAAA": `[{"address":"::1","ttl":600}]`,
+				"MX":   `[{"exchange":"mx.example.com","preference":10}]`,
+			},
+		},
+		"aaaa_keeps_a_record": {
+			password: "password",
+			records: map[string]json.RawMessage{
+				"A": json.RawMessage(`[{"address":"1.1.1.1","ttl":600}]`),
+			},
+			ip:             netip.MustParseAddr("2001:db8::1"),
+			expectedIP:     netip.MustParseAddr("2001:db8::1"),
+			changeRequests: 1,
+			expectedTypes: map[string]string{
+				"A":    `[{"address":"1.1.1.1","ttl":600}]`,
+				"AAAA": `[{"priority":10,"value":"2001:db8::1"}]`,
+			},
+		},
+		"up_to_date": {
+			password: "password",
+			records: map[string]json.RawMessage{
+				"A": json.RawMessage(`[{"address":"2.2.2.2","priority":10,"ttl":600}]`),
+			},
+			ip:             netip.MustParseAddr("2.2.2.2"),
+			expectedIP:     netip.MustParseAddr("2.2.2.2"),
+			changeRequests: 0,
+			expectedTypes: map[string]string{
+				"A": `[{"address":"2.2.2.2","priority":10,"ttl":600}]`,
+			},
+		},
+		"auth_failure": {
+			password:   "wrong",
+			records:    map[string]json.RawMessage{},
+			ip:         netip.MustParseAddr("2.2.2.2"),
+			errWrapped: errors.ErrAuth,
+			errMessage: "sub.example.com: getData: bad authentication: AUTH_ERROR: No such user",
+		},
+		"malformed_json": {
+			password:    "password",
+			rawResponse: "not json",
+			ip:          netip.MustParseAddr("2.2.2.2"),
+			errMessage: "sub.example.com: getData: Failed unmarshalling response: " +
+				"invalid character 'o' in literal null (expecting 'u')",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := &fakeServer{
+				password:    "password",
+				rawResponse: testCase.rawResponse,
+				records: map[string]map[string]json.RawMessage{
+					"sub.example.com": testCase.records,
+				},
+			}
+			httpServer := httptest.NewServer(server)
+			t.Cleanup(httpServer.Close)
+
+			provider := newTestProvider(t, httpServer.URL, testCase.password, ipversion.IP4or6)
+
+			newIP, err := provider.Update(context.Background(), httpServer.Client(), testCase.ip)
+
+			if testCase.errWrapped != nil {
+				assert.ErrorIs(t, err, testCase.errWrapped)
+			}
+			if testCase.errMessage != "" {
+				assert.EqualError(t, err, testCase.errMessage)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expectedIP, newIP)
+			assert.Equal(t, testCase.changeRequests, server.changeRequests)
+
+			records := server.records["sub.example.com"]
+			require.Len(t, records, len(testCase.expectedTypes))
+			for recordType, expected := range testCase.expectedTypes {
+				assert.JSONEq(t, expected, string(records[recordType]), recordType)
+			}
+		})
+	}
+}
+
+// fakeAPI is a begetAPI implementation holding a single record set.
+type fakeAPI struct {
+	records    map[string]json.RawMessage
+	changed    map[string]json.RawMessage
+	domains    []domainEntry
+	subdomains []domainEntry
+	added      []string
+	getData    int
+	// changeErrs are the errors returned by the next ChangeRecords calls.
+	changeErrs []error
+}
+
+func (f *fakeAPI) GetData(_ context.Context, _ *http.Client, _ string) (
+	records map[string]json.RawMessage, err error) {
+	f.getData++
+	records = make(map[string]json.RawMessage, len(f.records))
+	for recordType, raw := range f.records {
+		records[recordType] = raw
+	}
+	return records, nil
+}
+
+func (f *fakeAPI) ChangeRecords(_ context.Context, _ *http.Client, _ string,
+	records map[string]json.RawMessage) (err error) {
+	if len(f.changeErrs) > 0 {
+		err, f.changeErrs = f.changeErrs[0], f.changeErrs[1:]
+		if err != nil {
+			return err
+		}
+	}
+	f.changed = records
+	f.records = records
+	return nil
+}
+
+func (f *fakeAPI) ListDomains(_ context.Context, _ *http.Client) (domains []domainEntry, err error) {
+	return f.domains, nil
+}
+
+func (f *fakeAPI) ListSubdomains(_ context.Context, _ *http.Client) (subdomains []domainEntry, err error) {
+	return f.subdomains, nil
+}
+
+func (f *fakeAPI) AddSubdomain(_ context.Context, _ *http.Client, subdomain string, domainID int64) (err error) {
+	f.added = append(f.added, fmt.Sprintf("%s:%d", subdomain, domainID))
+	return nil
+}
+
+func Test_Provider_Update_merge(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		records    string
+		historyIPs []netip.Addr
+		expected   string
+		notChanged bool
+	}{
+		"entry_with_priority": {
+			records:  `[{"address":"1.1.1.1","priority":10},{"address":"3.3.3.3","priority":20}]`,
+			expected: `[{"priority":10,"value":"2.2.2.2"},{"priority":20,"value":"3.3.3.3"}]`,
+		},
+		"entry_of_history_ip": {
+			records:    `[{"address":"1.1.1.1","priority":5},{"address":"3.3.3.3","priority":10}]`,
+			historyIPs: []netip.Addr{netip.MustParseAddr("1.1.1.1")},
+			expected:   `[{"priority":10,"value":"2.2.2.2"},{"priority":10,"value":"3.3.3.3"}]`,
+		},
+		"own_entry_up_to_date": {
+			records:    `[{"address":"2.2.2.2","priority":5},{"address":"3.3.3.3","priority":10}]`,
+			historyIPs: []netip.Addr{netip.MustParseAddr("2.2.2.2")},
+			notChanged: true,
+		},
+		"other_host_entry_with_same_ip": {
+			records:  `[{"address":"2.2.2.2","priority":20}]`,
+			expected: `[{"priority":20,"value":"2.2.2.2"},{"priority":10,"value":"2.2.2.2"}]`,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			api := &fakeAPI{
+				records: map[string]json.RawMessage{
+					"A": json.RawMessage(testCase.records),
+				},
+			}
+			settings := json.RawMessage(`{"login":"login","password":"password",` +
+				`"domain":"example.com","priority":10,"record_mode":"merge"}`)
+			provider, err := New(settings, "example.com", "@", ipversion.IP4, netip.Prefix{}, withAPI(api))
+			require.NoError(t, err)
+			provider.SetPreviousIPs(testCase.historyIPs)
+
+			_, err = provider.Update(context.Background(), nil, netip.MustParseAddr("2.2.2.2"))
+			require.NoError(t, err)
+
+			if testCase.notChanged {
+				assert.Nil(t, api.changed)
+				return
+			}
+			assert.JSONEq(t, testCase.expected, string(api.changed["A"]))
+		})
+	}
+}
+
+func Test_Provider_Update_replace(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		records    string
+		notChanged bool
+	}{
+		"up_to_date": {
+			records:    `[{"address":"2.2.2.2","priority":10,"ttl":600}]`,
+			notChanged: true,
+		},
+		"ttl_differs": {
+			records: `[{"address":"2.2.2.2","priority":10,"ttl":300}]`,
+		},
+		"ttl_not_set": {
+			records: `[{"address":"2.2.2.2","priority":10}]`,
+		},
+		"priority_differs": {
+			records: `[{"address":"2.2.2.2","priority":20,"ttl":600}]`,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			api := &fakeAPI{
+				records: map[string]json.RawMessage{
+					"A": json.RawMessage(testCase.records),
+				},
+			}
+			settings := json.RawMessage(`{"login":"login","password":"password",` +
+				`"domain":"example.com","priority":10,"ttl":600}`)
+			provider, err := New(settings, "example.com", "@", ipversion.IP4, netip.Prefix{}, withAPI(api))
+			require.NoError(t, err)
+
+			_, err = provider.Update(context.Background(), nil, netip.MustParseAddr("2.2.2.2"))
+			require.NoError(t, err)
+
+			if testCase.notChanged {
+				assert.Nil(t, api.changed)
+				return
+			}
+			assert.JSONEq(t, `[{"priority":10,"ttl":600,"value":"2.2.2.2"}]`, string(api.changed["A"]))
+		})
+	}
+}
+
+func Test_Provider_Update_cache(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeAPI{}
+	settings := json.RawMessage(`{"login":"login","password":"password","domain":"example.com","cache_ttl":"1h"}`)
+	provider, err := New(settings, "example.com", "@", ipversion.IP4, netip.Prefix{}, withAPI(api))
+	require.NoError(t, err)
+
+	ip := netip.MustParseAddr("2.2.2.2")
+	_, err = provider.Update(context.Background(), nil, ip)
+	require.NoError(t, err)
+	assert.Equal(t, 2, api.getData) // fetch and verification
+
+	// The record set verified is cached, so no call is made
+	// while the IP address does not change.
+	_, err = provider.Update(context.Background(), nil, ip)
+	require.NoError(t, err)
+	assert.Equal(t, 2, api.getData)
+
+	// The record set is fetched again before writing a new IP address.
+	api.changed = nil
+	_, err = provider.Update(context.Background(), nil, netip.MustParseAddr("3.3.3.3"))
+	require.NoError(t, err)
+	assert.Equal(t, 4, api.getData)
+	assert.NotNil(t, api.changed)
+}
+
+func Test_Provider_Update_srv(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeAPI{
+		records: map[string]json.RawMessage{
+			"A": json.RawMessage(`[{"address":"2.2.2.2","priority":10}]`),
+		},
+	}
+	settings := json.RawMessage(`{"login":"login","password":"password","domain":"mc.example.com","srv":[` +
+		`{"priority":0,"weight":5,"port":25565},` +
+		`{"priority":10,"weight":5,"port":25565,"target":"backup.example.com"}]}`)
+	provider, err := New(settings, "example.com", "mc", ipversion.IP4, netip.Prefix{}, withAPI(api))
+	require.NoError(t, err)
+
+	ip := netip.MustParseAddr("2.2.2.2")
+	_, err = provider.Update(context.Background(), nil, ip)
+	require.NoError(t, err)
+
+	expected := `[{"priority":0,"weight":5,"port":25565,"target":"mc.example.com"},` +
+		`{"priority":10,"weight":5,"port":25565,"target":"backup.example.com"}]`
+	assert.JSONEq(t, expected, string(api.changed["SRV"]))
+	assert.JSONEq(t, `[{"address":"2.2.2.2","priority":10}]`, string(api.changed["A"]))
+
+	// Records are up to date, so changeRecords is not called again.
+	api.changed = nil
+	_, err = provider.Update(context.Background(), nil, ip)
+	require.NoError(t, err)
+	assert.Nil(t, api.changed)
+
+	_, err = New(json.RawMessage(`{"login":"login","password":"password","domain":"example.com","srv":[{"port":0}]}`),
+		"example.com", "@", ipversion.IP4, netip.Prefix{}, withAPI(api))
+	assert.ErrorIs(t, err, errors.ErrPortNotValid)
+}
+
+func Test_Provider_Update_rollback(t *testing.T) {
+	t.Parallel()
+
+	errTimeout := fmt.Errorf("dns/changeRecords: Failed performing HTTP request: %w",
+		context.DeadlineExceeded)
+
+	testCases := map[string]struct {
+		changeErr  error
+		errMessage string
+		rollback   bool
+	}{
+		"transport_error": {
+			changeErr: errTimeout,
+			errMessage: "example.com: dns/changeRecords: Failed performing HTTP request: " +
+				"context deadline exceeded (record set restored to its state before the update)",
+			rollback: true,
+		},
+		"rate_limited": {
+			changeErr:  errors.ErrRateLimit,
+			errMessage: "example.com: rate limit exceeded",
+		},
+		"suspended": {
+			changeErr:  errors.ErrSuspended,
+			errMessage: "example.com: provider calls suspended",
+		},
+		"method_failed": {
+			changeErr:  errors.ErrUnsuccessful,
+			errMessage: "example.com: unsuccessful result",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			original := map[string]json.RawMessage{
+				"A":  json.RawMessage(`[{"address":"1.1.1.1"}]`),
+				"MX": json.RawMessage(`[{"exchange":"mx.example.com","preference":10}]`),
+			}
+			api := &fakeAPI{
+				records:    original,
+				changeErrs: []error{testCase.changeErr},
+			}
+			snapshotDir := t.TempDir()
+			settings, err := json.Marshal(map[string]any{
+				"login":        "login",
+				"password":     "password",
+				"domain":       "example.com",
+				"snapshot_dir": snapshotDir,
+			})
+			require.NoError(t, err)
+			provider, err := New(settings, "example.com", "@", ipversion.IP4, netip.Prefix{}, withAPI(api))
+			require.NoError(t, err)
+
+			_, err = provider.Update(context.Background(), nil, netip.MustParseAddr("2.2.2.2"))
+
+			assert.ErrorIs(t, err, testCase.changeErr)
+			assert.EqualError(t, err, testCase.errMessage)
+			if testCase.rollback {
+				assert.Equal(t, original, api.changed)
+			} else {
+				assert.Nil(t, api.changed)
+			}
+
+			paths, err := filepath.Glob(filepath.Join(snapshotDir, "example.com-*.json"))
+			require.NoError(t, err)
+			require.Len(t, paths, 1)
+			data, err := os.ReadFile(paths[0])
+			require.NoError(t, err)
+			var saved snapshot
+			err = json.Unmarshal(data, &saved)
+			require.NoError(t, err)
+			assert.Equal(t, "example.com", saved.FQDN)
+			require.Len(t, saved.Records, len(original))
+			for recordType, raw := range original {
+				assert.JSONEq(t, string(raw), string(saved.Records[recordType]))
+			}
+		})
+	}
+}
+
+func Test_Provider_saveSnapshot(t *testing.T) {
+	t.Parallel()
+
+	snapshotDir := t.TempDir()
+	provider := &Provider{snapshotDir: snapshotDir}
+	records := map[string]json.RawMessage{"A": json.RawMessage(`[{"address":"1.1.1.1"}]`)}
+
+	first, err := provider.saveSnapshot("example.com", records)
+	require.NoError(t, err)
+	second, err := provider.saveSnapshot("example.com", records)
+	require.NoError(t, err)
+
+	// Each write keeps its own snapshot instead of overwriting the last one.
+	assert.NotEqual(t, first, second)
+	paths, err := filepath.Glob(filepath.Join(snapshotDir, "example.com-*.json"))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{first, second}, paths)
+}
+
+func Test_Provider_Update_dryRun(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeAPI{
+		records: map[string]json.RawMessage{
+			"MX": json.RawMessage(`[{"exchange":"mx.example.com","preference":10}]`),
+		},
+	}
+	settings := json.RawMessage(`{"login":"login","password":"password",` +
+		`"domain":"example.com","priority":10,"dry_run":true}`)
+	provider, err := New(settings, "example.com", "@", ipversion.IP4, netip.Prefix{}, withAPI(api))
+	require.NoError(t, err)
+
+	_, err = provider.Update(context.Background(), nil, netip.MustParseAddr("2.2.2.2"))
+
+	assert.ErrorIs(t, err, errors.ErrDryRun)
+	assert.EqualError(t, err, `example.com: dry run: changeRecords not called with records `+
+		`{"A":[{"priority":10,"value":"2.2.2.2"}],"MX":[{"exchange":"mx.example.com","preference":10}]}`)
+	assert.Nil(t, api.changed)
+}
+
+func Test_Provider_Update_createMissing(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeAPI{
+		domains: []domainEntry{
+			{ID: 1, FQDN: "example.com"},
+			{ID: 2, FQDN: "dev.example.com"},
+		},
+		subdomains: []domainEntry{{ID: 3, FQDN: "www.example.com"}},
+	}
+	settings := json.RawMessage(`{"login":"login","password":"password",` +
+		`"domain":"example.com","hosts":["www","vpn.dev"],"create_missing":true}`)
+	provider, err := New(settings, "example.com", "@", ipversion.IP4, netip.Prefix{}, withAPI(api))
+	require.NoError(t, err)
+
+	ip := netip.MustParseAddr("2.2.2.2")
+	_, err = provider.Update(context.Background(), nil, ip)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vpn:2"}, api.added)
+
+	// Existing FQDNs are remembered, so nothing is created again.
+	_, err = provider.Update(context.Background(), nil, ip)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vpn:2"}, api.added)
+}
+
+func Test_validateSettings(t *testing.T) {
+	t.Parallel()
+
+	ttl := ptrTo[uint32]
+
+	testCases := map[string]struct {
+		fqdns      []string
+		discover   bool
+		hosts      []hostSetting
+		aliases    []string
+		ttl        *uint32
+		recordMode string
+		priority   int
+		errWrapped error
+		errMessage string
+	}{
+		"valid": {
+			fqdns:      []string{"example.com", "www.example.com"},
+			ttl:        ttl(600),
+			recordMode: recordModeReplace,
+		},
+		"ttl_too_low": {
+			fqdns:      []string{"example.com"},
+			ttl:        ttl(60),
+			recordMode: recordModeReplace,
+			errWrapped: errors.ErrTTLTooLow,
+			errMessage: "TTL is too low: 60 must be at least 300",
+		},
+		"bad_record_mode": {
+			fqdns:      []string{"example.com"},
+			recordMode: "append",
+			errWrapped: errors.ErrRecordModeNotValid,
+			errMessage: `record mode is not valid: "append" must be one of "replace" or "merge"`,
+		},
+		"priority_too_high": {
+			fqdns:      []string{"example.com"},
+			recordMode: recordModeReplace,
+			priority:   65536,
+			errWrapped: errors.ErrPriorityNotValid,
+			errMessage: "priority is not valid: 65536 must be between 0 and 65535",
+		},
+		"host_priority_negative": {
+			fqdns:      []string{"example.com", "www.example.com"},
+			hosts:      []hostSetting{{Host: "www", Priority: ptrTo(-1)}},
+			recordMode: recordModeReplace,
+			errWrapped: errors.ErrPriorityNotValid,
+			errMessage: "for host www: priority is not valid: -1 must be between 0 and 65535",
+		},
+		"hosts_with_discovery": {
+			discover:   true,
+			hosts:      []hostSetting{{Host: "www"}},
+			recordMode: recordModeReplace,
+			errWrapped: errors.ErrDomainNotValid,
+			errMessage: `domain is not valid: hosts cannot be set with the account wide domain "*"`,
+		},
+		"aliases_with_discovery": {
+			discover:   true,
+			aliases:    []string{"www"},
+			recordMode: recordModeReplace,
+			errWrapped: errors.ErrDomainNotValid,
+			errMessage: `domain is not valid: aliases cannot be set with the account wide domain "*"`,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateSettings(testCase.fqdns, testCase.discover, testCase.hosts,
+				testCase.aliases, testCase.ttl, testCase.recordMode, testCase.priority)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+		})
+	}
+}
+
+func ptrTo[T any](value T) *T { return &value }
+
+func Test_buildFQDNs(t *testing.T) {
+	t.Parallel()
+
+	var hosts []hostSetting
+	err := json.Unmarshal([]byte(`["@", "www", {"host": "mx", "priority": 20}, {"host": "@", "priority": 5}]`),
+		&hosts)
+	require.NoError(t, err)
+
+	fqdns, priorities := buildFQDNs("example.com", "example.com", hosts, nil)
+
+	assert.Equal(t, []string{"example.com", "www.example.com", "mx.example.com"}, fqdns)
+	assert.Equal(t, map[string]int{"mx.example.com": 20, "example.com": 5}, priorities)
+}
+
+func Test_buildFQDNs_aliases(t *testing.T) {
+	t.Parallel()
+
+	fqdns, _ := buildFQDNs("sub.example.com", "example.com",
+		[]hostSetting{{Host: "vpn"}}, []string{"@", "www", "sub", "www"})
+
+	assert.Equal(t, []string{"sub.example.com", "vpn.sub.example.com",
+		"example.com", "www.example.com"}, fqdns)
+}
+
+func Test_resolveTarget(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		domain       string
+		owner        string
+		legacyDomain string
+		target       string
+		errWrapped   error
+		errMessage   string
+	}{
+		"subdomain": {
+			domain: "example.com",
+			owner:  "sub",
+			target: "sub.example.com",
+		},
+		"apex": {
+			domain: "example.com",
+			owner:  "@",
+			target: "example.com",
+		},
+		"wildcard": {
+			domain: "example.com",
+			owner:  "*",
+			target: "*.example.com",
+		},
+		"internationalized": {
+			domain:       "Пример.рф",
+			owner:        "почта",
+			legacyDomain: "почта.пример.рф",
+			target:       "xn--80a1acny.xn--e1afmkfd.xn--p1ai",
+		},
+		"legacy_matching": {
+			domain:       "example.com",
+			owner:        "sub",
+			legacyDomain: "Sub.Example.com.",
+			target:       "sub.example.com",
+		},
+		"legacy_list": {
+			domain:       "example.com",
+			owner:        "www",
+			legacyDomain: "example.com, www.example.com",
+			target:       "www.example.com",
+		},
+		"legacy_owner_setting": {
+			domain:       "example.com",
+			owner:        "sub",
+			legacyDomain: "example.com",
+			target:       "sub.example.com",
+		},
+		"legacy_account_wide": {
+			domain:       "example.com",
+			owner:        "@",
+			legacyDomain: "*",
+			target:       "*",
+		},
+		"legacy_mismatch": {
+			domain:       "example.com",
+			owner:        "www",
+			legacyDomain: "sub.example.com",
+			errWrapped:   errors.ErrDomainNotValid,
+			errMessage: `domain is not valid: "sub.example.com" set in the provider settings ` +
+				`does not match "www.example.com" built from the domain and owner`,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			target, err := resolveTarget(testCase.domain, testCase.owner, testCase.legacyDomain)
+
+			assert.Equal(t, testCase.target, target)
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+		})
+	}
+}
+
+func Test_Provider_LastAPIStatus(t *testing.T) {
+	t.Parallel()
+
+	server := &fakeServer{password: "password"}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	provider := newTestProvider(t, httpServer.URL, "wrong", ipversion.IP4or6)
+	assert.True(t, provider.LastAPIStatus().Time.IsZero())
+
+	_, err := provider.Update(context.Background(), httpServer.Client(), netip.MustParseAddr("2.2.2.2"))
+	require.Error(t, err)
+
+	status := provider.LastAPIStatus()
+	assert.Equal(t, "dns/getData", status.Endpoint)
+	assert.Equal(t, "AUTH_ERROR", status.ErrorCode)
+	assert.Equal(t, "getData: bad authentication: AUTH_ERROR: No such user", status.Error)
+}
+
+func Test_Provider_HTML(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		domain     string
+		settings   string
+		htmlDomain string
+		details    string
+		skipLookup bool
+	}{
+		"hosts": {
+			domain: "example.com",
+			settings: `{"login":"login","password":"password",` +
+				`"hosts":["@",{"host":"mx","priority":20}],"ttl":600}`,
+			htmlDomain: `<a href="http://example.com">example.com</a>`,
+			details:    "FQDNs: example.com (priority 10), mx.example.com (priority 20)<br>TTL: 600s",
+		},
+		"discover": {
+			domain:     "example.com",
+			settings:   `{"login":"login","password":"password","domain":"*"}`,
+			htmlDomain: `<a href="http://example.com">example.com</a>`,
+			details:    "FQDNs: all of the account<br>TTL: Beget default",
+			skipLookup: true,
+		},
+		"wildcard_domain": {
+			domain:     "*",
+			settings:   `{"login":"login","password":"password"}`,
+			htmlDomain: "*",
+			details:    "FQDNs: all of the account<br>TTL: Beget default",
+			skipLookup: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			provider, err := New(json.RawMessage(testCase.settings), testCase.domain, "@",
+				ipversion.IP4, netip.Prefix{}, withAPI(&fakeAPI{}))
+			require.NoError(t, err)
+
+			row := provider.HTML()
+
+			assert.Equal(t, testCase.htmlDomain, row.Domain)
+			assert.Equal(t, testCase.details, row.Details)
+			assert.False(t, provider.Proxied())
+			assert.Equal(t, testCase.skipLookup, provider.SkipLookup())
+		})
+	}
+}
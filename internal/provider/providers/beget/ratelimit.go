@@ -0,0 +1,162 @@
+package beget
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket limiting the rate of the API calls
+// of a Beget account. Calls exceeding the rate wait for a token
+// instead of failing, so that Beget does not throttle the account.
+type rateLimiter struct {
+	mutex    sync.Mutex
+	interval time.Duration // between two tokens
+	burst    float64
+	tokens   float64
+	last     time.Time
+	timeNow  func() time.Time
+	// leases maps the lease IDs of the providers using the
+	// limiter to their requests per minute, see acquire.
+	leases    map[uint]uint
+	nextLease uint
+}
+
+// rateLimitBurst is the number of API calls allowed in a row,
+// enough for a getData, changeRecords and verifying getData.
+const rateLimitBurst = 3
+
+func newRateLimiter(requestsPerMinute uint, timeNow func() time.Time) *rateLimiter {
+	return &rateLimiter{
+		interval: time.Minute / time.Duration(requestsPerMinute),
+		burst:    rateLimitBurst,
+		tokens:   rateLimitBurst,
+		last:     timeNow(),
+		timeNow:  timeNow,
+		leases:   make(map[uint]uint),
+	}
+}
+
+// acquire registers a provider using the limiter with its rate, and
+// returns the ID of its lease to give to release once it is not used.
+// The lowest rate of the providers using the limiter applies.
+func (r *rateLimiter) acquire(requestsPerMinute uint) (id uint) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	id = r.nextLease
+	r.nextLease++
+	r.leases[id] = requestsPerMinute
+	r.applyLeases()
+	return id
+}
+
+// release unregisters the provider lease id, so that only the rates
+// of the providers still using the limiter apply, and returns true if
+// no provider uses the limiter anymore.
+func (r *rateLimiter) release(id uint) (unused bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.leases, id)
+	if len(r.leases) == 0 {
+		return true
+	}
+	r.applyLeases()
+	return false
+}
+
+func (r *rateLimiter) applyLeases() {
+	var requestsPerMinute uint
+	for _, leaseRate := range r.leases {
+		if requestsPerMinute == 0 || leaseRate < requestsPerMinute {
+			requestsPerMinute = leaseRate
+		}
+	}
+	r.interval = time.Minute / time.Duration(requestsPerMinute)
+}
+
+// wait waits for a token to be available and takes it, or returns
+// an error if the context is canceled before that.
+func (r *rateLimiter) wait(ctx context.Context) (err error) {
+	delay := r.reserve()
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		r.cancel()
+		return fmt.Errorf("waiting for the account rate limit: %w", ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
+
+// reserve takes a token, possibly bringing the bucket into debt,
+// and returns the delay to wait for before the token is usable.
+func (r *rateLimiter) reserve() (delay time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := r.timeNow()
+	r.tokens += float64(now.Sub(r.last)) / float64(r.interval)
+	r.tokens = min(r.tokens, r.burst)
+	r.last = now
+
+	r.tokens--
+	if r.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-r.tokens * float64(r.interval))
+}
+
+// cancel gives back a token reserved but not used.
+func (r *rateLimiter) cancel() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.tokens = min(r.tokens+1, r.burst)
+}
+
+// rateLimiters holds the rate limiter of each Beget account,
+// shared by all the providers using the same account.
+type rateLimiters struct {
+	mutex    sync.Mutex
+	limiters map[string]*rateLimiter
+}
+
+func newRateLimiters() *rateLimiters {
+	return &rateLimiters{
+		limiters: make(map[string]*rateLimiter),
+	}
+}
+
+var defaultRateLimiters = newRateLimiters() //nolint:gochecknoglobals
+
+// get returns the rate limiter of the account identified by accountKey,
+// limited to requestsPerMinute, or nil if requestsPerMinute is zero, and
+// the function to call once the provider does not use it anymore, after
+// which the limiter is forgotten if no other provider uses it.
+func (l *rateLimiters) get(accountKey string, requestsPerMinute uint) (
+	limiter *rateLimiter, release func()) {
+	if requestsPerMinute == 0 {
+		return nil, func() {}
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	limiter, ok := l.limiters[accountKey]
+	if !ok {
+		limiter = newRateLimiter(requestsPerMinute, time.Now)
+		l.limiters[accountKey] = limiter
+	}
+	id := limiter.acquire(requestsPerMinute)
+	return limiter, func() {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		if limiter.release(id) && l.limiters[accountKey] == limiter {
+			delete(l.limiters, accountKey)
+		}
+	}
+}
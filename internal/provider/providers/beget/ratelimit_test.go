@@ -0,0 +1,76 @@
+package beget
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_rateLimiter_reserve(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+	limiter := newRateLimiter(60, func() time.Time { return now })
+
+	for range rateLimitBurst {
+		assert.Zero(t, limiter.reserve())
+	}
+	assert.Equal(t, time.Second, limiter.reserve())
+	assert.Equal(t, 2*time.Second, limiter.reserve())
+
+	now = now.Add(10 * time.Second)
+	assert.Zero(t, limiter.reserve())
+
+	fast := limiter.acquire(120)
+	assert.Equal(t, 500*time.Millisecond, limiter.interval)
+	slow := limiter.acquire(30)
+	assert.Equal(t, 2*time.Second, limiter.interval)
+	assert.False(t, limiter.release(slow))
+	assert.Equal(t, 500*time.Millisecond, limiter.interval)
+	assert.True(t, limiter.release(fast))
+}
+
+func Test_rateLimiter_wait(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+	limiter := newRateLimiter(1, func() time.Time { return now })
+	for range rateLimitBurst {
+		require.NoError(t, limiter.wait(context.Background()))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := limiter.wait(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, limiter.tokens)
+}
+
+func Test_rateLimiters_get(t *testing.T) {
+	t.Parallel()
+
+	limiters := newRateLimiters()
+
+	limiter, release := limiters.get("key", 0)
+	assert.Nil(t, limiter)
+	release()
+
+	limiter, releaseOld := limiters.get("key", 30)
+	sameLimiter, releaseNew := limiters.get("key", 60)
+	assert.Same(t, limiter, sameLimiter)
+	assert.Equal(t, 2*time.Second, limiter.interval)
+
+	// the rate configured before a reload no longer applies
+	releaseOld()
+	assert.Equal(t, time.Second, limiter.interval)
+
+	otherLimiter, releaseOther := limiters.get("other", 60)
+	assert.NotSame(t, limiter, otherLimiter)
+	releaseOther()
+
+	releaseNew()
+	assert.Empty(t, limiters.limiters)
+}
@@ -0,0 +1,228 @@
+package beget
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"slices"
+
+	"github.com/qdm12/ddns-updater/internal/provider/constants"
+)
+
+// recordEntry is a single entry of an A or AAAA record
+// as expected by the changeRecords method.
+type recordEntry struct {
+	Priority int     `json:"priority"`
+	Value    string  `json:"value"`
+	TTL      *uint32 `json:"ttl,omitempty"`
+}
+
+// currentEntry is a single entry of an A or AAAA record as returned
+// by the getData method, which uses "address" instead of "value".
+type currentEntry struct {
+	Address  string  `json:"address"`
+	Value    string  `json:"value"`
+	Priority int     `json:"priority"`
+	TTL      *uint32 `json:"ttl,omitempty"`
+}
+
+func (e currentEntry) ip() (ip netip.Addr, err error) {
+	value := e.Value
+	if value == "" {
+		value = e.Address
+	}
+	return netip.ParseAddr(value)
+}
+
+func (e currentEntry) toRecordEntry() recordEntry {
+	value := e.Value
+	if value == "" {
+		value = e.Address
+	}
+	return recordEntry{
+		Priority: e.Priority,
+		Value:    value,
+		TTL:      e.TTL,
+	}
+}
+
+func ipRecordType(ip netip.Addr) (recordType string) {
+	if ip.Is6() {
+		return constants.AAAA
+	}
+	return constants.A
+}
+
+// currentEntries returns the entries of the record of type recordType in records.
+func currentEntries(records map[string]json.RawMessage, recordType string) (
+	entries []currentEntry, err error) {
+	raw, ok := records[recordType]
+	if !ok {
+		return nil, nil
+	}
+
+	err = json.Unmarshal(raw, &entries)
+	if err != nil {
+		return nil, fmt.Errorf("Failed unmarshalling current %s entries: %w", recordType, err)
+	}
+	return entries, nil
+}
+
+// currentIPs returns the IP addresses of the record matching
+// the IP family of ip in records.
+func currentIPs(records map[string]json.RawMessage, ip netip.Addr) (
+	ips []netip.Addr, err error) {
+	recordType := ipRecordType(ip)
+	entries, err := currentEntries(records, recordType)
+	if err != nil {
+		return nil, err
+	}
+
+	ips = make([]netip.Addr, len(entries))
+	for i, entry := range entries {
+		ips[i], err = entry.ip()
+		if err != nil {
+			return nil, fmt.Errorf("parsing current %s entry: %w", recordType, err)
+		}
+	}
+	return ips, nil
+}
+
+// ipRecordUpToDate returns true if the record matching the IP family
+// of ip is up to date for fqdn. In replace mode, it must have a single
+// entry with the same IP address as ip, and with the configured priority
+// and TTL, if any, whereas in merge mode, the entry
+// of this provider, see ownEntryIndex, must have the IP address ip, so
+// that an entry of another host with the same IP address is not taken
+// for its own.
+func (p *Provider) ipRecordUpToDate(records map[string]json.RawMessage,
+	fqdn string, ip netip.Addr) (upToDate bool, err error) {
+	if p.recordMode == recordModeMerge {
+		recordType := ipRecordType(ip)
+		entries, err := currentEntries(records, recordType)
+		if err != nil {
+			return false, err
+		}
+		index := p.ownEntryIndex(entries, fqdn, recordType)
+		if index == -1 {
+			return false, nil
+		}
+		entryIP, err := entries[index].ip()
+		return err == nil && entryIP.Compare(ip) == 0, nil
+	}
+
+	ips, err := currentIPs(records, ip)
+	if err != nil {
+		return false, err
+	} else if len(ips) != 1 || ips[0].Compare(ip) != 0 {
+		return false, nil
+	}
+	entries, err := currentEntries(records, ipRecordType(ip))
+	if err != nil {
+		return false, err
+	}
+	entry := entries[0]
+	if entry.Priority != p.priorityOf(fqdn) {
+		return false, nil
+	}
+	return p.ttl == nil || (entry.TTL != nil && *entry.TTL == *p.ttl), nil
+}
+
+// ownEntryIndex returns the index of the entry of fqdn written by this
+// provider in the entries of the record of type recordType, which is the
+// entry with the IP address previously written, or else the entry with
+// the configured priority, or -1 if there is none.
+func (p *Provider) ownEntryIndex(entries []currentEntry, fqdn, recordType string) (index int) {
+	previousIP := p.previousIP(fqdn, recordType)
+	if previousIP.IsValid() {
+		index = slices.IndexFunc(entries, func(entry currentEntry) bool {
+			entryIP, err := entry.ip()
+			return err == nil && entryIP.Compare(previousIP) == 0
+		})
+		if index != -1 {
+			return index
+		}
+	}
+	priority := p.priorityOf(fqdn)
+	return slices.IndexFunc(entries, func(entry currentEntry) bool {
+		return entry.Priority == priority
+	})
+}
+
+// previousIP returns the IP address of the record of type recordType
+// last written or found up to date for fqdn, or else the last one of
+// the record history set with SetPreviousIPs, so that the entry of this
+// provider is still found after a restart.
+func (p *Provider) previousIP(fqdn, recordType string) netip.Addr {
+	p.previousIPsMutex.Lock()
+	defer p.previousIPsMutex.Unlock()
+	ip, ok := p.previousIPs[previousIPKey{fqdn: fqdn, recordType: recordType}]
+	if ok {
+		return ip
+	}
+	return p.historyIPs[recordType]
+}
+
+// setPreviousIP sets ip as the IP address of the record
+// of its IP family last written or found up to date for fqdn.
+func (p *Provider) setPreviousIP(fqdn string, ip netip.Addr) {
+	p.previousIPsMutex.Lock()
+	defer p.previousIPsMutex.Unlock()
+	p.previousIPs[previousIPKey{fqdn: fqdn, recordType: ipRecordType(ip)}] = ip
+}
+
+// setIPRecord sets the record matching the IP family of ip in records.
+// Only that record type is modified, so that updating AAAA keeps the A
+// record untouched and vice versa. In replace mode, the record is replaced
+// by a single entry, whereas in merge mode only one entry is rewritten
+// and the other entries are kept, see mergeEntries.
+func (p *Provider) setIPRecord(records map[string]json.RawMessage,
+	fqdn string, ip netip.Addr) (err error) {
+	recordType := ipRecordType(ip)
+	newEntry := recordEntry{
+		Priority: p.priorityOf(fqdn),
+		Value:    ip.String(),
+		TTL:      p.ttl,
+	}
+
+	entries := []recordEntry{newEntry}
+	if p.recordMode == recordModeMerge {
+		entries, err = p.mergeEntries(records, fqdn, recordType, newEntry)
+		if err != nil {
+			return err
+		}
+	}
+
+	newEntriesJSON, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("Couldn't marshal new %s entries JSON: %w", recordType, err)
+	}
+	records[recordType] = json.RawMessage(newEntriesJSON)
+	return nil
+}
+
+// mergeEntries returns the current entries of the record of type recordType
+// with newEntry replacing the entry of this provider, see ownEntryIndex.
+// If no such entry exists, newEntry is appended to the current entries.
+func (p *Provider) mergeEntries(records map[string]json.RawMessage,
+	fqdn, recordType string, newEntry recordEntry) (entries []recordEntry, err error) {
+	current, err := currentEntries(records, recordType)
+	if err != nil {
+		return nil, err
+	}
+
+	replaceIndex := p.ownEntryIndex(current, fqdn, recordType)
+
+	entries = make([]recordEntry, 0, len(current)+1)
+	for i, entry := range current {
+		if i == replaceIndex {
+			entries = append(entries, newEntry)
+			continue
+		}
+		entries = append(entries, entry.toRecordEntry())
+	}
+	if replaceIndex == -1 {
+		entries = append(entries, newEntry)
+	}
+	return entries, nil
+}
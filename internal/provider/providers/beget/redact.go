@@ -0,0 +1,48 @@
+package beget
+
+import (
+	"net/url"
+	"strings"
+)
+
+// redactedError is an error with the credentials removed from its message.
+// It still wraps the original error so errors.Is and errors.As keep working.
+type redactedError struct {
+	err     error
+	message string
+}
+
+func (e *redactedError) Error() string { return e.message }
+func (e *redactedError) Unwrap() error { return e.err }
+
+const redacted = "[redacted]"
+
+// redactError returns err with the login, password and token of the client,
+// as they are and URL encoded, replaced in its message. It returns err as
+// it is if its message contains none of them.
+func (c *apiClient) redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	message := err.Error()
+	redactedMessage := c.redactString(message)
+	if redactedMessage == message {
+		return err
+	}
+	return &redactedError{err: err, message: redactedMessage}
+}
+
+// redactString returns s with the login, password and token of the
+// client, as they are and URL encoded, replaced.
+func (c *apiClient) redactString(s string) string {
+	for _, secret := range []string{c.token, c.password, c.login} {
+		if secret == "" {
+			continue
+		}
+		for _, form := range []string{secret, url.QueryEscape(secret), url.PathEscape(secret)} {
+			s = strings.ReplaceAll(s, form, redacted)
+		}
+	}
+	return s
+}
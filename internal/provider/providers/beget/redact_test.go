@@ -0,0 +1,37 @@
+package beget
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_apiClient_redactError(t *testing.T) {
+	t.Parallel()
+
+	client := &apiClient{login: "user", password: "p@ss word"}
+
+	assert.NoError(t, client.redactError(nil))
+
+	err := fmt.Errorf("%w: nothing secret", errors.ErrAuth)
+	assert.Same(t, err, client.redactError(err))
+
+	err = fmt.Errorf("%w: login=user&passwd=p%%40ss+word and p@ss word",
+		errors.ErrAuth)
+	redactedErr := client.redactError(err)
+	assert.ErrorIs(t, redactedErr, errors.ErrAuth)
+	assert.EqualError(t, redactedErr,
+		"bad authentication: login=[redacted]&passwd=[redacted] and [redacted]")
+}
+
+func Test_apiClient_redactError_token(t *testing.T) {
+	t.Parallel()
+
+	client := &apiClient{token: "t0k/en"}
+
+	err := fmt.Errorf("%w: Authorization: Bearer t0k/en, t0k%%2Fen", errors.ErrAuth)
+	assert.EqualError(t, client.redactError(err),
+		"bad authentication: Authorization: Bearer [redacted], [redacted]")
+}
@@ -0,0 +1,149 @@
+package beget
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+	"github.com/qdm12/ddns-updater/internal/provider/utils"
+)
+
+const (
+	statusSuccess = "success"
+	statusError   = "error"
+)
+
+// apiResponse is the envelope common to all Beget API responses.
+// The outer status reports errors in the request itself (i.e. bad
+// credentials), whereas the answer status reports errors of the method.
+type apiResponse struct {
+	Status    string     `json:"status"`
+	ErrorCode string     `json:"error_code"`
+	ErrorText string     `json:"error_text"`
+	Answer    *apiAnswer `json:"answer"`
+}
+
+type apiAnswer struct {
+	Status string          `json:"status"`
+	Errors []apiError      `json:"errors"`
+	Result json.RawMessage `json:"result"`
+}
+
+type apiError struct {
+	ErrorCode string `json:"error_code"`
+	ErrorText string `json:"error_text"`
+}
+
+// decodeResponse checks the Beget API response b indicates success and JSON
+// decodes its answer result into result. Beget error codes are mapped to
+// the shared provider errors, and their error text is kept in the error.
+func decodeResponse(b []byte, result any) (err error) {
+	var response apiResponse
+	err = json.Unmarshal(b, &response)
+	if err != nil {
+		return fmt.Errorf("Failed unmarshalling response: %w", err)
+	}
+
+	switch response.Status {
+	case statusSuccess:
+	case statusError:
+		return makeError([]apiError{{
+			ErrorCode: response.ErrorCode,
+			ErrorText: response.ErrorText,
+		}})
+	default:
+		return fmt.Errorf("%w: status is %q: %s", errors.ErrUnknownResponse,
+			response.Status, trimErrorText(string(b)))
+	}
+
+	if response.Answer == nil {
+		return fmt.Errorf("%w: answer field is missing: %s",
+			errors.ErrUnknownResponse, trimErrorText(string(b)))
+	}
+
+	switch response.Answer.Status {
+	case statusSuccess:
+	case statusError:
+		if len(response.Answer.Errors) == 0 {
+			return fmt.Errorf("%w: answer status is %q without errors",
+				errors.ErrUnsuccessful, response.Answer.Status)
+		}
+		return makeError(response.Answer.Errors)
+	default:
+		return fmt.Errorf("%w: answer status is %q: %s", errors.ErrUnknownResponse,
+			response.Answer.Status, trimErrorText(string(b)))
+	}
+
+	if len(response.Answer.Result) == 0 ||
+		bytes.Equal(response.Answer.Result, []byte("null")) {
+		return fmt.Errorf("%w", errors.ErrReceivedNoResult)
+	}
+
+	err = json.Unmarshal(response.Answer.Result, result)
+	if err != nil {
+		return fmt.Errorf("Failed unmarshalling answer result: %w", err)
+	}
+	return nil
+}
+
+// makeError maps the first Beget API error code to one of the shared
+// provider errors, and lists all the error codes and texts in the message.
+func makeError(apiErrs []apiError) (err error) {
+	var sentinelErr error
+	switch apiErrs[0].ErrorCode {
+	case "AUTH_ERROR":
+		sentinelErr = errors.ErrAuth
+	case "LIMIT_ERROR":
+		sentinelErr = errors.ErrRateLimit
+	case "INVALID_DATA", "INCORRECT_REQUEST", "NO_SUCH_METHOD":
+		sentinelErr = errors.ErrBadRequest
+	case "METHOD_FAILED":
+		sentinelErr = errors.ErrUnsuccessful
+	default:
+		sentinelErr = errors.ErrUnknownResponse
+	}
+
+	messages := make([]string, len(apiErrs))
+	for i, apiErr := range apiErrs {
+		code := apiErr.ErrorCode
+		if code == "" {
+			code = "no error code"
+		}
+		text := trimErrorText(apiErr.ErrorText)
+		if text == "" {
+			messages[i] = code
+			continue
+		}
+		messages[i] = code + ": " + text
+	}
+	return &apiCodeError{
+		code: apiErrs[0].ErrorCode,
+		text: apiErrs[0].ErrorText,
+		err:  fmt.Errorf("%w: %s", sentinelErr, strings.Join(messages, "; ")),
+	}
+}
+
+// apiCodeError is an error of the Beget API, with the first
+// error code of the response, given in the JSON logs, and its text.
+type apiCodeError struct {
+	code string
+	text string
+	err  error
+}
+
+func (e *apiCodeError) Error() string     { return e.err.Error() }
+func (e *apiCodeError) Unwrap() error     { return e.err }
+func (e *apiCodeError) ErrorCode() string { return e.code }
+
+// trimErrorText returns the text s on a single line, truncated
+// to maxLength characters so it does not flood the logs.
+func trimErrorText(s string) string {
+	const maxLength = 256
+	runes := []rune(strings.TrimSpace(utils.ToSingleLine(s)))
+	if len(runes) > maxLength {
+		return string(runes[:maxLength]) + "..."
+	}
+	return string(runes)
+}
@@ -0,0 +1,106 @@
+package beget
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_decodeResponse(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		response   string
+		result     bool
+		errWrapped error
+		errMessage string
+	}{
+		"success": {
+			response: `{"status":"success","answer":{"status":"success","result":true}}`,
+			result:   true,
+		},
+		"malformed": {
+			response:   `{"status":`,
+			errMessage: "Failed unmarshalling response: unexpected end of JSON input",
+		},
+		"request_error": {
+			response:   `{"status":"error","error_code":"AUTH_ERROR","error_text":" No such user\n"}`,
+			errWrapped: errors.ErrAuth,
+			errMessage: "bad authentication: AUTH_ERROR: No such user",
+		},
+		"request_error_without_code": {
+			response:   `{"status":"error"}`,
+			errWrapped: errors.ErrUnknownResponse,
+			errMessage: "unknown response received: no error code",
+		},
+		"unknown_status": {
+			response:   `{"status":"pending"}`,
+			errWrapped: errors.ErrUnknownResponse,
+			errMessage: `unknown response received: status is "pending": {"status":"pending"}`,
+		},
+		"missing_answer": {
+			response:   `{"status":"success"}`,
+			errWrapped: errors.ErrUnknownResponse,
+			errMessage: `unknown response received: answer field is missing: {"status":"success"}`,
+		},
+		"answer_errors": {
+			response: `{"status":"success","answer":{"status":"error","errors":[` +
+				`{"error_code":"INVALID_DATA","error_text":"fqdn is not valid"},` +
+				`{"error_code":"METHOD_FAILED","error_text":"records not changed"}]}}`,
+			errWrapped: errors.ErrBadRequest,
+			errMessage: "bad request sent: INVALID_DATA: fqdn is not valid; " +
+				"METHOD_FAILED: records not changed",
+		},
+		"answer_error_without_errors": {
+			response:   `{"status":"success","answer":{"status":"error"}}`,
+			errWrapped: errors.ErrUnsuccessful,
+			errMessage: `unsuccessful result: answer status is "error" without errors`,
+		},
+		"missing_result": {
+			response:   `{"status":"success","answer":{"status":"success"}}`,
+			errWrapped: errors.ErrReceivedNoResult,
+			errMessage: "received no result in response",
+		},
+		"null_result": {
+			response:   `{"status":"success","answer":{"status":"success","result":null}}`,
+			errWrapped: errors.ErrReceivedNoResult,
+			errMessage: "received no result in response",
+		},
+		"wrong_result_type": {
+			response: `{"status":"success","answer":{"status":"success","result":"yes"}}`,
+			errMessage: "Failed unmarshalling answer result: " +
+				"json: cannot unmarshal string into Go value of type bool",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var result bool
+			err := decodeResponse([]byte(testCase.response), &result)
+
+			if testCase.errWrapped != nil {
+				assert.ErrorIs(t, err, testCase.errWrapped)
+			}
+			if testCase.errMessage != "" {
+				assert.EqualError(t, err, testCase.errMessage)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, testCase.result, result)
+		})
+	}
+}
+
+func Test_trimErrorText(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "a b", trimErrorText("  a\n b \r\n"))
+
+	long := strings.Repeat("д", 300)
+	assert.Equal(t, strings.Repeat("д", 256)+"...", trimErrorText(long))
+}
@@ -0,0 +1,125 @@
+package beget
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/bits"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+)
+
+// retryPolicy defines how failed idempotent Beget API calls are retried.
+type retryPolicy struct {
+	maxAttempts uint
+	baseDelay   time.Duration
+	// maxDelay caps the exponential backoff delay, before the jitter.
+	maxDelay time.Duration
+	jitter   time.Duration
+}
+
+type retrySettings struct {
+	MaxAttempts *uint  `json:"max_attempts,omitempty"`
+	BaseDelay   string `json:"base_delay,omitempty"`
+	MaxDelay    string `json:"max_delay,omitempty"`
+	Jitter      string `json:"jitter,omitempty"`
+}
+
+func (s retrySettings) toPolicy() (policy retryPolicy, err error) {
+	const defaultMaxAttempts = 3
+	policy = retryPolicy{
+		maxAttempts: defaultMaxAttempts,
+		baseDelay:   time.Second,
+		jitter:      time.Second,
+	}
+
+	if s.MaxAttempts != nil {
+		policy.maxAttempts = *s.MaxAttempts
+		if policy.maxAttempts == 0 {
+			policy.maxAttempts = 1
+		}
+	}
+
+	if s.BaseDelay != "" {
+		policy.baseDelay, err = parseRetryDelay("base delay", s.BaseDelay)
+		if err != nil {
+			return policy, err
+		}
+	}
+
+	// The default maximum delay never caps a longer base delay set.
+	const defaultMaxDelay = time.Minute
+	policy.maxDelay = max(defaultMaxDelay, policy.baseDelay)
+	if s.MaxDelay != "" {
+		policy.maxDelay, err = parseRetryDelay("max delay", s.MaxDelay)
+		if err != nil {
+			return policy, err
+		}
+	}
+
+	if s.Jitter != "" {
+		policy.jitter, err = parseRetryDelay("jitter", s.Jitter)
+		if err != nil {
+			return policy, err
+		}
+	}
+
+	return policy, nil
+}
+
+// parseRetryDelay parses the duration s of the retry setting name,
+// which cannot be negative.
+func parseRetryDelay(name, s string) (delay time.Duration, err error) {
+	delay, err = time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("parsing retry %s: %w", name, err)
+	} else if delay < 0 {
+		return 0, fmt.Errorf("%w: retry %s %s is negative", errors.ErrDelayNotValid, name, s)
+	}
+	return delay, nil
+}
+
+// delay returns the exponential backoff delay to wait for after the
+// given failed attempt number, starting from 1, capped to the maximum
+// delay, with a random jitter.
+func (r retryPolicy) delay(attempt uint) time.Duration {
+	delay := r.maxDelay
+	shift := attempt - 1
+	// Shifting the base delay past its leading zero bits, but
+	// the sign bit, would overflow it.
+	if r.baseDelay == 0 || shift < uint(bits.LeadingZeros64(uint64(r.baseDelay)))-1 {
+		delay = min(r.baseDelay<<shift, r.maxDelay)
+	}
+	if r.jitter > 0 {
+		delay += rand.N(r.jitter) //nolint:gosec
+	}
+	return delay
+}
+
+// isLimitError returns true if the Beget API response body b
+// reports the LIMIT_ERROR error code.
+func isLimitError(b []byte) bool {
+	return slices.Contains(errorCodes(b), "LIMIT_ERROR")
+}
+
+// errorCodes returns the error codes reported by the Beget API
+// response body b, starting with the top level error code.
+func errorCodes(b []byte) (codes []string) {
+	var response apiResponse
+	err := json.Unmarshal(b, &response)
+	if err != nil {
+		return nil
+	}
+	if response.ErrorCode != "" {
+		codes = append(codes, response.ErrorCode)
+	}
+	if response.Answer == nil {
+		return codes
+	}
+	for _, apiErr := range response.Answer.Errors {
+		codes = append(codes, apiErr.ErrorCode)
+	}
+	return codes
+}
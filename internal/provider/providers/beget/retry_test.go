@@ -0,0 +1,151 @@
+package beget
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_retrySettings_toPolicy(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		settings   retrySettings
+		policy     retryPolicy
+		errWrapped error
+		errMessage string
+	}{
+		"defaults": {
+			policy: retryPolicy{
+				maxAttempts: 3,
+				baseDelay:   time.Second,
+				maxDelay:    time.Minute,
+				jitter:      time.Second,
+			},
+		},
+		"all_set": {
+			settings: retrySettings{
+				MaxAttempts: ptrTo(uint(5)),
+				BaseDelay:   "2s",
+				MaxDelay:    "10s",
+				Jitter:      "0s",
+			},
+			policy: retryPolicy{
+				maxAttempts: 5,
+				baseDelay:   2 * time.Second,
+				maxDelay:    10 * time.Second,
+			},
+		},
+		"long_base_delay": {
+			settings: retrySettings{BaseDelay: "2m"},
+			policy: retryPolicy{
+				maxAttempts: 3,
+				baseDelay:   2 * time.Minute,
+				maxDelay:    2 * time.Minute,
+				jitter:      time.Second,
+			},
+		},
+		"negative_base_delay": {
+			settings:   retrySettings{BaseDelay: "-1s"},
+			errWrapped: errors.ErrDelayNotValid,
+			errMessage: "delay is not valid: retry base delay -1s is negative",
+		},
+		"negative_max_delay": {
+			settings:   retrySettings{MaxDelay: "-1s"},
+			errWrapped: errors.ErrDelayNotValid,
+			errMessage: "delay is not valid: retry max delay -1s is negative",
+		},
+		"negative_jitter": {
+			settings:   retrySettings{Jitter: "-1ms"},
+			errWrapped: errors.ErrDelayNotValid,
+			errMessage: "delay is not valid: retry jitter -1ms is negative",
+		},
+		"malformed_jitter": {
+			settings:   retrySettings{Jitter: "soon"},
+			errMessage: `parsing retry jitter: time: invalid duration "soon"`,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			policy, err := testCase.settings.toPolicy()
+
+			if testCase.errMessage != "" {
+				if testCase.errWrapped != nil {
+					assert.ErrorIs(t, err, testCase.errWrapped)
+				}
+				assert.EqualError(t, err, testCase.errMessage)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.policy, policy)
+		})
+	}
+}
+
+func Test_retryPolicy_delay(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		policy  retryPolicy
+		attempt uint
+		delay   time.Duration
+	}{
+		"first_attempt": {
+			policy:  retryPolicy{baseDelay: time.Second, maxDelay: time.Minute},
+			attempt: 1,
+			delay:   time.Second,
+		},
+		"doubling": {
+			policy:  retryPolicy{baseDelay: time.Second, maxDelay: time.Minute},
+			attempt: 4,
+			delay:   8 * time.Second,
+		},
+		"capped": {
+			policy:  retryPolicy{baseDelay: time.Second, maxDelay: time.Minute},
+			attempt: 10,
+			delay:   time.Minute,
+		},
+		"overflowing_shift": {
+			policy:  retryPolicy{baseDelay: time.Second, maxDelay: time.Minute},
+			attempt: 40,
+			delay:   time.Minute,
+		},
+		"overflowing_attempt": {
+			policy:  retryPolicy{baseDelay: time.Second, maxDelay: time.Minute},
+			attempt: math.MaxUint,
+			delay:   time.Minute,
+		},
+		"zero_base_delay": {
+			policy:  retryPolicy{maxDelay: time.Minute},
+			attempt: 100,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			delay := testCase.policy.delay(testCase.attempt)
+
+			assert.Equal(t, testCase.delay, delay)
+		})
+	}
+}
+
+func Test_retryPolicy_delay_jitter(t *testing.T) {
+	t.Parallel()
+
+	policy := retryPolicy{baseDelay: time.Second, maxDelay: 2 * time.Second, jitter: time.Second}
+
+	for range 100 {
+		delay := policy.delay(5)
+		assert.GreaterOrEqual(t, delay, 2*time.Second)
+		assert.Less(t, delay, 3*time.Second)
+	}
+}
@@ -0,0 +1,108 @@
+package beget
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+)
+
+// snapshot is a record set of an FQDN as fetched before an update.
+type snapshot struct {
+	FQDN    string                     `json:"fqdn"`
+	Time    time.Time                  `json:"time"`
+	Records map[string]json.RawMessage `json:"records"`
+}
+
+// snapshotTimeFormat is the format of the time in the snapshot file names,
+// sorting them chronologically and telling apart the writes of a second.
+const snapshotTimeFormat = "20060102T150405.000000000Z"
+
+// saveSnapshot writes the record set of fqdn fetched before an update to
+// a new file <fqdn>-<time>.json in the snapshot directory, if set, so it can
+// be restored manually if both the update and its automatic rollback fail,
+// or found again after later updates. It returns the file path written, or
+// an empty path if the snapshot directory is not set.
+func (p *Provider) saveSnapshot(fqdn string, records map[string]json.RawMessage) (
+	path string, err error) {
+	if p.snapshotDir == "" {
+		return "", nil
+	}
+
+	now := time.Now().UTC()
+	data, err := json.MarshalIndent(snapshot{
+		FQDN:    fqdn,
+		Time:    now,
+		Records: records,
+	}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encoding snapshot: %w", err)
+	}
+
+	const dirPerms, filePerms = 0o700, 0o600
+	err = os.MkdirAll(p.snapshotDir, dirPerms)
+	if err != nil {
+		return "", fmt.Errorf("creating snapshot directory: %w", err)
+	}
+
+	path = filepath.Join(p.snapshotDir, fqdn+"-"+now.Format(snapshotTimeFormat)+".json")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, filePerms)
+	if err != nil {
+		return "", fmt.Errorf("creating snapshot: %w", err)
+	}
+	_, err = file.Write(data)
+	if err != nil {
+		_ = file.Close()
+		return "", fmt.Errorf("writing snapshot: %w", err)
+	}
+	err = file.Close()
+	if err != nil {
+		return "", fmt.Errorf("writing snapshot: %w", err)
+	}
+	return path, nil
+}
+
+// mayBeApplied returns true if the record set may have been written
+// despite the write failing with err, which is the case if the record set
+// verified differs from the one sent, or if no answer from Beget tells the
+// write was rejected, such as on a transport error. Writes rejected before
+// being sent, or refused by Beget, leave the record set as it was.
+func mayBeApplied(err error) bool {
+	switch {
+	case stderrors.Is(err, errors.ErrRecordsMismatch):
+		return true
+	case stderrors.Is(err, errors.ErrSuspended),
+		stderrors.Is(err, errors.ErrAuth),
+		stderrors.Is(err, errors.ErrRateLimit),
+		stderrors.Is(err, errors.ErrBadRequest),
+		stderrors.Is(err, errors.ErrUnsuccessful):
+		return false
+	default:
+		return true
+	}
+}
+
+// rollback writes back the record set snapshot of fqdn, replacing the
+// submitted record set, after the update failed with updateErr, and
+// returns updateErr with the rollback outcome.
+func (p *Provider) rollback(ctx context.Context, client *http.Client, fqdn string,
+	submitted, snapshot map[string]json.RawMessage, snapshotPath string,
+	updateErr error) (err error) {
+	auditErr, rollbackErr := p.changeRecords(ctx, client, fqdn, submitted, snapshot)
+	if rollbackErr == nil {
+		err = fmt.Errorf("%w (record set restored to its state before the update)", updateErr)
+		return stderrors.Join(err, auditErr)
+	}
+
+	err = fmt.Errorf("%w (%w: %w)", updateErr, errors.ErrRollbackFailed, rollbackErr)
+	if snapshotPath != "" {
+		err = fmt.Errorf("%w: record set before the update is saved in %s", err, snapshotPath)
+	}
+	return stderrors.Join(err, auditErr)
+}
@@ -0,0 +1,77 @@
+package beget
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+	"github.com/qdm12/ddns-updater/internal/provider/utils"
+)
+
+const srvRecordType = "SRV"
+
+// srvEntry is a single entry of an SRV record, in the format
+// used by both the getData and changeRecords methods.
+type srvEntry struct {
+	Priority uint16 `json:"priority"`
+	Weight   uint16 `json:"weight"`
+	Port     uint16 `json:"port"`
+	Target   string `json:"target"`
+}
+
+func validateSRVEntries(entries []srvEntry) (err error) {
+	for i, entry := range entries {
+		if entry.Port == 0 {
+			return fmt.Errorf("%w: SRV entry %d: port cannot be 0",
+				errors.ErrPortNotValid, i+1)
+		}
+		if entry.Target == "" {
+			continue
+		}
+		err = utils.CheckDomain(toASCII(entry.Target))
+		if err != nil {
+			return fmt.Errorf("%w: SRV entry %d target: %w",
+				errors.ErrDomainNotValid, i+1, err)
+		}
+	}
+	return nil
+}
+
+// setSRVRecord sets the SRV record of fqdn in records to the SRV entries
+// configured, where an empty target designates fqdn itself. It returns
+// true if the SRV record was already up to date. It does nothing if no
+// SRV entry is configured, leaving any SRV record as it is.
+func (p *Provider) setSRVRecord(records map[string]json.RawMessage,
+	fqdn string) (upToDate bool, err error) {
+	if len(p.srv) == 0 {
+		return true, nil
+	}
+
+	entries := make([]srvEntry, len(p.srv))
+	for i, entry := range p.srv {
+		if entry.Target == "" {
+			entry.Target = fqdn
+		}
+		entry.Target = toASCII(entry.Target)
+		entries[i] = entry
+	}
+
+	var current []srvEntry
+	if raw, ok := records[srvRecordType]; ok {
+		err = json.Unmarshal(raw, &current)
+		if err != nil {
+			return false, fmt.Errorf("Failed unmarshalling current SRV entries: %w", err)
+		}
+	}
+	if slices.Equal(current, entries) {
+		return true, nil
+	}
+
+	entriesJSON, err := json.Marshal(entries)
+	if err != nil {
+		return false, fmt.Errorf("Couldn't marshal new SRV entries JSON: %w", err)
+	}
+	records[srvRecordType] = json.RawMessage(entriesJSON)
+	return false, nil
+}
@@ -0,0 +1,160 @@
+package beget
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+	"github.com/qdm12/ddns-updater/internal/provider/utils"
+)
+
+// staticRecord is a record enforced on every update of the FQDN
+// of its host, relative to the target, replacing the current
+// entries of its type.
+type staticRecord struct {
+	Host    string        `json:"host"`
+	Type    string        `json:"type"`
+	Entries []staticEntry `json:"entries"`
+}
+
+// staticEntry is an entry of a static record, in the
+// format expected by the changeRecords method.
+type staticEntry struct {
+	Priority int    `json:"priority"`
+	Value    string `json:"value"`
+}
+
+// currentStaticEntry is an entry of a static record as returned by the
+// getData method, which names the value field after the record type,
+// and the priority of MX records "preference".
+type currentStaticEntry struct {
+	Priority   int    `json:"priority"`
+	Preference int    `json:"preference"`
+	Value      string `json:"value"`
+	Exchange   string `json:"exchange"`
+	TXTData    string `json:"txtdata"`
+	CNAME      string `json:"cname"`
+	NSDName    string `json:"nsdname"`
+}
+
+func (e currentStaticEntry) toStaticEntry() staticEntry {
+	entry := staticEntry{
+		Priority: max(e.Priority, e.Preference),
+		Value:    e.Value,
+	}
+	for _, value := range []string{e.Exchange, e.TXTData, e.CNAME, e.NSDName} {
+		if entry.Value == "" {
+			entry.Value = value
+		}
+	}
+	return entry
+}
+
+// staticRecordTypes are the record types which can be declared as static
+// records. A, AAAA and SRV records are managed by their own settings.
+var staticRecordTypes = []string{"CNAME", "MX", "NS", "TXT"} //nolint:gochecknoglobals
+
+// validateStaticRecords checks the static records are of a supported type
+// and that their host FQDN, built relative to target, is one of the fqdns
+// updated, so that they are enforced. It also sets the default host "@".
+func validateStaticRecords(records []staticRecord, target string, fqdns []string) (err error) {
+	for i, record := range records {
+		if record.Host == "" {
+			records[i].Host = "@"
+		}
+		if !slices.Contains(staticRecordTypes, record.Type) {
+			return fmt.Errorf("%w: static record %d: %q must be one of %v",
+				errors.ErrRecordTypeNotValid, i+1, record.Type, staticRecordTypes)
+		}
+		fqdn := toASCII(utils.BuildURLQueryHostname(records[i].Host, target))
+		if !slices.Contains(fqdns, fqdn) {
+			return fmt.Errorf("%w: static record %d: %s is not updated by this entry, "+
+				"add its host to the hosts setting", errors.ErrDomainNotValid, i+1, fqdn)
+		}
+	}
+	return nil
+}
+
+// setStaticRecords sets the static records of fqdn in records. It returns
+// true if they were already up to date, comparing the entries regardless of
+// their order and of the different field names of getData, see
+// staticEntriesEqual.
+func (p *Provider) setStaticRecords(records map[string]json.RawMessage,
+	fqdn string) (upToDate bool, err error) {
+	upToDate = true
+	for _, record := range p.staticRecords {
+		if toASCII(utils.BuildURLQueryHostname(record.Host, p.target)) != fqdn {
+			continue
+		}
+
+		currentEntries, err := decodeStaticEntries(records[record.Type])
+		if err != nil {
+			return false, fmt.Errorf("Failed unmarshalling current %s entries: %w", record.Type, err)
+		}
+		if staticEntriesEqual(record.Type, currentEntries, record.Entries) {
+			continue
+		}
+
+		entriesJSON, err := json.Marshal(record.Entries)
+		if err != nil {
+			return false, fmt.Errorf("Couldn't marshal new %s entries JSON: %w", record.Type, err)
+		}
+		records[record.Type] = json.RawMessage(entriesJSON)
+		upToDate = false
+	}
+	return upToDate, nil
+}
+
+// decodeStaticEntries decodes the entries raw, in the getData
+// or changeRecords format, as static entries.
+func decodeStaticEntries(raw json.RawMessage) (entries []staticEntry, err error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var current []currentStaticEntry
+	err = json.Unmarshal(raw, &current)
+	if err != nil {
+		return nil, err
+	}
+	entries = make([]staticEntry, len(current))
+	for i, entry := range current {
+		entries[i] = entry.toStaticEntry()
+	}
+	return entries, nil
+}
+
+// staticJSONEqual returns true if the entries a and b of the record type
+// recordType, each in the getData or changeRecords format, are equal.
+func staticJSONEqual(recordType string, a, b json.RawMessage) (equal bool, err error) {
+	aEntries, err := decodeStaticEntries(a)
+	if err != nil {
+		return false, err
+	}
+	bEntries, err := decodeStaticEntries(b)
+	if err != nil {
+		return false, err
+	}
+	return staticEntriesEqual(recordType, aEntries, bEntries), nil
+}
+
+// staticEntriesEqual returns true if a and b have the same entries in any
+// order. Priorities are only compared for MX records, since getData may not
+// return the priorities of other record types.
+func staticEntriesEqual(recordType string, a, b []staticEntry) bool {
+	normalize := func(entries []staticEntry) []staticEntry {
+		entries = slices.Clone(entries)
+		if recordType != "MX" {
+			for i := range entries {
+				entries[i].Priority = 0
+			}
+		}
+		slices.SortFunc(entries, func(x, y staticEntry) int {
+			return cmp.Or(strings.Compare(x.Value, y.Value), cmp.Compare(x.Priority, y.Priority))
+		})
+		return entries
+	}
+	return slices.Equal(normalize(a), normalize(b))
+}
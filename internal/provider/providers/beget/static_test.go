@@ -0,0 +1,84 @@
+package beget
+
+import (
+	"context"
+	"encoding/json"
+	"net/netip"
+	"testing"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+	"github.com/qdm12/ddns-updater/pkg/publicip/ipversion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Provider_Update_staticRecords(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeAPI{
+		records: map[string]json.RawMessage{
+			"A":   json.RawMessage(`[{"address":"2.2.2.2"}]`),
+			"MX":  json.RawMessage(`[{"exchange":"mx.example.com","preference":10}]`),
+			"TXT": json.RawMessage(`[{"txtdata":"old"}]`),
+		},
+	}
+	settings := json.RawMessage(`{"login":"login","password":"password","hosts":["www"],"static_records":[` +
+		`{"type":"MX","entries":[{"priority":10,"value":"mx.example.com"}]},` +
+		`{"type":"TXT","entries":[{"priority":10,"value":"v=spf1 mx -all"}]}]}`)
+	provider, err := New(settings, "example.com", "@", ipversion.IP4, netip.Prefix{}, withAPI(api))
+	require.NoError(t, err)
+
+	ip := netip.MustParseAddr("2.2.2.2")
+	_, err = provider.Update(context.Background(), nil, ip)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `[{"exchange":"mx.example.com","preference":10}]`, string(api.changed["MX"]))
+	assert.JSONEq(t, `[{"priority":10,"value":"v=spf1 mx -all"}]`, string(api.changed["TXT"]))
+
+	// Beget returns the TXT entry with its own field names, which is up to date.
+	api.records["TXT"] = json.RawMessage(`[{"txtdata":"v=spf1 mx -all"}]`)
+	api.changed = nil
+	_, err = provider.Update(context.Background(), nil, ip)
+	require.NoError(t, err)
+	assert.Nil(t, api.changed)
+}
+
+func Test_validateStaticRecords(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		records    []staticRecord
+		errWrapped error
+		errMessage string
+	}{
+		"valid": {
+			records: []staticRecord{{Type: "MX"}, {Host: "www", Type: "CNAME"}},
+		},
+		"managed_type": {
+			records:    []staticRecord{{Type: "A"}},
+			errWrapped: errors.ErrRecordTypeNotValid,
+			errMessage: `record type is not valid: static record 1: "A" must be one of [CNAME MX NS TXT]`,
+		},
+		"host_not_updated": {
+			records:    []staticRecord{{Host: "mail", Type: "TXT"}},
+			errWrapped: errors.ErrDomainNotValid,
+			errMessage: "domain is not valid: static record 1: mail.example.com is not " +
+				"updated by this entry, add its host to the hosts setting",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateStaticRecords(testCase.records, "example.com",
+				[]string{"example.com", "www.example.com"})
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+		})
+	}
+}
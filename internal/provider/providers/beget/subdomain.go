@@ -0,0 +1,92 @@
+package beget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+)
+
+// AddSubdomain creates the virtual subdomain of the domain with the given ID.
+// See https://beget.com/en/kb/api/functions-for-work-with-domains#addsubdomainvirtual
+func (c *apiClient) AddSubdomain(ctx context.Context, client *http.Client,
+	subdomain string, domainID int64) (err error) {
+	inputData := struct {
+		Subdomain string `json:"subdomain"`
+		DomainID  int64  `json:"domain_id"`
+	}{
+		Subdomain: subdomain,
+		DomainID:  domainID,
+	}
+
+	inputDataRaw, err := json.Marshal(inputData)
+	if err != nil {
+		return fmt.Errorf("Couldn't marshal json: %w", err)
+	}
+
+	// The call is not retried, since retrying a creation whose response
+	// is lost fails or creates the subdomain twice. The subdomain created
+	// anyway is found when listing the account entries on the next update.
+	responseRaw, err := c.apiCall(ctx, client, "/api/domain/addSubdomainVirtual",
+		inputDataRaw, withoutRetry())
+	if err != nil {
+		return fmt.Errorf("Calling addSubdomainVirtual failed: %w", err)
+	}
+
+	// The result is the ID of the subdomain created.
+	var subdomainID int64
+	err = c.redactError(decodeResponse(responseRaw, &subdomainID))
+	if err != nil {
+		return fmt.Errorf("addSubdomainVirtual: %w", err)
+	}
+	return nil
+}
+
+// ensureFQDNExists creates the subdomain fqdn on Beget if the create_missing
+// setting is enabled and fqdn is neither a domain nor a subdomain of the account.
+// FQDNs found or created are remembered, so the account is listed only once.
+func (p *Provider) ensureFQDNExists(ctx context.Context, client *http.Client,
+	fqdn string) (err error) {
+	if !p.createMissing {
+		return nil
+	}
+	if _, ok := p.existingFQDNs[fqdn]; ok {
+		return nil
+	}
+
+	domains, subdomains, err := p.listEntries(ctx, client)
+	if err != nil {
+		return fmt.Errorf("listing account domains: %w", err)
+	}
+
+	exists := slices.ContainsFunc(slices.Concat(domains, subdomains),
+		func(entry domainEntry) bool { return entry.FQDN == fqdn })
+	if exists {
+		p.existingFQDNs[fqdn] = struct{}{}
+		return nil
+	}
+
+	// Create the subdomain on the account domain closest to fqdn.
+	var parent domainEntry
+	for _, domain := range domains {
+		if strings.HasSuffix(fqdn, "."+domain.FQDN) && len(domain.FQDN) > len(parent.FQDN) {
+			parent = domain
+		}
+	}
+	if parent.FQDN == "" {
+		return fmt.Errorf("%w: no domain of the account contains %s",
+			errors.ErrDomainNotFound, fqdn)
+	}
+
+	subdomain := strings.TrimSuffix(fqdn, "."+parent.FQDN)
+	err = p.api.AddSubdomain(ctx, client, subdomain, parent.ID)
+	if err != nil {
+		return fmt.Errorf("creating subdomain %s of %s: %w", subdomain, parent.FQDN, err)
+	}
+	p.existingFQDNs[fqdn] = struct{}{}
+	return nil
+}
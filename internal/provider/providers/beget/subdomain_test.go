@@ -0,0 +1,39 @@
+package beget
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+	"github.com/qdm12/ddns-updater/internal/provider/providers/beget/begettest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_apiClient_AddSubdomain_noRetry(t *testing.T) {
+	t.Parallel()
+
+	server := begettest.New(begettest.Settings{Domains: []string{"example.com"}})
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+	apiURL, err := url.Parse(httpServer.URL)
+	require.NoError(t, err)
+	client := newAPIClient("login", "password", "", apiURL,
+		retryPolicy{maxAttempts: 3}, nil, nil)
+	ctx := context.Background()
+
+	// An idempotent call is retried.
+	server.Fail(begettest.Failure{StatusCode: http.StatusBadGateway, Times: 1})
+	_, err = client.GetData(ctx, httpServer.Client(), "example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 2, server.Calls("dns/getData"))
+
+	// The subdomain creation is not, since it may have been applied.
+	server.Fail(begettest.Failure{StatusCode: http.StatusBadGateway, Times: 1})
+	err = client.AddSubdomain(ctx, httpServer.Client(), "home", 1)
+	assert.ErrorIs(t, err, errors.ErrHTTPStatusNotValid)
+	assert.Equal(t, 1, server.Calls("domain/addSubdomainVirtual"))
+}
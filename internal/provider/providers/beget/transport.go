@@ -0,0 +1,199 @@
+package beget
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+)
+
+// transportSettings are the provider settings of the HTTP client used
+// for the Beget API of a record, for example to go through a corporate
+// proxy or TLS inspecting middlebox.
+type transportSettings struct {
+	Timeout       string `json:"timeout"`
+	MaxIdleConns  *uint  `json:"max_idle_conns"`
+	TLSMinVersion string `json:"tls_min_version"`
+	ProxyURL      string `json:"proxy_url"`
+	// CAFile is the path to a PEM file of certificate authorities
+	// trusted in addition to the system ones.
+	CAFile string `json:"ca_file"`
+	// UserAgent replaces the User-Agent header of the requests.
+	UserAgent string `json:"user_agent"`
+}
+
+// httpSettings are the settings of the HTTP client used for the Beget API,
+// overriding the ones of the HTTP client shared by all providers.
+type httpSettings struct {
+	timeout   time.Duration
+	transport *http.Transport
+	userAgent string
+}
+
+func parseHTTPSettings(transport transportSettings) (settings httpSettings, err error) {
+	if transport.Timeout != "" {
+		settings.timeout, err = time.ParseDuration(transport.Timeout)
+		if err != nil {
+			return settings, fmt.Errorf("parsing timeout: %w", err)
+		}
+		if settings.timeout <= 0 {
+			return settings, fmt.Errorf("%w: %s must be positive",
+				errors.ErrTimeoutNotValid, settings.timeout)
+		}
+	}
+
+	if strings.ContainsAny(transport.UserAgent, "\r\n") {
+		return settings, fmt.Errorf("%w: %q cannot contain line breaks",
+			errors.ErrUserAgentNotValid, transport.UserAgent)
+	}
+	settings.userAgent = transport.UserAgent
+
+	if transport.MaxIdleConns == nil && transport.TLSMinVersion == "" &&
+		transport.ProxyURL == "" && transport.CAFile == "" {
+		return settings, nil
+	}
+
+	defaultTransport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		panic(fmt.Sprintf("default transport %T is not *http.Transport", http.DefaultTransport))
+	}
+	settings.transport = defaultTransport.Clone()
+
+	if transport.MaxIdleConns != nil {
+		settings.transport.MaxIdleConns = int(*transport.MaxIdleConns)
+		settings.transport.MaxIdleConnsPerHost = int(*transport.MaxIdleConns)
+	}
+
+	tlsConfig := &tls.Config{} //nolint:gosec
+	switch transport.TLSMinVersion {
+	case "":
+	case "1.2":
+		tlsConfig.MinVersion = tls.VersionTLS12
+	case "1.3":
+		tlsConfig.MinVersion = tls.VersionTLS13
+	default:
+		return settings, fmt.Errorf("%w: %q must be 1.2 or 1.3",
+			errors.ErrTLSVersionNotValid, transport.TLSMinVersion)
+	}
+
+	if transport.CAFile != "" {
+		tlsConfig.RootCAs, err = readCAFile(transport.CAFile)
+		if err != nil {
+			return settings, err
+		}
+	}
+
+	if transport.TLSMinVersion != "" || transport.CAFile != "" {
+		settings.transport.TLSClientConfig = tlsConfig
+	}
+
+	if transport.ProxyURL != "" {
+		proxy, err := parseProxyURL(transport.ProxyURL)
+		if err != nil {
+			return settings, err
+		}
+		settings.transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	return settings, nil
+}
+
+// readCAFile returns the system certificate pool with the certificates
+// of the PEM file at path added to it.
+func readCAFile(path string) (pool *x509.CertPool, err error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA file: %w", err)
+	}
+	pool, err = x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%w: no PEM certificate found in %s",
+			errors.ErrCAFileNotValid, path)
+	}
+	return pool, nil
+}
+
+// parseProxyURL parses the URL of the HTTP, HTTPS or SOCKS5 proxy
+// to use for the Beget API only, instead of the proxy set with
+// the HTTP_PROXY environment variable for the whole program.
+// Errors never contain the proxy password.
+func parseProxyURL(proxyURL string) (u *url.URL, err error) {
+	u, err = url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: proxy URL cannot be parsed", errors.ErrURLNotValid)
+	}
+	switch {
+	case u.Scheme != "http" && u.Scheme != "https" &&
+		u.Scheme != "socks5" && u.Scheme != "socks5h":
+		return nil, fmt.Errorf("%w: proxy URL %s scheme must be http, https, socks5 or socks5h",
+			errors.ErrURLNotValid, u.Redacted())
+	case u.Host == "":
+		return nil, fmt.Errorf("%w: proxy URL %s has no host",
+			errors.ErrURLNotValid, u.Redacted())
+	}
+	return u, nil
+}
+
+// proxyingRoundTripper is implemented by round trippers wrapping another
+// round tripper, such as the one logging requests at the debug level.
+type proxyingRoundTripper interface {
+	WithProxied(proxied http.RoundTripper) http.RoundTripper
+}
+
+// userAgentRoundTripper sets the User-Agent header of the requests
+// before sending them with the round tripper it wraps.
+type userAgentRoundTripper struct {
+	userAgent string
+	wrapped   http.RoundTripper
+}
+
+func (u *userAgentRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	request = request.Clone(request.Context())
+	request.Header.Set("User-Agent", u.userAgent)
+	return u.wrapped.RoundTrip(request)
+}
+
+// wrapClient returns a copy of client using the timeout and the transport
+// of the HTTP settings, if set. If the transport of client wraps another
+// transport, the transport of the settings is wrapped instead. The user
+// agent of the settings, if set, is set by the outermost transport, so
+// it is also the one logged by a wrapping debug logging transport.
+func (s httpSettings) wrapClient(client *http.Client) *http.Client {
+	if s.timeout == 0 && s.transport == nil && s.userAgent == "" {
+		return client
+	}
+
+	if client == nil {
+		client = &http.Client{}
+	}
+	wrapped := *client
+
+	if s.timeout > 0 {
+		wrapped.Timeout = s.timeout
+	}
+
+	if s.transport != nil {
+		wrapped.Transport = s.transport
+		if proxying, ok := client.Transport.(proxyingRoundTripper); ok {
+			wrapped.Transport = proxying.WithProxied(s.transport)
+		}
+	}
+
+	if s.userAgent != "" {
+		transport := wrapped.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		wrapped.Transport = &userAgentRoundTripper{userAgent: s.userAgent, wrapped: transport}
+	}
+	return &wrapped
+}
@@ -0,0 +1,157 @@
+package beget
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+)
+
+// updateAll updates the A and/or AAAA records of all the FQDNs
+// of the provider, carrying on with the next FQDNs on error.
+func (p *Provider) updateAll(ctx context.Context, client *http.Client, ips ...netip.Addr) (err error) {
+	err = p.refreshFQDNs(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, fqdn := range p.getFQDNs() {
+		err = p.updateRecords(ctx, client, fqdn, ips...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", fqdn, err))
+		}
+	}
+
+	// The records are updated regardless, for example to prepare
+	// a migration to the Beget nameservers.
+	if !p.discover {
+		err = p.delegation.check(ctx, p.domain)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return stderrors.Join(errs...)
+}
+
+func (p *Provider) updateRecords(ctx context.Context, client *http.Client,
+	fqdn string, ips ...netip.Addr) (err error) {
+	// Beget API DNS administration docs: https://beget.com/en/kb/api/dns-administration-functions
+
+	err = p.ensureFQDNExists(ctx, client, fqdn)
+	if err != nil {
+		return err
+	}
+
+	fqdn, err = p.entityFor(ctx, client, fqdn)
+	if err != nil {
+		return err
+	}
+
+	// The cached record set is only used to skip up to date records: records
+	// are always fetched again before a write, so that changes made since
+	// on the Beget control panel are not overwritten.
+	if cached, ok := p.cache.get(fqdn); ok {
+		upToDate, err := p.setRecords(cached, fqdn, ips)
+		if err == nil && upToDate {
+			return nil
+		}
+	}
+
+	if p.dryRun {
+		return p.dryRunUpdate(ctx, client, fqdn, ips)
+	}
+
+	err = p.applyChange(ctx, client, fqdn, func(records map[string]json.RawMessage) (
+		changed bool, err error) {
+		upToDate, err := p.setRecords(records, fqdn, ips)
+		return !upToDate, err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, ip := range ips {
+		if ip.IsValid() {
+			p.setPreviousIP(fqdn, ip)
+		}
+	}
+	return p.propagation.wait(ctx, fqdn, ips)
+}
+
+// setRecords sets the A, AAAA and SRV records of fqdn in records,
+// and returns true if they were all already up to date.
+func (p *Provider) setRecords(records map[string]json.RawMessage,
+	fqdn string, ips []netip.Addr) (upToDate bool, err error) {
+	ipsUpToDate, err := p.setIPRecords(records, fqdn, ips)
+	if err != nil {
+		return false, err
+	}
+	srvUpToDate, err := p.setSRVRecord(records, fqdn)
+	if err != nil {
+		return false, err
+	}
+	staticUpToDate, err := p.setStaticRecords(records, fqdn)
+	if err != nil {
+		return false, err
+	}
+	return ipsUpToDate && srvUpToDate && staticUpToDate, nil
+}
+
+// setIPRecords sets the records of the valid IP addresses ips in records,
+// and returns true if they were all already up to date.
+func (p *Provider) setIPRecords(records map[string]json.RawMessage,
+	fqdn string, ips []netip.Addr) (upToDate bool, err error) {
+	upToDate = true
+	for _, ip := range ips {
+		if !ip.IsValid() {
+			continue
+		}
+		ipUpToDate, err := p.ipRecordUpToDate(records, fqdn, ip)
+		if err != nil {
+			return false, err
+		}
+		if ipUpToDate {
+			p.setPreviousIP(fqdn, ip)
+			continue
+		}
+		upToDate = false
+		err = p.setIPRecord(records, fqdn, ip)
+		if err != nil {
+			return false, err
+		}
+	}
+	return upToDate, nil
+}
+
+// dryRunUpdate fetches the record set of fqdn and computes the changeRecords
+// payload, but does not send it. It returns an error wrapping errors.ErrDryRun
+// with the payload, which contains no credentials since these are sent as
+// separate form values, or nil if the records are already up to date.
+// The updater does not count errors.ErrDryRun as an update failure.
+func (p *Provider) dryRunUpdate(ctx context.Context, client *http.Client,
+	fqdn string, ips []netip.Addr) (err error) {
+	records, err := p.api.GetData(ctx, client, fqdn)
+	if err != nil {
+		return err
+	}
+
+	upToDate, err := p.setRecords(records, fqdn, ips)
+	if err != nil {
+		return err
+	}
+	if upToDate {
+		return nil
+	}
+
+	recordsJSON, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("Couldn't marshal json: %w", err)
+	}
+	return fmt.Errorf("%w: changeRecords not called with records %s",
+		errors.ErrDryRun, recordsJSON)
+}
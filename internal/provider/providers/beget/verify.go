@@ -0,0 +1,153 @@
+package beget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/qdm12/ddns-updater/internal/provider/constants"
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+)
+
+// verifyRecords fetches the record set of fqdn again and returns an error
+// listing the differences with the records submitted to changeRecords.
+// Since changeRecords replaces the whole record set, this catches any
+// record lost or altered by the update.
+func (p *Provider) verifyRecords(ctx context.Context, client *http.Client,
+	fqdn string, submitted map[string]json.RawMessage) (err error) {
+	fetched, err := p.api.GetData(ctx, client, fqdn)
+	if err != nil {
+		return fmt.Errorf("fetching records to verify: %w", err)
+	}
+
+	differences, err := diffRecords(submitted, fetched)
+	if err != nil {
+		return fmt.Errorf("verifying records: %w", err)
+	}
+	if len(differences) > 0 {
+		return fmt.Errorf("%w: after changeRecords: %s",
+			errors.ErrRecordsMismatch, strings.Join(differences, "; "))
+	}
+	p.cache.set(fqdn, fetched)
+	return nil
+}
+
+// diffRecords returns a description of each record type differing between
+// the submitted and fetched record sets.
+func diffRecords(submitted, fetched map[string]json.RawMessage) (
+	differences []string, err error) {
+	recordTypes, err := differingRecordTypes(submitted, fetched)
+	if err != nil {
+		return nil, err
+	}
+	for _, recordType := range recordTypes {
+		differences = append(differences, fmt.Sprintf("%s: submitted %s but got %s",
+			recordType, recordString(submitted[recordType]), recordString(fetched[recordType])))
+	}
+	return differences, nil
+}
+
+// differingRecordTypes returns the sorted record types differing between
+// the record sets a and b. A and AAAA records are compared by IP addresses
+// only, and CNAME, MX, NS and TXT records by values and MX priorities only,
+// since getData and changeRecords use different fields.
+func differingRecordTypes(a, b map[string]json.RawMessage) (
+	recordTypes []string, err error) {
+	allRecordTypes := make([]string, 0, len(a))
+	for recordType := range a {
+		allRecordTypes = append(allRecordTypes, recordType)
+	}
+	for recordType := range b {
+		if _, ok := a[recordType]; !ok {
+			allRecordTypes = append(allRecordTypes, recordType)
+		}
+	}
+	slices.Sort(allRecordTypes)
+
+	for _, recordType := range allRecordTypes {
+		var equal bool
+		switch recordType {
+		case constants.A, constants.AAAA:
+			equal, err = ipEntriesEqual(a, b, recordType)
+		case "CNAME", "MX", "NS", "TXT":
+			equal, err = staticJSONEqual(recordType, a[recordType], b[recordType])
+		default:
+			equal, err = jsonEqual(a[recordType], b[recordType])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("comparing %s records: %w", recordType, err)
+		}
+		if !equal {
+			recordTypes = append(recordTypes, recordType)
+		}
+	}
+	return recordTypes, nil
+}
+
+func ipEntriesEqual(submitted, fetched map[string]json.RawMessage,
+	recordType string) (equal bool, err error) {
+	submittedIPs, err := sortedEntryIPs(submitted, recordType)
+	if err != nil {
+		return false, fmt.Errorf("submitted: %w", err)
+	}
+	fetchedIPs, err := sortedEntryIPs(fetched, recordType)
+	if err != nil {
+		return false, fmt.Errorf("fetched: %w", err)
+	}
+	return slices.Equal(submittedIPs, fetchedIPs), nil
+}
+
+func sortedEntryIPs(records map[string]json.RawMessage, recordType string) (
+	ips []netip.Addr, err error) {
+	entries, err := currentEntries(records, recordType)
+	if err != nil {
+		return nil, err
+	}
+	ips = make([]netip.Addr, len(entries))
+	for i, entry := range entries {
+		ips[i], err = entry.ip()
+		if err != nil {
+			return nil, err
+		}
+	}
+	slices.SortFunc(ips, netip.Addr.Compare)
+	return ips, nil
+}
+
+// jsonEqual returns true if a and b are semantically equal JSON values,
+// where a missing value is equal to an empty list of entries.
+func jsonEqual(a, b json.RawMessage) (equal bool, err error) {
+	var aValue, bValue any
+	if len(a) > 0 {
+		err = json.Unmarshal(a, &aValue)
+		if err != nil {
+			return false, err
+		}
+	}
+	if len(b) > 0 {
+		err = json.Unmarshal(b, &bValue)
+		if err != nil {
+			return false, err
+		}
+	}
+	return reflect.DeepEqual(emptyToNil(aValue), emptyToNil(bValue)), nil
+}
+
+func emptyToNil(value any) any {
+	if list, ok := value.([]any); ok && len(list) == 0 {
+		return nil
+	}
+	return value
+}
+
+func recordString(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return "nothing"
+	}
+	return trimErrorText(string(raw))
+}
@@ -0,0 +1,69 @@
+package beget
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_diffRecords(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		submitted   map[string]json.RawMessage
+		fetched     map[string]json.RawMessage
+		differences []string
+		errMessage  string
+	}{
+		"equal": {
+			submitted: map[string]json.RawMessage{
+				"A":   json.RawMessage(`[{"priority":10,"value":"1.1.1.1"},{"priority":20,"value":"2.2.2.2"}]`),
+				"MX":  json.RawMessage(`[{"exchange":"mx.example.com","preference":10}]`),
+				"TXT": json.RawMessage(`[]`),
+			},
+			fetched: map[string]json.RawMessage{
+				"A":  json.RawMessage(`[{"address":"2.2.2.2","ttl":600},{"address":"1.1.1.1","ttl":600}]`),
+				"MX": json.RawMessage(`[{"preference":10, "exchange":"mx.example.com"}]`),
+			},
+		},
+		"differences": {
+			submitted: map[string]json.RawMessage{
+				"A":  json.RawMessage(`[{"priority":10,"value":"1.1.1.1"}]`),
+				"MX": json.RawMessage(`[{"exchange":"mx.example.com","preference":10}]`),
+			},
+			fetched: map[string]json.RawMessage{
+				"A":   json.RawMessage(`[{"address":"3.3.3.3"}]`),
+				"TXT": json.RawMessage(`[{"txtdata":"v=spf1"}]`),
+			},
+			differences: []string{
+				`A: submitted [{"priority":10,"value":"1.1.1.1"}] but got [{"address":"3.3.3.3"}]`,
+				`MX: submitted [{"exchange":"mx.example.com","preference":10}] but got nothing`,
+				`TXT: submitted nothing but got [{"txtdata":"v=spf1"}]`,
+			},
+		},
+		"malformed": {
+			submitted: map[string]json.RawMessage{
+				"MX": json.RawMessage(`[`),
+			},
+			errMessage: "comparing MX records: unexpected end of JSON input",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			differences, err := diffRecords(testCase.submitted, testCase.fetched)
+
+			if testCase.errMessage != "" {
+				assert.EqualError(t, err, testCase.errMessage)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.differences, differences)
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package beget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+)
+
+const (
+	zoneFormatJSON = "json"
+	zoneFormatBIND = "bind"
+)
+
+// ExportZone fetches the record set of fqdn, the provider domain or one of its
+// subdomains, and returns it encoded in format: "json" for a snapshot file in
+// the format of the snapshot_dir files, or "bind" for a BIND style zone text.
+func (p *Provider) ExportZone(ctx context.Context, client *http.Client,
+	fqdn, format string) (data []byte, err error) {
+	fqdn, err = p.withinDomain(fqdn)
+	if err != nil {
+		return nil, err
+	}
+	if format != zoneFormatJSON && format != zoneFormatBIND {
+		return nil, fmt.Errorf("%w: %q must be %q or %q",
+			errors.ErrFormatNotValid, format, zoneFormatJSON, zoneFormatBIND)
+	}
+
+	records, err := p.api.GetData(ctx, p.http.wrapClient(client), fqdn)
+	if err != nil {
+		return nil, fmt.Errorf("fetching records: %w", err)
+	}
+
+	if format == zoneFormatBIND {
+		return []byte(bindZone(fqdn, records)), nil
+	}
+	data, err = json.MarshalIndent(snapshot{
+		FQDN:    fqdn,
+		Time:    time.Now(),
+		Records: records,
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding records: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// RestoreZone writes back the record set of a snapshot, in the
+// format of the snapshot_dir files and of the zones exported as JSON,
+// replacing the whole record set of its FQDN with changeRecords.
+// The differences with the current record set are given to confirm,
+// and the record set is only written if it returns true. Nothing is
+// written, nor confirmed, if the record sets are already equal.
+func (p *Provider) RestoreZone(ctx context.Context, client *http.Client, data []byte,
+	confirm func(fqdn, diff string) bool) (err error) {
+	var restored snapshot
+	err = json.Unmarshal(data, &restored)
+	if err != nil {
+		return fmt.Errorf("decoding snapshot: %w", err)
+	}
+	fqdn, err := p.withinDomain(restored.FQDN)
+	if err != nil {
+		return err
+	}
+
+	return p.applyChange(ctx, p.http.wrapClient(client), fqdn,
+		func(records map[string]json.RawMessage) (changed bool, err error) {
+			recordTypes, err := differingRecordTypes(records, restored.Records)
+			if err != nil {
+				return false, err
+			}
+			if len(recordTypes) == 0 {
+				return false, nil
+			}
+
+			diff := make([]string, len(recordTypes))
+			for i, recordType := range recordTypes {
+				diff[i] = fmt.Sprintf("%s: %s -> %s", recordType,
+					diffString(records[recordType]), diffString(restored.Records[recordType]))
+			}
+			if !confirm(fqdn, strings.Join(diff, "\n")) {
+				return false, fmt.Errorf("%w: for %s", errors.ErrRestoreDeclined, fqdn)
+			}
+
+			clear(records)
+			maps.Copy(records, restored.Records)
+			return true, nil
+		})
+}
+
+// bindEntry is an entry of any record type, with the fields
+// of both the getData and changeRecords formats.
+type bindEntry struct {
+	Address    string  `json:"address"`
+	Value      string  `json:"value"`
+	Exchange   string  `json:"exchange"`
+	TXTData    string  `json:"txtdata"`
+	CNAME      string  `json:"cname"`
+	NSDName    string  `json:"nsdname"`
+	Target     string  `json:"target"`
+	Preference *int    `json:"preference"`
+	Priority   *int    `json:"priority"`
+	Weight     int     `json:"weight"`
+	Port       int     `json:"port"`
+	TTL        *uint32 `json:"ttl"`
+}
+
+// bindZone returns the records of fqdn as BIND style zone lines, sorted
+// by record type. Entries of unknown record types are written as comments
+// with their JSON, so no record is silently left out.
+func bindZone(fqdn string, records map[string]json.RawMessage) string {
+	recordTypes := make([]string, 0, len(records))
+	for recordType := range records {
+		recordTypes = append(recordTypes, recordType)
+	}
+	slices.Sort(recordTypes)
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "; %s record set exported from Beget on %s\n",
+		fqdn, time.Now().UTC().Format(time.RFC3339))
+	for _, recordType := range recordTypes {
+		var rawEntries []json.RawMessage
+		err := json.Unmarshal(records[recordType], &rawEntries)
+		if err != nil {
+			fmt.Fprintf(&builder, "; %s: %s\n", recordType, records[recordType])
+			continue
+		}
+		for _, rawEntry := range rawEntries {
+			var entry bindEntry
+			err = json.Unmarshal(rawEntry, &entry)
+			data, ok := bindData(recordType, entry)
+			if err != nil || !ok {
+				fmt.Fprintf(&builder, "; %s: %s\n", recordType, rawEntry)
+				continue
+			}
+			ttl := ""
+			if entry.TTL != nil {
+				ttl = strconv.FormatUint(uint64(*entry.TTL), 10) + " "
+			}
+			fmt.Fprintf(&builder, "%s. %sIN %s %s\n", fqdn, ttl, recordType, data)
+		}
+	}
+	return builder.String()
+}
+
+// bindData returns the data of the entry of type recordType in BIND
+// format, or false if the record type is not supported.
+func bindData(recordType string, entry bindEntry) (data string, ok bool) {
+	value := firstNonEmpty(entry.Address, entry.Exchange, entry.TXTData,
+		entry.CNAME, entry.NSDName, entry.Target, entry.Value)
+	priority := entry.Priority
+	if entry.Preference != nil {
+		priority = entry.Preference
+	}
+
+	switch recordType {
+	case "A", "AAAA":
+		return value, value != ""
+	case "CNAME", "NS":
+		return fqdnDot(value), value != ""
+	case "TXT":
+		return strconv.Quote(value), true
+	case "MX":
+		if priority == nil {
+			return "", false
+		}
+		return fmt.Sprintf("%d %s", *priority, fqdnDot(value)), value != ""
+	case srvRecordType:
+		if priority == nil {
+			return "", false
+		}
+		return fmt.Sprintf("%d %d %d %s", *priority, entry.Weight, entry.Port,
+			fqdnDot(value)), value != ""
+	default:
+		return "", false
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func fqdnDot(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+// diffString returns the entries of a record type in full, unlike
+// recordString, since a restore is confirmed based on them.
+func diffString(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return "nothing"
+	}
+	return string(raw)
+}
@@ -0,0 +1,193 @@
+package beget
+
+import (
+	"context"
+	"encoding/json"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+	"github.com/qdm12/ddns-updater/pkg/publicip/ipversion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_bindZone(t *testing.T) {
+	t.Parallel()
+
+	records := map[string]json.RawMessage{
+		"A":     json.RawMessage(`[{"ttl":600,"address":"1.2.3.4"}]`),
+		"MX":    json.RawMessage(`[{"preference":10,"exchange":"mx.example.com"}]`),
+		"TXT":   json.RawMessage(`[{"txtdata":"v=spf1 -all"}]`),
+		"NS":    json.RawMessage(`[{"nsdname":"ns1.beget.com."}]`),
+		"SRV":   json.RawMessage(`[{"priority":0,"weight":5,"port":25565,"target":"mc.example.com"}]`),
+		"CAA":   json.RawMessage(`[{"flags":0,"tag":"issue","value":"letsencrypt.org"}]`),
+		"CNAME": json.RawMessage(`[]`),
+	}
+
+	zone := bindZone("example.com", records)
+
+	lines := strings.Split(strings.TrimSuffix(zone, "\n"), "\n")
+	require.NotEmpty(t, lines)
+	assert.True(t, strings.HasPrefix(lines[0], "; example.com record set exported from Beget on "))
+	expected := []string{
+		"example.com. 600 IN A 1.2.3.4",
+		`; CAA: {"flags":0,"tag":"issue","value":"letsencrypt.org"}`,
+		"example.com. IN MX 10 mx.example.com.",
+		"example.com. IN NS ns1.beget.com.",
+		"example.com. IN SRV 0 5 25565 mc.example.com.",
+		`example.com. IN TXT "v=spf1 -all"`,
+	}
+	assert.Equal(t, expected, lines[1:])
+}
+
+func Test_Provider_ExportZone(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		fqdn       string
+		format     string
+		check      func(t *testing.T, data []byte)
+		errWrapped error
+		errMessage string
+	}{
+		"json": {
+			fqdn:   "www.example.com.",
+			format: "json",
+			check: func(t *testing.T, data []byte) {
+				t.Helper()
+				var exported snapshot
+				err := json.Unmarshal(data, &exported)
+				require.NoError(t, err)
+				assert.Equal(t, "www.example.com", exported.FQDN)
+				assert.JSONEq(t, `[{"address":"1.2.3.4"}]`, string(exported.Records["A"]))
+			},
+		},
+		"bind": {
+			fqdn:   "example.com",
+			format: "bind",
+			check: func(t *testing.T, data []byte) {
+				t.Helper()
+				assert.Contains(t, string(data), "example.com. IN A 1.2.3.4\n")
+			},
+		},
+		"outside_domain": {
+			fqdn:       "example.net",
+			format:     "json",
+			errWrapped: errors.ErrDomainNotValid,
+			errMessage: "domain is not valid: example.net is not within example.com",
+		},
+		"bad_format": {
+			fqdn:       "example.com",
+			format:     "yaml",
+			errWrapped: errors.ErrFormatNotValid,
+			errMessage: `format is not valid: "yaml" must be "json" or "bind"`,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			api := &fakeAPI{records: map[string]json.RawMessage{
+				"A": json.RawMessage(`[{"address":"1.2.3.4"}]`),
+			}}
+			provider, err := New(json.RawMessage(`{"login":"login","password":"password"}`), "example.com", "@",
+				ipversion.IP4, netip.Prefix{}, withAPI(api))
+			require.NoError(t, err)
+
+			data, err := provider.ExportZone(context.Background(), nil,
+				testCase.fqdn, testCase.format)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+				return
+			}
+			testCase.check(t, data)
+		})
+	}
+}
+
+func Test_Provider_RestoreZone(t *testing.T) {
+	t.Parallel()
+
+	const snapshotData = `{"fqdn":"example.com","time":"2024-01-02T03:04:05Z",` +
+		`"records":{"A":[{"address":"1.2.3.4"}],"TXT":[{"txtdata":"hello"}]}}`
+
+	testCases := map[string]struct {
+		records    map[string]json.RawMessage
+		data       string
+		confirm    bool
+		diff       string
+		changed    map[string]json.RawMessage
+		errWrapped error
+		errMessage string
+	}{
+		"restored": {
+			records: map[string]json.RawMessage{
+				"A": json.RawMessage(`[{"address":"5.6.7.8"}]`),
+			},
+			data:    snapshotData,
+			confirm: true,
+			diff: `A: [{"address":"5.6.7.8"}] -> [{"address":"1.2.3.4"}]` + "\n" +
+				`TXT: nothing -> [{"txtdata":"hello"}]`,
+			changed: map[string]json.RawMessage{
+				"A":   json.RawMessage(`[{"address":"1.2.3.4"}]`),
+				"TXT": json.RawMessage(`[{"txtdata":"hello"}]`),
+			},
+		},
+		"declined": {
+			records: map[string]json.RawMessage{
+				"A": json.RawMessage(`[{"address":"5.6.7.8"}]`),
+			},
+			data: snapshotData,
+			diff: `A: [{"address":"5.6.7.8"}] -> [{"address":"1.2.3.4"}]` + "\n" +
+				`TXT: nothing -> [{"txtdata":"hello"}]`,
+			errWrapped: errors.ErrRestoreDeclined,
+			errMessage: "restore declined: for example.com",
+		},
+		"already_equal": {
+			records: map[string]json.RawMessage{
+				"A":   json.RawMessage(`[{"address":"1.2.3.4"}]`),
+				"TXT": json.RawMessage(`[{"txtdata":"hello"}]`),
+			},
+			data: snapshotData,
+		},
+		"outside_domain": {
+			data:       `{"fqdn":"example.net","records":{}}`,
+			errWrapped: errors.ErrDomainNotValid,
+			errMessage: "domain is not valid: example.net is not within example.com",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			api := &fakeAPI{records: testCase.records}
+			provider, err := New(json.RawMessage(`{"login":"login","password":"password","verify":false}`), "example.com", "@",
+				ipversion.IP4, netip.Prefix{}, withAPI(api))
+			require.NoError(t, err)
+
+			var diff string
+			confirm := func(fqdn, d string) bool {
+				assert.Equal(t, "example.com", fqdn)
+				diff = d
+				return testCase.confirm
+			}
+			err = provider.RestoreZone(context.Background(), nil,
+				[]byte(testCase.data), confirm)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.Equal(t, testCase.diff, diff)
+			assert.Equal(t, testCase.changed, api.changed)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package external
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+)
+
+// ProtocolVersion is the version of the plugin protocol,
+// sent to the plugin command in each request.
+const ProtocolVersion = 1
+
+// ActionUpdate is the action of the requests updating a record.
+const ActionUpdate = "update"
+
+// Request is the JSON object written to the standard input
+// of the plugin command.
+type Request struct {
+	ProtocolVersion int    `json:"protocol_version"`
+	Action          string `json:"action"`
+	Domain          string `json:"domain"`
+	Owner           string `json:"owner"`
+	FQDN            string `json:"fqdn"`
+	IPVersion       string `json:"ip_version"`
+	// IP is the IP address to set on the record.
+	IP string `json:"ip"`
+	// Settings are the "settings" of the config entry, passed
+	// as they are, such as the credentials of the provider.
+	Settings json.RawMessage `json:"settings,omitempty"`
+}
+
+// Response is the JSON object written by the plugin command
+// to its standard output.
+type Response struct {
+	// IP is the IP address of the record once updated, and
+	// defaults to the IP address of the request if empty.
+	IP string `json:"ip,omitempty"`
+	// Error is the error message of a failed update.
+	Error string `json:"error,omitempty"`
+	// ErrorKind is the kind of the error, one of the error kinds,
+	// or empty for an unsuccessful update of no particular kind.
+	ErrorKind string `json:"error_kind,omitempty"`
+}
+
+// Error kinds of the responses.
+const (
+	ErrorKindAuth       = "auth"
+	ErrorKindRateLimit  = "rate_limit"
+	ErrorKindBanned     = "banned"
+	ErrorKindBadRequest = "bad_request"
+)
+
+// errorKinds maps the error kinds to the shared provider errors.
+var errorKinds = map[string]error{ //nolint:gochecknoglobals
+	ErrorKindAuth:       errors.ErrAuth,
+	ErrorKindRateLimit:  errors.ErrRateLimit,
+	ErrorKindBanned:     errors.ErrBannedAbuse,
+	ErrorKindBadRequest: errors.ErrBadRequest,
+}
+
+// ErrorKind returns the error kind of err, to set in a
+// response, or an empty string if it has no particular kind.
+func ErrorKind(err error) (kind string) {
+	for kind, kindErr := range errorKinds {
+		if stderrors.Is(err, kindErr) {
+			return kind
+		}
+	}
+	return ""
+}
+
+// err returns the error of the response, wrapping the shared
+// provider error of its kind, or nil if the response has no error.
+func (r Response) err() error {
+	if r.Error == "" && r.ErrorKind == "" {
+		return nil
+	}
+	kindErr, ok := errorKinds[r.ErrorKind]
+	if !ok {
+		kindErr = errors.ErrUnsuccessful
+	}
+	if r.Error == "" {
+		return fmt.Errorf("%w", kindErr)
+	}
+	return fmt.Errorf("%w: %s", kindErr, r.Error)
+}
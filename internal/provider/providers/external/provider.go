@@ -0,0 +1,198 @@
+// Package external implements a provider running a plugin command for each
+// update, exchanging JSON objects over its standard input and output, so
+// that providers not built in the program can be used.
+package external
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/netip"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/models"
+	"github.com/qdm12/ddns-updater/internal/provider/constants"
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+	"github.com/qdm12/ddns-updater/internal/provider/utils"
+	"github.com/qdm12/ddns-updater/pkg/publicip/ipversion"
+)
+
+type Provider struct {
+	domain     string
+	owner      string
+	ipVersion  ipversion.IPVersion
+	ipv6Suffix netip.Prefix
+	command    []string
+	timeout    time.Duration
+	settings   json.RawMessage
+}
+
+func New(data json.RawMessage, domain, owner string,
+	ipVersion ipversion.IPVersion, ipv6Suffix netip.Prefix) (
+	p *Provider, err error) {
+	extraSettings := struct {
+		Command  []string        `json:"command"`
+		Timeout  string          `json:"timeout"`
+		Settings json.RawMessage `json:"settings"`
+	}{}
+	err = json.Unmarshal(data, &extraSettings)
+	if err != nil {
+		return nil, fmt.Errorf("JSON decoding provider specific settings: %w", err)
+	}
+
+	const defaultTimeout = time.Minute
+	timeout := defaultTimeout
+	if extraSettings.Timeout != "" {
+		timeout, err = time.ParseDuration(extraSettings.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("validating provider specific settings: parsing timeout: %w", err)
+		}
+	}
+
+	err = validateSettings(domain, extraSettings.Command, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("validating provider specific settings: %w", err)
+	}
+
+	return &Provider{
+		domain:     domain,
+		owner:      owner,
+		ipVersion:  ipVersion,
+		ipv6Suffix: ipv6Suffix,
+		command:    extraSettings.Command,
+		timeout:    timeout,
+		settings:   extraSettings.Settings,
+	}, nil
+}
+
+func validateSettings(domain string, command []string, timeout time.Duration) (err error) {
+	err = utils.CheckDomain(domain)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errors.ErrDomainNotValid, err)
+	}
+
+	switch {
+	case len(command) == 0 || command[0] == "":
+		return fmt.Errorf("%w", errors.ErrCommandNotSet)
+	case timeout <= 0:
+		return fmt.Errorf("%w: %s must be positive", errors.ErrTimeoutNotValid, timeout)
+	default:
+		return nil
+	}
+}
+
+func (p *Provider) String() string {
+	return utils.ToString(p.domain, p.owner, constants.External, p.ipVersion)
+}
+
+func (p *Provider) Domain() string {
+	return p.domain
+}
+
+func (p *Provider) Owner() string {
+	return p.owner
+}
+
+func (p *Provider) IPVersion() ipversion.IPVersion {
+	return p.ipVersion
+}
+
+func (p *Provider) IPv6Suffix() netip.Prefix {
+	return p.ipv6Suffix
+}
+
+func (p *Provider) Proxied() bool {
+	return false
+}
+
+func (p *Provider) BuildDomainName() string {
+	return utils.BuildDomainName(p.owner, p.domain)
+}
+
+func (p *Provider) HTML() models.HTMLRow {
+	return models.HTMLRow{
+		Domain:    fmt.Sprintf("<a href=\"http://%s\">%s</a>", p.BuildDomainName(), p.BuildDomainName()),
+		Owner:     p.Owner(),
+		Provider:  fmt.Sprintf("%s: %s", constants.External, html.EscapeString(filepath.Base(p.command[0]))),
+		IPVersion: p.ipVersion.String(),
+	}
+}
+
+// Update runs the plugin command with an update request for the ip
+// address, and returns the IP address of its response.
+func (p *Provider) Update(ctx context.Context, _ *http.Client, ip netip.Addr) (newIP netip.Addr, err error) {
+	request := Request{
+		ProtocolVersion: ProtocolVersion,
+		Action:          ActionUpdate,
+		Domain:          p.domain,
+		Owner:           p.owner,
+		FQDN:            p.BuildDomainName(),
+		IPVersion:       p.ipVersion.String(),
+		IP:              ip.String(),
+		Settings:        p.settings,
+	}
+
+	response, err := p.run(ctx, request)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	if response.IP == "" {
+		return ip, nil
+	}
+	newIP, err = netip.ParseAddr(response.IP)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("%w: %w", errors.ErrIPReceivedMalformed, err)
+	} else if newIP.Compare(ip) != 0 {
+		return netip.Addr{}, fmt.Errorf("%w: sent ip %s to update but received %s",
+			errors.ErrIPReceivedMismatch, ip, newIP)
+	}
+	return newIP, nil
+}
+
+// run runs the plugin command with the request written to its standard
+// input, and returns the response written to its standard output, or the
+// error of the response. The command is killed after the timeout.
+func (p *Provider) run(ctx context.Context, request Request) (response Response, err error) {
+	input, err := json.Marshal(request)
+	if err != nil {
+		return response, fmt.Errorf("encoding request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, p.command[0], p.command[1:]...) //nolint:gosec
+	cmd.Stdin = bytes.NewReader(input)
+	stdout := bytes.NewBuffer(nil)
+	cmd.Stdout = stdout
+	stderr := bytes.NewBuffer(nil)
+	cmd.Stderr = stderr
+	runErr := cmd.Run()
+
+	output := bytes.TrimSpace(stdout.Bytes())
+	decodeErr := json.Unmarshal(output, &response)
+	switch {
+	case runErr != nil && decodeErr != nil:
+		if ctx.Err() != nil {
+			return response, fmt.Errorf("running plugin command: %w", ctx.Err())
+		}
+		return response, fmt.Errorf("%w: running plugin command: %w: %s",
+			errors.ErrUnsuccessful, runErr, utils.ToSingleLine(stderr.String()))
+	case decodeErr != nil:
+		return response, fmt.Errorf("%w: decoding plugin response: %w: %s",
+			errors.ErrUnknownResponse, decodeErr, utils.ToSingleLine(string(output)))
+	}
+
+	err = response.err()
+	if err == nil && runErr != nil {
+		return response, fmt.Errorf("%w: running plugin command: %w",
+			errors.ErrUnsuccessful, runErr)
+	}
+	return response, err
+}
@@ -0,0 +1,126 @@
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/qdm12/ddns-updater/internal/provider/errors"
+	"github.com/qdm12/ddns-updater/pkg/publicip/ipversion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeScript writes a shell script with the body given to a
+// temporary directory and returns its path.
+func writeScript(t *testing.T, body string) (path string) {
+	t.Helper()
+	path = filepath.Join(t.TempDir(), "plugin.sh")
+	const perms = 0o700
+	err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), perms)
+	require.NoError(t, err)
+	return path
+}
+
+func Test_Provider_Update(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not supported")
+	}
+
+	testCases := map[string]struct {
+		script   string
+		ip       netip.Addr
+		errWrap  error
+		errMatch string
+	}{
+		"success": {
+			script: `read -r request
+case "$request" in
+  *'"settings":{"token":"secret"}'*) echo '{"ip":"1.2.3.4"}' ;;
+  *) echo '{"error":"settings not received"}' ;;
+esac`,
+			ip: netip.MustParseAddr("1.2.3.4"),
+		},
+		"no_ip_in_response": {
+			script: `cat > /dev/null; echo '{}'`,
+			ip:     netip.MustParseAddr("1.2.3.4"),
+		},
+		"auth_error": {
+			script:   `cat > /dev/null; echo '{"error":"token rejected","error_kind":"auth"}'; exit 1`,
+			errWrap:  errors.ErrAuth,
+			errMatch: "bad authentication: token rejected",
+		},
+		"unknown_error_kind": {
+			script:   `cat > /dev/null; echo '{"error":"zone locked","error_kind":"locked"}'`,
+			errWrap:  errors.ErrUnsuccessful,
+			errMatch: "unsuccessful result: zone locked",
+		},
+		"exit_without_response": {
+			script:   `cat > /dev/null; echo 'cannot connect' >&2; exit 3`,
+			errWrap:  errors.ErrUnsuccessful,
+			errMatch: "unsuccessful result: running plugin command: exit status 3: cannot connect",
+		},
+		"malformed_response": {
+			script:  `cat > /dev/null; echo 'done'`,
+			errWrap: errors.ErrUnknownResponse,
+			errMatch: "unknown response received: decoding plugin response: " +
+				"invalid character 'd' looking for beginning of value: done",
+		},
+		"ip_mismatch": {
+			script:   `cat > /dev/null; echo '{"ip":"5.6.7.8"}'`,
+			errWrap:  errors.ErrIPReceivedMismatch,
+			errMatch: "mismatching IP address received: sent ip 1.2.3.4 to update but received 5.6.7.8",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			settings, err := json.Marshal(map[string]any{
+				"command":  []string{writeScript(t, testCase.script)},
+				"settings": map[string]string{"token": "secret"},
+			})
+			require.NoError(t, err)
+			provider, err := New(settings, "example.com", "@", ipversion.IP4, netip.Prefix{})
+			require.NoError(t, err)
+
+			ip, err := provider.Update(context.Background(), nil, netip.MustParseAddr("1.2.3.4"))
+
+			assert.ErrorIs(t, err, testCase.errWrap)
+			if testCase.errWrap != nil {
+				assert.EqualError(t, err, testCase.errMatch)
+			}
+			assert.Equal(t, testCase.ip, ip)
+		})
+	}
+}
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(json.RawMessage(`{}`), "example.com", "@", ipversion.IP4, netip.Prefix{})
+	assert.ErrorIs(t, err, errors.ErrCommandNotSet)
+
+	_, err = New(json.RawMessage(`{"command":["plugin"],"timeout":"-1s"}`),
+		"example.com", "@", ipversion.IP4, netip.Prefix{})
+	assert.ErrorIs(t, err, errors.ErrTimeoutNotValid)
+}
+
+func Test_ErrorKind(t *testing.T) {
+	t.Parallel()
+
+	for kind, kindErr := range errorKinds {
+		response := Response{Error: "test", ErrorKind: kind}
+		assert.Equal(t, kind, ErrorKind(response.err()))
+		assert.ErrorIs(t, response.err(), kindErr)
+	}
+	assert.Empty(t, ErrorKind(errors.ErrUnsuccessful))
+}
@@ -0,0 +1,67 @@
+package records
+
+import (
+	"fmt"
+
+	"github.com/qdm12/ddns-updater/internal/constants"
+)
+
+// MirrorGroup is the state of the records of a mirror group.
+type MirrorGroup struct {
+	Name    string
+	Records uint
+	// Failed is the number of records of the group
+	// whose last update failed.
+	Failed uint
+}
+
+const (
+	MirrorGroupOK       = "ok"
+	MirrorGroupDegraded = "degraded"
+	MirrorGroupFailed   = "failed"
+)
+
+// Status returns MirrorGroupOK if no record of the group failed,
+// MirrorGroupFailed if all of them failed, and MirrorGroupDegraded
+// if some of them failed.
+func (g MirrorGroup) Status() string {
+	switch g.Failed {
+	case 0:
+		return MirrorGroupOK
+	case g.Records:
+		return MirrorGroupFailed
+	default:
+		return MirrorGroupDegraded
+	}
+}
+
+// HTML returns the group status to append to the status of its records.
+func (g MirrorGroup) HTML() string {
+	class := "success"
+	switch g.Status() {
+	case MirrorGroupDegraded:
+		class = "paused"
+	case MirrorGroupFailed:
+		class = "error"
+	}
+	return fmt.Sprintf(`, mirror group %s <span class="%s">%s</span> (%d of %d failed)`,
+		g.Name, class, g.Status(), g.Failed, g.Records)
+}
+
+// MirrorGroups returns the mirror groups of the records by name.
+func MirrorGroups(records []Record) (groups map[string]MirrorGroup) {
+	groups = make(map[string]MirrorGroup)
+	for _, record := range records {
+		if record.MirrorGroup == "" {
+			continue
+		}
+		group := groups[record.MirrorGroup]
+		group.Name = record.MirrorGroup
+		group.Records++
+		if record.Status == constants.FAIL || record.Status == constants.SUSPENDED {
+			group.Failed++
+		}
+		groups[record.MirrorGroup] = group
+	}
+	return groups
+}
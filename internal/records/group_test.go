@@ -0,0 +1,34 @@
+package records
+
+import (
+	"testing"
+
+	"github.com/qdm12/ddns-updater/internal/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MirrorGroups(t *testing.T) {
+	t.Parallel()
+
+	records := []Record{
+		{MirrorGroup: "home", Status: constants.SUCCESS},
+		{MirrorGroup: "home", Status: constants.FAIL},
+		{},
+		{MirrorGroup: "vpn", Status: constants.FAIL},
+		{MirrorGroup: "web", Status: constants.UPTODATE},
+	}
+
+	groups := MirrorGroups(records)
+
+	expected := map[string]MirrorGroup{
+		"home": {Name: "home", Records: 2, Failed: 1},
+		"vpn":  {Name: "vpn", Records: 1, Failed: 1},
+		"web":  {Name: "web", Records: 1},
+	}
+	assert.Equal(t, expected, groups)
+	assert.Equal(t, MirrorGroupDegraded, groups["home"].Status())
+	assert.Equal(t, MirrorGroupFailed, groups["vpn"].Status())
+	assert.Equal(t, MirrorGroupOK, groups["web"].Status())
+	assert.Equal(t, `, mirror group home <span class="paused">degraded</span> (1 of 2 failed)`,
+		groups["home"].HTML())
+}
@@ -0,0 +1,74 @@
+package records
+
+import (
+	"encoding/csv"
+	"io"
+	"path"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/provider"
+)
+
+// HistoryEntry is an IP address set for a record, as exported.
+type HistoryEntry struct {
+	Time      time.Time `json:"time"`
+	FQDN      string    `json:"fqdn"`
+	Domain    string    `json:"domain"`
+	Owner     string    `json:"owner"`
+	Provider  string    `json:"provider"`
+	IPVersion string    `json:"ip_version"`
+	IP        string    `json:"ip"`
+}
+
+// HistoryEntries returns the IP addresses history of the records,
+// from the oldest to the newest.
+func HistoryEntries(records []Record) (entries []HistoryEntry) {
+	entries = []HistoryEntry{}
+	for _, record := range records {
+		for _, event := range record.History {
+			entries = append(entries, HistoryEntry{
+				Time:      event.Time,
+				FQDN:      record.Provider.BuildDomainName(),
+				Domain:    record.Provider.Domain(),
+				Owner:     record.Provider.Owner(),
+				Provider:  ProviderName(record.Provider),
+				IPVersion: record.Provider.IPVersion().String(),
+				IP:        event.IP.String(),
+			})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	return entries
+}
+
+// WriteHistoryCSV writes the history entries to w in CSV, with a header line.
+func WriteHistoryCSV(w io.Writer, entries []HistoryEntry) (err error) {
+	writer := csv.NewWriter(w)
+	err = writer.Write([]string{"time", "fqdn", "domain", "owner", "provider", "ip_version", "ip"})
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		err = writer.Write([]string{entry.Time.Format(time.RFC3339), entry.FQDN,
+			entry.Domain, entry.Owner, entry.Provider, entry.IPVersion, entry.IP})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ProviderName returns the name of the provider, which
+// is the name of the package implementing it, such as beget.
+func ProviderName(p provider.Provider) string {
+	typ := reflect.TypeOf(p)
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return path.Base(typ.PkgPath())
+}
@@ -0,0 +1,57 @@
+package records
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/models"
+	"github.com/qdm12/ddns-updater/internal/provider/providers/beget"
+	"github.com/qdm12/ddns-updater/pkg/publicip/ipversion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_HistoryEntries(t *testing.T) {
+	t.Parallel()
+
+	settings := json.RawMessage(`{"login":"login","password":"password"}`)
+	ipv4Provider, err := beget.New(settings, "example.com", "home", ipversion.IP4, netip.Prefix{})
+	require.NoError(t, err)
+	ipv6Provider, err := beget.New(settings, "example.com", "@", ipversion.IP6, netip.Prefix{})
+	require.NoError(t, err)
+
+	start := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	records := []Record{
+		New(ipv4Provider, []models.HistoryEvent{
+			{IP: netip.MustParseAddr("1.1.1.1"), Time: start},
+			{IP: netip.MustParseAddr("2.2.2.2"), Time: start.Add(2 * time.Hour)},
+		}),
+		New(ipv6Provider, []models.HistoryEvent{
+			{IP: netip.MustParseAddr("2001:db8::1"), Time: start.Add(time.Hour)},
+		}),
+	}
+
+	entries := HistoryEntries(records)
+
+	expected := []HistoryEntry{
+		{Time: start, FQDN: "home.example.com", Domain: "example.com", Owner: "home",
+			Provider: "beget", IPVersion: "ipv4", IP: "1.1.1.1"},
+		{Time: start.Add(time.Hour), FQDN: "example.com", Domain: "example.com", Owner: "@",
+			Provider: "beget", IPVersion: "ipv6", IP: "2001:db8::1"},
+		{Time: start.Add(2 * time.Hour), FQDN: "home.example.com", Domain: "example.com", Owner: "home",
+			Provider: "beget", IPVersion: "ipv4", IP: "2.2.2.2"},
+	}
+	assert.Equal(t, expected, entries)
+
+	buffer := bytes.NewBuffer(nil)
+	err = WriteHistoryCSV(buffer, entries)
+	require.NoError(t, err)
+	expectedCSV := "time,fqdn,domain,owner,provider,ip_version,ip\n" +
+		"2024-06-01T10:00:00Z,home.example.com,example.com,home,beget,ipv4,1.1.1.1\n" +
+		"2024-06-01T11:00:00Z,example.com,example.com,@,beget,ipv6,2001:db8::1\n" +
+		"2024-06-01T12:00:00Z,home.example.com,example.com,home,beget,ipv4,2.2.2.2\n"
+	assert.Equal(t, expectedCSV, buffer.String())
+}
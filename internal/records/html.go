@@ -27,6 +27,10 @@ func (r *Record) HTML(now time.Time) models.HTMLRow {
 			message,
 			time.Since(r.Time).Round(time.Second).String()+" ago")
 	}
+	if now.Before(r.RetryTime) {
+		row.Status += fmt.Sprintf(", %d failures in a row, next try in %s",
+			r.Failures, r.RetryTime.Sub(now).Round(time.Second))
+	}
 	currentIP := r.History.GetCurrentIP()
 	if currentIP.IsValid() {
 		row.CurrentIP = `<a href="https://ipinfo.io/` + currentIP.String() + `">` + currentIP.String() + "</a>"
@@ -47,6 +51,13 @@ func (r *Record) HTML(now time.Time) models.HTMLRow {
 		}
 		row.PreviousIPs = strings.Join(previousIPsStr, ", ")
 	}
+	if r.Paused {
+		row.Status = `<span class="paused">Paused</span>, last status: ` + row.Status
+		row.Paused = true
+	}
+	if row.Details == "" {
+		row.Details = NotAvailable
+	}
 	return row
 }
 
@@ -60,6 +71,8 @@ func convertStatus(status models.Status) string {
 		return `<span class="uptodate">Up to date</span>`
 	case constants.UPDATING:
 		return `<span class="updating">Updating</span>`
+	case constants.SUSPENDED:
+		return `<span class="suspended">Suspended</span>`
 	case constants.UNSET:
 		return `<span class="unset">Unset</span>`
 	default:
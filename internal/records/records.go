@@ -2,6 +2,8 @@ package records
 
 import (
 	"fmt"
+	"net"
+	"net/netip"
 	"time"
 
 	"github.com/qdm12/ddns-updater/internal/constants"
@@ -17,10 +19,63 @@ type Record struct { // internal
 	Message  string
 	Time     time.Time
 	LastBan  *time.Time // nil means no last ban
+	// Paused is true if the record must not be updated until resumed.
+	Paused bool
+	// Period is the period to check the record for an update,
+	// overriding the global period if it is not zero.
+	Period time.Duration
+	// Jitter is the duration across which the checks of the records
+	// are spread, overriding the global jitter if it is not zero.
+	Jitter time.Duration
+	// Failures is the number of consecutive failed updates.
+	Failures uint
+	// RetryTime is the time before which the record is not updated
+	// again due to its consecutive failures, or zero if none.
+	RetryTime time.Time
+	// MirrorGroup is the name of the mirror group of the record, or empty.
+	MirrorGroup string
+	// MaxAge is the maximum duration since the last success of the
+	// record above which the program is unhealthy, or zero for no maximum.
+	MaxAge time.Duration
+	// LastSuccess is the time the record was last found up to date or
+	// updated successfully, or zero if it was not since the program started.
+	LastSuccess time.Time
+	// Resolver is the resolver used to look up the record before
+	// updating it, or nil to use the global resolver.
+	Resolver *net.Resolver
 }
 
-// New returns a new Record with provider and some history.
+// Settings are the settings of a record from its config entry.
+type Settings struct {
+	Provider provider.Provider
+	// Period overrides the global update period if it is not zero.
+	Period time.Duration
+	// Jitter overrides the global update jitter if it is not zero.
+	Jitter time.Duration
+	// MirrorGroup is the name of the group of records updated together,
+	// or empty if the record is not in a group.
+	MirrorGroup string
+	// MaxAge is the maximum duration since the last success of the
+	// record, or zero for no maximum.
+	MaxAge time.Duration
+	// Resolver overrides the global resolver if it is not nil.
+	Resolver *net.Resolver
+}
+
+// Providers returns the providers of the settings given.
+func Providers(settings []Settings) (providers []provider.Provider) {
+	providers = make([]provider.Provider, len(settings))
+	for i, setting := range settings {
+		providers[i] = setting.Provider
+	}
+	return providers
+}
+
+// New returns a new Record with provider and some history. The last IP
+// addresses of the history are given to the provider if it implements
+// provider.PreviousIPsSetter.
 func New(provider provider.Provider, events []models.HistoryEvent) Record {
+	setPreviousIPs(provider, events)
 	return Record{
 		Provider: provider,
 		History:  events,
@@ -28,6 +83,57 @@ func New(provider provider.Provider, events []models.HistoryEvent) Record {
 	}
 }
 
+func setPreviousIPs(p provider.Provider, events []models.HistoryEvent) {
+	setter, ok := p.(provider.PreviousIPsSetter)
+	if ok {
+		setter.SetPreviousIPs(lastIPs(events))
+	}
+}
+
+// lastIPs returns the last IPv4 and IPv6 addresses of the
+// history events, if any.
+func lastIPs(events []models.HistoryEvent) (ips []netip.Addr) {
+	var ipv4Found, ipv6Found bool
+	for i := len(events) - 1; i >= 0 && !(ipv4Found && ipv6Found); i-- {
+		ip := events[i].IP
+		switch {
+		case ip.Is4() && !ipv4Found:
+			ipv4Found = true
+		case ip.Is6() && !ipv6Found:
+			ipv6Found = true
+		default:
+			continue
+		}
+		ips = append(ips, ip)
+	}
+	return ips
+}
+
+// Release releases the providers of the settings given which implement
+// provider.Releaser, once they are not used. Providers can be nil.
+func Release(settings []Settings) {
+	for _, setting := range settings {
+		ReleaseProvider(setting.Provider)
+	}
+}
+
+// ReleaseProvider releases the provider given if it implements
+// provider.Releaser, once it is not used. The provider can be nil.
+func ReleaseProvider(p provider.Provider) {
+	releaser, ok := p.(provider.Releaser)
+	if ok {
+		releaser.Release()
+	}
+}
+
+// Key returns a key identifying the record of a provider, made of its
+// domain, owner, provider name and IP version, which stays the same when
+// the other settings of the provider change. The provider name is part of
+// it since the same FQDN can be updated with several providers.
+func Key(provider provider.Provider) string {
+	return provider.String()
+}
+
 func (r *Record) String() string {
 	status := string(r.Status)
 	if r.Message != "" {
@@ -0,0 +1,55 @@
+package records
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_lastIPs(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	event := func(ip string, hours int) models.HistoryEvent {
+		return models.HistoryEvent{
+			IP:   netip.MustParseAddr(ip),
+			Time: start.Add(time.Duration(hours) * time.Hour),
+		}
+	}
+
+	testCases := map[string]struct {
+		events []models.HistoryEvent
+		ips    []netip.Addr
+	}{
+		"no_event": {},
+		"ipv4_only": {
+			events: []models.HistoryEvent{event("1.1.1.1", 0), event("2.2.2.2", 1)},
+			ips:    []netip.Addr{netip.MustParseAddr("2.2.2.2")},
+		},
+		"both_families": {
+			events: []models.HistoryEvent{
+				event("1.1.1.1", 0),
+				event("2001:db8::1", 1),
+				event("2.2.2.2", 2),
+				event("3.3.3.3", 3),
+			},
+			ips: []netip.Addr{
+				netip.MustParseAddr("3.3.3.3"),
+				netip.MustParseAddr("2001:db8::1"),
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ips := lastIPs(testCase.events)
+
+			assert.Equal(t, testCase.ips, ips)
+		})
+	}
+}
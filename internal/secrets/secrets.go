@@ -0,0 +1,75 @@
+// Package secrets resolves secret values from files, such as Docker and
+// Kubernetes secrets, and from references to environment variables.
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrEnvNotSet       = errors.New("environment variable is not set")
+	ErrValueAndFileSet = errors.New("value and file cannot be both set")
+)
+
+// ReadFile returns the content of the secret file at path,
+// trimmed of surrounding whitespace such as a trailing new line.
+func ReadFile(path string) (secret string, err error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// Resolve returns the secret named name from either its value,
+// or the content of the file at filePath, trimmed of surrounding whitespace.
+// Both value and filePath have their ${ENV_VAR} references expanded first.
+func Resolve(name, value, filePath string,
+	lookupEnv func(key string) (value string, ok bool)) (secret string, err error) {
+	value, err = ExpandEnv(value, lookupEnv)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	filePath, err = ExpandEnv(filePath, lookupEnv)
+	if err != nil {
+		return "", fmt.Errorf("%s_file: %w", name, err)
+	}
+
+	switch {
+	case filePath == "":
+		return value, nil
+	case value != "":
+		return "", fmt.Errorf("%w: %s and %s_file", ErrValueAndFileSet, name, name)
+	}
+
+	secret, err = ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("reading %s file: %w", name, err)
+	}
+	return secret, nil
+}
+
+var envReferenceRegex = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ExpandEnv replaces the ${ENV_VAR} references in s with the values of
+// the environment variables, returning an error if one is not set.
+// Other dollar signs are left untouched, since they may be part of a password.
+func ExpandEnv(s string, lookupEnv func(key string) (value string, ok bool)) (
+	expanded string, err error) {
+	expanded = envReferenceRegex.ReplaceAllStringFunc(s, func(reference string) string {
+		key := envReferenceRegex.FindStringSubmatch(reference)[1]
+		value, ok := lookupEnv(key)
+		if !ok && err == nil {
+			err = fmt.Errorf("%w: %s", ErrEnvNotSet, key)
+		}
+		return value
+	})
+	if err != nil {
+		return "", err
+	}
+	return expanded, nil
+}
@@ -0,0 +1,79 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Resolve(t *testing.T) {
+	t.Parallel()
+
+	filePath := filepath.Join(t.TempDir(), "password")
+	err := os.WriteFile(filePath, []byte("file$ecret\n"), 0o600)
+	require.NoError(t, err)
+
+	env := map[string]string{
+		"DB_PASSWORD": "env-secret",
+		"SECRETS_DIR": filepath.Dir(filePath),
+	}
+	lookupEnv := func(key string) (value string, ok bool) {
+		value, ok = env[key]
+		return value, ok
+	}
+
+	testCases := map[string]struct {
+		value      string
+		filePath   string
+		secret     string
+		errWrapped error
+		errMessage string
+	}{
+		"plain_value": {
+			value:  "pa$$word",
+			secret: "pa$$word",
+		},
+		"env_expansion": {
+			value:  "${DB_PASSWORD}-suffix",
+			secret: "env-secret-suffix",
+		},
+		"env_not_set": {
+			value:      "${MISSING}",
+			errWrapped: ErrEnvNotSet,
+			errMessage: "password: environment variable is not set: MISSING",
+		},
+		"file": {
+			filePath: "${SECRETS_DIR}/password",
+			secret:   "file$ecret",
+		},
+		"value_and_file": {
+			value:      "secret",
+			filePath:   filePath,
+			errWrapped: ErrValueAndFileSet,
+			errMessage: "value and file cannot be both set: password and password_file",
+		},
+		"file_not_found": {
+			filePath:   filepath.Join(filepath.Dir(filePath), "missing"),
+			errWrapped: os.ErrNotExist,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			secret, err := Resolve("password", testCase.value,
+				testCase.filePath, lookupEnv)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errMessage != "" {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.Equal(t, testCase.secret, secret)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DefaultDir is the directory where Docker and Kubernetes mount secrets.
+const DefaultDir = "/run/secrets"
+
+// Source is a settings source reading the value of an environment variable
+// key from the file at the path given by the key suffixed with _FILE, or
+// else from the file named after the key in lowercase in the secrets
+// directory. It is meant to have a lower priority than the environment
+// variables source, and is only read for the keys read by the program, so
+// other variables ending with _FILE are left alone.
+type Source struct {
+	environ map[string]string
+	dir     string
+
+	mutex  sync.Mutex
+	errors []error
+}
+
+// NewSource creates a new secret files source given the environment as
+// a slice of key-value pairs, and the secrets directory, which can be empty
+// to only read the files given by _FILE environment variables.
+func NewSource(environ []string, dir string) *Source {
+	source := &Source{
+		environ: make(map[string]string, len(environ)),
+		dir:     dir,
+	}
+	for _, keyValue := range environ {
+		key, value, ok := strings.Cut(keyValue, "=")
+		if ok {
+			source.environ[key] = value
+		}
+	}
+	return source
+}
+
+func (s *Source) String() string {
+	return "secret file"
+}
+
+// Get returns the content of the secret file of the key, and whether
+// it is set. An error reading a file the key points to is recorded and
+// returned by Err, with the key reported as not set.
+func (s *Source) Get(key string) (value string, isSet bool) {
+	fileKey := key + "_FILE"
+	if path, ok := s.environ[fileKey]; ok {
+		value, err := ReadFile(path)
+		if err != nil {
+			s.addError(fmt.Errorf("reading %s file: %w", fileKey, err))
+			return "", false
+		}
+		return value, true
+	}
+
+	if s.dir == "" {
+		return "", false
+	}
+	path := filepath.Join(s.dir, strings.ToLower(key))
+	value, err := ReadFile(path)
+	switch {
+	case err == nil:
+		return value, true
+	case errors.Is(err, os.ErrNotExist):
+		return "", false
+	default:
+		s.addError(fmt.Errorf("reading secret file for %s: %w", key, err))
+		return "", false
+	}
+}
+
+// KeyTransform transforms a generic key to an environment variable key,
+// the same way as the environment variables source.
+func (s *Source) KeyTransform(key string) (newKey string) {
+	newKey = strings.ToUpper(key)
+	return strings.ReplaceAll(newKey, "-", "_")
+}
+
+func (s *Source) addError(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.errors = append(s.errors, err)
+}
+
+// Err returns the errors encountered reading secret files so far,
+// joined together, or nil if there is none.
+func (s *Source) Err() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return errors.Join(s.errors...)
+}
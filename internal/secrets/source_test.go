@@ -0,0 +1,79 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Source_Get(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	const perms = 0o600
+	err := os.WriteFile(filepath.Join(dir, "token"), []byte("from-env-file\n"), perms)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(dir, "email_smtp_password"), []byte(" from-dir "), perms)
+	require.NoError(t, err)
+
+	source := NewSource([]string{"TOKEN_FILE=" + filepath.Join(dir, "token")}, dir)
+
+	testCases := map[string]struct {
+		key   string
+		value string
+		isSet bool
+	}{
+		"env_file": {
+			key:   "TOKEN",
+			value: "from-env-file",
+			isSet: true,
+		},
+		"secrets_dir": {
+			key:   "EMAIL_SMTP_PASSWORD",
+			value: "from-dir",
+			isSet: true,
+		},
+		"not_found": {
+			key: "WEBHOOK_URL",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			value, isSet := source.Get(source.KeyTransform(testCase.key))
+
+			assert.Equal(t, testCase.value, value)
+			assert.Equal(t, testCase.isSet, isSet)
+		})
+	}
+}
+
+func Test_Source_Err(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	err := os.Mkdir(filepath.Join(dir, "directory"), 0o700)
+	require.NoError(t, err)
+	source := NewSource([]string{
+		"MISSING_FILE=" + filepath.Join(dir, "missing"),
+		"NOT_READ_FILE=" + filepath.Join(dir, "missing"),
+	}, dir)
+	require.NoError(t, source.Err())
+
+	_, isSet := source.Get("MISSING")
+	assert.False(t, isSet)
+	_, isSet = source.Get("DIRECTORY")
+	assert.False(t, isSet)
+
+	err = source.Err()
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.ErrorContains(t, err, "reading MISSING_FILE file: ")
+	assert.ErrorContains(t, err, "reading secret file for DIRECTORY: ")
+	assert.NotContains(t, err.Error(), "NOT_READ_FILE")
+}
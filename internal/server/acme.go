@@ -0,0 +1,87 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// acmeRequest is the request body sent by the lego httpreq DNS provider,
+// see https://go-acme.github.io/lego/dns/httpreq/
+type acmeRequest struct {
+	FQDN  string `json:"fqdn"`
+	Value string `json:"value"`
+}
+
+func (h *handlers) acmePresent(w http.ResponseWriter, r *http.Request) {
+	h.acmeHandle(w, r, func(challenger ACMEChallenger, request acmeRequest) error {
+		return challenger.PresentTXT(r.Context(), h.client, request.FQDN, request.Value)
+	})
+}
+
+func (h *handlers) acmeCleanup(w http.ResponseWriter, r *http.Request) {
+	h.acmeHandle(w, r, func(challenger ACMEChallenger, request acmeRequest) error {
+		return challenger.CleanupTXT(r.Context(), h.client, request.FQDN, request.Value)
+	})
+}
+
+func (h *handlers) acmeHandle(w http.ResponseWriter, r *http.Request,
+	handle func(challenger ACMEChallenger, request acmeRequest) error) {
+	if !h.acmeAuthorized(r) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="acme"`)
+		httpError(w, http.StatusUnauthorized, "")
+		return
+	}
+
+	var request acmeRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		httpError(w, http.StatusBadRequest, "decoding request body: "+err.Error())
+		return
+	} else if request.FQDN == "" || request.Value == "" {
+		httpError(w, http.StatusBadRequest, "fqdn and value must be set")
+		return
+	}
+
+	challenger := h.findACMEChallenger(request.FQDN)
+	if challenger == nil {
+		httpError(w, http.StatusNotFound, "no record configured supports ACME challenges for "+request.FQDN)
+		return
+	}
+
+	err = handle(challenger, request)
+	if err != nil {
+		httpError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *handlers) acmeAuthorized(r *http.Request) bool {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	usernameMatch := subtle.ConstantTimeCompare([]byte(username), []byte(h.acmeUsername)) == 1
+	passwordMatch := subtle.ConstantTimeCompare([]byte(password), []byte(h.acmePassword)) == 1
+	return usernameMatch && passwordMatch
+}
+
+// findACMEChallenger returns the provider of the first record whose
+// domain contains fqdn and which supports ACME challenges, or nil
+// if no such record exists.
+func (h *handlers) findACMEChallenger(fqdn string) (challenger ACMEChallenger) { //nolint:ireturn
+	fqdn = strings.TrimSuffix(fqdn, ".")
+	for _, record := range h.db.SelectAll() {
+		domain := record.Provider.Domain()
+		if fqdn != domain && !strings.HasSuffix(fqdn, "."+domain) {
+			continue
+		}
+		challenger, ok := record.Provider.(ACMEChallenger)
+		if ok {
+			return challenger
+		}
+	}
+	return nil
+}
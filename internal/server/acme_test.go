@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/qdm12/ddns-updater/internal/models"
+	"github.com/qdm12/ddns-updater/internal/provider/providers/beget"
+	"github.com/qdm12/ddns-updater/internal/provider/providers/beget/begettest"
+	"github.com/qdm12/ddns-updater/internal/records"
+	"github.com/qdm12/ddns-updater/pkg/publicip/ipversion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDatabase struct {
+	records []records.Record
+}
+
+func (d *fakeDatabase) SelectAll() []records.Record { return d.records }
+
+func (d *fakeDatabase) SetPaused(uint, bool) error { return nil }
+
+func Test_handlers_acme(t *testing.T) {
+	t.Parallel()
+
+	const fqdn = "_acme-challenge.example.com"
+	begetServer := begettest.New(begettest.Settings{
+		Domains:    []string{"example.com"},
+		Subdomains: []string{fqdn},
+	})
+	begetServer.SetRecords(fqdn, map[string]json.RawMessage{
+		"A":   json.RawMessage(`[{"address":"1.1.1.1","priority":10}]`),
+		"TXT": json.RawMessage(`[{"priority":10,"txtdata":"v=spf1 -all"}]`),
+	})
+	begetHTTPServer := httptest.NewServer(begetServer)
+	t.Cleanup(begetHTTPServer.Close)
+
+	providerSettings, err := json.Marshal(map[string]any{
+		"login":            "login",
+		"password":         "password",
+		"api_url":          begetHTTPServer.URL,
+		"retry":            map[string]any{"max_attempts": 1},
+		"rate_limit":       0,
+		"check_delegation": false,
+	})
+	require.NoError(t, err)
+	provider, err := beget.New(providerSettings, "example.com", "@", ipversion.IP4, netip.Prefix{})
+	require.NoError(t, err)
+	db := &fakeDatabase{records: []records.Record{records.New(provider, []models.HistoryEvent{})}}
+
+	settings := Settings{
+		Client:       begetHTTPServer.Client(),
+		ACMEUsername: "lego",
+		ACMEPassword: "secret",
+	}
+	handler := newHandler(context.Background(), settings, db, nil)
+
+	call := func(path, username, password, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		request.SetBasicAuth(username, password)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+	const body = `{"fqdn":"_acme-challenge.example.com.","value":"token"}`
+
+	response := call("/acme/present", "lego", "wrong", body)
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+	assert.Zero(t, begetServer.Calls("dns/changeRecords"))
+
+	response = call("/acme/present", "lego", "secret", `{"fqdn":"_acme-challenge.example.org","value":"token"}`)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	response = call("/acme/present", "lego", "secret", `{"fqdn":"_acme-challenge.example.com"}`)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+
+	response = call("/acme/present", "lego", "secret", body)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	beforeCleanup := begetServer.Records(fqdn)
+	assert.JSONEq(t, `[{"priority":10,"value":"v=spf1 -all"},{"priority":10,"value":"token"}]`,
+		string(beforeCleanup["TXT"]))
+	assert.JSONEq(t, `[{"address":"1.1.1.1","priority":10}]`, string(beforeCleanup["A"]))
+
+	response = call("/acme/cleanup", "lego", "secret", body)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	afterCleanup := begetServer.Records(fqdn)
+	assert.JSONEq(t, `[{"priority":10,"value":"v=spf1 -all"}]`, string(afterCleanup["TXT"]))
+	assert.JSONEq(t, `[{"address":"1.1.1.1","priority":10}]`, string(afterCleanup["A"]))
+}
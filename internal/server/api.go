@@ -0,0 +1,212 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/qdm12/ddns-updater/internal/records"
+)
+
+// apiRecord is a record as returned by the management API.
+type apiRecord struct {
+	ID        uint      `json:"id"`
+	Domain    string    `json:"domain"`
+	Owner     string    `json:"owner"`
+	FQDN      string    `json:"fqdn"`
+	IPVersion string    `json:"ip_version"`
+	Status    string    `json:"status"`
+	Message   string    `json:"message,omitempty"`
+	Time      time.Time `json:"time"`
+	CurrentIP string    `json:"current_ip,omitempty"`
+	Paused    bool      `json:"paused"`
+	// Failures is the number of consecutive failed updates.
+	Failures uint `json:"failures"`
+	// RetryTime is the time before which the record is not
+	// updated again due to its failures, if any.
+	RetryTime *time.Time `json:"retry_time,omitempty"`
+	// MirrorGroup is the mirror group of the record, if any, and
+	// MirrorGroupStatus is its status, see records.MirrorGroup.
+	MirrorGroup       string `json:"mirror_group,omitempty"`
+	MirrorGroupStatus string `json:"mirror_group_status,omitempty"`
+}
+
+func newAPIRecord(id uint, record records.Record, mirrorGroups map[string]records.MirrorGroup) apiRecord {
+	apiRecord := apiRecord{
+		ID:        id,
+		Domain:    record.Provider.Domain(),
+		Owner:     record.Provider.Owner(),
+		FQDN:      record.Provider.BuildDomainName(),
+		IPVersion: record.Provider.IPVersion().String(),
+		Status:    string(record.Status),
+		Message:   record.Message,
+		Time:      record.Time,
+		Paused:    record.Paused,
+		Failures:  record.Failures,
+	}
+	if record.MirrorGroup != "" {
+		apiRecord.MirrorGroup = record.MirrorGroup
+		apiRecord.MirrorGroupStatus = mirrorGroups[record.MirrorGroup].Status()
+	}
+	if !record.RetryTime.IsZero() {
+		retryTime := record.RetryTime
+		apiRecord.RetryTime = &retryTime
+	}
+	if currentIP := record.History.GetCurrentIP(); currentIP.IsValid() {
+		apiRecord.CurrentIP = currentIP.String()
+	}
+	return apiRecord
+}
+
+// newAPIRecord returns the API record of the record with the id given,
+// and false if there is no such record, for example since the records
+// were reloaded after the id was checked.
+func (h *handlers) newAPIRecord(id uint) (record apiRecord, ok bool) {
+	allRecords := h.db.SelectAll()
+	if id >= uint(len(allRecords)) {
+		return apiRecord{}, false
+	}
+	return newAPIRecord(id, allRecords[id], records.MirrorGroups(allRecords)), true
+}
+
+// writeAPIRecord writes the API record of the record with the id
+// given, or responds with a not found error if there is no such record.
+func (h *handlers) writeAPIRecord(w http.ResponseWriter, id uint) {
+	record, ok := h.newAPIRecord(id)
+	if !ok {
+		httpError(w, http.StatusNotFound, "record not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, record)
+}
+
+// newAPIRouter returns the router of the management API, whose
+// requests must all have the bearer token given in their
+// Authorization header.
+func (h *handlers) newAPIRouter(token string) http.Handler {
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !apiAuthorized(r, token) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+				httpError(w, http.StatusUnauthorized, "")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	router.Get("/records", h.apiRecords)
+	router.Get("/records/{id}", h.apiRecord)
+	router.Get("/records/{id}/history", h.apiHistory)
+	router.Post("/records/{id}/update", h.apiUpdateRecord)
+	router.Post("/records/{id}/pause", h.apiSetPaused(true))
+	router.Post("/records/{id}/resume", h.apiSetPaused(false))
+	router.Post("/update", h.apiUpdate)
+	router.Get("/history", h.apiHistoryExport)
+	return router
+}
+
+func apiAuthorized(r *http.Request, token string) bool {
+	requestToken, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(requestToken), []byte(token)) == 1
+}
+
+func (h *handlers) apiRecords(w http.ResponseWriter, _ *http.Request) {
+	allRecords := h.db.SelectAll()
+	mirrorGroups := records.MirrorGroups(allRecords)
+	apiRecords := make([]apiRecord, len(allRecords))
+	for i, record := range allRecords {
+		apiRecords[i] = newAPIRecord(uint(i), record, mirrorGroups)
+	}
+	writeJSON(w, http.StatusOK, apiRecords)
+}
+
+func (h *handlers) apiRecord(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.recordID(w, r)
+	if !ok {
+		return
+	}
+	h.writeAPIRecord(w, id)
+}
+
+func (h *handlers) apiHistory(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.recordID(w, r)
+	if !ok {
+		return
+	}
+	allRecords := h.db.SelectAll()
+	if id >= uint(len(allRecords)) {
+		httpError(w, http.StatusNotFound, "record not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, allRecords[id].History)
+}
+
+// apiHistoryExport writes the IP addresses history of all the records,
+// in JSON or, if the format query parameter is csv, in CSV.
+func (h *handlers) apiHistoryExport(w http.ResponseWriter, r *http.Request) {
+	entries := records.HistoryEntries(h.db.SelectAll())
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		writeJSON(w, http.StatusOK, entries)
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="ddns-updater-history.csv"`)
+		err := records.WriteHistoryCSV(w, entries)
+		if err != nil {
+			panic(err)
+		}
+	default:
+		httpError(w, http.StatusBadRequest, "format "+strconv.Quote(format)+" must be json or csv")
+	}
+}
+
+func (h *handlers) apiUpdateRecord(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.recordID(w, r)
+	if !ok {
+		return
+	}
+	errors := h.runner.ForceUpdateRecord(h.ctx, id) //nolint:contextcheck
+	if len(errors) > 0 {
+		httpErrors(w, http.StatusInternalServerError, errors)
+		return
+	}
+	h.writeAPIRecord(w, id)
+}
+
+func (h *handlers) apiSetPaused(paused bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := h.recordID(w, r)
+		if !ok {
+			return
+		}
+		err := h.db.SetPaused(id, paused)
+		if err != nil {
+			httpError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		h.writeAPIRecord(w, id)
+	}
+}
+
+func (h *handlers) apiUpdate(w http.ResponseWriter, _ *http.Request) {
+	errors := h.runner.ForceUpdate(h.ctx) //nolint:contextcheck
+	if len(errors) > 0 {
+		httpErrors(w, http.StatusInternalServerError, errors)
+		return
+	}
+	h.apiRecords(w, nil)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(body)
+	if err != nil {
+		panic(err)
+	}
+}
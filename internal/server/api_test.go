@@ -0,0 +1,46 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/qdm12/ddns-updater/internal/records"
+	"github.com/stretchr/testify/assert"
+)
+
+// reloadedDatabase has its records removed after the first
+// SelectAll call, as if they were removed by a config reload.
+type reloadedDatabase struct {
+	fakeDatabase
+	calls int
+}
+
+func (d *reloadedDatabase) SelectAll() []records.Record {
+	d.calls++
+	if d.calls > 1 {
+		return nil
+	}
+	return d.records
+}
+
+func Test_handlers_api_reloaded(t *testing.T) {
+	t.Parallel()
+
+	for _, path := range []string{"/api/v1/records/0", "/api/v1/records/0/history"} {
+		t.Run(path, func(t *testing.T) {
+			t.Parallel()
+
+			db := &reloadedDatabase{fakeDatabase: fakeDatabase{records: make([]records.Record, 1)}}
+			handler := newHandler(context.Background(), Settings{APIToken: "token"}, db, nil)
+			request := httptest.NewRequest(http.MethodGet, path, nil)
+			request.Header.Set("Authorization", "Bearer token")
+			recorder := httptest.NewRecorder()
+
+			handler.ServeHTTP(recorder, request)
+
+			assert.Equal(t, http.StatusNotFound, recorder.Code)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/models"
+)
+
+// auditData is the data rendered by the audit.html template.
+type auditData struct {
+	Entries []auditRow
+	Errors  []string
+}
+
+type auditRow struct {
+	Time   string
+	FQDN   string
+	Before string
+	After  string
+	Result string
+}
+
+// audit renders the changes recorded by the providers implementing
+// AuditLogger, from the newest to the oldest. Entries shared by the
+// providers of the same audit file are only shown once.
+func (h *handlers) audit(w http.ResponseWriter, _ *http.Request) {
+	var data auditData
+	var entries []models.AuditEntry
+	seen := make(map[models.AuditEntry]struct{})
+	for _, record := range h.db.SelectAll() {
+		auditLogger, ok := record.Provider.(AuditLogger)
+		if !ok {
+			continue
+		}
+		providerEntries, err := auditLogger.AuditEntries()
+		if err != nil {
+			data.Errors = append(data.Errors, record.Provider.String()+": "+err.Error())
+			continue
+		}
+		for _, entry := range providerEntries {
+			if _, ok := seen[entry]; ok {
+				continue
+			}
+			seen[entry] = struct{}{}
+			entries = append(entries, entry)
+		}
+	}
+
+	slices.SortStableFunc(entries, func(a, b models.AuditEntry) int {
+		return b.Time.Compare(a.Time)
+	})
+	for _, entry := range entries {
+		result := "success"
+		if entry.Error != "" {
+			result = entry.Error
+		}
+		data.Entries = append(data.Entries, auditRow{
+			Time:   entry.Time.Format(time.RFC3339),
+			FQDN:   entry.FQDN,
+			Before: entry.Before,
+			After:  entry.After,
+			Result: result,
+		})
+	}
+
+	err := h.auditTemplate.ExecuteTemplate(w, "audit.html", data)
+	if err != nil {
+		httpError(w, http.StatusInternalServerError, "failed generating webpage: "+err.Error())
+	}
+}
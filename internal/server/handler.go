@@ -18,7 +18,12 @@ type handlers struct {
 	// Objects
 	db            Database
 	runner        UpdateForcer
+	client        *http.Client
 	indexTemplate *template.Template
+	auditTemplate *template.Template
+	// ACME challenge endpoints basic authentication
+	acmeUsername string
+	acmePassword string
 	// Mockable functions
 	timeNow func() time.Time
 }
@@ -26,9 +31,10 @@ type handlers struct {
 //go:embed ui/*
 var uiFS embed.FS
 
-func newHandler(ctx context.Context, rootURL string,
+func newHandler(ctx context.Context, settings Settings,
 	db Database, runner UpdateForcer) http.Handler {
 	indexTemplate := template.Must(template.ParseFS(uiFS, "ui/index.html"))
+	auditTemplate := template.Must(template.ParseFS(uiFS, "ui/audit.html"))
 
 	staticFolder, err := fs.Sub(uiFS, "ui/static")
 	if err != nil {
@@ -39,15 +45,19 @@ func newHandler(ctx context.Context, rootURL string,
 		ctx:           ctx,
 		db:            db,
 		indexTemplate: indexTemplate,
+		auditTemplate: auditTemplate,
 		// TODO build information
-		timeNow: time.Now,
-		runner:  runner,
+		timeNow:      time.Now,
+		runner:       runner,
+		client:       settings.Client,
+		acmeUsername: settings.ACMEUsername,
+		acmePassword: settings.ACMEPassword,
 	}
 
 	router := chi.NewRouter()
 
 	router.Use(middleware.Logger)
-	rootURL = strings.TrimSuffix(rootURL, "/")
+	rootURL := strings.TrimSuffix(settings.RootURL, "/")
 
 	if rootURL != "" {
 		router.Handle(rootURL, http.RedirectHandler(rootURL+"/", http.StatusPermanentRedirect))
@@ -55,6 +65,23 @@ func newHandler(ctx context.Context, rootURL string,
 	router.Get(rootURL+"/", handlers.index)
 
 	router.Get(rootURL+"/update", handlers.update)
+	router.Post(rootURL+"/update/{id}", handlers.updateRecord)
+	router.Post(rootURL+"/pause/{id}", handlers.pause)
+	router.Post(rootURL+"/resume/{id}", handlers.resume)
+	router.Get(rootURL+"/audit", handlers.audit)
+
+	if settings.ACMEPassword != "" {
+		router.Post(rootURL+"/acme/present", handlers.acmePresent)
+		router.Post(rootURL+"/acme/cleanup", handlers.acmeCleanup)
+	}
+
+	if settings.APIToken != "" {
+		router.Mount(rootURL+"/api/v1", handlers.newAPIRouter(settings.APIToken))
+	}
+
+	if settings.Metrics != nil {
+		router.Handle(rootURL+"/metrics", settings.Metrics)
+	}
 
 	router.Handle(rootURL+"/static/*", http.StripPrefix(rootURL+"/static/", http.FileServerFS(staticFolder)))
 
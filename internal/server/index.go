@@ -4,12 +4,19 @@ import (
 	"net/http"
 
 	"github.com/qdm12/ddns-updater/internal/models"
+	"github.com/qdm12/ddns-updater/internal/records"
 )
 
 func (h *handlers) index(w http.ResponseWriter, _ *http.Request) {
 	var htmlData models.HTMLData
-	for _, record := range h.db.SelectAll() {
+	allRecords := h.db.SelectAll()
+	mirrorGroups := records.MirrorGroups(allRecords)
+	for i, record := range allRecords {
 		row := record.HTML(h.timeNow())
+		row.ID = uint(i)
+		if record.MirrorGroup != "" {
+			row.Status += mirrorGroups[record.MirrorGroup].HTML()
+		}
 		htmlData.Rows = append(htmlData.Rows, row)
 	}
 	err := h.indexTemplate.ExecuteTemplate(w, "index.html", htmlData)
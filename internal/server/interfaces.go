@@ -2,16 +2,33 @@ package server
 
 import (
 	"context"
+	"net/http"
 
+	"github.com/qdm12/ddns-updater/internal/models"
 	"github.com/qdm12/ddns-updater/internal/records"
 )
 
 type Database interface {
 	SelectAll() (records []records.Record)
+	SetPaused(id uint, paused bool) (err error)
 }
 
 type UpdateForcer interface {
 	ForceUpdate(ctx context.Context) (errors []error)
+	ForceUpdateRecord(ctx context.Context, id uint) (errors []error)
+}
+
+// ACMEChallenger is implemented by providers able to manage
+// TXT records for ACME DNS-01 challenges.
+type ACMEChallenger interface {
+	PresentTXT(ctx context.Context, client *http.Client, fqdn, value string) (err error)
+	CleanupTXT(ctx context.Context, client *http.Client, fqdn, value string) (err error)
+}
+
+// AuditLogger is implemented by providers recording
+// the changes they make to the records.
+type AuditLogger interface {
+	AuditEntries() (entries []models.AuditEntry, err error)
 }
 
 type Logger interface {
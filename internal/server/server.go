@@ -2,15 +2,34 @@ package server
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/qdm12/goservices/httpserver"
 )
 
-func New(ctx context.Context, address, rootURL string, db Database,
+type Settings struct {
+	Address string
+	RootURL string
+	// Client is the HTTP client used by providers for ACME challenges.
+	Client *http.Client
+	// ACMEUsername and ACMEPassword are the basic authentication
+	// credentials of the ACME challenge endpoints, which are
+	// disabled if ACMEPassword is empty.
+	ACMEUsername string
+	ACMEPassword string
+	// APIToken is the bearer token of the management API
+	// endpoints, which are disabled if APIToken is empty.
+	APIToken string
+	// Metrics serves the metrics in the Prometheus text format
+	// on the /metrics endpoint, if it is not nil.
+	Metrics http.Handler
+}
+
+func New(ctx context.Context, settings Settings, db Database,
 	logger Logger, runner UpdateForcer) (server *httpserver.Server, err error) {
 	return httpserver.New(httpserver.Settings{
-		Handler: newHandler(ctx, rootURL, db, runner),
-		Address: &address,
+		Handler: newHandler(ctx, settings, db, runner),
+		Address: &settings.Address,
 		Logger:  logger,
 	})
 }
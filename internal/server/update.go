@@ -2,6 +2,9 @@ package server
 
 import (
 	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
 )
 
 func (h *handlers) update(w http.ResponseWriter, _ *http.Request) {
@@ -16,3 +19,57 @@ func (h *handlers) update(w http.ResponseWriter, _ *http.Request) {
 	message := "All records updated successfully in " + duration.String()
 	_, _ = w.Write([]byte(message))
 }
+
+// updateRecord updates the record with the id given in the URL right
+// away, and redirects to the index page to show its new status.
+func (h *handlers) updateRecord(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.recordID(w, r)
+	if !ok {
+		return
+	}
+
+	errors := h.runner.ForceUpdateRecord(h.ctx, id) //nolint:contextcheck
+	if len(errors) > 0 {
+		httpErrors(w, http.StatusInternalServerError, errors)
+		return
+	}
+	http.Redirect(w, r, "../", http.StatusSeeOther)
+}
+
+// pause pauses the updates of the record with the id given in the URL,
+// and redirects to the index page to show its paused state.
+func (h *handlers) pause(w http.ResponseWriter, r *http.Request) {
+	h.setPaused(w, r, true)
+}
+
+// resume resumes the updates of the record with the id given in the URL,
+// and redirects to the index page to show its state.
+func (h *handlers) resume(w http.ResponseWriter, r *http.Request) {
+	h.setPaused(w, r, false)
+}
+
+func (h *handlers) setPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	id, ok := h.recordID(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.db.SetPaused(id, paused)
+	if err != nil {
+		httpError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	http.Redirect(w, r, "../", http.StatusSeeOther)
+}
+
+// recordID returns the id of the record given in the URL, or
+// responds with a not found error and returns false if there
+// is no such record.
+func (h *handlers) recordID(w http.ResponseWriter, r *http.Request) (id uint, ok bool) {
+	parsed, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 0)
+	if err != nil || parsed >= uint64(len(h.db.SelectAll())) {
+		httpError(w, http.StatusNotFound, "record not found")
+		return 0, false
+	}
+	return uint(parsed), true
+}
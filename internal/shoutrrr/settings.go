@@ -4,18 +4,31 @@ import (
 	"fmt"
 
 	"github.com/containrrr/shoutrrr"
+	"github.com/qdm12/ddns-updater/internal/models"
 	"github.com/qdm12/gosettings"
 )
 
 type Settings struct {
 	Addresses    []string
 	DefaultTitle string
-	Logger       Erroer
+	// Events are the update event types notified.
+	Events []string
+	// Message is the Go template of the update event messages,
+	// executed with the models.UpdateEvent.
+	Message string
+	Logger  Erroer
 }
 
+const defaultMessage = "{{.FQDN}} {{if .Error}}update failed: {{.Error}}{{else}}{{.Message}}{{end}}"
+
 func (s *Settings) setDefaults() {
 	s.Addresses = gosettings.DefaultSlice(s.Addresses, []string{})
 	s.DefaultTitle = gosettings.DefaultComparable(s.DefaultTitle, "DDNS Updater")
+	s.Events = gosettings.DefaultSlice(s.Events, []string{
+		models.EventUpdated, models.EventIPChanged, models.EventUpdateFailed,
+		models.EventCredentialsInvalid, models.EventSuspended, models.EventRecordRestored,
+	})
+	s.Message = gosettings.DefaultComparable(s.Message, defaultMessage)
 	s.Logger = gosettings.DefaultComparable[Erroer](s.Logger, &noopLogger{})
 }
 
@@ -1,18 +1,24 @@
 package shoutrrr
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"strings"
+	"text/template"
 
 	"github.com/containrrr/shoutrrr"
 	"github.com/containrrr/shoutrrr/pkg/router"
+	"github.com/qdm12/ddns-updater/internal/models"
+	"github.com/qdm12/ddns-updater/internal/notify"
 )
 
 type Client struct {
 	serviceRouter *router.ServiceRouter
 	serviceNames  []string
 	defaultTitle  string
+	filter        notify.Filter
+	message       *template.Template
 	logger        Erroer
 }
 
@@ -32,6 +38,11 @@ func New(settings Settings) (client *Client, err error) {
 		return nil, fmt.Errorf("creating service router: %w", err)
 	}
 
+	message, err := notify.ParseTemplate("message", settings.Message)
+	if err != nil {
+		return nil, err
+	}
+
 	serviceNames := make([]string, len(settings.Addresses))
 	for i, address := range settings.Addresses {
 		serviceNames[i] = strings.Split(address, ":")[0]
@@ -41,6 +52,8 @@ func New(settings Settings) (client *Client, err error) {
 		serviceRouter: serviceRouter,
 		serviceNames:  serviceNames,
 		defaultTitle:  settings.DefaultTitle,
+		filter:        notify.Filter{Events: settings.Events},
+		message:       message,
 		logger:        settings.Logger,
 	}, nil
 }
@@ -54,6 +67,22 @@ func (c *Client) Notify(message string) {
 	}
 }
 
+// Updated notifies the update event of a record, if its event type
+// is enabled. For a series of failed updates of the same record,
+// only the first failure is notified.
+func (c *Client) Updated(_ context.Context, event models.UpdateEvent) {
+	if !c.filter.Match(event) {
+		return
+	}
+
+	message, err := notify.Execute(c.message, event)
+	if err != nil {
+		c.logger.Error(err.Error())
+		return
+	}
+	c.Notify(message)
+}
+
 func addDefaultTitle(address, defaultTitle string) (updatedAddress string) {
 	u, err := url.Parse(address)
 	if err != nil {
@@ -0,0 +1,78 @@
+// Package systemd notifies systemd of the service state with the
+// sd_notify protocol, when the program runs as a systemd service.
+package systemd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// New creates a notifier using the NOTIFY_SOCKET, WATCHDOG_USEC and
+// WATCHDOG_PID environment variables set by systemd. If no notify socket
+// is set, it acts as no-op implementation.
+func New() *Notifier {
+	notifier := &Notifier{
+		socket: os.Getenv("NOTIFY_SOCKET"),
+	}
+
+	pid := os.Getenv("WATCHDOG_PID")
+	if pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		// the watchdog is meant for another process
+		return notifier
+	}
+	microseconds, err := strconv.ParseUint(os.Getenv("WATCHDOG_USEC"), 10, 63)
+	if err == nil {
+		notifier.watchdogTimeout = time.Duration(microseconds) * time.Microsecond
+	}
+	return notifier
+}
+
+type Notifier struct {
+	socket          string
+	watchdogTimeout time.Duration
+}
+
+// Ready notifies systemd the service finished starting up.
+func (n *Notifier) Ready() (err error) {
+	return n.notify("READY=1")
+}
+
+// Watchdog notifies systemd the service is still alive.
+func (n *Notifier) Watchdog() (err error) {
+	return n.notify("WATCHDOG=1")
+}
+
+// WatchdogPeriod returns the period at which Watchdog should be
+// called, which is half the systemd watchdog timeout, or zero
+// if the watchdog is not enabled.
+func (n *Notifier) WatchdogPeriod() time.Duration {
+	if n.socket == "" {
+		return 0
+	}
+	return n.watchdogTimeout / 2 //nolint:mnd
+}
+
+func (n *Notifier) notify(state string) (err error) {
+	if n.socket == "" {
+		return nil
+	}
+
+	// Note a socket name starting with @ is an abstract
+	// socket address, which the net package handles.
+	address := &net.UnixAddr{Name: n.socket, Net: "unixgram"}
+	connection, err := net.DialUnix("unixgram", nil, address)
+	if err != nil {
+		return fmt.Errorf("dialing notify socket: %w", err)
+	}
+
+	_, err = connection.Write([]byte(state))
+	if err != nil {
+		_ = connection.Close()
+		return fmt.Errorf("writing to notify socket: %w", err)
+	}
+
+	return connection.Close()
+}
@@ -0,0 +1,90 @@
+package systemd
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Notifier(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "notify.sock")
+	listener, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+
+	t.Setenv("NOTIFY_SOCKET", socketPath)
+	t.Setenv("WATCHDOG_USEC", "10000000")
+	t.Setenv("WATCHDOG_PID", "")
+
+	notifier := New()
+	assert.Equal(t, 5*time.Second, notifier.WatchdogPeriod())
+
+	buffer := make([]byte, 64)
+	err = notifier.Ready()
+	require.NoError(t, err)
+	n, err := listener.Read(buffer)
+	require.NoError(t, err)
+	assert.Equal(t, "READY=1", string(buffer[:n]))
+
+	err = notifier.Watchdog()
+	require.NoError(t, err)
+	n, err = listener.Read(buffer)
+	require.NoError(t, err)
+	assert.Equal(t, "WATCHDOG=1", string(buffer[:n]))
+}
+
+func Test_New(t *testing.T) {
+	testCases := map[string]struct {
+		socket         string
+		watchdogUSec   string
+		watchdogPID    string
+		watchdogPeriod time.Duration
+	}{
+		"no_socket": {
+			watchdogUSec: "10000000",
+		},
+		"no_watchdog": {
+			socket: "/run/notify.sock",
+		},
+		"watchdog": {
+			socket:         "/run/notify.sock",
+			watchdogUSec:   "10000000",
+			watchdogPeriod: 5 * time.Second,
+		},
+		"watchdog_for_other_process": {
+			socket:       "/run/notify.sock",
+			watchdogUSec: "10000000",
+			watchdogPID:  strconv.Itoa(os.Getpid() + 1),
+		},
+		"watchdog_for_this_process": {
+			socket:         "/run/notify.sock",
+			watchdogUSec:   "2000000",
+			watchdogPID:    strconv.Itoa(os.Getpid()),
+			watchdogPeriod: time.Second,
+		},
+		"malformed_watchdog": {
+			socket:       "/run/notify.sock",
+			watchdogUSec: "10s",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("NOTIFY_SOCKET", testCase.socket)
+			t.Setenv("WATCHDOG_USEC", testCase.watchdogUSec)
+			t.Setenv("WATCHDOG_PID", testCase.watchdogPID)
+
+			notifier := New()
+
+			assert.Equal(t, testCase.watchdogPeriod, notifier.WatchdogPeriod())
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// scopeName is the instrumentation scope of the spans.
+const scopeName = "github.com/qdm12/ddns-updater"
+
+// Span is a span started and not ended yet. A nil span, started with a nil
+// tracer, does nothing, so callers do not need to check tracing is enabled.
+type Span struct {
+	span trace.Span
+}
+
+// Kind is the kind of a span, as defined by OpenTelemetry.
+type Kind = trace.SpanKind
+
+const (
+	KindInternal = trace.SpanKindInternal
+	KindClient   = trace.SpanKindClient
+)
+
+// Attribute is a key value pair set on a span.
+type Attribute = attribute.KeyValue
+
+func String(key, value string) Attribute {
+	return attribute.String(key, value)
+}
+
+func Int(key string, value int) Attribute {
+	return attribute.Int(key, value)
+}
+
+func Bool(key string, value bool) Attribute {
+	return attribute.Bool(key, value)
+}
+
+// Start starts a span of the OpenTelemetry global tracer provider, set with
+// SetDefault, child of the span of ctx, if any, and returns a context holding
+// the span started. The span does nothing if no tracer is set as default.
+func Start(ctx context.Context, name string, kind Kind,
+	attributes ...Attribute) (context.Context, *Span) {
+	return startSpan(ctx, otel.Tracer(scopeName), name, kind, attributes)
+}
+
+// StartSpan starts a span of the tracer, child of the span of ctx, if any,
+// and returns a context holding the span started. It returns ctx and a nil
+// span if the tracer is nil.
+func (t *Tracer) StartSpan(ctx context.Context, name string, kind Kind,
+	attributes ...Attribute) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	return startSpan(ctx, t.provider.Tracer(scopeName), name, kind, attributes)
+}
+
+func startSpan(ctx context.Context, tracer trace.Tracer, name string, kind Kind,
+	attributes []Attribute) (context.Context, *Span) {
+	ctx, span := tracer.Start(ctx, name, trace.WithSpanKind(kind),
+		trace.WithAttributes(attributes...))
+	return ctx, &Span{span: span}
+}
+
+// WithSpan returns a copy of ctx holding the span of spanCtx, if any,
+// for example to start child spans from a context with a different
+// cancellation.
+func WithSpan(ctx, spanCtx context.Context) context.Context {
+	span := trace.SpanFromContext(spanCtx)
+	if !span.SpanContext().IsValid() {
+		return ctx
+	}
+	return trace.ContextWithSpan(ctx, span)
+}
+
+// Inject sets the W3C trace context headers of the span of ctx, if any,
+// on the headers of an outgoing request, so that the spans of its server
+// are part of the same trace.
+func Inject(ctx context.Context, header http.Header) {
+	propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// SetAttributes adds attributes to the span.
+func (s *Span) SetAttributes(attributes ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.span.SetAttributes(attributes...)
+}
+
+// End ends the span, with the error given recorded as its status
+// and as an exception event if it is not nil.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
@@ -0,0 +1,119 @@
+// Package tracing traces the update cycles with the OpenTelemetry SDK,
+// exporting the spans with the OpenTelemetry protocol (OTLP) over HTTP to
+// an OpenTelemetry collector or to a tracing backend such as Jaeger or Tempo.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+type Settings struct {
+	// Endpoint is the OTLP/HTTP traces endpoint URL, such
+	// as http://localhost:4318/v1/traces.
+	Endpoint string
+	// Headers are sent with each export request, for
+	// example to authenticate to a tracing backend.
+	Headers map[string]string
+	// ServiceName is the service.name resource attribute of the spans.
+	ServiceName string
+	// ServiceVersion is the service.version resource attribute of the spans.
+	ServiceVersion string
+	// Timeout is the timeout of each export request.
+	Timeout time.Duration
+	Logger  Logger
+}
+
+type Logger interface {
+	Warn(message string)
+}
+
+// Tracer is the OpenTelemetry tracer provider exporting
+// the spans ended in batches.
+type Tracer struct {
+	provider *sdktrace.TracerProvider
+	logger   Logger
+	stopOnce sync.Once
+	stopErr  error
+}
+
+const (
+	exportPeriod  = 5 * time.Second
+	exportTimeout = 10 * time.Second
+	// maxSpans is the maximum number of spans waiting to be exported,
+	// after which the spans ended are dropped.
+	maxSpans = 2048
+)
+
+// New returns a tracer exporting its spans to the endpoint of the settings.
+// The export requests are only sent once spans are ended.
+func New(settings Settings) (tracer *Tracer, err error) {
+	exporter, err := otlptracehttp.New(context.Background(),
+		otlptracehttp.WithEndpointURL(settings.Endpoint),
+		otlptracehttp.WithHeaders(settings.Headers),
+		otlptracehttp.WithTimeout(settings.Timeout))
+	if err != nil {
+		return nil, fmt.Errorf("creating OTLP exporter: %w", err)
+	}
+
+	attributes := []Attribute{semconv.ServiceName(settings.ServiceName)}
+	if settings.ServiceVersion != "" {
+		attributes = append(attributes, semconv.ServiceVersion(settings.ServiceVersion))
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter,
+			sdktrace.WithBatchTimeout(exportPeriod),
+			sdktrace.WithExportTimeout(exportTimeout),
+			sdktrace.WithMaxQueueSize(maxSpans)),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, attributes...)),
+	)
+	return &Tracer{
+		provider: provider,
+		logger:   settings.Logger,
+	}, nil
+}
+
+// SetDefault sets the tracer as the OpenTelemetry global tracer provider,
+// used by the spans started with Start, which is synchronized by the SDK.
+// The errors of the OpenTelemetry SDK, such as failed exports, are logged
+// as warnings.
+func SetDefault(tracer *Tracer) {
+	otel.SetTracerProvider(tracer.provider)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		tracer.logger.Warn(err.Error())
+	}))
+}
+
+func (t *Tracer) String() string {
+	return "tracing"
+}
+
+// Start does nothing, since the spans are exported
+// by the OpenTelemetry SDK once they are ended.
+func (t *Tracer) Start(context.Context) (runError <-chan error, startErr error) {
+	return nil, nil //nolint:nilnil
+}
+
+// Stop exports the spans not exported yet and shuts down the tracer.
+// It can be called several times and only stops the tracer once.
+func (t *Tracer) Stop() (err error) {
+	t.stopOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		defer cancel()
+		t.stopErr = t.provider.Shutdown(ctx)
+	})
+	return t.stopErr
+}
+
+// Flush exports the spans ended and not exported yet.
+func (t *Tracer) Flush(ctx context.Context) (err error) {
+	return t.provider.ForceFlush(ctx)
+}
@@ -0,0 +1,118 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func Test_Tracer_Flush(t *testing.T) {
+	t.Parallel()
+
+	var mutex sync.Mutex
+	var requests []*collectortrace.ExportTraceServiceRequest
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("Authorization"))
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		request := new(collectortrace.ExportTraceServiceRequest)
+		assert.NoError(t, proto.Unmarshal(body, request))
+		mutex.Lock()
+		requests = append(requests, request)
+		mutex.Unlock()
+	}))
+	t.Cleanup(server.Close)
+
+	tracer, err := New(Settings{
+		Endpoint:    server.URL + "/v1/traces",
+		Headers:     map[string]string{"Authorization": "secret"},
+		ServiceName: "ddns-updater",
+	})
+	require.NoError(t, err)
+
+	ctx, parent := tracer.StartSpan(context.Background(), "update cycle", KindInternal,
+		Bool("force", true))
+	_, child := tracer.StartSpan(ctx, "beget dns/changeRecords", KindClient,
+		String("beget.endpoint", "dns/changeRecords"))
+	child.SetAttributes(Int("attempts", 2))
+	child.End(errors.New("timeout"))
+	parent.End(nil)
+
+	err = tracer.Flush(context.Background())
+	require.NoError(t, err)
+	err = tracer.Stop()
+	require.NoError(t, err)
+	// Stopping again does not panic nor fail.
+	err = tracer.Stop()
+	require.NoError(t, err)
+
+	require.Len(t, requests, 1)
+	require.Len(t, requests[0].ResourceSpans, 1)
+	resourceSpans := requests[0].ResourceSpans[0]
+	assert.Equal(t, "service.name", resourceSpans.Resource.Attributes[0].Key)
+	assert.Equal(t, "ddns-updater", resourceSpans.Resource.Attributes[0].Value.GetStringValue())
+	require.Len(t, resourceSpans.ScopeSpans, 1)
+	spans := resourceSpans.ScopeSpans[0].Spans
+	require.Len(t, spans, 2)
+
+	childSpan, parentSpan := spans[0], spans[1]
+	assert.Equal(t, "update cycle", parentSpan.Name)
+	assert.Empty(t, parentSpan.ParentSpanId)
+	assert.Len(t, parentSpan.TraceId, 16)
+	assert.Len(t, parentSpan.SpanId, 8)
+	assert.Equal(t, tracev1.Status_STATUS_CODE_UNSET, parentSpan.Status.GetCode())
+
+	assert.Equal(t, "beget dns/changeRecords", childSpan.Name)
+	assert.Equal(t, tracev1.Span_SPAN_KIND_CLIENT, childSpan.Kind)
+	assert.Equal(t, parentSpan.TraceId, childSpan.TraceId)
+	assert.Equal(t, parentSpan.SpanId, childSpan.ParentSpanId)
+	assert.Equal(t, tracev1.Status_STATUS_CODE_ERROR, childSpan.Status.GetCode())
+	assert.Equal(t, "timeout", childSpan.Status.GetMessage())
+	require.Len(t, childSpan.Attributes, 2)
+	assert.Equal(t, int64(2), childSpan.Attributes[1].Value.GetIntValue())
+	require.Len(t, childSpan.Events, 1)
+	assert.Equal(t, "exception", childSpan.Events[0].Name)
+}
+
+func Test_Inject(t *testing.T) {
+	t.Parallel()
+
+	tracer, err := New(Settings{Endpoint: "http://localhost:4318/v1/traces"})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = tracer.Stop() })
+
+	header := http.Header{}
+	Inject(context.Background(), header)
+	assert.Empty(t, header)
+
+	ctx, span := tracer.StartSpan(context.Background(), "beget dns/getData", KindClient)
+	defer span.End(nil)
+	Inject(ctx, header)
+	spanContext := span.span.SpanContext()
+	assert.Equal(t, "00-"+spanContext.TraceID().String()+"-"+spanContext.SpanID().String()+"-01",
+		header.Get("traceparent"))
+}
+
+func Test_Tracer_nil(t *testing.T) {
+	t.Parallel()
+
+	var tracer *Tracer
+	ctx := context.Background()
+	spanCtx, span := tracer.StartSpan(ctx, "update cycle", KindInternal)
+	assert.Equal(t, ctx, spanCtx)
+	assert.Nil(t, span)
+	span.SetAttributes(Bool("force", true))
+	span.End(errors.New("test"))
+}
@@ -0,0 +1,105 @@
+package update
+
+import (
+	"fmt"
+	"net/netip"
+	"time"
+)
+
+// ipDebouncer holds back a new public IP address until it is detected a
+// number of consecutive times and for a minimum duration, so an address
+// seen briefly, for example a carrier-grade NAT address during an uplink
+// reconnection, does not trigger updates followed by reverts. It is only
+// accessed by the run goroutine.
+type ipDebouncer struct {
+	detections uint
+	duration   time.Duration
+	// ip, ipv4 and ipv6 are the states of the IP addresses
+	// detected for each IP version.
+	ip, ipv4, ipv6 debounceState
+}
+
+type debounceState struct {
+	// stable is the last IP address detected long enough,
+	// and is invalid before the first detection.
+	stable netip.Addr
+	// candidate is the new IP address detected, if any,
+	// count times in a row since the time since.
+	candidate netip.Addr
+	count     uint
+	since     time.Time
+}
+
+func newIPDebouncer(detections uint, duration time.Duration) *ipDebouncer {
+	return &ipDebouncer{
+		detections: detections,
+		duration:   duration,
+	}
+}
+
+// debounce returns the IP address to update the records with for the
+// IP address detected at now, which is the IP address detected if it is
+// stable or if no IP address was detected before, and the previous stable
+// IP address otherwise, with pending set to true. An invalid IP address,
+// when the detection failed, is returned as it is.
+func (s *debounceState) debounce(ip netip.Addr, now time.Time,
+	detections uint, duration time.Duration) (stable netip.Addr, pending bool) {
+	switch {
+	case !ip.IsValid():
+		return ip, false
+	case !s.stable.IsValid(), ip == s.stable:
+		s.stable = ip
+		s.candidate = netip.Addr{}
+		return ip, false
+	case ip != s.candidate:
+		s.candidate = ip
+		s.count = 0
+		s.since = now
+	}
+	s.count++
+
+	if s.count < detections || now.Sub(s.since) < duration {
+		return s.stable, true
+	}
+	s.stable = ip
+	s.candidate = netip.Addr{}
+	return ip, false
+}
+
+// accept sets the IP address detected as the stable one right away,
+// for forced updates.
+func (s *debounceState) accept(ip netip.Addr) {
+	if ip.IsValid() {
+		s.stable = ip
+		s.candidate = netip.Addr{}
+	}
+}
+
+// debounceIPs returns the public IP addresses to update the records with,
+// holding back the new IP addresses which are not stable yet, unless
+// force is true.
+func (s *Service) debounceIPs(ip, ipv4, ipv6 netip.Addr, now time.Time, force bool) (
+	stableIP, stableIPv4, stableIPv6 netip.Addr) {
+	d := s.debouncer
+	if d.detections <= 1 && d.duration <= 0 {
+		return ip, ipv4, ipv6
+	}
+
+	states := []*debounceState{&d.ip, &d.ipv4, &d.ipv6}
+	kinds := []string{"IP", "IPv4", "IPv6"}
+	addresses := []netip.Addr{ip, ipv4, ipv6}
+	for i, state := range states {
+		if force {
+			state.accept(addresses[i])
+			continue
+		}
+		var pending bool
+		addresses[i], pending = state.debounce(addresses[i], now, d.detections, d.duration)
+		if pending {
+			s.logger.Info(fmt.Sprintf("public %s address %s detected %d time(s) since %s, "+
+				"keeping %s until it is stable", kinds[i], state.candidate, state.count,
+				state.since.Format(time.RFC3339), state.stable))
+		}
+	}
+	return addresses[0], addresses[1], addresses[2]
+}
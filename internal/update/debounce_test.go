@@ -0,0 +1,101 @@
+package update
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_debounceState_debounce(t *testing.T) {
+	t.Parallel()
+
+	ipA := netip.MustParseAddr("1.1.1.1")
+	ipB := netip.MustParseAddr("100.64.0.1")
+	ipC := netip.MustParseAddr("2.2.2.2")
+	start := time.Unix(0, 0)
+
+	type detection struct {
+		ip      netip.Addr
+		after   time.Duration
+		stable  netip.Addr
+		pending bool
+	}
+
+	testCases := map[string]struct {
+		detections uint
+		duration   time.Duration
+		sequence   []detection
+	}{
+		"first_detection_accepted": {
+			detections: 3,
+			sequence: []detection{
+				{ip: ipA, stable: ipA},
+				{ip: ipA, stable: ipA},
+			},
+		},
+		"flapping_ignored": {
+			detections: 2,
+			sequence: []detection{
+				{ip: ipA, stable: ipA},
+				{ip: ipB, stable: ipA, pending: true},
+				{ip: ipA, stable: ipA},
+				{ip: ipB, stable: ipA, pending: true},
+			},
+		},
+		"consecutive_detections": {
+			detections: 3,
+			sequence: []detection{
+				{ip: ipA, stable: ipA},
+				{ip: ipB, stable: ipA, pending: true},
+				{ip: ipC, stable: ipA, pending: true},
+				{ip: ipC, stable: ipA, pending: true},
+				{ip: ipC, stable: ipC},
+			},
+		},
+		"failed_detection_ignored": {
+			detections: 2,
+			sequence: []detection{
+				{ip: ipA, stable: ipA},
+				{ip: ipC, stable: ipA, pending: true},
+				{},
+				{ip: ipC, stable: ipC},
+			},
+		},
+		"duration": {
+			detections: 1,
+			duration:   time.Minute,
+			sequence: []detection{
+				{ip: ipA, stable: ipA},
+				{ip: ipC, after: time.Minute, stable: ipA, pending: true},
+				{ip: ipC, after: 2 * time.Minute, stable: ipC},
+			},
+		},
+		"detections_and_duration": {
+			detections: 3,
+			duration:   time.Minute,
+			sequence: []detection{
+				{ip: ipA, stable: ipA},
+				{ip: ipC, stable: ipA, pending: true},
+				{ip: ipC, after: 2 * time.Minute, stable: ipA, pending: true},
+				{ip: ipC, after: 3 * time.Minute, stable: ipC},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var state debounceState
+			for i, detection := range testCase.sequence {
+				stable, pending := state.debounce(detection.ip, start.Add(detection.after),
+					testCase.detections, testCase.duration)
+				assert.Equal(t, detection.stable, stable, "detection %d", i)
+				assert.Equal(t, detection.pending, pending, "detection %d", i)
+			}
+		})
+	}
+}
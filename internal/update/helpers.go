@@ -5,6 +5,7 @@ import (
 	"net/netip"
 	"strings"
 
+	"github.com/qdm12/ddns-updater/internal/provider"
 	"github.com/qdm12/ddns-updater/internal/records"
 	"github.com/qdm12/ddns-updater/pkg/publicip/ipversion"
 )
@@ -22,6 +23,18 @@ func recordToLogString(record records.Record) string {
 		record.Provider.IPVersion())
 }
 
+func isDualStack(p provider.Provider) bool {
+	dualStackProvider, ok := p.(provider.DualStackProvider)
+	return ok && dualStackProvider.DualStack()
+}
+
+// skipsLookup returns true if the provider is proxied or implements
+// provider.LookupSkipper and skips DNS lookups.
+func skipsLookup(p provider.Provider) bool {
+	lookupSkipper, ok := p.(provider.LookupSkipper)
+	return p.Proxied() || (ok && lookupSkipper.SkipLookup())
+}
+
 func (s *Service) logDebugNoLookupSkip(hostname, ipKind string, lastIP, ip netip.Addr) {
 	s.logger.Debug(fmt.Sprintf("Last %s address stored for %s is %s and your %s address"+
 		" is %s, skipping update", ipKind, hostname, lastIP, ipKind, ip))
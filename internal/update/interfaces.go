@@ -4,8 +4,10 @@ import (
 	"context"
 	"net"
 	"net/netip"
+	"time"
 
 	"github.com/qdm12/ddns-updater/internal/healthchecksio"
+	"github.com/qdm12/ddns-updater/internal/models"
 	"github.com/qdm12/ddns-updater/internal/records"
 )
 
@@ -17,20 +19,24 @@ type PublicIPFetcher interface {
 
 type UpdaterInterface interface {
 	Update(ctx context.Context, recordID uint, ip netip.Addr) (err error)
+	UpdateDualStack(ctx context.Context, recordID uint, ipv4, ipv6 netip.Addr) (err error)
 }
 
 type Database interface {
 	Select(recordID uint) (record records.Record, err error)
 	SelectAll() (records []records.Record)
 	Update(recordID uint, record records.Record) (err error)
+	Reload(settings []records.Settings) (err error)
 }
 
 type LookupIPer interface {
 	LookupIP(ctx context.Context, network, host string) (ips []net.IP, err error)
 }
 
-type ShoutrrrClient interface {
-	Notify(message string)
+// EventNotifier is notified of the outcome of each record update,
+// such as the shoutrrr, webhook and email clients.
+type EventNotifier interface {
+	Updated(ctx context.Context, event models.UpdateEvent)
 }
 
 type Logger interface {
@@ -43,3 +49,10 @@ type Logger interface {
 type HealthchecksIOClient interface {
 	Ping(ctx context.Context, state healthchecksio.State) (err error)
 }
+
+// SystemdNotifier notifies systemd the service is ready and still alive.
+type SystemdNotifier interface {
+	Ready() (err error)
+	Watchdog() (err error)
+	WatchdogPeriod() time.Duration
+}
@@ -25,3 +25,17 @@ func ipv6WithSuffix(publicIP netip.Addr, ipv6Suffix netip.Prefix) (
 
 	return updateIP
 }
+
+// ipv6Prefix returns the prefix of the public IPv6 address replaced
+// by the IPv6 suffix of a record, or an invalid prefix if the record
+// has no IPv6 suffix or if the address is not a valid IPv6 address.
+func ipv6Prefix(publicIP netip.Addr, ipv6Suffix netip.Prefix) (prefix netip.Prefix) {
+	if !publicIP.IsValid() || !publicIP.Is6() ||
+		!ipv6Suffix.IsValid() || ipv6Suffix.Bits() == 0 {
+		return netip.Prefix{}
+	}
+	const ipv6Bits = 128
+	const bitsInByte = 8
+	prefixBits := (ipv6Bits - ipv6Suffix.Bits()) / bitsInByte * bitsInByte
+	return netip.PrefixFrom(publicIP, prefixBits).Masked()
+}
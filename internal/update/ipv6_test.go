@@ -55,3 +55,50 @@ func Test_ipv6WithSuffix(t *testing.T) {
 		})
 	}
 }
+
+func Test_ipv6Prefix(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		publicIP   netip.Addr
+		ipv6Suffix netip.Prefix
+		prefix     netip.Prefix
+	}{
+		"blank_inputs": {},
+		"ipv4_publicip": {
+			publicIP:   netip.MustParseAddr("1.2.3.4"),
+			ipv6Suffix: netip.MustParsePrefix("0:0:0:0:72ad:8fbb:a54e:bedd/64"),
+		},
+		"invalid_suffix": {
+			publicIP: netip.MustParseAddr("2001:db8::1"),
+		},
+		"zero_suffix": {
+			publicIP:   netip.MustParseAddr("e4db:af36:82e:1221:1b7f:2f54:6e9e:5e5f"),
+			ipv6Suffix: netip.MustParsePrefix("0:0:0:0:0:0:0:0/0"),
+		},
+		"suffix_64": {
+			publicIP:   netip.MustParseAddr("e4db:af36:82e:1221:1b7f:2f54:6e9e:5e5f"),
+			ipv6Suffix: netip.MustParsePrefix("0:0:0:0:72ad:8fbb:a54e:bedd/64"),
+			prefix:     netip.MustParsePrefix("e4db:af36:82e:1221::/64"),
+		},
+		"suffix_60": {
+			publicIP:   netip.MustParseAddr("e4db:af36:82e:1221:1b7f:2f54:6e9e:5e5f"),
+			ipv6Suffix: netip.MustParsePrefix("0:0:0:0:72ad:8fbb:a54e:bedd/60"),
+			prefix:     netip.MustParsePrefix("e4db:af36:82e:1221::/64"),
+		},
+		"suffix_72": {
+			publicIP:   netip.MustParseAddr("e4db:af36:82e:1221:1b7f:2f54:6e9e:5e5f"),
+			ipv6Suffix: netip.MustParsePrefix("0:0:0:0:72ad:8fbb:a54e:bedd/72"),
+			prefix:     netip.MustParsePrefix("e4db:af36:82e:1200::/56"),
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			prefix := ipv6Prefix(testCase.publicIP, testCase.ipv6Suffix)
+			assert.Equal(t, testCase.prefix, prefix)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package update
+
+import (
+	"hash/fnv"
+	"time"
+
+	librecords "github.com/qdm12/ddns-updater/internal/records"
+)
+
+// jitterSlots is the number of ticks within the jitter of the records,
+// so that their checks are spread across the jitter duration.
+const jitterSlots = 10
+
+// recordJitter returns the jitter of the record, or the global jitter
+// if it has none, at most equal to the period of the record.
+func (s *Service) recordJitter(record librecords.Record) (jitter time.Duration) {
+	period := s.period
+	if record.Period > 0 {
+		period = record.Period
+	}
+	jitter = s.jitter
+	if record.Jitter > 0 {
+		jitter = record.Jitter
+	}
+	return min(jitter, period)
+}
+
+// checkOffset returns how much earlier than its period the check following
+// the first check of the record identified by key is done. It is between
+// zero and jitter excluded, and is always the same for a given key, so
+// the records checked together on startup are then checked at different
+// times, keeping their period.
+func checkOffset(key string, jitter time.Duration) (offset time.Duration) {
+	if jitter <= 0 {
+		return 0
+	}
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(key))
+	return time.Duration(hash.Sum64() % uint64(jitter))
+}
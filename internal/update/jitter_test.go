@@ -0,0 +1,25 @@
+package update
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_checkOffset(t *testing.T) {
+	t.Parallel()
+
+	assert.Zero(t, checkOffset("a.example.com", 0))
+
+	const jitter = time.Minute
+	offsets := make(map[time.Duration]struct{})
+	for _, key := range []string{"a.example.com", "b.example.com", "c.example.com"} {
+		offset := checkOffset(key, jitter)
+		assert.GreaterOrEqual(t, offset, time.Duration(0))
+		assert.Less(t, offset, jitter)
+		assert.Equal(t, offset, checkOffset(key, jitter))
+		offsets[offset] = struct{}{}
+	}
+	assert.Len(t, offsets, 3)
+}
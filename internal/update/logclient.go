@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/qdm12/ddns-updater/internal/provider/utils"
@@ -60,8 +61,17 @@ func (lrt *loggingRoundTripper) RoundTrip(request *http.Request) (
 	return response, nil
 }
 
+// WithProxied returns a copy of the round tripper logging
+// with the same logger and proxying to the proxied round tripper.
+func (lrt *loggingRoundTripper) WithProxied(proxied http.RoundTripper) http.RoundTripper {
+	return &loggingRoundTripper{
+		proxied: proxied,
+		logger:  lrt.logger,
+	}
+}
+
 func requestToString(request *http.Request) (s string) {
-	s = request.Method + " " + request.URL.String()
+	s = request.Method + " " + redactURL(request.URL)
 
 	if request.Header != nil {
 		s += " | headers: " + headerToString(request.Header)
@@ -70,12 +80,58 @@ func requestToString(request *http.Request) (s string) {
 	if request.Body != nil {
 		newBody, bodyString := readAndResetBody(request.Body)
 		request.Body = newBody
+		if request.Header.Get("Content-Type") == "application/x-www-form-urlencoded" {
+			bodyString = redactForm(bodyString)
+		}
 		s += " | body: " + bodyString
 	}
 
 	return s
 }
 
+// isSensitiveKey returns true if the value of the
+// query or form key should be redacted from the debug logs.
+func isSensitiveKey(key string) bool {
+	switch strings.ToLower(key) {
+	case "passwd", "password", "pass", "pwd", "secret", "token", "apikey", "api_key":
+		return true
+	default:
+		return false
+	}
+}
+
+// redactURL returns the URL u as a string, with its user info
+// password and its sensitive query values redacted.
+func redactURL(u *url.URL) (s string) {
+	if u.RawQuery != "" {
+		redactedURL := *u
+		redactedURL.RawQuery = redactForm(u.RawQuery)
+		u = &redactedURL
+	}
+	return u.Redacted()
+}
+
+// redactForm returns the URL encoded form or query string s with the
+// values of its sensitive keys redacted. It returns s as it is if it
+// cannot be parsed or contains no sensitive key.
+func redactForm(s string) (redacted string) {
+	values, err := url.ParseQuery(s)
+	if err != nil {
+		return s
+	}
+	changed := false
+	for key := range values {
+		if isSensitiveKey(key) {
+			values.Set(key, "xxxxx")
+			changed = true
+		}
+	}
+	if !changed {
+		return s
+	}
+	return values.Encode()
+}
+
 func responseToString(response *http.Response) (s string) {
 	s = response.Status
 
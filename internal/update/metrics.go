@@ -0,0 +1,66 @@
+package update
+
+import (
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/metrics"
+	"github.com/qdm12/ddns-updater/internal/records"
+)
+
+// updateMetrics are the metrics of the record updates, labeled
+// with the domain, owner and IP version of each record.
+type updateMetrics struct {
+	lastSuccess *metrics.GaugeVec
+	failures    *metrics.CounterVec
+	ipChanges   *metrics.CounterVec
+	duration    *metrics.HistogramVec
+}
+
+func newUpdateMetrics(registry *metrics.Registry) *updateMetrics {
+	labels := []string{"domain", "owner", "ip_version"}
+	return &updateMetrics{
+		lastSuccess: registry.NewGaugeVec("ddns_record_last_success_timestamp_seconds",
+			"Unix time of the last successful update of the record.", labels...),
+		failures: registry.NewCounterVec("ddns_record_update_failures_total",
+			"Failed updates of the record.", labels...),
+		ipChanges: registry.NewCounterVec("ddns_record_ip_changes_total",
+			"Successful updates of the record changing its IP address.", labels...),
+		duration: registry.NewHistogramVec("ddns_record_update_duration_seconds",
+			"Durations of the record updates.",
+			[]float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}, labels...), //nolint:gomnd
+	}
+}
+
+func recordLabels(record records.Record) []string {
+	return []string{
+		record.Provider.Domain(),
+		record.Provider.Owner(),
+		record.Provider.IPVersion().String(),
+	}
+}
+
+// setLastSuccess sets the last success time of the record
+// from its history, if it has any.
+func (m *updateMetrics) setLastSuccess(record records.Record) {
+	successTime := record.History.GetSuccessTime()
+	if successTime.IsZero() {
+		return
+	}
+	m.lastSuccess.Set(float64(successTime.Unix()), recordLabels(record)...)
+}
+
+// observe records the outcome of an update of the record, which
+// had the previous record as state before the update.
+func (m *updateMetrics) observe(previous, record records.Record,
+	duration time.Duration, err error) {
+	labels := recordLabels(record)
+	m.duration.Observe(duration.Seconds(), labels...)
+	if err != nil {
+		m.failures.Inc(labels...)
+		return
+	}
+	m.setLastSuccess(record)
+	if record.History.GetCurrentIP() != previous.History.GetCurrentIP() {
+		m.ipChanges.Inc(labels...)
+	}
+}
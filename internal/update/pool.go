@@ -0,0 +1,137 @@
+package update
+
+import (
+	"context"
+	"net/netip"
+	"sync"
+
+	"github.com/qdm12/ddns-updater/internal/provider"
+	librecords "github.com/qdm12/ddns-updater/internal/records"
+)
+
+// updatePool limits the record updates done concurrently to a number of
+// workers, to a number of updates per API host, and to a single update
+// per account.
+type updatePool struct {
+	workers chan struct{}
+	perHost uint
+	// mutex protects hosts and accounts, which map the API hosts to
+	// their semaphore and the accounts to their mutex.
+	mutex    sync.Mutex
+	hosts    map[string]chan struct{}
+	accounts map[string]*sync.Mutex
+}
+
+// newUpdatePool returns an update pool, or nil if workers is 1 or less,
+// in which case the records are updated one after the other.
+func newUpdatePool(workers, perHost uint) *updatePool {
+	if workers <= 1 {
+		return nil
+	}
+	return &updatePool{
+		workers:  make(chan struct{}, workers),
+		perHost:  max(perHost, 1),
+		hosts:    make(map[string]chan struct{}),
+		accounts: make(map[string]*sync.Mutex),
+	}
+}
+
+// acquire blocks until an update can be done for the API host and
+// the account given, and returns the function to call once it is done.
+// An empty account is not serialized. The account is acquired before
+// the host and the host before the worker, so an update waiting for
+// another update of its account does not hold a worker.
+func (p *updatePool) acquire(host, account string) (release func()) {
+	p.mutex.Lock()
+	hostSemaphore, ok := p.hosts[host]
+	if !ok {
+		hostSemaphore = make(chan struct{}, p.perHost)
+		p.hosts[host] = hostSemaphore
+	}
+	var accountMutex *sync.Mutex
+	if account != "" {
+		accountMutex, ok = p.accounts[account]
+		if !ok {
+			accountMutex = new(sync.Mutex)
+			p.accounts[account] = accountMutex
+		}
+	}
+	p.mutex.Unlock()
+
+	if accountMutex != nil {
+		accountMutex.Lock()
+	}
+	hostSemaphore <- struct{}{}
+	p.workers <- struct{}{}
+	return func() {
+		<-p.workers
+		<-hostSemaphore
+		if accountMutex != nil {
+			accountMutex.Unlock()
+		}
+	}
+}
+
+// concurrencyScope returns the API host and the account of the provider,
+// which are its provider name and no account if it does not implement
+// provider.ConcurrencyScoper.
+func concurrencyScope(p provider.Provider) (host, account string) {
+	if scoper, ok := p.(provider.ConcurrencyScoper); ok {
+		return scoper.ConcurrencyScope()
+	}
+	return librecords.ProviderName(p), ""
+}
+
+// updateRecords updates the records with the ids given, one after the
+// other or concurrently with the update pool if there is one, and returns
+// the errors of the updates. No more update is started once the context
+// is canceled, in which case its error is returned with the others.
+func (s *Service) updateRecords(ctx context.Context, recordIDs map[uint]struct{},
+	records []librecords.Record, ip, ipv4, ipv6 netip.Addr) (errors []error) {
+	if s.pool == nil {
+		for id := range recordIDs {
+			if ctx.Err() != nil {
+				// the service is stopping, do not start more updates.
+				errors = append(errors, ctx.Err())
+				break
+			}
+			// Note: each record id has a matching valid public IP address.
+			err := s.updateRecord(ctx, id, records[id], ip, ipv4, ipv6)
+			if err != nil {
+				errors = append(errors, err) // logged by updateRecord
+			}
+		}
+		return errors
+	}
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	canceled := false
+	for id := range recordIDs {
+		host, account := concurrencyScope(records[id].Provider)
+		wg.Add(1)
+		go func(id uint) {
+			defer wg.Done()
+			release := s.pool.acquire(host, account)
+			defer release()
+			if ctx.Err() != nil {
+				mutex.Lock()
+				canceled = true
+				mutex.Unlock()
+				return
+			}
+			err := s.updateRecord(ctx, id, records[id], ip, ipv4, ipv6)
+			if err != nil {
+				mutex.Lock()
+				errors = append(errors, err) // logged by updateRecord
+				mutex.Unlock()
+			}
+		}(id)
+	}
+	wg.Wait()
+
+	if canceled {
+		errors = append(errors, ctx.Err())
+	}
+	return errors
+}
@@ -0,0 +1,103 @@
+package update
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_updatePool_acquire(t *testing.T) {
+	t.Parallel()
+
+	type scope struct {
+		host, account string
+	}
+
+	testCases := map[string]struct {
+		workers uint
+		perHost uint
+		held    []scope
+		next    scope
+		blocked bool
+	}{
+		"other_host": {
+			workers: 2,
+			perHost: 1,
+			held:    []scope{{host: "api.beget.com", account: "a"}},
+			next:    scope{host: "api.example.com"},
+		},
+		"host_cap_reached": {
+			workers: 3,
+			perHost: 1,
+			held:    []scope{{host: "api.beget.com", account: "a"}},
+			next:    scope{host: "api.beget.com", account: "b"},
+			blocked: true,
+		},
+		"host_cap_not_reached": {
+			workers: 3,
+			perHost: 2,
+			held:    []scope{{host: "api.beget.com", account: "a"}},
+			next:    scope{host: "api.beget.com", account: "b"},
+		},
+		"same_account": {
+			workers: 3,
+			perHost: 2,
+			held:    []scope{{host: "api.beget.com", account: "a"}},
+			next:    scope{host: "api.beget.com", account: "a"},
+			blocked: true,
+		},
+		"workers_busy": {
+			workers: 2,
+			perHost: 2,
+			held:    []scope{{host: "api.beget.com", account: "a"}, {host: "api.example.com"}},
+			next:    scope{host: "api.example.net"},
+			blocked: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			pool := newUpdatePool(testCase.workers, testCase.perHost)
+			releases := make([]func(), len(testCase.held))
+			for i, held := range testCase.held {
+				releases[i] = pool.acquire(held.host, held.account)
+			}
+
+			acquired := make(chan func())
+			go func() {
+				acquired <- pool.acquire(testCase.next.host, testCase.next.account)
+			}()
+
+			const wait = 50 * time.Millisecond
+			timer := time.NewTimer(wait)
+			defer timer.Stop()
+			select {
+			case release := <-acquired:
+				assert.False(t, testCase.blocked, "acquire did not block")
+				release()
+			case <-timer.C:
+				assert.True(t, testCase.blocked, "acquire blocked")
+				for _, release := range releases {
+					release()
+				}
+				release := <-acquired
+				release()
+				return
+			}
+			for _, release := range releases {
+				release()
+			}
+		})
+	}
+}
+
+func Test_newUpdatePool(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, newUpdatePool(1, 1))
+	assert.NotNil(t, newUpdatePool(2, 1))
+}
@@ -0,0 +1,44 @@
+package update
+
+import (
+	"fmt"
+	"net/netip"
+
+	librecords "github.com/qdm12/ddns-updater/internal/records"
+)
+
+// recordIPv6Prefix returns the prefix of the public IPv6 address the
+// AAAA record would be updated with, or an invalid prefix if the
+// record has no IPv6 suffix.
+func recordIPv6Prefix(record librecords.Record, ip, ipv6 netip.Addr) (prefix netip.Prefix) {
+	publicIP := ipv6
+	if !isDualStack(record.Provider) {
+		publicIP = getIPMatchingVersion(ip, netip.Addr{}, ipv6, record.Provider.IPVersion())
+	}
+	return ipv6Prefix(publicIP, record.Provider.IPv6Suffix())
+}
+
+// ipv6PrefixChanged returns true if the record has an IPv6 suffix and
+// the prefix of the public IPv6 address changed since it was last updated,
+// in which case its AAAA record must be updated even if its IP address
+// looks up to date. The prefix detected is only stored if no prefix was
+// stored yet, since it is otherwise stored once the record is updated.
+func (s *Service) ipv6PrefixChanged(record librecords.Record, ip, ipv6 netip.Addr) (changed bool) {
+	prefix := recordIPv6Prefix(record, ip, ipv6)
+	if !prefix.IsValid() {
+		return false
+	}
+	key := librecords.Key(record.Provider)
+	s.ipv6PrefixesMutex.Lock()
+	defer s.ipv6PrefixesMutex.Unlock()
+	lastPrefix, ok := s.ipv6Prefixes[key]
+	if !ok {
+		s.ipv6Prefixes[key] = prefix
+		return false
+	} else if lastPrefix == prefix {
+		return false
+	}
+	s.logger.Info(fmt.Sprintf("IPv6 prefix of %s changed from %s to %s, forcing update",
+		record.Provider.BuildDomainName(), lastPrefix, prefix))
+	return true
+}
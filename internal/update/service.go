@@ -2,58 +2,111 @@ package update
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 	"net/netip"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/qdm12/ddns-updater/internal/constants"
 	"github.com/qdm12/ddns-updater/internal/healthchecksio"
+	"github.com/qdm12/ddns-updater/internal/logging"
 	"github.com/qdm12/ddns-updater/internal/models"
+	settingserrors "github.com/qdm12/ddns-updater/internal/provider/errors"
 	librecords "github.com/qdm12/ddns-updater/internal/records"
+	"github.com/qdm12/ddns-updater/internal/tracing"
 	"github.com/qdm12/ddns-updater/pkg/publicip/ipversion"
 )
 
 type Service struct {
-	period    time.Duration
-	db        Database
-	updater   UpdaterInterface
-	cooldown  time.Duration
+	period   time.Duration
+	db       Database
+	updater  UpdaterInterface
+	cooldown time.Duration
+	// jitter is the duration across which the checks of the records
+	// are spread, unless a record has its own, or zero to disable it.
+	jitter time.Duration
+	// debouncer holds back the new public IP addresses until
+	// they are stable.
+	debouncer *ipDebouncer
+	// pool limits the record updates done concurrently,
+	// and is nil to update the records one after the other.
+	pool      *updatePool
 	resolver  LookupIPer
 	ipGetter  PublicIPFetcher
 	logger    Logger
 	timeNow   func() time.Time
 	hioClient HealthchecksIOClient
+	systemd   SystemdNotifier
+	// lastChecks maps the record keys, see librecords.Key, to the time
+	// their record was last checked, and is only accessed by the run
+	// goroutine.
+	lastChecks map[string]time.Time
+	// ipv6Prefixes maps the record keys to the prefix of the public IPv6
+	// address their AAAA record was last updated with, for the records
+	// with an IPv6 suffix, and is protected by ipv6PrefixesMutex since
+	// the records can be updated concurrently.
+	ipv6Prefixes      map[string]netip.Prefix
+	ipv6PrefixesMutex sync.Mutex
+
+	// shutdownTimeout is the time to wait, when stopping, for the
+	// record updates in progress to finish before canceling them.
+	shutdownTimeout time.Duration
 
 	// Service lifecycle
-	runCancel   context.CancelFunc
-	done        <-chan struct{}
-	force       chan struct{}
-	forceResult chan []error
+	runCancel    context.CancelFunc
+	updatesCtx   context.Context //nolint:containedctx
+	updatesStop  context.CancelFunc
+	done         <-chan struct{}
+	force        chan forceRequest
+	forceRecord  chan forceRequest
+	reload       chan []librecords.Settings
+	reloadResult chan error
 }
 
 func NewService(db Database, updater UpdaterInterface, ipGetter PublicIPFetcher,
-	period time.Duration, cooldown time.Duration, logger Logger, resolver LookupIPer,
-	timeNow func() time.Time, hioClient HealthchecksIOClient) *Service {
+	period, cooldown, jitter time.Duration, stableDetections uint, stableDuration time.Duration,
+	workers, workersPerHost uint, logger Logger, resolver LookupIPer,
+	timeNow func() time.Time, hioClient HealthchecksIOClient,
+	shutdownTimeout time.Duration, systemd SystemdNotifier) *Service {
 	return &Service{
-		period:      period,
-		db:          db,
-		updater:     updater,
-		force:       make(chan struct{}),
-		forceResult: make(chan []error),
-		cooldown:    cooldown,
-		resolver:    resolver,
-		ipGetter:    ipGetter,
-		logger:      logger,
-		timeNow:     timeNow,
-		hioClient:   hioClient,
+		systemd:         systemd,
+		period:          period,
+		shutdownTimeout: shutdownTimeout,
+		db:              db,
+		updater:         updater,
+		force:           make(chan forceRequest),
+		forceRecord:     make(chan forceRequest),
+		lastChecks:      make(map[string]time.Time),
+		ipv6Prefixes:    make(map[string]netip.Prefix),
+		reload:          make(chan []librecords.Settings),
+		reloadResult:    make(chan error),
+		cooldown:        cooldown,
+		jitter:          jitter,
+		debouncer:       newIPDebouncer(stableDetections, stableDuration),
+		pool:            newUpdatePool(workers, workersPerHost),
+		resolver:        resolver,
+		ipGetter:        ipGetter,
+		logger:          logger,
+		timeNow:         timeNow,
+		hioClient:       hioClient,
 	}
 }
 
-func (s *Service) lookupIPsResilient(ctx context.Context, hostname string, tries int) (
+// recordResolver returns the resolver of the record, or the global
+// resolver if the record has none.
+func (s *Service) recordResolver(record librecords.Record) LookupIPer {
+	if record.Resolver != nil {
+		return record.Resolver
+	}
+	return s.resolver
+}
+
+func lookupIPsResilient(ctx context.Context, resolver LookupIPer, hostname string, tries int) (
 	ipv4 netip.Addr, ipv6 netip.Addr, err error) {
 	for i := 0; i < tries; i++ {
-		ipv4, ipv6, err = s.lookupIPs(ctx, hostname)
+		ipv4, ipv6, err = lookupIPs(ctx, resolver, hostname)
 		if err == nil {
 			return ipv4, ipv6, nil
 		}
@@ -61,9 +114,9 @@ func (s *Service) lookupIPsResilient(ctx context.Context, hostname string, tries
 	return netip.Addr{}, netip.Addr{}, err
 }
 
-func (s *Service) lookupIPs(ctx context.Context, hostname string) (
+func lookupIPs(ctx context.Context, resolver LookupIPer, hostname string) (
 	ipv4 netip.Addr, ipv6 netip.Addr, err error) {
-	netIPs, err := s.resolver.LookupIP(ctx, "ip", hostname)
+	netIPs, err := resolver.LookupIP(ctx, "ip", hostname)
 	if err != nil {
 		return netip.Addr{}, netip.Addr{}, err
 	}
@@ -98,6 +151,9 @@ func doIPVersion(records []librecords.Record) (doIP, doIPv4, doIPv6 bool) {
 		case ipversion.IP6:
 			doIPv6 = true
 		}
+		if isDualStack(record.Provider) {
+			doIPv4, doIPv6 = true, true
+		}
 		if doIP && doIPv4 && doIPv6 {
 			return true, true, true
 		}
@@ -107,6 +163,17 @@ func doIPVersion(records []librecords.Record) (doIP, doIPv4, doIPv6 bool) {
 
 func (s *Service) getNewIPs(ctx context.Context, doIP, doIPv4, doIPv6 bool) (
 	ip, ipv4, ipv6 netip.Addr, errors []error) {
+	ctx, span := tracing.Start(ctx, "public IP detection", tracing.KindInternal)
+	defer func() {
+		names := []string{"ip", "ipv4", "ipv6"}
+		for i, address := range []netip.Addr{ip, ipv4, ipv6} {
+			if address.IsValid() {
+				span.SetAttributes(tracing.String(names[i], address.String()))
+			}
+		}
+		span.End(stderrors.Join(errors...))
+	}()
+
 	var err error
 	if doIP {
 		ip, err = tryAndRepeatGettingIP(ctx, s.ipGetter.IP, s.logger, ipversion.IP4or6)
@@ -130,9 +197,12 @@ func (s *Service) getNewIPs(ctx context.Context, doIP, doIPv4, doIPv6 bool) (
 }
 
 func (s *Service) getRecordIDsToUpdate(ctx context.Context, records []librecords.Record,
-	ip, ipv4, ipv6 netip.Addr) (recordIDs map[uint]struct{}) {
+	dueIDs map[uint]struct{}, ip, ipv4, ipv6 netip.Addr) (recordIDs map[uint]struct{}) {
 	recordIDs = make(map[uint]struct{})
 	for i, record := range records {
+		if _, due := dueIDs[uint(i)]; !due {
+			continue
+		}
 		shouldUpdate := s.shouldUpdateRecord(ctx, record, ip, ipv4, ipv6)
 		if shouldUpdate {
 			id := uint(i)
@@ -146,6 +216,12 @@ func (s *Service) shouldUpdateRecord(ctx context.Context, record librecords.Reco
 	ip, ipv4, ipv6 netip.Addr) (update bool) {
 	now := s.timeNow()
 
+	if record.Paused {
+		s.logger.Debug(fmt.Sprintf("record %s is paused, skipping update",
+			recordToLogString(record)))
+		return false
+	}
+
 	isWithinCooldown := now.Sub(record.History.GetSuccessTime()) < s.cooldown
 	if isWithinCooldown {
 		s.logger.Debug(fmt.Sprintf(
@@ -163,6 +239,14 @@ func (s *Service) shouldUpdateRecord(ctx context.Context, record librecords.Reco
 		return false
 	}
 
+	if s.ipv6PrefixChanged(record, ip, ipv6) {
+		return true
+	}
+
+	if isDualStack(record.Provider) {
+		return s.shouldUpdateDualStackRecord(ctx, record, ipv4, ipv6)
+	}
+
 	hostname := record.Provider.BuildDomainName()
 	ipVersion := record.Provider.IPVersion()
 	publicIP := getIPMatchingVersion(ip, ipv4, ipv6, ipVersion)
@@ -175,11 +259,30 @@ func (s *Service) shouldUpdateRecord(ctx context.Context, record librecords.Reco
 		publicIP = ipv6WithSuffix(publicIP, record.Provider.IPv6Suffix())
 	}
 
-	if record.Provider.Proxied() {
+	if skipsLookup(record.Provider) {
 		lastIP := record.History.GetCurrentIP() // can be nil
 		return s.shouldUpdateRecordNoLookup(hostname, ipVersion, lastIP, publicIP)
 	}
-	return s.shouldUpdateRecordWithLookup(ctx, hostname, ipVersion, publicIP)
+	return s.shouldUpdateRecordWithLookup(ctx, s.recordResolver(record), hostname, ipVersion, publicIP)
+}
+
+// shouldUpdateDualStackRecord returns true if either the A or
+// the AAAA record of a dual stack record needs to be updated.
+func (s *Service) shouldUpdateDualStackRecord(ctx context.Context, record librecords.Record,
+	ipv4, ipv6 netip.Addr) (update bool) {
+	hostname := record.Provider.BuildDomainName()
+	if !ipv4.IsValid() && !ipv6.IsValid() {
+		s.logger.Warn(fmt.Sprintf("Skipping update for %s because %s and %s addresses were not found",
+			hostname, ipversion.IP4, ipversion.IP6))
+		return false
+	}
+	ipv6 = ipv6WithSuffix(ipv6, record.Provider.IPv6Suffix())
+
+	resolver := s.recordResolver(record)
+	if ipv4.IsValid() && s.shouldUpdateRecordWithLookup(ctx, resolver, hostname, ipversion.IP4, ipv4) {
+		return true
+	}
+	return ipv6.IsValid() && s.shouldUpdateRecordWithLookup(ctx, resolver, hostname, ipversion.IP6, ipv6)
 }
 
 func (s *Service) shouldUpdateRecordNoLookup(hostname string, ipVersion ipversion.IPVersion,
@@ -193,10 +296,10 @@ func (s *Service) shouldUpdateRecordNoLookup(hostname string, ipVersion ipversio
 	return false
 }
 
-func (s *Service) shouldUpdateRecordWithLookup(ctx context.Context, hostname string,
-	ipVersion ipversion.IPVersion, publicIP netip.Addr) (update bool) {
+func (s *Service) shouldUpdateRecordWithLookup(ctx context.Context, resolver LookupIPer,
+	hostname string, ipVersion ipversion.IPVersion, publicIP netip.Addr) (update bool) {
 	const tries = 5
-	recordIPv4, recordIPv6, err := s.lookupIPsResilient(ctx, hostname, tries)
+	recordIPv4, recordIPv6, err := lookupIPsResilient(ctx, resolver, hostname, tries)
 	if err != nil {
 		ctxErr := ctx.Err()
 		if ctxErr != nil {
@@ -242,6 +345,7 @@ func setInitialUpToDateStatus(db Database, id uint, updateIP netip.Addr, now tim
 	}
 	record.Status = constants.UPTODATE
 	record.Time = now
+	record.LastSuccess = now
 	if !record.History.GetCurrentIP().IsValid() {
 		record.History = append(record.History, models.HistoryEvent{
 			IP:   updateIP,
@@ -251,6 +355,16 @@ func setInitialUpToDateStatus(db Database, id uint, updateIP netip.Addr, now tim
 	return db.Update(id, record)
 }
 
+// setLastSuccess sets the last success time of the record found up to date.
+func setLastSuccess(db Database, id uint, now time.Time) error {
+	record, err := db.Select(id)
+	if err != nil {
+		return err
+	}
+	record.LastSuccess = now
+	return db.Update(id, record)
+}
+
 func setInitialPublicIPFailStatus(db Database, id uint, now time.Time) error {
 	record, err := db.Select(id)
 	if err != nil {
@@ -262,34 +376,163 @@ func setInitialPublicIPFailStatus(db Database, id uint, now time.Time) error {
 	return db.Update(id, record)
 }
 
-func (s *Service) updateNecessary(ctx context.Context) (errors []error) {
+// tickPeriod returns the period of the ticker triggering the checks of
+// the records, which is the shortest of the global and record periods,
+// and of the jitter slots of the records, if any.
+func (s *Service) tickPeriod() (period time.Duration) {
+	period = s.period
+	for _, record := range s.db.SelectAll() {
+		if record.Period > 0 && record.Period < period {
+			period = record.Period
+		}
+		if jitter := s.recordJitter(record); jitter > 0 {
+			period = min(period, max(jitter/jitterSlots, time.Second))
+		}
+	}
+	return period
+}
+
+// getDueRecordIDs returns the ids of the records whose period, or the global
+// period if they have none, elapsed since their last check and which are not
+// backing off after failures, or all the record ids if force is true, and sets
+// their last check time to now, or to a bit earlier for their first or forced
+// check if they have a jitter. The records of the mirror group of a record due are due
+// as well, unless they are backing off.
+func (s *Service) getDueRecordIDs(records []librecords.Record, now time.Time,
+	force bool) (dueIDs map[uint]struct{}) {
+	// Half a tick is tolerated so a record is not checked a tick late.
+	tolerance := s.tickPeriod() / 2 //nolint:gomnd
+	dueIDs = make(map[uint]struct{}, len(records))
+	backingOff := make(map[uint]struct{})
+	dueGroups := make(map[string]struct{})
+	for i, record := range records {
+		if !force && now.Before(record.RetryTime.Add(-tolerance)) {
+			s.logger.Debug(fmt.Sprintf("record %s failed %d times in a row, skipping update until %s",
+				recordToLogString(record), record.Failures, record.RetryTime))
+			backingOff[uint(i)] = struct{}{}
+			continue
+		}
+		period := s.period
+		if record.Period > 0 {
+			period = record.Period
+		}
+		lastCheck, checked := s.lastChecks[librecords.Key(record.Provider)]
+		if !force && checked && now.Sub(lastCheck) < period-tolerance {
+			continue
+		}
+		dueIDs[uint(i)] = struct{}{}
+		if record.MirrorGroup != "" {
+			dueGroups[record.MirrorGroup] = struct{}{}
+		}
+	}
+
+	for i, record := range records {
+		_, isBackingOff := backingOff[uint(i)]
+		_, groupDue := dueGroups[record.MirrorGroup]
+		if groupDue && !isBackingOff {
+			dueIDs[uint(i)] = struct{}{}
+		}
+	}
+
+	for id := range dueIDs {
+		key := librecords.Key(records[id].Provider)
+		lastCheck := now
+		if _, checked := s.lastChecks[key]; !checked || force {
+			lastCheck = now.Add(-checkOffset(key, s.recordJitter(records[id])))
+		}
+		s.lastChecks[key] = lastCheck
+	}
+	return dueIDs
+}
+
+// addMirrorGroupIDs adds to recordIDs the ids of the records due which are
+// in the mirror group of a record to update, so the records of a group are
+// all updated with the same IP address in the same pass.
+func addMirrorGroupIDs(recordIDs, dueIDs map[uint]struct{}, records []librecords.Record,
+	ip, ipv4, ipv6 netip.Addr) {
+	groups := make(map[string]struct{})
+	for id := range recordIDs {
+		if group := records[id].MirrorGroup; group != "" {
+			groups[group] = struct{}{}
+		}
+	}
+
+	for id := range dueIDs {
+		record := records[id]
+		if _, ok := groups[record.MirrorGroup]; !ok || record.Paused {
+			continue
+		}
+		ipVersion := record.Provider.IPVersion()
+		hasIP := getIPMatchingVersion(ip, ipv4, ipv6, ipVersion).IsValid()
+		if isDualStack(record.Provider) {
+			hasIP = ipv4.IsValid() || ipv6.IsValid()
+		}
+		if hasIP {
+			recordIDs[id] = struct{}{}
+		}
+	}
+}
+
+// updateNecessary checks the records due for a check, or all the records if
+// force is true, and updates the ones whose IP address is not up to date.
+func (s *Service) updateNecessary(ctx context.Context, force bool) (errors []error) {
+	// Current time is used to select the records due for a check, and to
+	// set initial states for records already up to date or in the fail
+	// state due to the public IP not found. No need to have it queried
+	// within the next for loop since each iteration is fast and has no
+	// IO involved.
+	now := s.timeNow()
+
 	records := s.db.SelectAll()
-	doIP, doIPv4, doIPv6 := doIPVersion(records)
+	dueIDs := s.getDueRecordIDs(records, now, force)
+	if len(dueIDs) == 0 {
+		return nil
+	}
+	dueRecords := make([]librecords.Record, 0, len(dueIDs))
+	for id := range dueIDs {
+		dueRecords = append(dueRecords, records[id])
+	}
+
+	ctx, span := tracing.Start(ctx, "update cycle", tracing.KindInternal,
+		tracing.Bool("force", force), tracing.Int("records.due", len(dueIDs)))
+	defer func() {
+		span.End(stderrors.Join(errors...))
+	}()
+
+	doIP, doIPv4, doIPv6 := doIPVersion(dueRecords)
 	s.logger.Debug(fmt.Sprintf("configured to fetch IP: v4 or v6: %t, v4: %t, v6: %t", doIP, doIPv4, doIPv6))
 	ip, ipv4, ipv6, errors := s.getNewIPs(ctx, doIP, doIPv4, doIPv6)
 	s.logger.Debug(fmt.Sprintf("your public IP address are: v4 or v6: %s, v4: %s, v6: %s", ip, ipv4, ipv6))
 	for _, err := range errors {
 		s.logger.Error(err.Error())
 	}
+	ip, ipv4, ipv6 = s.debounceIPs(ip, ipv4, ipv6, now, force)
 
-	recordIDs := s.getRecordIDsToUpdate(ctx, records, ip, ipv4, ipv6)
+	recordIDs := s.getRecordIDsToUpdate(ctx, records, dueIDs, ip, ipv4, ipv6)
+	addMirrorGroupIDs(recordIDs, dueIDs, records, ip, ipv4, ipv6)
+	span.SetAttributes(tracing.Int("records.updated", len(recordIDs)))
 
-	// Current time is used to set initial states for records already
-	// up to date or in the fail state due to the public IP not found.
-	// No need to have it queried within the next for loop since each
-	// iteration is fast and has no IO involved.
-	now := s.timeNow()
-
-	for i, record := range records {
-		id := uint(i)
+	for id := range dueIDs {
+		record := records[id]
 		_, requireUpdate := recordIDs[id]
-		if requireUpdate || record.Status != constants.UNSET {
+		if requireUpdate || record.Paused {
 			continue
 		}
 
 		ipVersion := record.Provider.IPVersion()
 		updateIP := getIPMatchingVersion(ip, ipv4, ipv6, ipVersion)
-		if !updateIP.IsValid() {
+		if record.Status != constants.UNSET {
+			if !updateIP.IsValid() {
+				continue
+			}
+			err := setLastSuccess(s.db, id, now)
+			if err != nil {
+				err = fmt.Errorf("setting last success time: %w", err)
+				errors = append(errors, err)
+				s.logger.Error(err.Error())
+			}
+			continue
+		} else if !updateIP.IsValid() {
 			// warning was already logged in getRecordIDsToUpdate
 			err := setInitialPublicIPFailStatus(s.db, id, now)
 			if err != nil {
@@ -309,20 +552,7 @@ func (s *Service) updateNecessary(ctx context.Context) (errors []error) {
 			s.logger.Error(err.Error())
 		}
 	}
-	for id := range recordIDs {
-		record := records[id]
-		updateIP := getIPMatchingVersion(ip, ipv4, ipv6, record.Provider.IPVersion())
-		// Note: each record id has a matching valid public IP address.
-		if updateIP.Is6() {
-			updateIP = ipv6WithSuffix(updateIP, record.Provider.IPv6Suffix())
-		}
-		s.logger.Info("Updating record " + record.Provider.String() + " to use " + updateIP.String())
-		err := s.updater.Update(ctx, id, updateIP)
-		if err != nil {
-			errors = append(errors, err)
-			s.logger.Error(err.Error())
-		}
-	}
+	errors = append(errors, s.updateRecords(ctx, recordIDs, records, ip, ipv4, ipv6)...)
 
 	healthchecksIOState := healthchecksio.Ok
 	if len(errors) > 0 {
@@ -337,6 +567,92 @@ func (s *Service) updateNecessary(ctx context.Context) (errors []error) {
 	return errors
 }
 
+// updateRecord updates the record with the public IP addresses
+// matching its IP version. The update is not canceled when the service
+// stops, unless it does not finish within the shutdown timeout, so the
+// records of the provider are not left partially written. The context
+// given is only used for its tracing span.
+func (s *Service) updateRecord(ctx context.Context, id uint, record librecords.Record,
+	ip, ipv4, ipv6 netip.Addr) (err error) {
+	ctx = tracing.WithSpan(s.updatesCtx, ctx)
+	fields := []logging.Field{
+		logging.With("record", record.Provider.BuildDomainName()),
+		logging.With("provider", librecords.ProviderName(record.Provider)),
+		logging.With("ip_version", record.Provider.IPVersion().String()),
+	}
+	start := s.timeNow()
+	defer func() {
+		fields = append(fields, logging.With("duration_ms", s.timeNow().Sub(start).Milliseconds()))
+		if err != nil {
+			fields = append(fields, logging.ErrorFields(err)...)
+			if stderrors.Is(err, settingserrors.ErrSuspended) && record.Status == constants.SUSPENDED {
+				// the error is only logged once when the calls get suspended
+				s.logger.Debug(logging.Message(err.Error(), fields...))
+				return
+			} else if stderrors.Is(err, settingserrors.ErrDryRun) {
+				s.logger.Info(logging.Message(err.Error(), fields...))
+				return
+			}
+			s.logger.Error(logging.Message(err.Error(), fields...))
+			return
+		}
+		s.logger.Debug(logging.Message("Updated record "+record.Provider.String(), fields...))
+		if prefix := recordIPv6Prefix(record, ip, ipv6); prefix.IsValid() {
+			s.ipv6PrefixesMutex.Lock()
+			s.ipv6Prefixes[librecords.Key(record.Provider)] = prefix
+			s.ipv6PrefixesMutex.Unlock()
+		}
+	}()
+
+	if isDualStack(record.Provider) {
+		updateIPv6 := ipv6WithSuffix(ipv6, record.Provider.IPv6Suffix())
+		s.logger.Info(logging.Message("Updating record "+record.Provider.String()+" to use "+
+			ipv4.String()+" and "+updateIPv6.String(), append(fields,
+			logging.With("ipv4", ipv4.String()), logging.With("ipv6", updateIPv6.String()))...))
+		return s.updater.UpdateDualStack(ctx, id, ipv4, updateIPv6)
+	}
+	updateIP := getIPMatchingVersion(ip, ipv4, ipv6, record.Provider.IPVersion())
+	if updateIP.Is6() {
+		updateIP = ipv6WithSuffix(updateIP, record.Provider.IPv6Suffix())
+	}
+	s.logger.Info(logging.Message("Updating record "+record.Provider.String()+" to use "+
+		updateIP.String(), append(fields, logging.With("ip", updateIP.String()))...))
+	return s.updater.Update(ctx, id, updateIP)
+}
+
+// updateRecordNow updates the record with the given id, whether its
+// IP address changed or not, and even within the cooldown period.
+func (s *Service) updateRecordNow(ctx context.Context, id uint) (errors []error) {
+	record, err := s.db.Select(id)
+	if err != nil {
+		return []error{err}
+	} else if record.Paused {
+		return []error{fmt.Errorf("%w: %s", ErrRecordPaused, record.Provider)}
+	}
+
+	ctx, span := tracing.Start(ctx, "forced record update", tracing.KindInternal,
+		tracing.String("fqdn", record.Provider.BuildDomainName()))
+	defer func() {
+		span.End(stderrors.Join(errors...))
+	}()
+
+	doIP, doIPv4, doIPv6 := doIPVersion([]librecords.Record{record})
+	ip, ipv4, ipv6, errors := s.getNewIPs(ctx, doIP, doIPv4, doIPv6)
+	if len(errors) > 0 {
+		for _, err := range errors {
+			s.logger.Error(err.Error())
+		}
+		return errors
+	}
+	s.debounceIPs(ip, ipv4, ipv6, s.timeNow(), true)
+
+	err = s.updateRecord(ctx, id, record, ip, ipv4, ipv6)
+	if err != nil {
+		return []error{err} // logged by updateRecord
+	}
+	return nil
+}
+
 func (s *Service) String() string {
 	return "updater"
 }
@@ -345,6 +661,7 @@ func (s *Service) Start(ctx context.Context) (runError <-chan error, startErr er
 	ready := make(chan struct{})
 	runCtx, runCancel := context.WithCancel(context.Background())
 	s.runCancel = runCancel
+	s.updatesCtx, s.updatesStop = context.WithCancel(context.Background())
 	done := make(chan struct{})
 	s.done = done
 	go s.run(runCtx, ready, done) //nolint:contextcheck
@@ -359,14 +676,52 @@ func (s *Service) Start(ctx context.Context) (runError <-chan error, startErr er
 func (s *Service) run(ctx context.Context, ready chan<- struct{},
 	done chan<- struct{}) {
 	defer close(done)
-	ticker := time.NewTicker(s.period)
+	ticker := time.NewTicker(s.tickPeriod())
+	// The watchdog is notified from this loop, so systemd restarts
+	// the program if the loop is stuck, for example on a hung API call.
+	var watchdog <-chan time.Time
+	if period := s.systemd.WatchdogPeriod(); period > 0 {
+		watchdogTicker := time.NewTicker(period)
+		defer watchdogTicker.Stop()
+		watchdog = watchdogTicker.C
+	}
+	systemdReady := false
+	// cycleDone notifies systemd the program is ready after the
+	// first check and update cycle without error.
+	cycleDone := func(errors []error) []error {
+		if systemdReady || len(errors) > 0 {
+			return errors
+		}
+		systemdReady = true
+		err := s.systemd.Ready()
+		if err != nil {
+			s.logger.Error("notifying systemd of readiness: " + err.Error())
+		}
+		return errors
+	}
 	close(ready)
 	for {
 		select {
 		case <-ticker.C:
-			s.updateNecessary(ctx)
-		case <-s.force:
-			s.forceResult <- s.updateNecessary(ctx)
+			cycleDone(s.updateNecessary(ctx, false))
+		case request := <-s.force:
+			request.result <- cycleDone(s.updateNecessary(ctx, true))
+		case request := <-s.forceRecord:
+			request.result <- s.updateRecordNow(ctx, request.id)
+		case settings := <-s.reload:
+			err := s.db.Reload(settings)
+			s.reloadResult <- err
+			if err == nil {
+				ticker.Reset(s.tickPeriod())
+				s.pruneLastChecks()
+				// check the records added right away
+				cycleDone(s.updateNecessary(ctx, false))
+			}
+		case <-watchdog:
+			err := s.systemd.Watchdog()
+			if err != nil {
+				s.logger.Error("notifying systemd watchdog: " + err.Error())
+			}
 		case <-ctx.Done():
 			ticker.Stop()
 			return
@@ -374,19 +729,97 @@ func (s *Service) run(ctx context.Context, ready chan<- struct{},
 	}
 }
 
+// Stop stops checking and updating the records, and waits for the record
+// updates in progress to finish, for up to the shutdown timeout after
+// which they are canceled.
 func (s *Service) Stop() (err error) {
 	s.runCancel()
-	<-s.done
+	timer := time.NewTimer(s.shutdownTimeout)
+	select {
+	case <-s.done:
+		timer.Stop()
+	case <-timer.C:
+		s.logger.Warn("record updates not finished after " + s.shutdownTimeout.String() +
+			", canceling them")
+		s.updatesStop()
+		<-s.done
+	}
+	s.updatesStop()
 	return nil
 }
 
+// forceRequest is a request to update the records right away, with its
+// own result channel, buffered so the run loop never blocks sending the
+// result to a caller which is gone.
+type forceRequest struct {
+	// id is the id of the record to update, for
+	// the requests sent to forceRecord only.
+	id     uint
+	result chan []error
+}
+
 func (s *Service) ForceUpdate(ctx context.Context) (errs []error) {
-	s.force <- struct{}{}
+	return s.requestForce(ctx, s.force, forceRequest{})
+}
 
+// ForceUpdateRecord updates the record with the given id right away,
+// outside of the periodic updates, whether its IP address changed or not.
+func (s *Service) ForceUpdateRecord(ctx context.Context, id uint) (errs []error) {
+	return s.requestForce(ctx, s.forceRecord, forceRequest{id: id})
+}
+
+// requestForce sends the request to the run loop over the channel given,
+// and waits for its result. It returns without waiting if the context is
+// canceled or the service is stopped, the run loop then sending the result
+// to the buffered result channel of the request without blocking.
+func (s *Service) requestForce(ctx context.Context, requests chan<- forceRequest,
+	request forceRequest) (errs []error) {
+	request.result = make(chan []error, 1)
 	select {
-	case errs = <-s.forceResult:
+	case requests <- request:
+	case <-ctx.Done():
+		return []error{ctx.Err()}
+	case <-s.done:
+		return []error{ErrServiceStopped}
+	}
+
+	select {
+	case errs = <-request.result:
 	case <-ctx.Done():
 		errs = []error{ctx.Err()}
 	}
 	return errs
 }
+
+// Reload replaces the records to update with the records of the settings
+// given, once the updates being done, if any, are finished, and then checks
+// the records added.
+func (s *Service) Reload(ctx context.Context, settings []librecords.Settings) (err error) {
+	select {
+	case s.reload <- settings:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return <-s.reloadResult
+}
+
+// pruneLastChecks removes the last check times and IPv6 prefixes of
+// the records removed.
+func (s *Service) pruneLastChecks() {
+	keys := make(map[string]struct{})
+	for _, record := range s.db.SelectAll() {
+		keys[librecords.Key(record.Provider)] = struct{}{}
+	}
+	for key := range s.lastChecks {
+		if _, ok := keys[key]; !ok {
+			delete(s.lastChecks, key)
+		}
+	}
+	s.ipv6PrefixesMutex.Lock()
+	defer s.ipv6PrefixesMutex.Unlock()
+	for key := range s.ipv6Prefixes {
+		if _, ok := keys[key]; !ok {
+			delete(s.ipv6Prefixes, key)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/provider"
+	librecords "github.com/qdm12/ddns-updater/internal/records"
+	"github.com/qdm12/ddns-updater/pkg/publicip/ipversion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type noopLogger struct{}
+
+func (noopLogger) Debug(string) {}
+func (noopLogger) Info(string)  {}
+func (noopLogger) Warn(string)  {}
+func (noopLogger) Error(string) {}
+
+type noopSystemd struct{}
+
+func (noopSystemd) Ready() error                  { return nil }
+func (noopSystemd) Watchdog() error               { return nil }
+func (noopSystemd) WatchdogPeriod() time.Duration { return 0 }
+
+// fakeDatabase holds the records of the service in memory.
+type fakeDatabase struct {
+	records []librecords.Record
+}
+
+func (d *fakeDatabase) Select(id uint) (record librecords.Record, err error) {
+	return d.records[id], nil
+}
+
+func (d *fakeDatabase) SelectAll() (records []librecords.Record) { return d.records }
+
+func (d *fakeDatabase) Update(id uint, record librecords.Record) (err error) {
+	d.records[id] = record
+	return nil
+}
+
+func (d *fakeDatabase) Reload([]librecords.Settings) (err error) { return nil }
+
+func newTestService(t *testing.T, db Database, ipGetter PublicIPFetcher) *Service {
+	t.Helper()
+	const period, shutdownTimeout = time.Hour, time.Second
+	service := NewService(db, nil, ipGetter, period, 0, 0, 0, 0, 1, 1,
+		noopLogger{}, nil, time.Now, nil, shutdownTimeout, noopSystemd{})
+	_, err := service.Start(context.Background())
+	require.NoError(t, err)
+	return service
+}
+
+func Test_Service_ForceUpdate(t *testing.T) {
+	t.Parallel()
+
+	service := newTestService(t, &fakeDatabase{}, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.Empty(t, service.ForceUpdate(ctx))
+
+	err := service.Stop()
+	require.NoError(t, err)
+
+	// The forced update after the service stopped does not hang.
+	assert.Equal(t, []error{ErrServiceStopped}, service.ForceUpdate(ctx))
+}
+
+// blockingIPGetter blocks its first IPv4 address request until
+// release is closed, and fails all its requests.
+type blockingIPGetter struct {
+	calls   atomic.Int64
+	started chan struct{}
+	release chan struct{}
+}
+
+var (
+	errBlocked = errors.New("blocked request failed")
+	errServed  = errors.New("served request failed")
+)
+
+func (g *blockingIPGetter) IP(context.Context) (netip.Addr, error)  { return netip.Addr{}, errServed }
+func (g *blockingIPGetter) IP6(context.Context) (netip.Addr, error) { return netip.Addr{}, errServed }
+
+func (g *blockingIPGetter) IP4(context.Context) (netip.Addr, error) {
+	if g.calls.Add(1) > 1 {
+		return netip.Addr{}, errServed
+	}
+	close(g.started)
+	<-g.release
+	return netip.Addr{}, errBlocked
+}
+
+type ipv4Provider struct {
+	provider.Provider
+}
+
+func (ipv4Provider) BuildDomainName() string        { return "home.example.com" }
+func (ipv4Provider) IPVersion() ipversion.IPVersion { return ipversion.IP4 }
+
+func Test_Service_ForceUpdateRecord(t *testing.T) {
+	t.Parallel()
+
+	db := &fakeDatabase{records: []librecords.Record{{Provider: ipv4Provider{}}}}
+	ipGetter := &blockingIPGetter{started: make(chan struct{}), release: make(chan struct{})}
+	service := newTestService(t, db, ipGetter)
+	t.Cleanup(func() { _ = service.Stop() })
+
+	// The caller giving up on the first forced update does
+	// not leave the run loop stuck sending its result.
+	ctx, cancel := context.WithCancel(context.Background())
+	canceledErrs := make(chan []error)
+	go func() {
+		canceledErrs <- service.ForceUpdateRecord(ctx, 0)
+	}()
+	<-ipGetter.started
+	cancel()
+	assert.Equal(t, []error{context.Canceled}, <-canceledErrs)
+	close(ipGetter.release)
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	errs := service.ForceUpdateRecord(ctx, 0)
+	require.Len(t, errs, 1)
+	assert.ErrorContains(t, errs[0], errServed.Error())
+}
@@ -6,38 +6,109 @@ import (
 	"fmt"
 	"net/http"
 	"net/netip"
+	"strings"
 	"time"
 
 	"github.com/qdm12/ddns-updater/internal/constants"
+	"github.com/qdm12/ddns-updater/internal/metrics"
 	"github.com/qdm12/ddns-updater/internal/models"
+	"github.com/qdm12/ddns-updater/internal/provider"
 	settingserrors "github.com/qdm12/ddns-updater/internal/provider/errors"
+	"github.com/qdm12/ddns-updater/internal/records"
+	"github.com/qdm12/ddns-updater/internal/tracing"
 )
 
 type Updater struct {
 	db             Database
 	client         *http.Client
-	shoutrrrClient ShoutrrrClient
+	eventNotifiers []EventNotifier
 	logger         DebugLogger
 	timeNow        func() time.Time
+	metrics        *updateMetrics
+	period         time.Duration
+	backoffMax     time.Duration
 }
 
-func NewUpdater(db Database, client *http.Client, shoutrrrClient ShoutrrrClient,
-	logger DebugLogger, timeNow func() time.Time) *Updater {
+// NewUpdater creates an updater of the records of db, registering
+// the metrics of the record updates in registry. A record failing to
+// update is not updated again for its period, or the global period
+// given if it has none, doubled after each consecutive failure up to
+// backoffMax. A zero backoffMax disables this backoff.
+func NewUpdater(db Database, client *http.Client, eventNotifiers []EventNotifier,
+	logger DebugLogger, timeNow func() time.Time, registry *metrics.Registry,
+	period, backoffMax time.Duration) *Updater {
 	client = makeLogClient(client, logger)
+	updateMetrics := newUpdateMetrics(registry)
+	for _, record := range db.SelectAll() {
+		updateMetrics.setLastSuccess(record)
+	}
 	return &Updater{
 		db:             db,
 		client:         client,
-		shoutrrrClient: shoutrrrClient,
+		eventNotifiers: eventNotifiers,
 		logger:         logger,
 		timeNow:        timeNow,
+		metrics:        updateMetrics,
+		period:         period,
+		backoffMax:     backoffMax,
 	}
 }
 
 func (u *Updater) Update(ctx context.Context, id uint, ip netip.Addr) (err error) {
+	return u.update(ctx, id, func(ctx context.Context, record records.Record) (
+		historyIP netip.Addr, message string, err error) {
+		newIP, err := record.Provider.Update(ctx, u.client, ip)
+		return newIP, "changed to " + ip.String(), err
+	})
+}
+
+// UpdateDualStack updates both the A and AAAA records of a record
+// whose provider implements provider.DualStackProvider.
+func (u *Updater) UpdateDualStack(ctx context.Context, id uint, ipv4, ipv6 netip.Addr) (err error) {
+	return u.update(ctx, id, func(ctx context.Context, record records.Record) (
+		historyIP netip.Addr, message string, err error) {
+		dualStackProvider, ok := record.Provider.(provider.DualStackProvider)
+		if !ok {
+			return netip.Addr{}, "", fmt.Errorf("%w: %s", ErrDualStackNotSupported, record.Provider)
+		}
+		newIPv4, newIPv6, err := dualStackProvider.UpdateDualStack(ctx, u.client, ipv4, ipv6)
+		var changes []string
+		for _, ip := range []netip.Addr{ipv4, ipv6} {
+			if ip.IsValid() {
+				changes = append(changes, ip.String())
+			}
+		}
+		historyIP = newIPv4
+		if !historyIP.IsValid() {
+			historyIP = newIPv6
+		}
+		return historyIP, "changed to " + strings.Join(changes, " and "), err
+	})
+}
+
+var (
+	ErrDualStackNotSupported = errors.New("provider does not support dual stack updates")
+	ErrRecordPaused          = errors.New("record is paused")
+	ErrServiceStopped        = errors.New("updater service is stopped")
+)
+
+// update runs updateFunc for the record matching the id given, and
+// stores the resulting status, message and history IP in the database.
+func (u *Updater) update(ctx context.Context, id uint,
+	updateFunc func(ctx context.Context, record records.Record) (
+		historyIP netip.Addr, message string, err error)) (err error) {
 	record, err := u.db.Select(id)
 	if err != nil {
 		return err
 	}
+	ctx, span := tracing.Start(ctx, "record update", tracing.KindInternal,
+		tracing.String("record", record.Provider.String()),
+		tracing.String("fqdn", record.Provider.BuildDomainName()),
+		tracing.String("ip_version", record.Provider.IPVersion().String()))
+	defer func() {
+		span.End(err)
+	}()
+	previous := record
 	record.Time = u.timeNow()
 	record.Status = constants.UPDATING
 	err = u.db.Update(id, record)
@@ -45,31 +116,125 @@ func (u *Updater) Update(ctx context.Context, id uint, ip netip.Addr) (err error
 		return err
 	}
 	record.Status = constants.FAIL
-	newIP, err := record.Provider.Update(ctx, u.client, ip)
+	newIP, message, err := updateFunc(ctx, record)
+	duration := u.timeNow().Sub(record.Time)
+	if errors.Is(err, settingserrors.ErrDryRun) {
+		// No record was written, so the dry run is not a failure and
+		// the record keeps its status, failures and history, with the
+		// changes which would have been written as message.
+		record.Status = previous.Status
+		record.Message = err.Error()
+		if updateErr := u.db.Update(id, record); updateErr != nil {
+			return fmt.Errorf("%w (with database update error: %w)", err, updateErr)
+		}
+		return err
+	}
 	if err != nil {
+		u.metrics.observe(previous, record, duration, err)
 		record.Message = err.Error()
+		record.Failures++
+		switch {
+		case errors.Is(err, settingserrors.ErrNotPropagated):
+			// The record was written but is not served yet by the
+			// provider nameservers, so it stays in the updating state.
+			record.Status = constants.UPDATING
+		case errors.Is(err, settingserrors.ErrSuspended):
+			record.Status = constants.SUSPENDED
+			record.RetryTime = u.retryTime(record)
+		default:
+			record.RetryTime = u.retryTime(record)
+		}
 		if errors.Is(err, settingserrors.ErrBannedAbuse) {
 			lastBan := time.Unix(u.timeNow().Unix(), 0)
 			record.LastBan = &lastBan
-			domainName := record.Provider.BuildDomainName()
-			message := domainName + ": " + record.Message +
-				", no more updates will be attempted for an hour"
-			u.shoutrrrClient.Notify(message)
-			err = fmt.Errorf("%w: for domain %s, no more update will be attempted for 1h", err, domainName)
+			err = fmt.Errorf("%w: for domain %s, no more update will be attempted for 1h",
+				err, record.Provider.BuildDomainName())
 		} else {
 			record.LastBan = nil // clear a previous ban
 		}
+		u.notifyEvent(ctx, previous, record, err)
 		if updateErr := u.db.Update(id, record); updateErr != nil {
 			return fmt.Errorf("%w (with database update error: %w)", err, updateErr)
 		}
 		return err
 	}
 	record.Status = constants.SUCCESS
-	record.Message = "changed to " + ip.String()
+	record.Message = message
+	record.LastSuccess = u.timeNow()
+	record.Failures = 0
+	record.RetryTime = time.Time{}
 	record.History = append(record.History, models.HistoryEvent{
 		IP:   newIP,
 		Time: u.timeNow(),
 	})
-	u.shoutrrrClient.Notify(record.Provider.BuildDomainName() + " " + record.Message)
+	u.metrics.observe(previous, record, duration, nil)
+	u.notifyEvent(ctx, previous, record, nil)
 	return u.db.Update(id, record) // persists some data if needed (i.e new IP)
 }
+
+// notifyEvent notifies the event notifiers of the update of the
+// record, which had the previous record as state before the update.
+func (u *Updater) notifyEvent(ctx context.Context,
+	previous, record records.Record, err error) {
+	event := models.UpdateEvent{
+		Type:      models.EventUpdated,
+		Time:      u.timeNow(),
+		Domain:    record.Provider.Domain(),
+		Owner:     record.Provider.Owner(),
+		FQDN:      record.Provider.BuildDomainName(),
+		IPVersion: record.Provider.IPVersion().String(),
+		Message:   record.Message,
+	}
+	if previousIP := previous.History.GetCurrentIP(); previousIP.IsValid() {
+		event.PreviousIP = previousIP.String()
+	}
+	if err != nil {
+		event.Failures = record.Failures
+	}
+
+	if ip := record.History.GetCurrentIP(); err == nil && ip.IsValid() {
+		event.IP = ip.String()
+	}
+
+	switch {
+	case errors.Is(err, settingserrors.ErrSuspended):
+		if previous.Status == constants.SUSPENDED {
+			// notified once when the calls got suspended
+			return
+		}
+		event.Type = models.EventSuspended
+		event.Error = err.Error()
+	case errors.Is(err, settingserrors.ErrAuth):
+		event.Type = models.EventCredentialsInvalid
+		event.Error = err.Error()
+	case err != nil:
+		event.Type = models.EventUpdateFailed
+		event.Error = err.Error()
+	case previous.Failures > 0:
+		event.Type = models.EventRecordRestored
+		event.Failures = previous.Failures
+	case event.IP != "" && event.IP != event.PreviousIP:
+		event.Type = models.EventIPChanged
+	}
+
+	for _, notifier := range u.eventNotifiers {
+		notifier.Updated(ctx, event)
+	}
+}
+
+// retryTime returns the time before which the record, which just failed
+// to update, must not be updated again, or zero if the backoff is disabled.
+func (u *Updater) retryTime(record records.Record) time.Time {
+	if u.backoffMax <= 0 {
+		return time.Time{}
+	}
+	backoff := u.period
+	if record.Period > 0 {
+		backoff = record.Period
+	}
+	for i := uint(1); i < record.Failures && backoff < u.backoffMax; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, u.backoffMax)
+	return u.timeNow().Add(backoff)
+}
@@ -0,0 +1,162 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/qdm12/ddns-updater/internal/constants"
+	"github.com/qdm12/ddns-updater/internal/metrics"
+	"github.com/qdm12/ddns-updater/internal/models"
+	"github.com/qdm12/ddns-updater/internal/notify"
+	settingserrors "github.com/qdm12/ddns-updater/internal/provider/errors"
+	"github.com/qdm12/ddns-updater/internal/records"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Updater_retryTime(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+
+	testCases := map[string]struct {
+		backoffMax time.Duration
+		record     records.Record
+		retryTime  time.Time
+	}{
+		"disabled": {
+			record: records.Record{Failures: 1},
+		},
+		"first_failure": {
+			backoffMax: time.Hour,
+			record:     records.Record{Failures: 1},
+			retryTime:  now.Add(10 * time.Minute),
+		},
+		"third_failure": {
+			backoffMax: time.Hour,
+			record:     records.Record{Failures: 3},
+			retryTime:  now.Add(40 * time.Minute),
+		},
+		"capped": {
+			backoffMax: time.Hour,
+			record:     records.Record{Failures: 100},
+			retryTime:  now.Add(time.Hour),
+		},
+		"record_period": {
+			backoffMax: time.Hour,
+			record:     records.Record{Failures: 2, Period: time.Minute},
+			retryTime:  now.Add(2 * time.Minute),
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			updater := &Updater{
+				timeNow:    func() time.Time { return now },
+				period:     10 * time.Minute,
+				backoffMax: testCase.backoffMax,
+			}
+
+			retryTime := updater.retryTime(testCase.record)
+
+			assert.Equal(t, testCase.retryTime, retryTime)
+		})
+	}
+}
+
+// outcomeProvider returns the next error of errs on each update.
+type outcomeProvider struct {
+	ipv4Provider
+	errs []error
+}
+
+func (p *outcomeProvider) Domain() string { return "example.com" }
+func (p *outcomeProvider) Owner() string  { return "home" }
+func (p *outcomeProvider) String() string { return "fake provider" }
+
+func (p *outcomeProvider) Update(_ context.Context, _ *http.Client, ip netip.Addr) (
+	newIP netip.Addr, err error) {
+	err, p.errs = p.errs[0], p.errs[1:]
+	return ip, err
+}
+
+// filteredNotifier records the event types matching its
+// filter, as the shoutrrr, webhook and email clients do.
+type filteredNotifier struct {
+	filter notify.Filter
+	events []string
+}
+
+func (n *filteredNotifier) Updated(_ context.Context, event models.UpdateEvent) {
+	if n.filter.Match(event) {
+		n.events = append(n.events, event.Type)
+	}
+}
+
+func Test_Updater_Update_failureNotifications(t *testing.T) {
+	t.Parallel()
+
+	errUpdate := errors.New("update failed")
+	provider := &outcomeProvider{
+		errs: []error{errUpdate, errUpdate, errUpdate, nil, errUpdate, errUpdate},
+	}
+	db := &fakeDatabase{records: []records.Record{records.New(provider, nil)}}
+	notifier := &filteredNotifier{filter: notify.Filter{
+		Events: []string{models.EventUpdateFailed, models.EventRecordRestored},
+	}}
+	updater := NewUpdater(db, &http.Client{}, []EventNotifier{notifier}, noopLogger{},
+		time.Now, metrics.NewRegistry(), time.Hour, 0)
+
+	updates := len(provider.errs)
+	for range updates {
+		_ = updater.Update(context.Background(), 0, netip.MustParseAddr("1.2.3.4"))
+	}
+
+	// Only the first failure of each series of failures is notified,
+	// so a failure after the record recovered is notified again.
+	expected := []string{
+		models.EventUpdateFailed,
+		models.EventRecordRestored,
+		models.EventUpdateFailed,
+	}
+	assert.Equal(t, expected, notifier.events)
+	require.Empty(t, provider.errs)
+	assert.Equal(t, uint(2), db.records[0].Failures)
+}
+
+func Test_Updater_Update_dryRun(t *testing.T) {
+	t.Parallel()
+
+	dryRunErr := fmt.Errorf("%w: changeRecords not called with records {}", settingserrors.ErrDryRun)
+	provider := &outcomeProvider{errs: []error{dryRunErr}}
+	record := records.New(provider, []models.HistoryEvent{
+		{IP: netip.MustParseAddr("1.1.1.1"), Time: time.Unix(0, 0)},
+	})
+	record.Status = constants.SUCCESS
+	record.Failures = 1
+	db := &fakeDatabase{records: []records.Record{record}}
+	notifier := &filteredNotifier{filter: notify.Filter{
+		Events: []string{models.EventUpdateFailed, models.EventIPChanged},
+	}}
+	updater := NewUpdater(db, &http.Client{}, []EventNotifier{notifier}, noopLogger{},
+		time.Now, metrics.NewRegistry(), time.Hour, time.Hour)
+
+	err := updater.Update(context.Background(), 0, netip.MustParseAddr("2.2.2.2"))
+
+	assert.ErrorIs(t, err, settingserrors.ErrDryRun)
+	updated := db.records[0]
+	assert.Equal(t, constants.SUCCESS, updated.Status)
+	assert.Equal(t, uint(1), updated.Failures)
+	assert.True(t, updated.RetryTime.IsZero())
+	assert.Equal(t, record.History, updated.History)
+	assert.Equal(t, dryRunErr.Error(), updated.Message)
+	assert.Empty(t, notifier.events)
+}
@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"github.com/qdm12/ddns-updater/internal/models"
+	"github.com/qdm12/ddns-updater/internal/notify"
+	"github.com/qdm12/gosettings"
+)
+
+type Settings struct {
+	// URL is the URL the events are posted to.
+	// An empty URL disables the webhook.
+	URL string
+	// Events are the update event types sent.
+	Events []string
+	// Headers are headers in the form "Name: value".
+	Headers []string
+	// Body is the Go template of the request body, executed with
+	// the models.UpdateEvent. An empty body sends the event in JSON.
+	Body string
+	// Failures is the number of consecutive failed updates of a
+	// record after which an update failure event is sent.
+	// It is the Failures field of the notify.Filter.
+	Failures uint
+	Client   *http.Client
+	Logger   Erroer
+}
+
+func (s *Settings) setDefaults() {
+	s.Events = gosettings.DefaultSlice(s.Events, []string{
+		models.EventIPChanged, models.EventUpdateFailed,
+		models.EventCredentialsInvalid, models.EventSuspended, models.EventRecordRestored,
+	})
+	s.Headers = gosettings.DefaultSlice(s.Headers, []string{})
+	s.Failures = gosettings.DefaultComparable(s.Failures, 1)
+	s.Client = gosettings.DefaultPointer(s.Client, http.Client{})
+	s.Logger = gosettings.DefaultComparable[Erroer](s.Logger, &noopLogger{})
+}
+
+func (s Settings) parse() (header http.Header, body *template.Template, err error) {
+	header = make(http.Header, len(s.Headers))
+	for _, headerString := range s.Headers {
+		name, value, ok := strings.Cut(headerString, ":")
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %q", ErrHeaderNotValid, headerString)
+		}
+		header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	body, err = notify.ParseTemplate("body", s.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return header, body, nil
+}
+
+type Erroer interface {
+	Error(s string)
+}
+
+type noopLogger struct{}
+
+func (l noopLogger) Error(_ string) {}
@@ -0,0 +1,98 @@
+// Package webhook posts the update events
+// of the records to a configured URL.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"text/template"
+
+	"github.com/qdm12/ddns-updater/internal/models"
+	"github.com/qdm12/ddns-updater/internal/notify"
+)
+
+var ErrHeaderNotValid = errors.New("header is not valid")
+
+type Client struct {
+	url    string
+	header http.Header
+	body   *template.Template
+	filter notify.Filter
+	client *http.Client
+	logger Erroer
+}
+
+func New(settings Settings) (client *Client, err error) {
+	settings.setDefaults()
+	header, body, err := settings.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		url:    settings.URL,
+		header: header,
+		body:   body,
+		filter: notify.Filter{Events: settings.Events, Failures: settings.Failures},
+		client: settings.Client,
+		logger: settings.Logger,
+	}, nil
+}
+
+// Updated sends the update event of a record, if the webhook is enabled
+// and if the event matches the filter, so failure events are only sent
+// for records which failed to update for the number of consecutive
+// times configured.
+func (c *Client) Updated(ctx context.Context, event models.UpdateEvent) {
+	if c.url == "" || !c.filter.Match(event) {
+		return
+	}
+
+	err := c.send(ctx, event)
+	if err != nil {
+		c.logger.Error("webhook: " + err.Error())
+	}
+}
+
+var ErrHTTPStatusNotValid = errors.New("HTTP status is not valid")
+
+func (c *Client) send(ctx context.Context, event models.UpdateEvent) (err error) {
+	var body bytes.Buffer
+	if c.body == nil {
+		err = json.NewEncoder(&body).Encode(event)
+	} else {
+		var message string
+		message, err = notify.Execute(c.body, event)
+		body.WriteString(message)
+	}
+	if err != nil {
+		return fmt.Errorf("encoding body: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, &body)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	request.Header = c.header.Clone()
+	if request.Header.Get("Content-Type") == "" {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, response.Body)
+	_ = response.Body.Close()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%w: %d %s", ErrHTTPStatusNotValid,
+			response.StatusCode, http.StatusText(response.StatusCode))
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/qdm12/ddns-updater/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Client_Updated(t *testing.T) {
+	t.Parallel()
+
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.Equal(t, "text/plain", r.Header.Get("Content-Type"))
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		bodies = append(bodies, string(body))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := New(Settings{
+		URL:      server.URL,
+		Headers:  []string{"Authorization: Bearer token", "Content-Type: text/plain"},
+		Body:     "{{.Type}} {{.FQDN}} {{.PreviousIP}} {{.IP}} {{.Failures}} {{.Error}}",
+		Failures: 2,
+		Client:   server.Client(),
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	event := models.UpdateEvent{FQDN: "example.com", IPVersion: "ipv4", PreviousIP: "1.1.1.1"}
+
+	unchanged := event
+	unchanged.Type = models.EventUpdated
+	unchanged.IP = "1.1.1.1"
+	client.Updated(ctx, unchanged)
+
+	changed := event
+	changed.Type = models.EventIPChanged
+	changed.IP = "2.2.2.2"
+	client.Updated(ctx, changed)
+
+	failed := event
+	failed.Type = models.EventUpdateFailed
+	failed.Error = "bad auth"
+	for failures := uint(1); failures <= 3; failures++ {
+		failed.Failures = failures
+		client.Updated(ctx, failed)
+	}
+
+	expected := []string{
+		"ip_changed example.com 1.1.1.1 2.2.2.2 0 ",
+		"update_failed example.com 1.1.1.1  2 bad auth",
+	}
+	assert.Equal(t, expected, bodies)
+}
+
+func Test_Client_Updated_disabled(t *testing.T) {
+	t.Parallel()
+
+	client, err := New(Settings{})
+	require.NoError(t, err)
+
+	client.Updated(context.Background(), models.UpdateEvent{Type: models.EventIPChanged})
+}
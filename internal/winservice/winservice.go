@@ -0,0 +1,20 @@
+// Package winservice runs the program as a native Windows service,
+// stopping it gracefully on a service stop or a system shutdown, and
+// installs, uninstalls, starts and stops its Windows service.
+package winservice
+
+import "errors"
+
+const (
+	// Name is the name of the Windows service of the program.
+	Name        = "ddns-updater"
+	displayName = "DDNS Updater"
+	description = "Updates DNS records with the public IP address."
+)
+
+var (
+	ErrNotSupported     = errors.New("Windows services are only supported on Windows") //nolint:stylecheck
+	ErrStopRequested    = errors.New("Windows service stop requested")                 //nolint:stylecheck
+	ErrAlreadyInstalled = errors.New("service is already installed")
+	ErrStopTimeout      = errors.New("timed out waiting for the service to stop")
+)
@@ -0,0 +1,39 @@
+//go:build !windows
+
+package winservice
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Service is the Windows service the program runs as,
+// which is never the case on this platform.
+type Service struct{}
+
+// Run returns the context given and a nil service,
+// since the program never runs as a Windows service
+// on this platform.
+func Run(ctx context.Context, _ func() time.Duration) (
+	serviceCtx context.Context, service *Service, err error) {
+	return ctx, nil, nil
+}
+
+// Output returns nil.
+func (s *Service) Output() io.Writer { return nil }
+
+// Stopped does nothing.
+func (s *Service) Stopped(int) {}
+
+// Install returns ErrNotSupported.
+func Install([]string) error { return ErrNotSupported }
+
+// Uninstall returns ErrNotSupported.
+func Uninstall() error { return ErrNotSupported }
+
+// Start returns ErrNotSupported.
+func Start() error { return ErrNotSupported }
+
+// Stop returns ErrNotSupported.
+func Stop(time.Duration) error { return ErrNotSupported }
@@ -0,0 +1,272 @@
+//go:build windows
+
+package winservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/eventlog"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// Service is the Windows service the program runs as.
+type Service struct {
+	handler  *handler
+	runErr   chan error
+	eventLog *eventlog.Log
+}
+
+// Run returns a nil service and the context given if the program does not
+// run as a Windows service. Otherwise, it reports the service as running
+// to the service control manager, and returns a context canceled with the
+// cause ErrStopRequested when the service is stopped or the system shuts
+// down. waitHint returns the time the program may take to stop, reported
+// to the service control manager while stopping. Service.Stopped must be
+// called once the program stopped.
+func Run(ctx context.Context, waitHint func() time.Duration) (
+	serviceCtx context.Context, service *Service, err error) {
+	isService, err := svc.IsWindowsService()
+	if err != nil {
+		return nil, nil, fmt.Errorf("detecting Windows service: %w", err)
+	} else if !isService {
+		return ctx, nil, nil
+	}
+
+	eventLog, err := eventlog.Open(Name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening event log: %w", err)
+	}
+
+	serviceCtx, cancel := context.WithCancelCause(ctx)
+	service = &Service{
+		handler: &handler{
+			cancel:   cancel,
+			waitHint: waitHint,
+			done:     make(chan int, 1),
+		},
+		runErr:   make(chan error, 1),
+		eventLog: eventLog,
+	}
+	go func() {
+		err := svc.Run(Name, service.handler)
+		if err != nil {
+			cancel(fmt.Errorf("running Windows service: %w", err))
+		}
+		service.runErr <- err
+	}()
+	return serviceCtx, service, nil
+}
+
+// Output returns a writer writing each log line to the Windows event log,
+// or nil if the program does not run as a Windows service.
+func (s *Service) Output() io.Writer {
+	if s == nil {
+		return nil
+	}
+	return &eventLogWriter{log: s.eventLog}
+}
+
+// Stopped reports the service as stopped with the exit code given to the
+// service control manager, unless the service was stopped by it, in which
+// case the exit code is ignored. It does nothing if the program does not
+// run as a Windows service.
+func (s *Service) Stopped(exitCode int) {
+	if s == nil {
+		return
+	}
+	s.handler.done <- exitCode
+	<-s.runErr
+	_ = s.eventLog.Close()
+}
+
+type handler struct {
+	cancel   context.CancelCauseFunc
+	waitHint func() time.Duration
+	done     chan int
+}
+
+func (h *handler) Execute(_ []string, requests <-chan svc.ChangeRequest,
+	changes chan<- svc.Status) (serviceSpecificExitCode bool, exitCode uint32) {
+	const accepted = svc.AcceptStop | svc.AcceptShutdown
+	changes <- svc.Status{State: svc.StartPending}
+	changes <- svc.Status{State: svc.Running, Accepts: accepted}
+	for {
+		select {
+		case code := <-h.done:
+			// the program stopped on its own, for example on an error
+			changes <- svc.Status{State: svc.StopPending}
+			return code != 0, uint32(code) //nolint:gosec
+		case request := <-requests:
+			switch request.Cmd {
+			case svc.Interrogate:
+				changes <- request.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				changes <- svc.Status{
+					State:    svc.StopPending,
+					WaitHint: uint32(h.waitHint().Milliseconds()), //nolint:gosec
+				}
+				h.cancel(ErrStopRequested)
+				<-h.done
+				return false, 0
+			default:
+			}
+		}
+	}
+}
+
+// eventLogWriter writes each log line to the Windows event log,
+// as an error, a warning or an information event depending on
+// its log level.
+type eventLogWriter struct {
+	log *eventlog.Log
+}
+
+func (w *eventLogWriter) Write(p []byte) (n int, err error) {
+	const eventID = 1
+	message := strings.TrimRight(string(p), "\r\n")
+	// log lines are written as "time LEVEL message"
+	var level string
+	if fields := strings.Fields(message); len(fields) > 1 {
+		level = fields[1]
+	}
+	switch {
+	case strings.Contains(level, "ERROR"):
+		err = w.log.Error(eventID, message)
+	case strings.Contains(level, "WARN"):
+		err = w.log.Warning(eventID, message)
+	default:
+		err = w.log.Info(eventID, message)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Install installs the Windows service of the program, started
+// automatically with the arguments given, such as --datadir=C:\ddns,
+// and restarted a minute after it fails, and registers its event
+// log source.
+func Install(args []string) (err error) {
+	exePath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("getting executable path: %w", err)
+	}
+
+	manager, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("connecting to the service control manager: %w", err)
+	}
+	defer manager.Disconnect() //nolint:errcheck
+
+	service, err := manager.OpenService(Name)
+	if err == nil {
+		_ = service.Close()
+		return fmt.Errorf("%w: %s", ErrAlreadyInstalled, Name)
+	}
+
+	service, err = manager.CreateService(Name, exePath, mgr.Config{
+		DisplayName: displayName,
+		Description: description,
+		StartType:   mgr.StartAutomatic,
+	}, args...)
+	if err != nil {
+		return fmt.Errorf("creating service: %w", err)
+	}
+	defer service.Close()
+
+	const restartDelay = time.Minute
+	const resetPeriod = 24 * time.Hour
+	err = service.SetRecoveryActions([]mgr.RecoveryAction{
+		{Type: mgr.ServiceRestart, Delay: restartDelay},
+	}, uint32(resetPeriod.Seconds()))
+	if err != nil {
+		_ = service.Delete()
+		return fmt.Errorf("setting service recovery actions: %w", err)
+	}
+
+	err = eventlog.InstallAsEventCreate(Name, eventlog.Error|eventlog.Warning|eventlog.Info)
+	if err != nil {
+		_ = service.Delete()
+		return fmt.Errorf("installing event log source: %w", err)
+	}
+	return nil
+}
+
+// Uninstall removes the Windows service of the
+// program and its event log source.
+func Uninstall() (err error) {
+	return withService(func(service *mgr.Service) error {
+		err := service.Delete()
+		if err != nil {
+			return fmt.Errorf("deleting service: %w", err)
+		}
+		err = eventlog.Remove(Name)
+		if err != nil {
+			return fmt.Errorf("removing event log source: %w", err)
+		}
+		return nil
+	})
+}
+
+// Start starts the Windows service of the program.
+func Start() (err error) {
+	return withService(func(service *mgr.Service) error {
+		err := service.Start()
+		if err != nil {
+			return fmt.Errorf("starting service: %w", err)
+		}
+		return nil
+	})
+}
+
+// Stop stops the Windows service of the program, and waits
+// for it to be stopped for up to the timeout given.
+func Stop(timeout time.Duration) (err error) {
+	return withService(func(service *mgr.Service) error {
+		status, err := service.Control(svc.Stop)
+		if err != nil {
+			return fmt.Errorf("stopping service: %w", err)
+		}
+		deadline := time.Now().Add(timeout)
+		const pollPeriod = 300 * time.Millisecond
+		for status.State != svc.Stopped {
+			if time.Now().After(deadline) {
+				return fmt.Errorf("%w: after %s", ErrStopTimeout, timeout)
+			}
+			time.Sleep(pollPeriod)
+			status, err = service.Query()
+			if err != nil {
+				return fmt.Errorf("querying service status: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// withService runs f with the Windows service of the program.
+func withService(f func(service *mgr.Service) error) (err error) {
+	manager, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("connecting to the service control manager: %w", err)
+	}
+	defer manager.Disconnect() //nolint:errcheck
+
+	service, err := manager.OpenService(Name)
+	if errors.Is(err, windows.ERROR_SERVICE_DOES_NOT_EXIST) {
+		return fmt.Errorf("service %s is not installed: %w", Name, err)
+	} else if err != nil {
+		return fmt.Errorf("opening service: %w", err)
+	}
+	defer service.Close()
+
+	return f(service)
+}
@@ -0,0 +1,68 @@
+package publicip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+var ErrNoQuorum = errors.New("fetchers do not agree on the IP address")
+
+// consensus runs fetch with all the fetchers at the same time, and returns
+// the IP address found by at least f.quorum of them, as soon as it is found.
+// Otherwise, it waits for all of them to describe their results in the error.
+func (f *Fetcher) consensus(ctx context.Context,
+	fetch func(fetcher ipFetcher, ctx context.Context) (netip.Addr, error)) (
+	ip netip.Addr, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		ip  netip.Addr
+		err error
+	}
+	results := make(chan result, len(f.fetchers))
+	for _, fetcher := range f.fetchers {
+		go func(fetcher ipFetcher) {
+			ip, err := fetch(fetcher, ctx)
+			results <- result{ip: ip, err: err}
+		}(fetcher)
+	}
+
+	votes := make(map[netip.Addr]uint, len(f.fetchers))
+	var errs []error
+	for range f.fetchers {
+		result := <-results
+		if result.err != nil {
+			errs = append(errs, result.err)
+			continue
+		}
+		votes[result.ip]++
+		if votes[result.ip] == f.quorum {
+			return result.ip, nil
+		}
+	}
+
+	return netip.Addr{}, fmt.Errorf("%w: %d needed out of %d fetchers: %s",
+		ErrNoQuorum, f.quorum, len(f.fetchers), describeVotes(votes, errs))
+}
+
+func describeVotes(votes map[netip.Addr]uint, errs []error) string {
+	parts := make([]string, 0, len(votes)+1)
+	for ip, count := range votes {
+		parts = append(parts, fmt.Sprintf("%s found by %d", ip, count))
+	}
+	sort.Strings(parts)
+	if len(errs) > 0 {
+		errStrings := make([]string, len(errs))
+		for i, err := range errs {
+			errStrings[i] = err.Error()
+		}
+		sort.Strings(errStrings)
+		parts = append(parts, fmt.Sprintf("%d failed (%s)", len(errs), strings.Join(errStrings, "; ")))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,85 @@
+package publicip
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testFetcher struct {
+	ip  netip.Addr
+	err error
+}
+
+func (f testFetcher) IP(context.Context) (netip.Addr, error)  { return f.ip, f.err }
+func (f testFetcher) IP4(context.Context) (netip.Addr, error) { return f.ip, f.err }
+func (f testFetcher) IP6(context.Context) (netip.Addr, error) { return f.ip, f.err }
+
+func Test_Fetcher_consensus(t *testing.T) {
+	t.Parallel()
+
+	ipA := netip.AddrFrom4([4]byte{1, 1, 1, 1})
+	ipB := netip.AddrFrom4([4]byte{2, 2, 2, 2})
+
+	testCases := map[string]struct {
+		fetchers   []ipFetcher
+		quorum     uint
+		ip         netip.Addr
+		errMessage string
+	}{
+		"all_agree": {
+			fetchers: []ipFetcher{testFetcher{ip: ipA}, testFetcher{ip: ipA}},
+			quorum:   2,
+			ip:       ipA,
+		},
+		"majority": {
+			fetchers: []ipFetcher{
+				testFetcher{ip: ipA}, testFetcher{ip: ipB},
+				testFetcher{ip: ipA}, testFetcher{err: errors.New("test error")},
+			},
+			quorum: 2,
+			ip:     ipA,
+		},
+		"disagreement": {
+			fetchers: []ipFetcher{testFetcher{ip: ipA}, testFetcher{ip: ipB}},
+			quorum:   2,
+			errMessage: "fetchers do not agree on the IP address: 2 needed out of 2 fetchers: " +
+				"1.1.1.1 found by 1, 2.2.2.2 found by 1",
+		},
+		"errors": {
+			fetchers: []ipFetcher{
+				testFetcher{ip: ipA},
+				testFetcher{err: errors.New("test error")},
+				testFetcher{err: errors.New("test error")},
+			},
+			quorum: 2,
+			errMessage: "fetchers do not agree on the IP address: 2 needed out of 3 fetchers: " +
+				"1.1.1.1 found by 1, 2 failed (test error; test error)",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			fetcher := &Fetcher{
+				fetchers: testCase.fetchers,
+				counter:  new(uint32),
+				quorum:   testCase.quorum,
+			}
+
+			ip, err := fetcher.IP4(context.Background())
+
+			if testCase.errMessage != "" {
+				assert.EqualError(t, err, testCase.errMessage)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, testCase.ip, ip)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+// Package external gets the public IP address from the output of a
+// command or from the content of a file, for setups no other fetcher
+// supports, such as a router API queried with a script or a VPN
+// client status file.
+package external
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type Fetcher struct {
+	command []string
+	file    string
+	timeout time.Duration
+}
+
+var (
+	ErrSourceNotSet    = errors.New("command or file must be set")
+	ErrSourceDuplicate = errors.New("command and file cannot be both set")
+)
+
+func New(options ...Option) (f *Fetcher, err error) {
+	settings := newDefaultSettings()
+	for _, option := range options {
+		err = option(&settings)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case len(settings.command) == 0 && settings.file == "":
+		return nil, fmt.Errorf("%w", ErrSourceNotSet)
+	case len(settings.command) > 0 && settings.file != "":
+		return nil, fmt.Errorf("%w", ErrSourceDuplicate)
+	}
+
+	return &Fetcher{
+		command: settings.command,
+		file:    settings.file,
+		timeout: settings.timeout,
+	}, nil
+}
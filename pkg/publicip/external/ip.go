@@ -0,0 +1,110 @@
+package external
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/qdm12/ddns-updater/pkg/publicip/ipversion"
+)
+
+var (
+	ErrIPNotFound           = errors.New("no IP address found")
+	ErrIPNotFoundForVersion = errors.New("IP addresses found but not for IP version")
+)
+
+func (f *Fetcher) IP(ctx context.Context) (publicIP netip.Addr, err error) {
+	return f.ip(ctx, ipversion.IP4or6)
+}
+
+func (f *Fetcher) IP4(ctx context.Context) (publicIP netip.Addr, err error) {
+	return f.ip(ctx, ipversion.IP4)
+}
+
+func (f *Fetcher) IP6(ctx context.Context) (publicIP netip.Addr, err error) {
+	return f.ip(ctx, ipversion.IP6)
+}
+
+func (f *Fetcher) ip(ctx context.Context, version ipversion.IPVersion) (
+	publicIP netip.Addr, err error) {
+	output, err := f.read(ctx, version)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	ips := parseIPs(output)
+	if len(ips) == 0 {
+		return netip.Addr{}, fmt.Errorf("%w in %s", ErrIPNotFound, f.source())
+	}
+
+	for _, ip := range ips {
+		switch {
+		case version == ipversion.IP4or6,
+			version == ipversion.IP4 && ip.Is4(),
+			version == ipversion.IP6 && ip.Is6():
+			return ip, nil
+		}
+	}
+	return netip.Addr{}, fmt.Errorf("%w: %s in %s", ErrIPNotFoundForVersion, version, f.source())
+}
+
+// read returns the output of the command or the content of the file.
+// The command is run with the IP version wanted in its IP_VERSION
+// environment variable, set to "ipv4", "ipv6" or "ipv4 or ipv6".
+func (f *Fetcher) read(ctx context.Context, version ipversion.IPVersion) (
+	output string, err error) {
+	if f.file != "" {
+		data, err := os.ReadFile(f.file)
+		if err != nil {
+			return "", fmt.Errorf("reading file: %w", err)
+		}
+		return string(data), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, f.command[0], f.command[1:]...) //nolint:gosec
+	cmd.Env = append(os.Environ(), "IP_VERSION="+version.String())
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	data, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			err = fmt.Errorf("%w: %s", err, message)
+		}
+		return "", fmt.Errorf("running command %s: %w", f.command[0], err)
+	}
+	return string(data), nil
+}
+
+func (f *Fetcher) source() string {
+	if f.file != "" {
+		return "file " + f.file
+	}
+	return "output of command " + f.command[0]
+}
+
+// parseIPs returns the IP addresses found in the text, in their order of
+// appearance. The text can be only an IP address or be made of other
+// content, such as JSON, in which case the IP addresses are the sequences
+// of hexadecimal digits, dots and colons forming a valid IP address.
+func parseIPs(text string) (ips []netip.Addr) {
+	isIPCharacter := func(r rune) bool {
+		return r == '.' || r == ':' ||
+			(r >= '0' && r <= '9') || (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F')
+	}
+	fields := strings.FieldsFunc(text, func(r rune) bool { return !isIPCharacter(r) })
+	for _, field := range fields {
+		ip, err := netip.ParseAddr(field)
+		if err != nil || ip.IsUnspecified() {
+			continue
+		}
+		ips = append(ips, ip.Unmap())
+	}
+	return ips
+}
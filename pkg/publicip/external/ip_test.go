@@ -0,0 +1,96 @@
+package external
+
+import (
+	"context"
+	"net/netip"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseIPs(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		text string
+		ips  []netip.Addr
+	}{
+		"empty": {},
+		"ip": {
+			text: "1.2.3.4\n",
+			ips:  []netip.Addr{netip.MustParseAddr("1.2.3.4")},
+		},
+		"json": {
+			text: `{"wan": {"ip": "1.2.3.4", "ipv6": "2001:db8::1/64", "updated": "12:30:45"}}`,
+			ips: []netip.Addr{
+				netip.MustParseAddr("1.2.3.4"),
+				netip.MustParseAddr("2001:db8::1"),
+			},
+		},
+		"mapped_and_unspecified": {
+			text: "0.0.0.0 ::ffff:5.6.7.8 cafe",
+			ips:  []netip.Addr{netip.MustParseAddr("5.6.7.8")},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ips := parseIPs(testCase.text)
+
+			assert.Equal(t, testCase.ips, ips)
+		})
+	}
+}
+
+func Test_Fetcher_file(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "status")
+	err := os.WriteFile(path, []byte("wan 2001:db8::1 1.2.3.4"), 0o600)
+	require.NoError(t, err)
+
+	fetcher, err := New(SetFile(path))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	ip, err := fetcher.IP(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, netip.MustParseAddr("2001:db8::1"), ip)
+
+	ipv4, err := fetcher.IP4(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, netip.MustParseAddr("1.2.3.4"), ipv4)
+
+	err = os.WriteFile(path, []byte("wan 1.2.3.4"), 0o600)
+	require.NoError(t, err)
+	_, err = fetcher.IP6(ctx)
+	assert.EqualError(t, err, "IP addresses found but not for IP version: ipv6 in file "+path)
+}
+
+func Test_Fetcher_command(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	fetcher, err := New(SetCommand(`sh -c 'echo "$IP_VERSION 1.2.3.4"'`))
+	require.NoError(t, err)
+
+	ip, err := fetcher.IP4(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, netip.MustParseAddr("1.2.3.4"), ip)
+
+	fetcher, err = New(SetCommand(`sh -c 'echo failed >&2; exit 1'`))
+	require.NoError(t, err)
+
+	_, err = fetcher.IP(context.Background())
+	assert.EqualError(t, err, "running command sh: exit status 1: failed")
+}
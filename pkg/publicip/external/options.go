@@ -0,0 +1,94 @@
+package external
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type settings struct {
+	command []string
+	file    string
+	timeout time.Duration
+}
+
+func newDefaultSettings() settings {
+	const defaultTimeout = 10 * time.Second
+	return settings{
+		timeout: defaultTimeout,
+	}
+}
+
+type Option func(s *settings) error
+
+// SetCommand sets the command to run to get the public IP address
+// from its output. The command is split into its program and
+// arguments on spaces, which can be kept in an argument by quoting
+// it with single or double quotes, and it is not run by a shell.
+func SetCommand(command string) Option {
+	return func(s *settings) (err error) {
+		s.command, err = SplitCommand(command)
+		return err
+	}
+}
+
+// SetFile sets the file to read the public IP address from.
+func SetFile(path string) Option {
+	return func(s *settings) (err error) {
+		s.file = path
+		return nil
+	}
+}
+
+// SetTimeout sets the timeout to run the command.
+func SetTimeout(timeout time.Duration) Option {
+	return func(s *settings) (err error) {
+		s.timeout = timeout
+		return nil
+	}
+}
+
+var (
+	ErrCommandEmpty         = errors.New("command is empty")
+	ErrCommandQuoteNotEnded = errors.New("command quote is not closed")
+)
+
+// SplitCommand splits the command given into its program and arguments,
+// on spaces outside single or double quotes.
+func SplitCommand(command string) (fields []string, err error) {
+	var field strings.Builder
+	inField := false
+	var quote rune
+	for _, r := range command {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			field.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inField = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+		default:
+			field.WriteRune(r)
+			inField = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("%w: %s", ErrCommandQuoteNotEnded, command)
+	} else if inField {
+		fields = append(fields, field.String())
+	}
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%w", ErrCommandEmpty)
+	}
+	return fields, nil
+}
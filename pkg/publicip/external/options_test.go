@@ -0,0 +1,53 @@
+package external
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SplitCommand(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		command    string
+		fields     []string
+		errMessage string
+	}{
+		"empty": {
+			errMessage: "command is empty",
+		},
+		"program": {
+			command: "/usr/bin/get-ip",
+			fields:  []string{"/usr/bin/get-ip"},
+		},
+		"arguments": {
+			command: "  curl -s  https://router/api ",
+			fields:  []string{"curl", "-s", "https://router/api"},
+		},
+		"quotes": {
+			command: `jq -r '.wan ip' "/run/status file" ""`,
+			fields:  []string{"jq", "-r", ".wan ip", "/run/status file", ""},
+		},
+		"quote_not_ended": {
+			command:    `cat "file`,
+			errMessage: `command quote is not closed: cat "file`,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			fields, err := SplitCommand(testCase.command)
+
+			if testCase.errMessage != "" {
+				assert.EqualError(t, err, testCase.errMessage)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, testCase.fields, fields)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+// Package iface gets the public IP address from the addresses of
+// a local network interface, for hosts such as VPS having their
+// public IP address assigned to one of their network interfaces.
+package iface
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+)
+
+type Fetcher struct {
+	name     string
+	prefixes []netip.Prefix
+	// addrs returns the addresses of the interface, and is
+	// replaced in tests to not depend on the host interfaces.
+	addrs func(name string) (addresses []net.Addr, err error)
+}
+
+var ErrNameEmpty = errors.New("interface name is empty")
+
+// New creates a fetcher of the public IP address from the
+// addresses of the network interface with the name given.
+func New(name string, options ...Option) (f *Fetcher, err error) {
+	if name == "" {
+		return nil, fmt.Errorf("%w", ErrNameEmpty)
+	}
+
+	settings := newDefaultSettings()
+	for _, option := range options {
+		err = option(&settings)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Fetcher{
+		name:     name,
+		prefixes: settings.prefixes,
+		addrs:    interfaceAddrs,
+	}, nil
+}
+
+func interfaceAddrs(name string) (addresses []net.Addr, err error) {
+	netInterface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+	return netInterface.Addrs()
+}
@@ -0,0 +1,75 @@
+package iface
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+)
+
+var (
+	ErrIPNotFound           = errors.New("no matching IP address found on interface")
+	ErrIPNotFoundForVersion = errors.New("IP addresses found but not for IP version")
+)
+
+func (f *Fetcher) IP(_ context.Context) (publicIP netip.Addr, err error) {
+	return f.ip(func(netip.Addr) bool { return true })
+}
+
+func (f *Fetcher) IP4(_ context.Context) (publicIP netip.Addr, err error) {
+	publicIP, err = f.ip(netip.Addr.Is4)
+	if errors.Is(err, ErrIPNotFound) {
+		return netip.Addr{}, fmt.Errorf("%w: ipv4", ErrIPNotFoundForVersion)
+	}
+	return publicIP, err
+}
+
+func (f *Fetcher) IP6(_ context.Context) (publicIP netip.Addr, err error) {
+	publicIP, err = f.ip(netip.Addr.Is6)
+	if errors.Is(err, ErrIPNotFound) {
+		return netip.Addr{}, fmt.Errorf("%w: ipv6", ErrIPNotFoundForVersion)
+	}
+	return publicIP, err
+}
+
+// ip returns the first address of the interface matching the version
+// filter given and within one of the prefixes set, if any. Without
+// prefixes, only global unicast addresses which are not private are
+// used, so loopback, link local, RFC 1918 and unique local addresses
+// are ignored.
+func (f *Fetcher) ip(matchVersion func(ip netip.Addr) bool) (
+	publicIP netip.Addr, err error) {
+	addresses, err := f.addrs(f.name)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("getting addresses of interface %s: %w", f.name, err)
+	}
+
+	for _, address := range addresses {
+		ipNet, ok := address.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		ip, ok := netip.AddrFromSlice(ipNet.IP)
+		if !ok {
+			continue
+		}
+		ip = ip.Unmap()
+		if matchVersion(ip) && f.match(ip) {
+			return ip, nil
+		}
+	}
+	return netip.Addr{}, fmt.Errorf("%w: %s", ErrIPNotFound, f.name)
+}
+
+func (f *Fetcher) match(ip netip.Addr) bool {
+	if len(f.prefixes) == 0 {
+		return ip.IsGlobalUnicast() && !ip.IsPrivate()
+	}
+	for _, prefix := range f.prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package iface
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Fetcher(t *testing.T) {
+	t.Parallel()
+
+	addresses := []net.Addr{
+		&net.IPNet{IP: net.ParseIP("127.0.0.1"), Mask: net.CIDRMask(8, 32)},
+		&net.IPNet{IP: net.ParseIP("192.168.1.2"), Mask: net.CIDRMask(24, 32)},
+		&net.IPNet{IP: net.ParseIP("fe80::1"), Mask: net.CIDRMask(64, 128)},
+		&net.IPNet{IP: net.ParseIP("fd00::1"), Mask: net.CIDRMask(64, 128)},
+		&net.IPNet{IP: net.ParseIP("203.0.113.5"), Mask: net.CIDRMask(24, 32)},
+		&net.IPNet{IP: net.ParseIP("2001:db8::5"), Mask: net.CIDRMask(64, 128)},
+	}
+
+	testCases := map[string]struct {
+		prefixes  []netip.Prefix
+		addrsErr  error
+		ip        netip.Addr
+		ipv4      netip.Addr
+		ipv6      netip.Addr
+		errString string
+	}{
+		"public_addresses": {
+			ip:   netip.MustParseAddr("203.0.113.5"),
+			ipv4: netip.MustParseAddr("203.0.113.5"),
+			ipv6: netip.MustParseAddr("2001:db8::5"),
+		},
+		"prefixes": {
+			prefixes: []netip.Prefix{
+				netip.MustParsePrefix("fd00::/8"),
+				netip.MustParsePrefix("192.168.0.0/16"),
+			},
+			ip:   netip.MustParseAddr("192.168.1.2"),
+			ipv4: netip.MustParseAddr("192.168.1.2"),
+			ipv6: netip.MustParseAddr("fd00::1"),
+		},
+		"no_match": {
+			prefixes:  []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+			errString: "no matching IP address found on interface: eth0",
+		},
+		"addresses_error": {
+			addrsErr:  errors.New("test error"),
+			errString: "getting addresses of interface eth0: test error",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			fetcher, err := New("eth0", SetPrefixes(testCase.prefixes...))
+			require.NoError(t, err)
+			fetcher.addrs = func(name string) ([]net.Addr, error) {
+				assert.Equal(t, "eth0", name)
+				return addresses, testCase.addrsErr
+			}
+
+			ctx := context.Background()
+			ip, err := fetcher.IP(ctx)
+			if testCase.errString != "" {
+				assert.EqualError(t, err, testCase.errString)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.ip, ip)
+
+			ipv4, err := fetcher.IP4(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.ipv4, ipv4)
+
+			ipv6, err := fetcher.IP6(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.ipv6, ipv6)
+		})
+	}
+}
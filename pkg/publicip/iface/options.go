@@ -0,0 +1,23 @@
+package iface
+
+import "net/netip"
+
+type settings struct {
+	prefixes []netip.Prefix
+}
+
+func newDefaultSettings() settings {
+	return settings{}
+}
+
+type Option func(s *settings) error
+
+// SetPrefixes only uses the addresses of the interface within one
+// of the prefixes given, for example to select the address of a
+// given subnet when the interface has several addresses.
+func SetPrefixes(prefixes ...netip.Prefix) Option {
+	return func(s *settings) (err error) {
+		s.prefixes = prefixes
+		return nil
+	}
+}
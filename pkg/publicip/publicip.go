@@ -3,10 +3,15 @@ package publicip
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/netip"
 
 	"github.com/qdm12/ddns-updater/pkg/publicip/dns"
+	"github.com/qdm12/ddns-updater/pkg/publicip/external"
 	"github.com/qdm12/ddns-updater/pkg/publicip/http"
+	"github.com/qdm12/ddns-updater/pkg/publicip/iface"
+	"github.com/qdm12/ddns-updater/pkg/publicip/router"
+	"github.com/qdm12/ddns-updater/pkg/publicip/stun"
 )
 
 type ipFetcher interface {
@@ -20,19 +25,36 @@ type Fetcher struct {
 	fetchers []ipFetcher
 	// Cycling effect if both are enabled
 	counter *uint32 // 32 bit for 32 bit systems
+	// quorum is the number of fetchers which must agree on the
+	// IP address, if more than 1, instead of cycling between them.
+	quorum uint
 }
 
-var ErrNoFetchTypeSpecified = errors.New("at least one fetcher type must be specified")
+var (
+	ErrNoFetchTypeSpecified = errors.New("at least one fetcher type must be specified")
+	ErrQuorumTooHigh        = errors.New("quorum is higher than the number of fetchers")
+)
 
-func NewFetcher(dnsSettings DNSSettings, httpSettings HTTPSettings) (f *Fetcher, err error) {
+// NewFetcher creates a fetcher using the fetchers enabled in turn or, if
+// quorum is higher than 1, all of them, returning the IP address found by
+// at least quorum of them.
+func NewFetcher(dnsSettings DNSSettings, httpSettings HTTPSettings,
+	stunSettings STUNSettings, interfaceSettings InterfaceSettings,
+	externalSettings ExternalSettings, routerSettings RouterSettings,
+	quorum uint) (f *Fetcher, err error) {
 	settings := settings{
-		dns:  dnsSettings,
-		http: httpSettings,
+		dns:      dnsSettings,
+		http:     httpSettings,
+		stun:     stunSettings,
+		iface:    interfaceSettings,
+		external: externalSettings,
+		router:   routerSettings,
 	}
 
 	fetcher := &Fetcher{
 		settings: settings,
 		counter:  new(uint32),
+		quorum:   quorum,
 	}
 
 	if settings.dns.Enabled {
@@ -51,21 +73,65 @@ func NewFetcher(dnsSettings DNSSettings, httpSettings HTTPSettings) (f *Fetcher,
 		fetcher.fetchers = append(fetcher.fetchers, subFetcher)
 	}
 
+	if settings.stun.Enabled {
+		subFetcher, err := stun.New(settings.stun.Options...)
+		if err != nil {
+			return nil, err
+		}
+		fetcher.fetchers = append(fetcher.fetchers, subFetcher)
+	}
+
+	if settings.iface.Enabled {
+		subFetcher, err := iface.New(settings.iface.Name, settings.iface.Options...)
+		if err != nil {
+			return nil, err
+		}
+		fetcher.fetchers = append(fetcher.fetchers, subFetcher)
+	}
+
+	if settings.external.Enabled {
+		subFetcher, err := external.New(settings.external.Options...)
+		if err != nil {
+			return nil, err
+		}
+		fetcher.fetchers = append(fetcher.fetchers, subFetcher)
+	}
+
+	if settings.router.Enabled {
+		subFetcher, err := router.New(settings.router.Options...)
+		if err != nil {
+			return nil, err
+		}
+		fetcher.fetchers = append(fetcher.fetchers, subFetcher)
+	}
+
 	if len(fetcher.fetchers) == 0 {
 		return nil, ErrNoFetchTypeSpecified
+	} else if int(quorum) > len(fetcher.fetchers) {
+		return nil, fmt.Errorf("%w: %d for %d fetchers",
+			ErrQuorumTooHigh, quorum, len(fetcher.fetchers))
 	}
 
 	return fetcher, nil
 }
 
 func (f *Fetcher) IP(ctx context.Context) (ip netip.Addr, err error) {
+	if f.quorum > 1 {
+		return f.consensus(ctx, ipFetcher.IP)
+	}
 	return f.getSubFetcher().IP(ctx)
 }
 
 func (f *Fetcher) IP4(ctx context.Context) (ipv4 netip.Addr, err error) {
+	if f.quorum > 1 {
+		return f.consensus(ctx, ipFetcher.IP4)
+	}
 	return f.getSubFetcher().IP4(ctx)
 }
 
 func (f *Fetcher) IP6(ctx context.Context) (ipv6 netip.Addr, err error) {
+	if f.quorum > 1 {
+		return f.consensus(ctx, ipFetcher.IP6)
+	}
 	return f.getSubFetcher().IP6(ctx)
 }
@@ -0,0 +1,59 @@
+package router
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+var (
+	ErrDefaultGatewayNotFound = errors.New("default gateway not found")
+	ErrGatewayMalformed       = errors.New("gateway is malformed")
+)
+
+// defaultGateway returns the IPv4 default gateway of the host, read
+// from the Linux kernel routing table.
+func defaultGateway() (gateway netip.Addr, err error) {
+	file, err := os.Open("/proc/net/route")
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	defer file.Close()
+	return parseRoutes(file)
+}
+
+// parseRoutes returns the gateway of the default route of the routing
+// table given in the format of /proc/net/route, whose addresses are
+// hexadecimal in the host byte order, which is little endian.
+func parseRoutes(reader io.Reader) (gateway netip.Addr, err error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Scan() // skip header line
+	for scanner.Scan() {
+		const destinationIndex, gatewayIndex, maskIndex = 1, 2, 7
+		fields := strings.Fields(scanner.Text())
+		if len(fields) <= maskIndex ||
+			fields[destinationIndex] != "00000000" || fields[maskIndex] != "00000000" {
+			continue
+		}
+
+		gatewayBytes, err := hex.DecodeString(fields[gatewayIndex])
+		if err != nil || len(gatewayBytes) != 4 { //nolint:gomnd
+			return netip.Addr{}, fmt.Errorf("%w: %q", ErrGatewayMalformed, fields[gatewayIndex])
+		}
+		var address [4]byte
+		binary.BigEndian.PutUint32(address[:], binary.LittleEndian.Uint32(gatewayBytes))
+		return netip.AddrFrom4(address), nil
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return netip.Addr{}, fmt.Errorf("%w", ErrDefaultGatewayNotFound)
+}
@@ -0,0 +1,53 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+var (
+	ErrIPv6NotSupported = errors.New("IPv6 is not supported by routers")
+	ErrAddressNotPublic = errors.New("router external address is not public")
+)
+
+func (f *Fetcher) IP(ctx context.Context) (publicIP netip.Addr, err error) {
+	return f.IP4(ctx)
+}
+
+func (f *Fetcher) IP4(ctx context.Context) (publicIP netip.Addr, err error) {
+	var errs []error
+	for _, protocol := range f.protocols {
+		switch protocol {
+		case NATPMP:
+			publicIP, err = f.natPMP(ctx)
+		case UPnP:
+			publicIP, err = f.upnp(ctx)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", protocol, err))
+			continue
+		}
+		publicIP = publicIP.Unmap()
+		if !isPublic(publicIP) {
+			// The router is behind another NAT, such as the carrier grade
+			// NAT of the ISP, so the other protocols would give the same.
+			return netip.Addr{}, fmt.Errorf("%w: %s: %s is behind another NAT",
+				ErrAddressNotPublic, protocol, publicIP)
+		}
+		return publicIP, nil
+	}
+	return netip.Addr{}, errors.Join(errs...)
+}
+
+func (f *Fetcher) IP6(_ context.Context) (publicIP netip.Addr, err error) {
+	return netip.Addr{}, fmt.Errorf("%w", ErrIPv6NotSupported)
+}
+
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10") //nolint:gochecknoglobals
+
+func isPublic(ip netip.Addr) bool {
+	return ip.Is4() && ip.IsGlobalUnicast() && !ip.IsPrivate() &&
+		!sharedAddressSpace.Contains(ip)
+}
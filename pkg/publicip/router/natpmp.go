@@ -0,0 +1,99 @@
+package router
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"strconv"
+	"time"
+)
+
+const natPMPPort = 5351
+
+var (
+	ErrNATPMPResponseNotValid = errors.New("NAT-PMP response is not valid")
+	ErrNATPMPResultCode       = errors.New("NAT-PMP request failed")
+)
+
+// natPMP returns the external address of the gateway, with the NAT-PMP
+// request retransmitted with a doubling timeout as described in RFC 6886.
+func (f *Fetcher) natPMP(ctx context.Context) (publicIP netip.Addr, err error) {
+	gateway := f.gateway
+	if !gateway.IsValid() {
+		gateway, err = defaultGateway()
+		if err != nil {
+			return netip.Addr{}, fmt.Errorf("finding default gateway: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+
+	address := net.JoinHostPort(gateway.String(), strconv.Itoa(int(f.natPMPPort)))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp4", address)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	defer conn.Close()
+
+	// version 0 and opcode 0 for the external address request
+	request := []byte{0, 0}
+	response := make([]byte, 16) //nolint:gomnd
+	const initialTimeout = 250 * time.Millisecond
+	for timeout := initialTimeout; ; timeout *= 2 {
+		deadline := time.Now().Add(timeout)
+		ctxDeadline, _ := ctx.Deadline()
+		lastTry := !ctxDeadline.After(deadline)
+		if lastTry {
+			deadline = ctxDeadline
+		}
+		err = conn.SetDeadline(deadline)
+		if err != nil {
+			return netip.Addr{}, fmt.Errorf("setting deadline: %w", err)
+		}
+
+		_, err = conn.Write(request)
+		if err != nil {
+			return netip.Addr{}, fmt.Errorf("sending request: %w", err)
+		}
+
+		var n int
+		n, err = conn.Read(response)
+		switch {
+		case err == nil:
+			return parseNATPMPResponse(response[:n])
+		case !errors.Is(err, os.ErrDeadlineExceeded):
+			return netip.Addr{}, fmt.Errorf("reading response: %w", err)
+		case lastTry:
+			return netip.Addr{}, fmt.Errorf("%w: no response from %s", context.DeadlineExceeded, address)
+		}
+	}
+}
+
+func parseNATPMPResponse(response []byte) (publicIP netip.Addr, err error) {
+	const responseSize = 12
+	const externalAddressOpcode = 128
+	switch {
+	case len(response) < responseSize:
+		return netip.Addr{}, fmt.Errorf("%w: %d bytes instead of %d",
+			ErrNATPMPResponseNotValid, len(response), responseSize)
+	case response[0] != 0:
+		return netip.Addr{}, fmt.Errorf("%w: version %d is not supported",
+			ErrNATPMPResponseNotValid, response[0])
+	case response[1] != externalAddressOpcode:
+		return netip.Addr{}, fmt.Errorf("%w: opcode %d instead of %d",
+			ErrNATPMPResponseNotValid, response[1], externalAddressOpcode)
+	}
+
+	resultCode := binary.BigEndian.Uint16(response[2:4])
+	if resultCode != 0 {
+		return netip.Addr{}, fmt.Errorf("%w: result code %d", ErrNATPMPResultCode, resultCode)
+	}
+
+	return netip.AddrFrom4([4]byte(response[8:12])), nil
+}
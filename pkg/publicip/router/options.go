@@ -0,0 +1,81 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+)
+
+type Protocol string
+
+const (
+	NATPMP Protocol = "natpmp"
+	UPnP   Protocol = "upnp"
+)
+
+func ListProtocols() []Protocol {
+	return []Protocol{
+		NATPMP,
+		UPnP,
+	}
+}
+
+var ErrUnknownProtocol = errors.New("unknown router protocol")
+
+func ValidateProtocol(protocol Protocol) error {
+	for _, possible := range ListProtocols() {
+		if protocol == possible {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrUnknownProtocol, protocol)
+}
+
+type settings struct {
+	protocols []Protocol
+	gateway   netip.Addr
+	timeout   time.Duration
+}
+
+func newDefaultSettings() settings {
+	const defaultTimeout = 3 * time.Second
+	return settings{
+		protocols: ListProtocols(),
+		timeout:   defaultTimeout,
+	}
+}
+
+type Option func(s *settings) error
+
+// SetProtocols sets the protocols to try in order, until one of them
+// gives the public IP address.
+func SetProtocols(first Protocol, protocols ...Protocol) Option {
+	return func(s *settings) (err error) {
+		protocols = append([]Protocol{first}, protocols...)
+		for _, protocol := range protocols {
+			err = ValidateProtocol(protocol)
+			if err != nil {
+				return err
+			}
+		}
+		s.protocols = protocols
+		return nil
+	}
+}
+
+// SetGateway sets the address of the gateway to query with NAT-PMP,
+// instead of the default gateway of the host.
+func SetGateway(gateway netip.Addr) Option {
+	return func(s *settings) (err error) {
+		s.gateway = gateway
+		return nil
+	}
+}
+
+func SetTimeout(timeout time.Duration) Option {
+	return func(s *settings) (err error) {
+		s.timeout = timeout
+		return nil
+	}
+}
@@ -0,0 +1,46 @@
+// Package router gets the public IPv4 address from the local gateway,
+// with NAT-PMP (RFC 6886) or with the GetExternalIPAddress action of
+// UPnP Internet Gateway Devices.
+package router
+
+import (
+	"net/http"
+	"net/netip"
+	"sync"
+	"time"
+)
+
+type Fetcher struct {
+	protocols []Protocol
+	gateway   netip.Addr
+	timeout   time.Duration
+	client    *http.Client
+	// natPMPPort and ssdpAddress are the NAT-PMP port of the gateway
+	// and the SSDP multicast address, changed in tests.
+	natPMPPort  uint16
+	ssdpAddress string
+	// controlURL and serviceType are the UPnP WAN connection service
+	// found, cached until an action fails.
+	controlURL  string
+	serviceType string
+	upnpMutex   sync.Mutex
+}
+
+func New(options ...Option) (f *Fetcher, err error) {
+	settings := newDefaultSettings()
+	for _, option := range options {
+		err = option(&settings)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Fetcher{
+		protocols:   settings.protocols,
+		gateway:     settings.gateway,
+		timeout:     settings.timeout,
+		client:      &http.Client{Timeout: settings.timeout},
+		natPMPPort:  natPMPPort,
+		ssdpAddress: ssdpAddress,
+	}, nil
+}
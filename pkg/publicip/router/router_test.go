@@ -0,0 +1,192 @@
+package router
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestNATPMPGateway runs a NAT-PMP gateway answering with the
+// response given, and returns its port.
+func newTestNATPMPGateway(t *testing.T, response []byte) (port uint16) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	go func() {
+		buffer := make([]byte, 16)
+		for {
+			n, address, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			if n == 2 && buffer[0] == 0 && buffer[1] == 0 {
+				_, _ = conn.WriteTo(response, address)
+			}
+		}
+	}()
+
+	return uint16(conn.LocalAddr().(*net.UDPAddr).Port)
+}
+
+func Test_Fetcher_natPMP(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		response   []byte
+		publicIP   netip.Addr
+		errMessage string
+	}{
+		"success": {
+			response: []byte{0, 128, 0, 0, 0, 0, 0, 1, 203, 0, 113, 7},
+			publicIP: netip.AddrFrom4([4]byte{203, 0, 113, 7}),
+		},
+		"result_code": {
+			response:   []byte{0, 128, 0, 3, 0, 0, 0, 1, 0, 0, 0, 0},
+			errMessage: "natpmp: NAT-PMP request failed: result code 3",
+		},
+		"too_short": {
+			response:   []byte{0, 128, 0, 0},
+			errMessage: "natpmp: NAT-PMP response is not valid: 4 bytes instead of 12",
+		},
+		"carrier_grade_nat": {
+			response: []byte{0, 128, 0, 0, 0, 0, 0, 1, 100, 64, 1, 2},
+			errMessage: "router external address is not public: " +
+				"natpmp: 100.64.1.2 is behind another NAT",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			fetcher, err := New(SetProtocols(NATPMP),
+				SetGateway(netip.AddrFrom4([4]byte{127, 0, 0, 1})))
+			require.NoError(t, err)
+			fetcher.natPMPPort = newTestNATPMPGateway(t, testCase.response)
+
+			publicIP, err := fetcher.IP(context.Background())
+
+			if testCase.errMessage != "" {
+				assert.EqualError(t, err, testCase.errMessage)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, testCase.publicIP, publicIP)
+		})
+	}
+}
+
+const testDescription = `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <device>
+    <deviceType>urn:schemas-upnp-org:device:InternetGatewayDevice:1</deviceType>
+    <serviceList>
+      <service>
+        <serviceType>urn:schemas-upnp-org:service:Layer3Forwarding:1</serviceType>
+        <controlURL>/l3f</controlURL>
+      </service>
+    </serviceList>
+    <deviceList>
+      <device>
+        <deviceType>urn:schemas-upnp-org:device:WANDevice:1</deviceType>
+        <deviceList>
+          <device>
+            <deviceType>urn:schemas-upnp-org:device:WANConnectionDevice:1</deviceType>
+            <serviceList>
+              <service>
+                <serviceType>urn:schemas-upnp-org:service:WANIPConnection:1</serviceType>
+                <controlURL>/ctl/IPConn</controlURL>
+              </service>
+            </serviceList>
+          </device>
+        </deviceList>
+      </device>
+    </deviceList>
+  </device>
+</root>`
+
+const testExternalIPResponse = `<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+  <s:Body>
+    <u:GetExternalIPAddressResponse xmlns:u="urn:schemas-upnp-org:service:WANIPConnection:1">
+      <NewExternalIPAddress>203.0.113.7</NewExternalIPAddress>
+    </u:GetExternalIPAddressResponse>
+  </s:Body>
+</s:Envelope>`
+
+func Test_Fetcher_upnp(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rootDesc.xml":
+			_, _ = w.Write([]byte(testDescription))
+		case "/ctl/IPConn":
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, `"urn:schemas-upnp-org:service:WANIPConnection:1#GetExternalIPAddress"`,
+				r.Header.Get("SOAPAction"))
+			_, _ = w.Write([]byte(testExternalIPResponse))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	ssdpConn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ssdpConn.Close() })
+	go func() {
+		buffer := make([]byte, 2048)
+		for {
+			n, address, err := ssdpConn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			if !strings.HasPrefix(string(buffer[:n]), "M-SEARCH * HTTP/1.1\r\n") {
+				continue
+			}
+			response := "HTTP/1.1 200 OK\r\n" +
+				"ST: urn:schemas-upnp-org:device:InternetGatewayDevice:1\r\n" +
+				"LOCATION: " + server.URL + "/rootDesc.xml\r\n\r\n"
+			_, _ = ssdpConn.WriteTo([]byte(response), address)
+		}
+	}()
+
+	fetcher, err := New(SetProtocols(UPnP))
+	require.NoError(t, err)
+	fetcher.ssdpAddress = ssdpConn.LocalAddr().String()
+
+	publicIP, err := fetcher.IP4(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, netip.AddrFrom4([4]byte{203, 0, 113, 7}), publicIP)
+	assert.Equal(t, server.URL+"/ctl/IPConn", fetcher.controlURL)
+
+	_, err = fetcher.IP6(context.Background())
+	assert.ErrorIs(t, err, ErrIPv6NotSupported)
+}
+
+func Test_parseRoutes(t *testing.T) {
+	t.Parallel()
+
+	const routes = "Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\t\tMTU\tWindow\tIRTT\n" +
+		"eth0\t0001A8C0\t00000000\t0001\t0\t0\t100\t00FFFFFF\t0\t0\t0\n" +
+		"eth0\t00000000\t0101A8C0\t0003\t0\t0\t100\t00000000\t0\t0\t0\n"
+
+	gateway, err := parseRoutes(strings.NewReader(routes))
+
+	require.NoError(t, err)
+	assert.Equal(t, netip.AddrFrom4([4]byte{192, 168, 1, 1}), gateway)
+
+	_, err = parseRoutes(strings.NewReader(routes[:strings.Index(routes, "eth0\t00000000")]))
+	assert.ErrorIs(t, err, ErrDefaultGatewayNotFound)
+}
@@ -0,0 +1,235 @@
+package router
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const ssdpAddress = "239.255.255.250:1900"
+
+var (
+	ErrGatewayDeviceNotFound  = errors.New("no UPnP internet gateway device found")
+	ErrWANServiceNotFound     = errors.New("no WAN connection service found")
+	ErrHTTPStatusNotOK        = errors.New("HTTP status is not OK")
+	ErrExternalAddressInvalid = errors.New("external IP address is not valid")
+)
+
+// upnp returns the external address of the UPnP internet gateway
+// device found on the network, whose WAN connection service control
+// URL is cached for the next calls.
+func (f *Fetcher) upnp(ctx context.Context) (publicIP netip.Addr, err error) {
+	f.upnpMutex.Lock()
+	defer f.upnpMutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+
+	if f.controlURL == "" {
+		location, err := f.discover(ctx)
+		if err != nil {
+			return netip.Addr{}, fmt.Errorf("discovering gateway: %w", err)
+		}
+		f.controlURL, f.serviceType, err = f.getWANService(ctx, location)
+		if err != nil {
+			return netip.Addr{}, fmt.Errorf("reading gateway description: %w", err)
+		}
+	}
+
+	publicIP, err = f.getExternalIPAddress(ctx, f.controlURL, f.serviceType)
+	if err != nil {
+		// the gateway may have changed or restarted with another URL
+		f.controlURL, f.serviceType = "", ""
+		return netip.Addr{}, fmt.Errorf("getting external IP address: %w", err)
+	}
+	return publicIP, nil
+}
+
+// discover sends a SSDP search for internet gateway devices and returns
+// the location of the description of the first device answering.
+func (f *Fetcher) discover(ctx context.Context) (location string, err error) {
+	conn, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		return "", fmt.Errorf("setting deadline: %w", err)
+	}
+
+	destination, err := net.ResolveUDPAddr("udp4", f.ssdpAddress)
+	if err != nil {
+		return "", err
+	}
+
+	for _, searchTarget := range []string{
+		"urn:schemas-upnp-org:device:InternetGatewayDevice:1",
+		"urn:schemas-upnp-org:device:InternetGatewayDevice:2",
+	} {
+		request := "M-SEARCH * HTTP/1.1\r\n" +
+			"HOST: " + ssdpAddress + "\r\n" +
+			"MAN: \"ssdp:discover\"\r\n" +
+			"MX: 2\r\n" +
+			"ST: " + searchTarget + "\r\n\r\n"
+		_, err = conn.WriteTo([]byte(request), destination)
+		if err != nil {
+			return "", fmt.Errorf("sending search request: %w", err)
+		}
+	}
+
+	buffer := make([]byte, 2048) //nolint:gomnd
+	for {
+		n, _, err := conn.ReadFrom(buffer)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return "", fmt.Errorf("%w", ErrGatewayDeviceNotFound)
+		} else if err != nil {
+			return "", fmt.Errorf("reading search response: %w", err)
+		}
+
+		response, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(buffer[:n])), nil)
+		if err != nil {
+			continue // not a SSDP response
+		}
+		_ = response.Body.Close()
+		location := response.Header.Get("Location")
+		if response.StatusCode == http.StatusOK && location != "" {
+			return location, nil
+		}
+	}
+}
+
+type upnpRoot struct {
+	URLBase string     `xml:"URLBase"`
+	Device  upnpDevice `xml:"device"`
+}
+
+type upnpDevice struct {
+	Services []upnpService `xml:"serviceList>service"`
+	Devices  []upnpDevice  `xml:"deviceList>device"`
+}
+
+type upnpService struct {
+	ServiceType string `xml:"serviceType"`
+	ControlURL  string `xml:"controlURL"`
+}
+
+// findWANService returns the first WAN IP or PPP connection service of
+// the device or of its embedded devices.
+func (d upnpDevice) findWANService() (service upnpService, ok bool) {
+	for _, service := range d.Services {
+		if strings.HasPrefix(service.ServiceType, "urn:schemas-upnp-org:service:WANIPConnection:") ||
+			strings.HasPrefix(service.ServiceType, "urn:schemas-upnp-org:service:WANPPPConnection:") {
+			return service, true
+		}
+	}
+	for _, device := range d.Devices {
+		service, ok = device.findWANService()
+		if ok {
+			return service, true
+		}
+	}
+	return upnpService{}, false
+}
+
+func (f *Fetcher) getWANService(ctx context.Context, location string) (
+	controlURL, serviceType string, err error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return "", "", err
+	}
+
+	response, err := f.client.Do(request)
+	if err != nil {
+		return "", "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("%w: %d", ErrHTTPStatusNotOK, response.StatusCode)
+	}
+
+	var root upnpRoot
+	err = xml.NewDecoder(response.Body).Decode(&root)
+	if err != nil {
+		return "", "", fmt.Errorf("decoding device description: %w", err)
+	}
+
+	service, ok := root.Device.findWANService()
+	if !ok {
+		return "", "", fmt.Errorf("%w", ErrWANServiceNotFound)
+	}
+
+	base := location
+	if root.URLBase != "" {
+		base = root.URLBase
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", "", fmt.Errorf("parsing base URL: %w", err)
+	}
+	serviceControlURL, err := baseURL.Parse(service.ControlURL)
+	if err != nil {
+		return "", "", fmt.Errorf("parsing control URL: %w", err)
+	}
+	return serviceControlURL.String(), service.ServiceType, nil
+}
+
+func (f *Fetcher) getExternalIPAddress(ctx context.Context, controlURL, serviceType string) (
+	publicIP netip.Addr, err error) {
+	body := `<?xml version="1.0"?>` +
+		`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" ` +
+		`s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">` +
+		`<s:Body><u:GetExternalIPAddress xmlns:u="` + serviceType + `"/></s:Body>` +
+		`</s:Envelope>`
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, controlURL, strings.NewReader(body))
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	request.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	request.Header.Set("SOAPAction", `"`+serviceType+`#GetExternalIPAddress"`)
+
+	response, err := f.client.Do(request)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		const maxBodySize = 512
+		data, _ := io.ReadAll(io.LimitReader(response.Body, maxBodySize))
+		return netip.Addr{}, fmt.Errorf("%w: %d: %s",
+			ErrHTTPStatusNotOK, response.StatusCode, bytes.TrimSpace(data))
+	}
+
+	var envelope struct {
+		Body struct {
+			Response struct {
+				ExternalIPAddress string `xml:"NewExternalIPAddress"`
+			} `xml:",any"`
+		} `xml:"Body"`
+	}
+	err = xml.NewDecoder(response.Body).Decode(&envelope)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("decoding response: %w", err)
+	}
+
+	address := strings.TrimSpace(envelope.Body.Response.ExternalIPAddress)
+	publicIP, err = netip.ParseAddr(address)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("%w: %q", ErrExternalAddressInvalid, address)
+	}
+	return publicIP, nil
+}
@@ -4,13 +4,22 @@ import (
 	"net/http"
 
 	"github.com/qdm12/ddns-updater/pkg/publicip/dns"
+	"github.com/qdm12/ddns-updater/pkg/publicip/external"
 	iphttp "github.com/qdm12/ddns-updater/pkg/publicip/http"
+	"github.com/qdm12/ddns-updater/pkg/publicip/iface"
+	"github.com/qdm12/ddns-updater/pkg/publicip/router"
+	"github.com/qdm12/ddns-updater/pkg/publicip/stun"
 )
 
 type settings struct {
-	// If both dns and http are enabled it will cycle between both of them.
+	// If several fetchers are enabled it will cycle between them.
 	dns  DNSSettings
 	http HTTPSettings
+	stun STUNSettings
+	// iface is the network interface fetcher settings.
+	iface    InterfaceSettings
+	external ExternalSettings
+	router   RouterSettings
 }
 
 type DNSSettings struct {
@@ -23,3 +32,25 @@ type HTTPSettings struct {
 	Client  *http.Client
 	Options []iphttp.Option
 }
+
+type STUNSettings struct {
+	Enabled bool
+	Options []stun.Option
+}
+
+type InterfaceSettings struct {
+	Enabled bool
+	// Name is the name of the network interface, such as eth0.
+	Name    string
+	Options []iface.Option
+}
+
+type ExternalSettings struct {
+	Enabled bool
+	Options []external.Option
+}
+
+type RouterSettings struct {
+	Enabled bool
+	Options []router.Option
+}
@@ -0,0 +1,245 @@
+package stun
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"time"
+)
+
+const (
+	headerSize  = 20
+	magicCookie = 0x2112A442
+
+	bindingRequest       = 0x0001
+	bindingSuccess       = 0x0101
+	bindingErrorResponse = 0x0111
+
+	attributeMappedAddress    = 0x0001
+	attributeErrorCode        = 0x0009
+	attributeXORMappedAddress = 0x0020
+
+	familyIPv4 = 0x01
+	familyIPv6 = 0x02
+)
+
+type transactionID [12]byte
+
+// fetch sends a binding request to the STUN server over the network given,
+// retransmitting it with a doubling timeout as UDP messages can be lost,
+// and returns the address mapped for the client in the server response.
+func fetch(ctx context.Context, network, server string) (
+	publicIP netip.Addr, err error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, server)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	defer conn.Close()
+
+	var id transactionID
+	_, err = rand.Read(id[:])
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("generating transaction id: %w", err)
+	}
+	request := newBindingRequest(id)
+	response := make([]byte, 1500) //nolint:gomnd
+
+	const initialTimeout = 500 * time.Millisecond
+	for timeout := initialTimeout; ; timeout *= 2 {
+		err = ctx.Err()
+		if err != nil {
+			return netip.Addr{}, err
+		}
+
+		deadline := time.Now().Add(timeout)
+		ctxDeadline, lastTry := ctx.Deadline()
+		lastTry = lastTry && !ctxDeadline.After(deadline)
+		if lastTry {
+			deadline = ctxDeadline
+		}
+		err = conn.SetDeadline(deadline)
+		if err != nil {
+			return netip.Addr{}, fmt.Errorf("setting deadline: %w", err)
+		}
+
+		_, err = conn.Write(request)
+		if err == nil {
+			publicIP, err = readBindingResponse(conn, response, id)
+		} else {
+			err = fmt.Errorf("sending binding request: %w", err)
+		}
+		switch {
+		case err == nil:
+			return publicIP, nil
+		case !errors.Is(err, os.ErrDeadlineExceeded):
+			return netip.Addr{}, err
+		case lastTry:
+			// the connection deadline can be reached slightly
+			// before the context is marked as done.
+			return netip.Addr{}, fmt.Errorf("%w: no binding response received", context.DeadlineExceeded)
+		}
+	}
+}
+
+func newBindingRequest(id transactionID) (request []byte) {
+	request = make([]byte, headerSize)
+	binary.BigEndian.PutUint16(request[0:2], bindingRequest)
+	// message length is 0 since the request has no attribute
+	binary.BigEndian.PutUint32(request[4:8], magicCookie)
+	copy(request[8:20], id[:])
+	return request
+}
+
+// readBindingResponse reads messages from conn into buffer until one of
+// them is the response to the binding request with the transaction id given.
+func readBindingResponse(conn net.Conn, buffer []byte, id transactionID) (
+	publicIP netip.Addr, err error) {
+	for {
+		n, err := conn.Read(buffer)
+		if err != nil {
+			return netip.Addr{}, err
+		}
+
+		publicIP, err = parseBindingResponse(buffer[:n], id)
+		if errors.Is(err, ErrTransactionIDMismatch) {
+			continue
+		}
+		return publicIP, err
+	}
+}
+
+var (
+	ErrMessageTooShort       = errors.New("message is too short")
+	ErrNotSTUNMessage        = errors.New("message is not a STUN message")
+	ErrTransactionIDMismatch = errors.New("transaction id does not match")
+	ErrBindingFailed         = errors.New("binding request failed")
+	ErrMessageTypeUnexpected = errors.New("message type is not expected")
+	ErrAttributeMalformed    = errors.New("attribute is malformed")
+	ErrMappedAddressNotFound = errors.New("mapped address not found")
+	ErrAddressFamilyNotValid = errors.New("address family is not valid")
+)
+
+// parseBindingResponse returns the address of the XOR-MAPPED-ADDRESS
+// attribute of the binding response, or else of its MAPPED-ADDRESS
+// attribute, used by older servers.
+func parseBindingResponse(message []byte, id transactionID) (
+	publicIP netip.Addr, err error) {
+	if len(message) < headerSize {
+		return netip.Addr{}, fmt.Errorf("%w: %d bytes", ErrMessageTooShort, len(message))
+	}
+
+	messageType := binary.BigEndian.Uint16(message[0:2])
+	length := int(binary.BigEndian.Uint16(message[2:4]))
+	cookie := binary.BigEndian.Uint32(message[4:8])
+	if cookie != magicCookie || messageType&0xc000 != 0 {
+		return netip.Addr{}, fmt.Errorf("%w", ErrNotSTUNMessage)
+	} else if transactionID(message[8:20]) != id {
+		return netip.Addr{}, fmt.Errorf("%w", ErrTransactionIDMismatch)
+	} else if headerSize+length > len(message) {
+		return netip.Addr{}, fmt.Errorf("%w: %d bytes instead of %d",
+			ErrMessageTooShort, len(message), headerSize+length)
+	}
+
+	attributes, err := parseAttributes(message[headerSize : headerSize+length])
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	switch messageType {
+	case bindingSuccess:
+	case bindingErrorResponse:
+		return netip.Addr{}, fmt.Errorf("%w: %s",
+			ErrBindingFailed, parseErrorCode(attributes[attributeErrorCode]))
+	default:
+		return netip.Addr{}, fmt.Errorf("%w: 0x%04x", ErrMessageTypeUnexpected, messageType)
+	}
+
+	if value, ok := attributes[attributeXORMappedAddress]; ok {
+		return parseAddress(value, true, id)
+	} else if value, ok := attributes[attributeMappedAddress]; ok {
+		return parseAddress(value, false, id)
+	}
+	return netip.Addr{}, fmt.Errorf("%w", ErrMappedAddressNotFound)
+}
+
+// parseAttributes returns the values of the attributes by type,
+// keeping the first attribute of a type given several times.
+func parseAttributes(data []byte) (attributes map[uint16][]byte, err error) {
+	const attributeHeaderSize = 4
+	attributes = make(map[uint16][]byte)
+	for len(data) > 0 {
+		if len(data) < attributeHeaderSize {
+			return nil, fmt.Errorf("%w: header is truncated", ErrAttributeMalformed)
+		}
+		attributeType := binary.BigEndian.Uint16(data[0:2])
+		length := int(binary.BigEndian.Uint16(data[2:4]))
+		data = data[attributeHeaderSize:]
+		if length > len(data) {
+			return nil, fmt.Errorf("%w: value of attribute 0x%04x is truncated",
+				ErrAttributeMalformed, attributeType)
+		}
+		if _, ok := attributes[attributeType]; !ok {
+			attributes[attributeType] = data[:length]
+		}
+		// values are padded to a multiple of 4 bytes
+		padded := (length + 3) &^ 3 //nolint:gomnd
+		data = data[min(padded, len(data)):]
+	}
+	return attributes, nil
+}
+
+func parseAddress(value []byte, xor bool, id transactionID) (
+	address netip.Addr, err error) {
+	const familyIndex, addressIndex = 1, 4
+	if len(value) < addressIndex {
+		return netip.Addr{}, fmt.Errorf("%w: address is too short", ErrAttributeMalformed)
+	}
+
+	var ip []byte
+	switch family := value[familyIndex]; family {
+	case familyIPv4:
+		ip = make([]byte, net.IPv4len)
+	case familyIPv6:
+		ip = make([]byte, net.IPv6len)
+	default:
+		return netip.Addr{}, fmt.Errorf("%w: 0x%02x", ErrAddressFamilyNotValid, family)
+	}
+	if len(value) != addressIndex+len(ip) {
+		return netip.Addr{}, fmt.Errorf("%w: address has %d bytes instead of %d",
+			ErrAttributeMalformed, len(value), addressIndex+len(ip))
+	}
+	copy(ip, value[addressIndex:])
+
+	if xor {
+		// the address is XOR-ed with the magic cookie followed
+		// by the transaction id, see RFC 5389 section 15.2.
+		key := make([]byte, net.IPv6len)
+		binary.BigEndian.PutUint32(key[0:4], magicCookie)
+		copy(key[4:], id[:])
+		for i := range ip {
+			ip[i] ^= key[i]
+		}
+	}
+
+	address, _ = netip.AddrFromSlice(ip)
+	return address, nil
+}
+
+// parseErrorCode returns the error code and reason of the ERROR-CODE
+// attribute value, or a message if it is missing or malformed.
+func parseErrorCode(value []byte) string {
+	const reasonIndex = 4
+	if len(value) < reasonIndex {
+		return "no error code"
+	}
+	const classMask = 0x07
+	class := int(value[2] & classMask)
+	number := int(value[3])
+	return fmt.Sprintf("%d %s", class*100+number, value[reasonIndex:]) //nolint:gomnd
+}
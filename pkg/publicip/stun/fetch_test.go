@@ -0,0 +1,182 @@
+package stun
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestResponse(messageType uint16, id transactionID,
+	attributes ...[]byte) (response []byte) {
+	response = make([]byte, headerSize)
+	binary.BigEndian.PutUint16(response[0:2], messageType)
+	binary.BigEndian.PutUint32(response[4:8], magicCookie)
+	copy(response[8:20], id[:])
+	for _, attribute := range attributes {
+		response = append(response, attribute...)
+	}
+	binary.BigEndian.PutUint16(response[2:4], uint16(len(response)-headerSize))
+	return response
+}
+
+func newTestAttribute(attributeType uint16, value []byte) (attribute []byte) {
+	attribute = make([]byte, 4) //nolint:gomnd
+	binary.BigEndian.PutUint16(attribute[0:2], attributeType)
+	binary.BigEndian.PutUint16(attribute[2:4], uint16(len(value)))
+	attribute = append(attribute, value...)
+	for len(attribute)%4 != 0 {
+		attribute = append(attribute, 0)
+	}
+	return attribute
+}
+
+func newTestAddress(ip netip.Addr, xor bool, id transactionID) (value []byte) {
+	family := byte(familyIPv4)
+	if ip.Is6() {
+		family = familyIPv6
+	}
+	value = append([]byte{0, family, 0, 0}, ip.AsSlice()...)
+	if xor {
+		key := append(binary.BigEndian.AppendUint32(nil, magicCookie), id[:]...)
+		for i := 4; i < len(value); i++ {
+			value[i] ^= key[i-4]
+		}
+	}
+	return value
+}
+
+func Test_parseBindingResponse(t *testing.T) {
+	t.Parallel()
+
+	id := transactionID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	ipv4 := netip.AddrFrom4([4]byte{1, 2, 3, 4})
+	ipv6 := netip.MustParseAddr("2001:db8::1")
+
+	testCases := map[string]struct {
+		message    []byte
+		publicIP   netip.Addr
+		errWrapped error
+		errMessage string
+	}{
+		"too_short": {
+			message:    []byte{1},
+			errWrapped: ErrMessageTooShort,
+			errMessage: "message is too short: 1 bytes",
+		},
+		"not_stun": {
+			message:    make([]byte, headerSize),
+			errWrapped: ErrNotSTUNMessage,
+			errMessage: "message is not a STUN message",
+		},
+		"other_transaction": {
+			message:    newTestResponse(bindingSuccess, transactionID{}),
+			errWrapped: ErrTransactionIDMismatch,
+			errMessage: "transaction id does not match",
+		},
+		"xor_mapped_ipv4": {
+			message: newTestResponse(bindingSuccess, id,
+				newTestAttribute(attributeMappedAddress, newTestAddress(ipv6, false, id)),
+				newTestAttribute(attributeXORMappedAddress, newTestAddress(ipv4, true, id))),
+			publicIP: ipv4,
+		},
+		"xor_mapped_ipv6": {
+			message: newTestResponse(bindingSuccess, id,
+				newTestAttribute(attributeXORMappedAddress, newTestAddress(ipv6, true, id))),
+			publicIP: ipv6,
+		},
+		"mapped_ipv4": {
+			message: newTestResponse(bindingSuccess, id,
+				newTestAttribute(0x8022, []byte("software")),
+				newTestAttribute(attributeMappedAddress, newTestAddress(ipv4, false, id))),
+			publicIP: ipv4,
+		},
+		"no_address": {
+			message:    newTestResponse(bindingSuccess, id),
+			errWrapped: ErrMappedAddressNotFound,
+			errMessage: "mapped address not found",
+		},
+		"error_response": {
+			message: newTestResponse(bindingErrorResponse, id,
+				newTestAttribute(attributeErrorCode, append([]byte{0, 0, 4, 0}, "Bad Request"...))),
+			errWrapped: ErrBindingFailed,
+			errMessage: "binding request failed: 400 Bad Request",
+		},
+		"truncated_attribute": {
+			message: newTestResponse(bindingSuccess, id,
+				[]byte{0, 0x20, 0, 8, 0, 1}),
+			errWrapped: ErrAttributeMalformed,
+			errMessage: "attribute is malformed: value of attribute 0x0020 is truncated",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			publicIP, err := parseBindingResponse(testCase.message, id)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.Equal(t, testCase.publicIP, publicIP)
+		})
+	}
+}
+
+func Test_fetch(t *testing.T) {
+	t.Parallel()
+
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	publicIP := netip.AddrFrom4([4]byte{1, 2, 3, 4})
+	go func() {
+		buffer := make([]byte, 1500) //nolint:gomnd
+		for requests := 0; ; requests++ {
+			n, address, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			if requests == 0 || n != headerSize ||
+				binary.BigEndian.Uint16(buffer[0:2]) != bindingRequest {
+				continue // first request is "lost"
+			}
+			id := transactionID(buffer[8:20])
+			response := newTestResponse(bindingSuccess, id,
+				newTestAttribute(attributeXORMappedAddress, newTestAddress(publicIP, true, id)))
+			_, _ = conn.WriteTo(response, address)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ip, err := fetch(ctx, "udp4", conn.LocalAddr().String())
+
+	require.NoError(t, err)
+	assert.Equal(t, publicIP, ip)
+}
+
+func Test_fetch_timeout(t *testing.T) {
+	t.Parallel()
+
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = fetch(ctx, "udp4", conn.LocalAddr().String())
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
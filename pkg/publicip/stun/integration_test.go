@@ -0,0 +1,31 @@
+//go:build integration
+// +build integration
+
+package stun
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_integration(t *testing.T) {
+	t.Parallel()
+
+	fetcher, err := New()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	publicIP1, err := fetcher.IP4(ctx)
+	require.NoError(t, err)
+	assert.True(t, publicIP1.Is4())
+
+	publicIP2, err := fetcher.IP4(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, publicIP1.String(), publicIP2.String())
+	t.Logf("IPv4 address: %s", publicIP1)
+}
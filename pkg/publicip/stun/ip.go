@@ -0,0 +1,52 @@
+package stun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"sync/atomic"
+)
+
+var (
+	ErrIPNotFoundForVersion = errors.New("IP address found but not for IP version")
+)
+
+func (f *Fetcher) IP(ctx context.Context) (publicIP netip.Addr, err error) {
+	return f.ip(ctx, "udp")
+}
+
+func (f *Fetcher) IP4(ctx context.Context) (publicIP netip.Addr, err error) {
+	publicIP, err = f.ip(ctx, "udp4")
+	if err != nil {
+		return netip.Addr{}, err
+	} else if !publicIP.Is4() {
+		return netip.Addr{}, fmt.Errorf("%w: ipv4", ErrIPNotFoundForVersion)
+	}
+	return publicIP, nil
+}
+
+func (f *Fetcher) IP6(ctx context.Context) (publicIP netip.Addr, err error) {
+	publicIP, err = f.ip(ctx, "udp6")
+	if err != nil {
+		return netip.Addr{}, err
+	} else if !publicIP.Is6() {
+		return netip.Addr{}, fmt.Errorf("%w: ipv6", ErrIPNotFoundForVersion)
+	}
+	return publicIP, nil
+}
+
+func (f *Fetcher) ip(ctx context.Context, network string) (
+	publicIP netip.Addr, err error) {
+	index := int(atomic.AddUint32(f.ring.counter, 1)) % len(f.ring.servers)
+	server := f.ring.servers[index]
+
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+
+	publicIP, err = fetch(ctx, network, server)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("STUN server %s: %w", server, err)
+	}
+	return publicIP, nil
+}
@@ -0,0 +1,73 @@
+package stun
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+type settings struct {
+	servers []string
+	timeout time.Duration
+}
+
+func newDefaultSettings() settings {
+	const defaultTimeout = 3 * time.Second
+	return settings{
+		servers: ListDefaultServers(),
+		timeout: defaultTimeout,
+	}
+}
+
+// ListDefaultServers returns the addresses of the STUN servers used by default.
+func ListDefaultServers() []string {
+	return []string{
+		"stun.l.google.com:19302",
+		"stun.cloudflare.com:3478",
+	}
+}
+
+type Option func(s *settings) error
+
+var ErrServerAddressNotValid = errors.New("STUN server address is not valid")
+
+// ValidateServer returns an error if the server address
+// is not a host and port, such as stun.example.com:3478.
+func ValidateServer(server string) (err error) {
+	host, port, err := net.SplitHostPort(server)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrServerAddressNotValid, err)
+	} else if host == "" {
+		return fmt.Errorf("%w: %s: host is empty", ErrServerAddressNotValid, server)
+	}
+	_, err = strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return fmt.Errorf("%w: %s: port is not valid", ErrServerAddressNotValid, server)
+	}
+	return nil
+}
+
+// SetServers sets the addresses of the STUN servers to use in turn,
+// each made of a host and a port, such as stun.example.com:3478.
+func SetServers(first string, servers ...string) Option {
+	return func(s *settings) (err error) {
+		servers = append(servers, first)
+		for _, server := range servers {
+			err = ValidateServer(server)
+			if err != nil {
+				return err
+			}
+		}
+		s.servers = servers
+		return nil
+	}
+}
+
+func SetTimeout(timeout time.Duration) Option {
+	return func(s *settings) (err error) {
+		s.timeout = timeout
+		return nil
+	}
+}
@@ -0,0 +1,34 @@
+package stun
+
+import "time"
+
+// Fetcher fetches the public IP address with STUN binding
+// requests, as described in RFC 5389.
+type Fetcher struct {
+	ring    ring
+	timeout time.Duration
+}
+
+type ring struct {
+	// counter is used to get an index in the servers slice
+	counter *uint32 // uint32 for 32 bit systems atomic operations
+	servers []string
+}
+
+func New(options ...Option) (f *Fetcher, err error) {
+	settings := newDefaultSettings()
+	for _, option := range options {
+		err = option(&settings)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Fetcher{
+		ring: ring{
+			counter: new(uint32),
+			servers: settings.servers,
+		},
+		timeout: settings.timeout,
+	}, nil
+}